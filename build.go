@@ -31,6 +31,12 @@ var buildTargets = map[string]BuildTarget{
 		BinaryName:  "enqueue",
 		Description: "CLI tool to enqueue game-watching tasks into Redis",
 	},
+	"migratetasks": {
+		Name:        "migratetasks",
+		SourcePath:  "./watchgameupdates/cmd/migratetasks",
+		BinaryName:  "migratetasks",
+		Description: "CLI tool to move pending Cloud Tasks tasks to the Redis queue",
+	},
 	"localCloudTasksTest": {
 		Name:        "localCloudTasksTest",
 		SourcePath:  "./localCloudTasksTest",
@@ -43,11 +49,23 @@ var buildTargets = map[string]BuildTarget{
 		BinaryName:  "schedulegametrackers",
 		Description: "Game tracker task scheduler - fetches NHL schedule and creates tasks",
 	},
+	"apnschannels": {
+		Name:        "apnschannels",
+		SourcePath:  "./watchgameupdates/cmd/apnschannels",
+		BinaryName:  "apnschannels",
+		Description: "CLI tool to sync, list and delete APNs Live Activity broadcast channels",
+	},
+	"fakeapns": {
+		Name:        "fakeapns",
+		SourcePath:  "./watchgameupdates/cmd/fakeapns",
+		BinaryName:  "fakeapns",
+		Description: "Local APNs stand-in for end-to-end Live Activity tests",
+	},
 }
 
 func main() {
 	var (
-		target = flag.String("target", "", "Target to build (watchgameupdates, localCloudTasksTest, schedulegametrackers)")
+		target = flag.String("target", "", "Target to build (watchgameupdates, enqueue, migratetasks, localCloudTasksTest, schedulegametrackers, apnschannels, fakeapns)")
 		list   = flag.Bool("list", false, "List available build targets")
 		all    = flag.Bool("all", false, "Build all available targets")
 	)
@@ -0,0 +1,118 @@
+// Command apnschannels manages the APNs broadcast channels used for Live
+// Activity updates.
+//
+//	apnschannels sync   [-env production|development] [-prune] [-dry-run]
+//	apnschannels list   [-env ...]
+//	apnschannels delete [-env ...] <channel-id>
+//
+// sync makes sure every team in the registry maps to a channel that exists on
+// APNs, adopting the compiled-in IDs where they are still live and minting new
+// channels otherwise. Results are written to the store selected by
+// APNS_CHANNEL_STORE (file or redis), which the running backend reads.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"watchgameupdates/internal/notification/liveactivity"
+	"watchgameupdates/internal/teams"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	cmd, args := os.Args[1], os.Args[2:]
+	fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+	env := fs.String("env", "production", "APNs channel environment (production or development)")
+	prune := fs.Bool("prune", false, "sync: delete APNs channels no team maps to")
+	dryRun := fs.Bool("dry-run", false, "sync: report changes without creating, deleting or storing anything")
+	timeout := fs.Duration("timeout", 2*time.Minute, "overall timeout")
+	fs.Parse(args)
+
+	cfg, err := liveactivity.LoadConfigForEnvironment(*env)
+	if err != nil {
+		log.Fatalf("Failed to load APNs config: %v", err)
+	}
+	manager, err := liveactivity.NewChannelManager(cfg)
+	if err != nil {
+		log.Fatalf("Failed to create channel manager: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	switch cmd {
+	case "sync":
+		if cfg.ChannelStore == "builtin" && !*dryRun {
+			log.Fatal("sync needs a writable store: set APNS_CHANNEL_STORE=file or redis (or pass -dry-run)")
+		}
+		store, err := liveactivity.NewChannelStore(cfg)
+		if err != nil {
+			log.Fatalf("Failed to open channel store: %v", err)
+		}
+		opts := liveactivity.SyncOptions{
+			Seed:   liveactivity.BuiltinSeed(cfg),
+			Prune:  *prune,
+			DryRun: *dryRun,
+		}
+		report, err := liveactivity.SyncChannels(ctx, manager, store, teams.Tricodes, opts)
+		if err != nil {
+			log.Fatalf("Sync failed: %v", err)
+		}
+		printReport(report, *dryRun)
+		if report.Failed() > 0 {
+			os.Exit(1)
+		}
+
+	case "list":
+		ids, err := manager.List(ctx)
+		if err != nil {
+			log.Fatalf("Failed to list channels: %v", err)
+		}
+		for _, id := range ids {
+			fmt.Println(id)
+		}
+
+	case "delete":
+		if fs.NArg() != 1 {
+			log.Fatal("delete requires exactly one channel ID")
+		}
+		if err := manager.Delete(ctx, fs.Arg(0)); err != nil {
+			log.Fatalf("Failed to delete channel: %v", err)
+		}
+		log.Printf("Deleted channel %s", fs.Arg(0))
+
+	default:
+		usage()
+		os.Exit(2)
+	}
+}
+
+func printReport(report liveactivity.SyncReport, dryRun bool) {
+	if dryRun {
+		fmt.Println("DRY RUN - no changes made")
+	}
+	for _, r := range report.Teams {
+		if r.Err != nil {
+			fmt.Printf("%-4s %-8s %v\n", r.Team, r.Action, r.Err)
+			continue
+		}
+		fmt.Printf("%-4s %-8s %s\n", r.Team, r.Action, r.ChannelID)
+	}
+	for _, id := range report.Pruned {
+		fmt.Printf("pruned   %s\n", id)
+	}
+	fmt.Printf("%d teams, %d failed, %d pruned\n", len(report.Teams), report.Failed(), len(report.Pruned))
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: apnschannels <sync|list|delete> [-env production|development] [-prune] [-dry-run] [channel-id]")
+}
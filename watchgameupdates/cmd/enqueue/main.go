@@ -50,7 +50,7 @@ func main() {
 		log.Fatalf("Failed to create task: %v", err)
 	}
 
-	opts := []asynq.Option{}
+	opts := tasks.UniqueOptions(payload)
 	if *delay > 0 {
 		opts = append(opts, asynq.ProcessIn(*delay))
 	}
@@ -0,0 +1,91 @@
+// Command fakeapns runs a local APNs stand-in for end-to-end Live Activity
+// tests. It serves HTTP/2 over TLS with a self-signed certificate and writes the
+// certificate to FAKE_APNS_CA_OUT so the backend can trust it via APNS_CA_FILE.
+//
+// It validates provider JWTs against the same APNS_AUTH_KEY / APNS_KEY_ID /
+// APNS_TEAM_ID / APNS_TOPIC the backend uses. See package fakeapns for the
+// routes and the /_fake/ control endpoints.
+package main
+
+import (
+	"crypto/tls"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"watchgameupdates/internal/fakeapns"
+)
+
+func main() {
+	healthcheck := flag.Bool("healthcheck", false, "probe a running server and exit (for container healthchecks)")
+	flag.Parse()
+
+	addr := getEnvOrDefault("FAKE_APNS_ADDR", ":8443")
+	if *healthcheck {
+		os.Exit(probe(addr))
+	}
+
+	hosts := strings.Split(getEnvOrDefault("FAKE_APNS_HOSTS", "localhost,127.0.0.1,fakeapns"), ",")
+	caOut := os.Getenv("FAKE_APNS_CA_OUT")
+
+	server, err := fakeapns.New(fakeapns.Options{
+		AuthKey:  os.Getenv("APNS_AUTH_KEY"),
+		KeyID:    os.Getenv("APNS_KEY_ID"),
+		TeamID:   os.Getenv("APNS_TEAM_ID"),
+		BundleID: os.Getenv("APNS_TOPIC"),
+	})
+	if err != nil {
+		log.Fatalf("Failed to create fake APNs server: %v", err)
+	}
+
+	cert, certPEM, err := fakeapns.SelfSignedCert(hosts)
+	if err != nil {
+		log.Fatalf("Failed to create TLS certificate: %v", err)
+	}
+	if caOut != "" {
+		if err := os.WriteFile(caOut, certPEM, 0644); err != nil {
+			log.Fatalf("Failed to write CA certificate: %v", err)
+		}
+		log.Printf("Wrote CA certificate to %s", caOut)
+	}
+
+	srv := &http.Server{
+		Addr:    addr,
+		Handler: server.Handler(),
+		// NextProtos enables HTTP/2 negotiation, which APNs requires.
+		TLSConfig: &tls.Config{Certificates: []tls.Certificate{cert}, NextProtos: []string{"h2", "http/1.1"}},
+	}
+	log.Printf("Fake APNs listening on %s (hosts %v, topic %s)", addr, hosts, os.Getenv("APNS_TOPIC"))
+	if err := srv.ListenAndServeTLS("", ""); err != nil {
+		log.Fatalf("Fake APNs server failed: %v", err)
+	}
+}
+
+// probe returns 0 once the server on addr answers, which also means the CA
+// certificate has been written.
+func probe(addr string) int {
+	if strings.HasPrefix(addr, ":") {
+		addr = "localhost" + addr
+	}
+	client := &http.Client{
+		Timeout:   2 * time.Second,
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+	}
+	resp, err := client.Get("https://" + addr + "/_fake/requests")
+	if err != nil {
+		log.Printf("healthcheck: %v", err)
+		return 1
+	}
+	resp.Body.Close()
+	return 0
+}
+
+func getEnvOrDefault(key, defaultVal string) string {
+	if val := os.Getenv(key); val != "" {
+		return val
+	}
+	return defaultVal
+}
@@ -0,0 +1,77 @@
+// Command migratetasks moves pending game tasks from Cloud Tasks to Redis,
+// keeping each task's payload, schedule time and task ID.
+//
+//	migratetasks [-dry-run] [-delete] [-report report.json]
+//
+// The Cloud Tasks queue is the one HTTP mode uses (GCP_PROJECT_ID,
+// GCP_LOCATION, CLOUD_TASKS_QUEUE, or the emulator with USE_TASKS_EMULATOR
+// and CLOUD_TASKS_EMULATOR_HOST); the Redis queue is the one worker mode uses
+// (REDIS_ADDRESS, REDIS_PASSWORD, REDIS_DB). Stop the HTTP handler first: a
+// check it is processing would schedule its successor in Cloud Tasks.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	"watchgameupdates/config"
+	"watchgameupdates/internal/migrate"
+	"watchgameupdates/internal/queue"
+)
+
+func main() {
+	log.SetFlags(0)
+
+	dryRun := flag.Bool("dry-run", false, "Report what would be migrated without writing to either queue")
+	deleteOriginals := flag.Bool("delete", false, "Delete each Cloud Tasks task once it is in Redis")
+	reportPath := flag.String("report", "", "Also write the report as JSON to this file")
+	timeout := flag.Duration("timeout", 5*time.Minute, "Overall timeout")
+	flag.Parse()
+
+	if *dryRun && *deleteOriginals {
+		log.Fatal("-dry-run and -delete are mutually exclusive")
+	}
+
+	cfg := config.LoadConfig()
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	src, err := queue.NewCloudTasksQueue(ctx, cfg)
+	if err != nil {
+		log.Fatalf("Failed to create Cloud Tasks queue: %v", err)
+	}
+	defer src.Close()
+
+	dst := queue.NewRedisQueue(cfg)
+	defer dst.Close()
+
+	log.Printf("Migrating tasks from Cloud Tasks queue projects/%s/locations/%s/queues/%s to Redis at %s (dry run: %t, delete: %t)",
+		cfg.ProjectID, cfg.LocationID, cfg.QueueID, cfg.RedisAddress, *dryRun, *deleteOriginals)
+
+	report, err := migrate.Run(ctx, src, dst, migrate.Options{DryRun: *dryRun, Delete: *deleteOriginals})
+	if err != nil {
+		log.Fatalf("Migration failed: %v", err)
+	}
+	if err := report.Write(os.Stdout); err != nil {
+		log.Fatalf("Failed to print report: %v", err)
+	}
+
+	if *reportPath != "" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			log.Fatalf("Failed to encode report: %v", err)
+		}
+		if err := os.WriteFile(*reportPath, data, 0o644); err != nil {
+			log.Fatalf("Failed to write report: %v", err)
+		}
+		log.Printf("Report written to %s", *reportPath)
+	}
+
+	if report.Failed > 0 {
+		os.Exit(1)
+	}
+}
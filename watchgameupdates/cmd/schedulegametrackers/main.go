@@ -2,33 +2,82 @@ package main
 
 import (
 	"context"
+	"flag"
 	"log"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"watchgameupdates/config"
+	"watchgameupdates/internal/leader"
 	"watchgameupdates/internal/notification/notifiers"
+	"watchgameupdates/internal/opsalert"
 	"watchgameupdates/internal/queue"
 	"watchgameupdates/internal/schedule"
 	"watchgameupdates/internal/scheduler"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// leaderLockKey is the Redis key -daemon replicas elect a leader on.
+	leaderLockKey = "schedulegametrackers:leader"
+	// leaderLockTTL is how long a crashed leader blocks a takeover.
+	leaderLockTTL = 30 * time.Second
 )
 
 func main() {
 	log.SetFlags(0)
 
+	watchdog := flag.Bool("watchdog", false, "Revive live games whose tracking loop died instead of scheduling the day")
+	from := flag.String("from", "", "Schedule every date from this one (YYYY-MM-DD; default SCHEDULE_DATE or today)")
+	to := flag.String("to", "", "Last date to schedule with -from (YYYY-MM-DD)")
+	days := flag.Int("days", 0, "Schedule this many days starting at -from")
+	season := flag.Bool("season", false, "Schedule every remaining game of the season for the TEAM_FILTER teams")
+	daemon := flag.Bool("daemon", false, "Keep running and re-poll the schedule; one replica leads through a Redis lock")
+	flag.Parse()
+
+	if *daemon && (*watchdog || *season || *from != "" || *to != "" || *days > 0) {
+		log.Fatal("-daemon cannot be combined with other modes")
+	}
+
+	if *to != "" && *days > 0 {
+		log.Fatal("-to and -days are mutually exclusive")
+	}
+	if *season && (*from != "" || *to != "" || *days > 0) {
+		log.Fatal("-season cannot be combined with -from, -to or -days")
+	}
+
 	cfg := config.LoadConfig()
+	if cfg.GameFilterErr != nil {
+		// Dropping a bad entry would widen the filter, e.g. to every team.
+		log.Fatalf("Invalid game filter: %v", cfg.GameFilterErr)
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	// Range and season runs enqueue up to a few hundred games.
+	timeout := 2 * time.Minute
+	if *season || *to != "" || *days > 0 {
+		timeout = 15 * time.Minute
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
 	// Create schedule fetcher (file-based or HTTP)
 	fetcher := schedule.NewScheduleFetcher(cfg.ScheduleFile, cfg.ScheduleAPIBaseURL)
 
-	// Create queue (cloudtasks or redis, selected by SCHEDULER_QUEUE env var)
-	var taskQueue scheduler.TaskEnqueuer
+	// Create queue (cloudtasks, redis or sqlite, selected by SCHEDULER_QUEUE env var)
+	var taskQueue scheduler.GameLoopQueue
 	switch cfg.SchedulerQueue {
 	case "redis":
 		log.Printf("Scheduler queue: Redis (%s)", cfg.RedisAddress)
 		taskQueue = queue.NewRedisQueue(cfg)
+	case "sqlite":
+		log.Printf("Scheduler queue: SQLite (%s)", cfg.SQLitePath)
+		sqliteQueue, err := queue.NewSQLiteQueue(cfg.SQLitePath)
+		if err != nil {
+			log.Fatalf("Failed to open SQLite queue: %v", err)
+		}
+		taskQueue = sqliteQueue
 	default:
 		log.Printf("Scheduler queue: Cloud Tasks")
 		ctQueue, err := queue.NewCloudTasksQueue(ctx, cfg)
@@ -42,15 +91,85 @@ func main() {
 	// Resolve target date
 	date := schedule.ResolveTargetDate(cfg.ScheduleDate)
 
+	if *watchdog {
+		w := scheduler.NewWatchdog(fetcher, taskQueue, cfg.GameMaxDurationHours, cfg.SchedulerNotify, cfg.GameFilter, opsalert.FromEnv())
+		if _, err := w.Check(ctx, date); err != nil {
+			log.Fatalf("Watchdog failed: %v", err)
+		}
+		log.Println("Watchdog completed successfully")
+		return
+	}
+
 	// Create notification service for scheduler completion summary
 	notifService := notifiers.New(cfg.SchedulerNotify)
 	defer notifService.Close()
 
 	// Create and run scheduler
-	s := scheduler.New(fetcher, taskQueue, cfg.GameMaxDurationHours, cfg.SchedulerNotify, cfg.TeamFilters, notifService, cfg.IncludeLiveGames)
-	if err := s.Run(ctx, date); err != nil {
+	s := scheduler.New(fetcher, taskQueue, cfg.GameMaxDurationHours, cfg.SchedulerNotify, cfg.GameFilter, notifService, cfg.IncludeLiveGames)
+	if cfg.PregameLeadMinutes > 0 {
+		s.EnablePregame(time.Duration(cfg.PregameLeadMinutes) * time.Minute)
+	}
+
+	if *daemon {
+		runDaemon(cfg, s)
+		return
+	}
+
+	var err error
+	switch {
+	case *season:
+		_, err = s.RunSeason(ctx)
+	case *to != "" || *days > 0:
+		if *from != "" {
+			date = *from
+		}
+		last := *to
+		if *days > 0 {
+			last, err = schedule.AddDays(date, *days-1)
+			if err != nil {
+				log.Fatalf("Invalid -from date: %v", err)
+			}
+		}
+		_, err = s.RunRange(ctx, date, last)
+	default:
+		if *from != "" {
+			date = *from
+		}
+		err = s.Run(ctx, date)
+	}
+	if err != nil {
 		log.Fatalf("Scheduler failed: %v", err)
 	}
 
 	log.Println("Scheduler completed successfully")
 }
+
+// runDaemon runs s as a daemon until SIGINT or SIGTERM. With the SQLite
+// queue there is a single host and no Redis, so the daemon always leads;
+// a second one would only enqueue duplicates the queue drops.
+func runDaemon(cfg *config.Config, s *scheduler.Scheduler) {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	interval := time.Duration(cfg.SchedulerPollIntervalSeconds) * time.Second
+	gameDayInterval := time.Duration(cfg.SchedulerGameDayPollIntervalSeconds) * time.Second
+
+	var lock scheduler.Lock
+	if cfg.SchedulerQueue == "sqlite" {
+		log.Printf("Scheduler daemon: polling %d day(s) every %v (%v on game days), no leader election",
+			cfg.SchedulerDaemonDays, interval, gameDayInterval)
+		lock = leader.NewSoleLock(leaderLockTTL)
+	} else {
+		client := redis.NewClient(&redis.Options{
+			Addr:     cfg.RedisAddress,
+			Password: cfg.RedisPassword,
+			DB:       cfg.RedisDB,
+		})
+		defer client.Close()
+
+		log.Printf("Scheduler daemon: polling %d day(s) every %v (%v on game days), leader lock %s on %s",
+			cfg.SchedulerDaemonDays, interval, gameDayInterval, leaderLockKey, cfg.RedisAddress)
+		lock = leader.NewRedisLock(client, leaderLockKey, leaderLockTTL)
+	}
+	scheduler.NewDaemon(s, lock, cfg.SchedulerDaemonDays, interval, gameDayInterval).Run(ctx)
+}
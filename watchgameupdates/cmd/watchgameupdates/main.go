@@ -4,30 +4,51 @@ import (
 	"context"
 	"encoding/json"
 	"flag"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"watchgameupdates/config"
+	"watchgameupdates/internal/admin"
 	"watchgameupdates/internal/handlers"
+	"watchgameupdates/internal/leader"
+	"watchgameupdates/internal/metrics"
 	"watchgameupdates/internal/models"
 	"watchgameupdates/internal/notification"
 	"watchgameupdates/internal/notification/notifiers"
+	"watchgameupdates/internal/queue"
+	"watchgameupdates/internal/schedule"
+	"watchgameupdates/internal/scheduler"
 	"watchgameupdates/internal/services"
+	"watchgameupdates/internal/taskauth"
 	"watchgameupdates/internal/tasks"
 
 	"github.com/GoogleCloudPlatform/functions-framework-go/funcframework"
 	"github.com/hibiken/asynq"
 )
 
-func makeHTTPHandler(svc *notification.Service) http.HandlerFunc {
+// makeHTTPHandler returns the Cloud Tasks handler. Requests that fail verifier
+// are rejected before their payload is acted on; a nil verifier accepts all.
+func makeHTTPHandler(cfg *config.Config, svc *notification.Service, rescheduler *services.Rescheduler, verifier taskauth.Verifier) http.HandlerFunc {
 	fetcher := &services.HTTPGameDataFetcher{}
+	games := schedule.NewHTTPScheduleFetcher(cfg.ScheduleAPIBaseURL)
 	return func(w http.ResponseWriter, r *http.Request) {
 		body, err := io.ReadAll(r.Body)
 		if err != nil {
 			http.Error(w, "Failed to read request body", http.StatusBadRequest)
 			return
 		}
+		if verifier != nil {
+			if err := verifier.Verify(r, body); err != nil {
+				log.Printf("Rejected unauthenticated task request from %s: %v", r.RemoteAddr, err)
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
 
 		var payload models.Payload
 		if err := json.Unmarshal(body, &payload); err != nil {
@@ -36,11 +57,18 @@ func makeHTTPHandler(svc *notification.Service) http.HandlerFunc {
 		}
 
 		shouldNotify := payload.ShouldNotify == nil || *payload.ShouldNotify
+		if r.Header.Get(tasks.TaskTypeHeader) == tasks.TypePregame {
+			handlers.PregameHandler(w, r, svc.WithShouldNotify(shouldNotify), payload)
+			return
+		}
 		handlers.WatchGameUpdatesHandler(
 			w,
 			r,
+			cfg,
+			rescheduler,
 			fetcher,
 			svc.WithShouldNotify(shouldNotify),
+			games,
 			payload)
 	}
 }
@@ -61,20 +89,61 @@ func startHTTPMode(cfg *config.Config) {
 	log.Printf("  CLOUD_TASKS_EMULATOR_HOST:  %s", cfg.CloudTasksAddress)
 	log.Printf("  HANDLER_HOST:               %s", cfg.HandlerAddress)
 	log.Printf("  MESSAGE_INTERVAL_SECONDS:   %d", cfg.MessageIntervalSeconds)
+	log.Printf("  FAST_INTERVAL_SECONDS:      %d", cfg.FastIntervalSeconds)
 	log.Printf("  PERIOD_END_INTERVAL_SECONDS:%d", cfg.PeriodEndIntervalSeconds)
+	log.Printf("  TASK_AUTH:                  %s", cfg.TaskAuth)
+
+	verifier, err := taskVerifier(cfg)
+	if err != nil {
+		log.Fatalf("Invalid task authentication config: %v", err)
+	}
+	if verifier == nil {
+		log.Printf("WARNING: TASK_AUTH is not set; anyone who can reach / can trigger notifications")
+	}
+
+	// One Cloud Tasks client for the process: every check reschedules through
+	// it, and the admin API lists and cancels through it.
+	ctQueue, err := queue.NewCloudTasksQueue(context.Background(), cfg)
+	if err != nil {
+		log.Fatalf("Failed to create Cloud Tasks queue: %v", err)
+	}
+	defer ctQueue.Close()
+	rescheduler := services.NewRescheduler(ctQueue)
+	metrics.WatchGameLoops(ctQueue.CountGames)
 
 	// Use the typed registration entrypoint so the handler signature is checked
 	// at compile time. RegisterHTTPFunction takes interface{} and panics at
 	// startup if the dynamic type is not exactly func(http.ResponseWriter,
 	// *http.Request) — http.HandlerFunc is a named type and fails that assertion.
-	if err := funcframework.RegisterHTTPFunctionContext(context.Background(), "/", makeHTTPHandler(sharedNotifService)); err != nil {
+	if err := funcframework.RegisterHTTPFunctionContext(context.Background(), "/", makeHTTPHandler(cfg, sharedNotifService, rescheduler, verifier)); err != nil {
 		log.Fatalf("Failed to register function: %v", err)
 	}
-	if err := funcframework.Start("8080"); err != nil {
+	if err := funcframework.RegisterHTTPFunctionContext(context.Background(), "/metrics", metrics.Handler().ServeHTTP); err != nil {
+		log.Fatalf("Failed to register route /metrics: %v", err)
+	}
+	for pattern, h := range sharedNotifService.Routes() {
+		if err := funcframework.RegisterHTTPFunctionContext(context.Background(), pattern, h.ServeHTTP); err != nil {
+			log.Fatalf("Failed to register route %s: %v", pattern, err)
+		}
+		log.Printf("Registered client API route %s", pattern)
+	}
+	if cfg.AdminAPIToken != "" {
+		for pattern, h := range adminRoutes(cfg, ctQueue) {
+			if err := funcframework.RegisterHTTPFunctionContext(context.Background(), pattern, h.ServeHTTP); err != nil {
+				log.Fatalf("Failed to register route %s: %v", pattern, err)
+			}
+			log.Printf("Registered admin API route %s", pattern)
+		}
+	}
+	if err := funcframework.Start(cfg.Port); err != nil {
 		log.Fatalf("Failed to start function: %v", err)
 	}
 }
 
+// workerConcurrency is how many tasks the worker, sqlite and standalone modes
+// process at once.
+const workerConcurrency = 10
+
 func startWorkerMode(cfg *config.Config) {
 	log.Printf("Starting in worker mode (Redis at %s)", cfg.RedisAddress)
 
@@ -84,19 +153,31 @@ func startWorkerMode(cfg *config.Config) {
 		DB:       cfg.RedisDB,
 	}
 
-	client := asynq.NewClient(redisOpt)
-	defer client.Close()
+	redisQueue := queue.NewRedisQueue(cfg)
+	defer redisQueue.Close()
 
 	srv := asynq.NewServer(redisOpt, asynq.Config{
-		Concurrency: 10,
+		Concurrency: workerConcurrency,
 		Queues: map[string]int{
 			"default": 1,
 		},
 	})
 
 	mux := asynq.NewServeMux()
-	handler := tasks.NewWatchGameUpdatesHandler(cfg, client)
-	mux.HandleFunc(tasks.TypeWatchGameUpdates, handler.ProcessTask)
+	handler := tasks.NewWatchGameUpdatesHandler(cfg, services.NewRescheduler(redisQueue))
+	mux.Handle(tasks.TypeWatchGameUpdates, redisQueue.DropCancelled(asynq.HandlerFunc(handler.ProcessTask)))
+	mux.HandleFunc(tasks.TypePregame, tasks.NewPregameHandler(handler.NotificationService()).ProcessTask)
+
+	metrics.WatchGameLoops(countGameLoops(redisQueue))
+	metrics.WatchAsynqQueue(redisQueue.QueueName(), redisQueue.Depth)
+
+	routes := handler.NotificationService().Routes()
+	if cfg.AdminAPIToken != "" {
+		for pattern, h := range adminRoutes(cfg, redisQueue) {
+			routes[pattern] = h
+		}
+	}
+	startAPIServer(cfg, routes)
 
 	log.Printf("Asynq worker ready, listening for tasks...")
 
@@ -105,11 +186,180 @@ func startWorkerMode(cfg *config.Config) {
 	}
 }
 
+func startSQLiteMode(cfg *config.Config) {
+	log.Printf("Starting in SQLite worker mode (%s)", cfg.SQLitePath)
+
+	sqliteQueue, err := queue.NewSQLiteQueue(cfg.SQLitePath)
+	if err != nil {
+		log.Fatalf("Failed to open SQLite queue: %v", err)
+	}
+	defer sqliteQueue.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	handler := tasks.NewWatchGameUpdatesHandler(cfg, services.NewRescheduler(sqliteQueue))
+	pregame := tasks.NewPregameHandler(handler.NotificationService())
+
+	metrics.WatchGameLoops(countGameLoops(sqliteQueue))
+
+	routes := handler.NotificationService().Routes()
+	if cfg.AdminAPIToken != "" {
+		for pattern, h := range adminRoutes(cfg, sqliteQueue) {
+			routes[pattern] = h
+		}
+	}
+	startAPIServer(cfg, routes)
+
+	if counts, err := sqliteQueue.Counts(ctx); err == nil {
+		log.Printf("SQLite worker ready, resuming %d scheduled, %d pending, %d retrying and %d interrupted tasks",
+			counts[queue.StateScheduled], counts[queue.StatePending], counts[queue.StateRetry], counts[queue.StateActive])
+	}
+
+	err = sqliteQueue.Serve(ctx, workerConcurrency, map[string]queue.TaskHandler{
+		tasks.TypeWatchGameUpdates: handler.Process,
+		tasks.TypePregame:          pregame.Process,
+	})
+	if err != nil {
+		log.Fatalf("SQLite worker failed: %v", err)
+	}
+	log.Printf("SQLite worker shut down")
+}
+
+// taskVerifier returns the verifier for cfg.TaskAuth, nil when task requests
+// are not authenticated.
+func taskVerifier(cfg *config.Config) (taskauth.Verifier, error) {
+	switch cfg.TaskAuth {
+	case taskauth.ModeNone:
+		return nil, nil
+	case taskauth.ModeOIDC:
+		return taskauth.NewOIDCVerifier(taskauth.OIDCOptions{
+			Audience:       cfg.TaskAuthAudience,
+			Issuer:         cfg.TaskAuthIssuer,
+			JWKSURL:        cfg.TaskAuthJWKSURL,
+			ServiceAccount: cfg.TaskAuthServiceAccount,
+		})
+	case taskauth.ModeHMAC:
+		if cfg.TaskAuthHMACSecret == "" {
+			return nil, fmt.Errorf("TASK_AUTH=hmac needs TASK_AUTH_HMAC_SECRET")
+		}
+		return taskauth.NewHMACVerifier(cfg.TaskAuthHMACSecret), nil
+	default:
+		return nil, fmt.Errorf("unknown TASK_AUTH %q; use oidc or hmac", cfg.TaskAuth)
+	}
+}
+
+// startStandaloneMode runs the scheduler, an in-process queue and the game
+// processor in one process, with no Cloud Tasks emulator or Redis.
+func startStandaloneMode(cfg *config.Config) {
+	log.Printf("Starting in standalone mode (in-process queue)")
+	if cfg.GameFilterErr != nil {
+		// Dropping a bad entry would widen the filter, e.g. to every team.
+		log.Fatalf("Invalid game filter: %v", cfg.GameFilterErr)
+	}
+
+	memQueue, err := queue.NewMemoryQueue(cfg.StandaloneQueueFile)
+	if err != nil {
+		log.Fatalf("Failed to create in-process queue: %v", err)
+	}
+	defer memQueue.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	handler := tasks.NewWatchGameUpdatesHandler(cfg, services.NewRescheduler(memQueue))
+	pregame := tasks.NewPregameHandler(handler.NotificationService())
+
+	metrics.WatchGameLoops(countGameLoops(memQueue))
+
+	routes := handler.NotificationService().Routes()
+	if cfg.AdminAPIToken != "" {
+		for pattern, h := range adminRoutes(cfg, memQueue) {
+			routes[pattern] = h
+		}
+	}
+	startAPIServer(cfg, routes)
+
+	go runStandaloneScheduler(ctx, cfg, memQueue, handler.NotificationService())
+
+	log.Printf("In-process queue ready, serving tasks...")
+	err = memQueue.Serve(ctx, workerConcurrency, map[string]queue.TaskHandler{
+		tasks.TypeWatchGameUpdates: handler.Process,
+		tasks.TypePregame:          pregame.Process,
+	})
+	if err != nil {
+		log.Fatalf("In-process queue failed: %v", err)
+	}
+	log.Printf("Standalone mode shut down")
+}
+
+// runStandaloneScheduler enqueues games to q. A SCHEDULE_FILE is read once:
+// the file fetcher moves its games to start shortly after every read, so
+// polling it would enqueue them again and again. Otherwise the scheduler
+// polls the NHL schedule as the -daemon scheduler does.
+func runStandaloneScheduler(ctx context.Context, cfg *config.Config, q *queue.MemoryQueue, svc *notification.Service) {
+	fetcher := schedule.NewScheduleFetcher(cfg.ScheduleFile, cfg.ScheduleAPIBaseURL)
+	s := scheduler.New(fetcher, q, cfg.GameMaxDurationHours, cfg.SchedulerNotify, cfg.GameFilter,
+		svc.WithShouldNotify(cfg.SchedulerNotify), cfg.IncludeLiveGames)
+	if cfg.PregameLeadMinutes > 0 {
+		s.EnablePregame(time.Duration(cfg.PregameLeadMinutes) * time.Minute)
+	}
+
+	if cfg.ScheduleFile != "" {
+		if loops, err := q.ListGames(ctx); err == nil && len(loops) > 0 {
+			log.Printf("Resuming %d game loops from %s; not reading SCHEDULE_FILE again", len(loops), cfg.StandaloneQueueFile)
+			return
+		}
+		if err := s.Run(ctx, schedule.ResolveTargetDate(cfg.ScheduleDate)); err != nil {
+			log.Printf("Scheduler failed: %v", err)
+		}
+		return
+	}
+
+	interval := time.Duration(cfg.SchedulerPollIntervalSeconds) * time.Second
+	gameDayInterval := time.Duration(cfg.SchedulerGameDayPollIntervalSeconds) * time.Second
+	log.Printf("Scheduler: polling %d day(s) every %v (%v on game days)", cfg.SchedulerDaemonDays, interval, gameDayInterval)
+	scheduler.NewDaemon(s, leader.NewSoleLock(30*time.Second), cfg.SchedulerDaemonDays, interval, gameDayInterval).Run(ctx)
+}
+
+// adminRoutes builds the admin API over q, resolving games from the NHL API.
+// Callers only mount it when ADMIN_API_TOKEN is set.
+func adminRoutes(cfg *config.Config, q admin.Queue) map[string]http.Handler {
+	games := schedule.NewHTTPScheduleFetcher(cfg.ScheduleAPIBaseURL)
+	return admin.New(q, games, cfg.AdminAPIToken, cfg.GameMaxDurationHours).Routes()
+}
+
+// countGameLoops counts the game loops in q, for metrics.WatchGameLoops.
+func countGameLoops(q admin.Queue) func(context.Context) (int, error) {
+	return func(ctx context.Context) (int, error) {
+		loops, err := q.ListGames(ctx)
+		return len(loops), err
+	}
+}
+
+// startAPIServer serves client-facing notifier endpoints (e.g. push-to-start
+// token registration), the admin API and /metrics in the modes without a
+// Cloud Tasks HTTP handler to mount them on.
+func startAPIServer(cfg *config.Config, routes map[string]http.Handler) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	for pattern, h := range routes {
+		mux.Handle(pattern, h)
+		log.Printf("Registered client API route %s", pattern)
+	}
+	go func() {
+		log.Printf("Client API listening on :%s", cfg.Port)
+		if err := http.ListenAndServe(":"+cfg.Port, mux); err != nil {
+			log.Fatalf("Client API server failed: %v", err)
+		}
+	}()
+}
+
 func main() {
 	// Remove timestamp prefix from logs - Docker/structured logging handles timestamps
 	log.SetFlags(0)
 
-	mode := flag.String("mode", "http", "Run mode: 'http' for Cloud Tasks handler, 'worker' for Redis queue worker")
+	mode := flag.String("mode", "http", "Run mode: 'http' for Cloud Tasks handler, 'worker' for Redis queue worker, 'sqlite' for SQLite queue worker, 'standalone' for scheduler and in-process queue in one process")
 	flag.Parse()
 
 	cfg := config.LoadConfig()
@@ -119,7 +369,11 @@ func main() {
 		startHTTPMode(cfg)
 	case "worker":
 		startWorkerMode(cfg)
+	case "sqlite":
+		startSQLiteMode(cfg)
+	case "standalone":
+		startStandaloneMode(cfg)
 	default:
-		log.Fatalf("Unknown mode: %s. Use 'http' or 'worker'.", *mode)
+		log.Fatalf("Unknown mode: %s. Use 'http', 'worker', 'sqlite' or 'standalone'.", *mode)
 	}
 }
@@ -2,14 +2,24 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
+
+	"watchgameupdates/internal/models"
+	"watchgameupdates/internal/taskauth"
+	"watchgameupdates/internal/teams"
 )
 
 type Config struct {
 	Env                      string
+	Port                     string // HTTP listen port for the handler and client-facing API
+	AdminAPIToken            string // bearer token for the admin API; empty disables it
 	MessageIntervalSeconds   int
+	FastIntervalSeconds      int // close late games, overtime and shootouts; capped at MessageIntervalSeconds
 	PeriodEndIntervalSeconds int
 
 	// Cloud Tasks configuration (HTTP mode)
@@ -20,26 +30,51 @@ type Config struct {
 	CloudTasksAddress string
 	HandlerAddress    string
 
+	// Task request authentication (HTTP mode); see package taskauth
+	TaskAuth               string // "oidc", "hmac" or "" (requests are not authenticated)
+	TaskAuthAudience       string // OIDC audience; defaults to HandlerAddress
+	TaskAuthIssuer         string // accepted OIDC issuer
+	TaskAuthJWKSURL        string // the issuer's signing keys
+	TaskAuthServiceAccount string // service account Cloud Tasks mints OIDC tokens as
+	TaskAuthHMACSecret     string // shared secret for hmac signatures
+
 	// Redis configuration (worker mode)
 	RedisAddress  string
 	RedisPassword string
 	RedisDB       int
 
+	// SQLite configuration (-mode=sqlite and SCHEDULER_QUEUE=sqlite)
+	SQLitePath string
+
 	// Scheduler-specific
 	ScheduleAPIBaseURL   string
 	ScheduleFile         string
 	ScheduleDate         string
 	GameMaxDurationHours int
 	SchedulerNotify      bool
-	TeamFilter           string   // raw value of TEAM_FILTER env var
-	TeamFilters          []string // parsed, normalized roster (empty = monitor all)
+	TeamFilter           string     // raw value of TEAM_FILTER env var
+	GameTypeFilter       string     // raw value of GAME_TYPE_FILTER env var
+	GameFilter           GameFilter // parsed TEAM_FILTER and GAME_TYPE_FILTER
+	GameFilterErr        error      // invalid TEAM_FILTER or GAME_TYPE_FILTER entries; the scheduler refuses to run
 	IncludeLiveGames     bool
-	SchedulerQueue       string // "cloudtasks" (default) or "redis"
+	SchedulerQueue       string // "cloudtasks" (default), "redis" or "sqlite"
+	PregameLeadMinutes   int    // pregame task this long before puck drop; 0 disables
+
+	// Standalone mode (-mode=standalone)
+	StandaloneQueueFile string // saves the in-process queue across restarts; empty keeps it in memory
+
+	// Scheduler daemon (-daemon)
+	SchedulerPollIntervalSeconds        int // re-poll cadence on days without games
+	SchedulerGameDayPollIntervalSeconds int // re-poll cadence while today has games
+	SchedulerDaemonDays                 int // dates per poll, starting today
 }
 
 func LoadConfig() *Config {
-	return &Config{
-		Env: os.Getenv("APP_ENV"),
+	cfg := &Config{
+		Env:  os.Getenv("APP_ENV"),
+		Port: getEnvOrDefault("PORT", "8080"),
+
+		AdminAPIToken: os.Getenv("ADMIN_API_TOKEN"),
 
 		// Cloud Tasks (preserved for HTTP mode)
 		ProjectID:         os.Getenv("GCP_PROJECT_ID"),
@@ -49,6 +84,13 @@ func LoadConfig() *Config {
 		CloudTasksAddress: os.Getenv("CLOUD_TASKS_EMULATOR_HOST"),
 		HandlerAddress:    os.Getenv("HANDLER_HOST"),
 
+		TaskAuth:               strings.ToLower(strings.TrimSpace(os.Getenv("TASK_AUTH"))),
+		TaskAuthAudience:       getEnvOrDefault("TASK_AUTH_AUDIENCE", os.Getenv("HANDLER_HOST")),
+		TaskAuthIssuer:         getEnvOrDefault("TASK_AUTH_ISSUER", taskauth.GoogleIssuer),
+		TaskAuthJWKSURL:        getEnvOrDefault("TASK_AUTH_JWKS_URL", taskauth.GoogleJWKSURL),
+		TaskAuthServiceAccount: os.Getenv("TASK_AUTH_SERVICE_ACCOUNT"),
+		TaskAuthHMACSecret:     os.Getenv("TASK_AUTH_HMAC_SECRET"),
+
 		// Redis (worker mode)
 		RedisAddress:  getEnvOrDefault("REDIS_ADDRESS", "localhost:6379"),
 		RedisPassword: os.Getenv("REDIS_PASSWORD"),
@@ -63,6 +105,9 @@ func LoadConfig() *Config {
 			return 0
 		}(),
 
+		// SQLite (-mode=sqlite)
+		SQLitePath: getEnvOrDefault("SQLITE_PATH", "watchgameupdates.db"),
+
 		MessageIntervalSeconds: func() int {
 			if val, ok := os.LookupEnv("MESSAGE_INTERVAL_SECONDS"); ok {
 				var intVal int
@@ -74,6 +119,7 @@ func LoadConfig() *Config {
 			}
 			return 60 // default value
 		}(),
+		FastIntervalSeconds: positiveIntEnv("FAST_INTERVAL_SECONDS", 20),
 		PeriodEndIntervalSeconds: func() int {
 			if val, ok := os.LookupEnv("PERIOD_END_INTERVAL_SECONDS"); ok {
 				var intVal int
@@ -94,7 +140,7 @@ func LoadConfig() *Config {
 		ScheduleFile: os.Getenv("SCHEDULE_FILE"),
 		ScheduleDate: os.Getenv("SCHEDULE_DATE"),
 		TeamFilter:       os.Getenv("TEAM_FILTER"),
-		TeamFilters:      ParseTeamFilter(os.Getenv("TEAM_FILTER")),
+		GameTypeFilter:   os.Getenv("GAME_TYPE_FILTER"),
 		IncludeLiveGames: os.Getenv("INCLUDE_LIVE_GAMES") == "true",
 		SchedulerQueue:   getEnvOrDefault("SCHEDULER_QUEUE", "cloudtasks"),
 		GameMaxDurationHours: func() int {
@@ -108,6 +154,11 @@ func LoadConfig() *Config {
 			}
 			return 5
 		}(),
+		PregameLeadMinutes:                  nonNegativeIntEnv("PREGAME_LEAD_MINUTES", 30),
+		SchedulerPollIntervalSeconds:        positiveIntEnv("SCHEDULER_POLL_INTERVAL_SECONDS", 3600),
+		SchedulerGameDayPollIntervalSeconds: positiveIntEnv("SCHEDULER_GAME_DAY_POLL_INTERVAL_SECONDS", 900),
+		SchedulerDaemonDays:                 positiveIntEnv("SCHEDULER_DAEMON_DAYS", 2),
+		StandaloneQueueFile:                 os.Getenv("STANDALONE_QUEUE_FILE"),
 		SchedulerNotify: func() bool {
 			val, ok := os.LookupEnv("SCHEDULER_SHOULD_NOTIFY")
 			if !ok {
@@ -116,6 +167,8 @@ func LoadConfig() *Config {
 			return val == "true"
 		}(),
 	}
+	cfg.GameFilter, cfg.GameFilterErr = ParseGameFilter(cfg.TeamFilter, cfg.GameTypeFilter)
+	return cfg
 }
 
 // ParseTeamFilter splits a comma-separated TEAM_FILTER value into a
@@ -138,6 +191,158 @@ func ParseTeamFilter(raw string) []string {
 	return out
 }
 
+// GameFilter selects the games the scheduler and watchdog track. The zero
+// value tracks every game.
+type GameFilter struct {
+	Teams        []string // tricodes to track; empty = every team
+	ExcludeTeams []string // tricodes whose games are never tracked
+	GameTypes    []int    // NHL gameType values to track; empty = every type
+}
+
+// gameTypeNames are the GAME_TYPE_FILTER names of the NHL gameType values.
+var gameTypeNames = map[string]int{
+	"PRESEASON": models.GameTypePreseason,
+	"REGULAR":   models.GameTypeRegular,
+	"PLAYOFFS":  models.GameTypePlayoff,
+	"ALLSTAR":   models.GameTypeAllStar,
+}
+
+// ParseGameFilter parses TEAM_FILTER and GAME_TYPE_FILTER, validating every
+// entry against the team registry. TEAM_FILTER entries are tricodes, division
+// names ("ATLANTIC") or conference names ("EAST"); a leading "-" excludes
+// instead ("-BOS", or "EAST,-BOS"). GAME_TYPE_FILTER entries are preseason,
+// regular, playoffs, allstar or a numeric gameType. Invalid entries are
+// reported together in the error; the returned filter holds the valid ones.
+func ParseGameFilter(teamFilter, gameTypeFilter string) (GameFilter, error) {
+	var filter GameFilter
+	var errs []error
+	includes := false
+	for _, entry := range ParseTeamFilter(teamFilter) {
+		name, exclude := strings.CutPrefix(entry, "-")
+		members, ok := teams.Group(name)
+		if !ok && teams.IsKnown(name) {
+			members, ok = []string{name}, true
+		}
+		if !ok {
+			errs = append(errs, fmt.Errorf("TEAM_FILTER: %q is not a team, division or conference", entry))
+			continue
+		}
+		if exclude {
+			filter.ExcludeTeams = appendNew(filter.ExcludeTeams, members...)
+		} else {
+			filter.Teams = appendNew(filter.Teams, members...)
+			includes = true
+		}
+	}
+	// An excluded team is never tracked, so it is not a team to track either
+	// (RunSeason fetches the Teams schedules).
+	filter.Teams = slices.DeleteFunc(filter.Teams, func(t string) bool {
+		return slices.Contains(filter.ExcludeTeams, t)
+	})
+	if includes && len(filter.Teams) == 0 {
+		filter.Teams = nil
+		errs = append(errs, fmt.Errorf("TEAM_FILTER: %q excludes every team it includes", teamFilter))
+	}
+
+	for _, entry := range ParseTeamFilter(gameTypeFilter) {
+		gameType, ok := gameTypeNames[entry]
+		if !ok {
+			n, err := strconv.Atoi(entry)
+			if err != nil || n <= 0 {
+				errs = append(errs, fmt.Errorf("GAME_TYPE_FILTER: %q is not preseason, regular, playoffs, allstar or a gameType number", entry))
+				continue
+			}
+			gameType = n
+		}
+		if !slices.Contains(filter.GameTypes, gameType) {
+			filter.GameTypes = append(filter.GameTypes, gameType)
+		}
+	}
+	return filter, errors.Join(errs...)
+}
+
+// appendNew appends the values not already in list.
+func appendNew(list []string, values ...string) []string {
+	for _, v := range values {
+		if !slices.Contains(list, v) {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// Matches reports whether the filter tracks a game between home and away of
+// gameType. A game with an excluded team is skipped even when the other team
+// is tracked. An unknown gameType (0) passes the game type filter.
+func (f GameFilter) Matches(home, away string, gameType int) bool {
+	home, away = strings.ToUpper(home), strings.ToUpper(away)
+	if slices.Contains(f.ExcludeTeams, home) || slices.Contains(f.ExcludeTeams, away) {
+		return false
+	}
+	if len(f.Teams) > 0 && !slices.Contains(f.Teams, home) && !slices.Contains(f.Teams, away) {
+		return false
+	}
+	return len(f.GameTypes) == 0 || gameType == 0 || slices.Contains(f.GameTypes, gameType)
+}
+
+// String describes the filter for the scheduler's startup log, e.g.
+// "teams [BOS TOR], excluding [NYR], game types [regular playoffs]".
+func (f GameFilter) String() string {
+	parts := []string{"ALL teams"}
+	if len(f.Teams) > 0 {
+		parts[0] = fmt.Sprintf("teams %v", f.Teams)
+	}
+	if len(f.ExcludeTeams) > 0 {
+		parts = append(parts, fmt.Sprintf("excluding %v", f.ExcludeTeams))
+	}
+	if len(f.GameTypes) > 0 {
+		names := make([]string, 0, len(f.GameTypes))
+		for _, gameType := range f.GameTypes {
+			names = append(names, gameTypeName(gameType))
+		}
+		parts = append(parts, fmt.Sprintf("game types %v", names))
+	} else {
+		parts = append(parts, "all game types")
+	}
+	return strings.Join(parts, ", ")
+}
+
+func gameTypeName(gameType int) string {
+	for name, t := range gameTypeNames {
+		if t == gameType {
+			return strings.ToLower(name)
+		}
+	}
+	return strconv.Itoa(gameType)
+}
+
+// positiveIntEnv returns the positive integer in key, or defaultVal when it is
+// unset or invalid.
+func positiveIntEnv(key string, defaultVal int) int {
+	if val, ok := os.LookupEnv(key); ok {
+		var intVal int
+		_, err := fmt.Sscanf(val, "%d", &intVal)
+		if err == nil && intVal > 0 {
+			return intVal
+		}
+		fmt.Printf("Invalid %s value '%s', using default of %d\n", key, val, defaultVal)
+	}
+	return defaultVal
+}
+
+// nonNegativeIntEnv is positiveIntEnv for settings where 0 means "off".
+func nonNegativeIntEnv(key string, defaultVal int) int {
+	if val, ok := os.LookupEnv(key); ok {
+		var intVal int
+		_, err := fmt.Sscanf(val, "%d", &intVal)
+		if err == nil && intVal >= 0 {
+			return intVal
+		}
+		fmt.Printf("Invalid %s value '%s', using default of %d\n", key, val, defaultVal)
+	}
+	return defaultVal
+}
+
 func getEnvOrDefault(key, defaultVal string) string {
 	if val := os.Getenv(key); val != "" {
 		return val
@@ -2,6 +2,7 @@ package config
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -52,3 +53,161 @@ func TestParseTeamFilter(t *testing.T) {
 		})
 	}
 }
+
+func TestParseGameFilter(t *testing.T) {
+	tests := []struct {
+		name       string
+		teams      string
+		gameTypes  string
+		wantTeams  []string
+		wantExcl   []string
+		wantTypes  []int
+		wantErrors []string
+	}{
+		{
+			name: "empty tracks everything",
+		},
+		{
+			name:      "tricodes and game type names",
+			teams:     "bos,TOR",
+			gameTypes: "regular, Playoffs",
+			wantTeams: []string{"BOS", "TOR"},
+			wantTypes: []int{2, 3},
+		},
+		{
+			name:      "division expands to its teams",
+			teams:     "METRO",
+			wantTeams: []string{"CAR", "CBJ", "NJD", "NYI", "NYR", "PHI", "PIT", "WSH"},
+		},
+		{
+			name:      "exclusion removes a conference member",
+			teams:     "ATLANTIC,-BOS,-TOR",
+			wantTeams: []string{"BUF", "DET", "FLA", "MTL", "OTT", "TBL"},
+			wantExcl:  []string{"BOS", "TOR"},
+		},
+		{
+			name:     "exclusions alone track all but them",
+			teams:    "-NYR",
+			wantExcl: []string{"NYR"},
+		},
+		{
+			name:      "numeric game type",
+			gameTypes: "3,3",
+			wantTypes: []int{3},
+		},
+		{
+			name:       "unknown entries are reported together",
+			teams:      "BOS,WAS,-ARI",
+			gameTypes:  "playoff",
+			wantTeams:  []string{"BOS"},
+			wantErrors: []string{`"WAS"`, `"-ARI"`, `"PLAYOFF"`},
+		},
+		{
+			name:       "excluding every included team is an error",
+			teams:      "BOS,-BOS",
+			wantExcl:   []string{"BOS"},
+			wantErrors: []string{"excludes every team"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseGameFilter(tc.teams, tc.gameTypes)
+			if !reflect.DeepEqual(got.Teams, tc.wantTeams) || !reflect.DeepEqual(got.ExcludeTeams, tc.wantExcl) || !reflect.DeepEqual(got.GameTypes, tc.wantTypes) {
+				t.Errorf("ParseGameFilter(%q, %q) = %+v, want teams %v excluding %v types %v",
+					tc.teams, tc.gameTypes, got, tc.wantTeams, tc.wantExcl, tc.wantTypes)
+			}
+			if (err != nil) != (len(tc.wantErrors) > 0) {
+				t.Fatalf("err = %v, want errors %v", err, tc.wantErrors)
+			}
+			for _, want := range tc.wantErrors {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("err = %v, want it to mention %s", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestGameFilter_Matches(t *testing.T) {
+	filter := GameFilter{Teams: []string{"BOS", "TOR"}, ExcludeTeams: []string{"MTL"}, GameTypes: []int{2, 3}}
+	tests := []struct {
+		home, away string
+		gameType   int
+		want       bool
+	}{
+		{"BOS", "NYR", 2, true},
+		{"nyr", "tor", 3, true},
+		{"NYR", "DET", 2, false}, // neither team tracked
+		{"TOR", "MTL", 2, false}, // excluded opponent
+		{"BOS", "NYR", 1, false}, // preseason
+		{"BOS", "NYR", 0, true},  // unknown game type passes
+	}
+	for _, tc := range tests {
+		if got := filter.Matches(tc.home, tc.away, tc.gameType); got != tc.want {
+			t.Errorf("Matches(%s, %s, %d) = %v, want %v", tc.home, tc.away, tc.gameType, got, tc.want)
+		}
+	}
+	if !(GameFilter{}).Matches("NYR", "DET", 1) {
+		t.Error("the zero filter should match every game")
+	}
+}
+
+func TestGameFilter_String(t *testing.T) {
+	if got, want := (GameFilter{}).String(), "ALL teams, all game types"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	filter := GameFilter{Teams: []string{"BOS"}, ExcludeTeams: []string{"MTL"}, GameTypes: []int{3}}
+	if got, want := filter.String(), "teams [BOS], excluding [MTL], game types [playoffs]"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestPositiveIntEnv(t *testing.T) {
+	tests := []struct {
+		name string
+		val  *string
+		want int
+	}{
+		{"unset uses default", nil, 900},
+		{"valid value", ptr("300"), 300},
+		{"zero uses default", ptr("0"), 900},
+		{"negative uses default", ptr("-5"), 900},
+		{"garbage uses default", ptr("soon"), 900},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.val != nil {
+				t.Setenv("SCHEDULER_GAME_DAY_POLL_INTERVAL_SECONDS", *tt.val)
+			}
+			if got := positiveIntEnv("SCHEDULER_GAME_DAY_POLL_INTERVAL_SECONDS", 900); got != tt.want {
+				t.Errorf("positiveIntEnv = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNonNegativeIntEnv(t *testing.T) {
+	tests := []struct {
+		name string
+		val  *string
+		want int
+	}{
+		{"unset uses default", nil, 30},
+		{"valid value", ptr("45"), 45},
+		{"zero disables", ptr("0"), 0},
+		{"negative uses default", ptr("-5"), 30},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.val != nil {
+				t.Setenv("PREGAME_LEAD_MINUTES", *tt.val)
+			}
+			if got := nonNegativeIntEnv("PREGAME_LEAD_MINUTES", 30); got != tt.want {
+				t.Errorf("nonNegativeIntEnv = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func ptr(s string) *string { return &s }
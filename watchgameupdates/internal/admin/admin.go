@@ -0,0 +1,234 @@
+// Package admin is the operator HTTP API for game tracking loops:
+//
+//	GET    /admin/games       → 200 [GameLoop...], soonest next run first
+//	POST   /admin/games       {"gameId": "2025020010", "shouldNotify": true}
+//	                          → 201 GameLoop; 409 if already tracked or over
+//	DELETE /admin/games/{id}  → 200 {"gameId": ..., "cancelled": n}; 404 if not tracked
+//
+// Every request needs "Authorization: Bearer $ADMIN_API_TOKEN". The API is
+// not mounted when ADMIN_API_TOKEN is unset.
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"watchgameupdates/internal/models"
+	"watchgameupdates/internal/queue"
+	"watchgameupdates/internal/schedule"
+)
+
+// Route patterns served by API.
+const (
+	GamesPath = "/admin/games"
+	GamePath  = "/admin/games/{id}"
+)
+
+// Queue is the task queue the game loops live in; queue.RedisQueue and
+// queue.CloudTasksQueue implement it.
+type Queue interface {
+	Enqueue(ctx context.Context, payload models.Payload, deliverAt time.Time) error
+	ListGames(ctx context.Context) ([]queue.GameLoop, error)
+	CancelGame(ctx context.Context, gameID string) (int, error)
+}
+
+// GameFetcher resolves a game ID to its teams and start time.
+type GameFetcher interface {
+	FetchGame(ctx context.Context, gameID string) (schedule.ScheduleGame, error)
+}
+
+// API serves the admin endpoints.
+type API struct {
+	queue           Queue
+	games           GameFetcher
+	token           string
+	gameMaxDuration time.Duration
+	now             func() time.Time
+}
+
+// New creates the admin API. gameMaxDurationHours bounds a started loop the
+// same way the scheduler does (GAME_MAX_DURATION_HOURS).
+func New(q Queue, games GameFetcher, token string, gameMaxDurationHours int) *API {
+	return &API{
+		queue:           q,
+		games:           games,
+		token:           token,
+		gameMaxDuration: time.Duration(gameMaxDurationHours) * time.Hour,
+		now:             time.Now,
+	}
+}
+
+// Routes returns the admin endpoints keyed by ServeMux pattern.
+func (a *API) Routes() map[string]http.Handler {
+	return map[string]http.Handler{
+		"GET " + GamesPath:   a.authorize(a.listGames),
+		"POST " + GamesPath:  a.authorize(a.startGame),
+		"DELETE " + GamePath: a.authorize(a.cancelGame),
+	}
+}
+
+// authorize rejects requests without the admin bearer token.
+func (a *API) authorize(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(a.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	})
+}
+
+func (a *API) listGames(w http.ResponseWriter, r *http.Request) {
+	loops, err := a.queue.ListGames(r.Context())
+	if err != nil {
+		log.Printf("ERROR: admin list games: %v", err)
+		http.Error(w, "failed to list games", http.StatusBadGateway)
+		return
+	}
+	if loops == nil {
+		loops = []queue.GameLoop{}
+	}
+	writeJSON(w, http.StatusOK, loops)
+}
+
+type startRequest struct {
+	GameID       string `json:"gameId"`
+	ShouldNotify *bool  `json:"shouldNotify,omitempty"` // default true
+}
+
+func (a *API) startGame(w http.ResponseWriter, r *http.Request) {
+	var req startRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.GameID = strings.TrimSpace(req.GameID)
+	if !validGameID(req.GameID) {
+		http.Error(w, "gameId must be a numeric NHL game ID", http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+
+	loops, err := a.queue.ListGames(ctx)
+	if err != nil {
+		log.Printf("ERROR: admin list games: %v", err)
+		http.Error(w, "failed to list games", http.StatusBadGateway)
+		return
+	}
+	for _, loop := range loops {
+		if loop.GameID == req.GameID {
+			http.Error(w, fmt.Sprintf("game %s is already tracked (task %s)", req.GameID, loop.TaskID), http.StatusConflict)
+			return
+		}
+	}
+
+	game, err := a.games.FetchGame(ctx, req.GameID)
+	if errors.Is(err, schedule.ErrGameNotFound) {
+		http.Error(w, "game not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: admin resolve game %s: %v", req.GameID, err)
+		http.Error(w, "failed to resolve game from the NHL API", http.StatusBadGateway)
+		return
+	}
+
+	payload, deliverAt, err := a.newPayload(game, req.ShouldNotify)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err := a.queue.Enqueue(ctx, payload, deliverAt); err != nil {
+		log.Printf("ERROR: admin enqueue game %s: %v", req.GameID, err)
+		http.Error(w, "failed to enqueue game", http.StatusBadGateway)
+		return
+	}
+
+	log.Printf("Admin started tracking game %s (%s @ %s), first check at %s",
+		payload.Game.ID, payload.Game.AwayTeam.Abbrev, payload.Game.HomeTeam.Abbrev, deliverAt.Format(time.RFC3339))
+	writeJSON(w, http.StatusCreated, queue.GameLoop{
+		GameID:       payload.Game.ID,
+		HomeTeam:     payload.Game.HomeTeam.Abbrev,
+		AwayTeam:     payload.Game.AwayTeam.Abbrev,
+		State:        queue.StateScheduled,
+		NextRun:      deliverAt,
+		ShouldNotify: *payload.ShouldNotify,
+		ExecutionEnd: *payload.ExecutionEnd,
+	})
+}
+
+// newPayload builds the first task of a loop the way the scheduler does: the
+// first check at puck drop, or now for a game already under way, and the
+// execution window running gameMaxDuration from then. A game under way resumes
+// at the watchdog's cycle, the seconds since puck drop, so a loop cancelled
+// and started again does not collide with the task IDs of its first run.
+func (a *API) newPayload(game schedule.ScheduleGame, shouldNotify *bool) (models.Payload, time.Time, error) {
+	switch game.GameState {
+	case "OFF", "FINAL":
+		return models.Payload{}, time.Time{}, fmt.Errorf("game %d is over (state %s)", game.ID, game.GameState)
+	}
+	startTime, err := time.Parse(time.RFC3339, game.StartTimeUTC)
+	if err != nil {
+		return models.Payload{}, time.Time{}, fmt.Errorf("game %d has no valid start time: %v", game.ID, err)
+	}
+
+	deliverAt := startTime
+	cycle := 0
+	if now := a.now(); deliverAt.Before(now) {
+		deliverAt = now
+		cycle = int(now.Sub(startTime).Seconds())
+	}
+	executionEnd := deliverAt.Add(a.gameMaxDuration).Format(time.RFC3339)
+	notify := shouldNotify == nil || *shouldNotify
+
+	return models.Payload{
+		Game:         game.Game(),
+		ExecutionEnd: &executionEnd,
+		ShouldNotify: &notify,
+		Cycle:        cycle,
+	}, deliverAt, nil
+}
+
+func (a *API) cancelGame(w http.ResponseWriter, r *http.Request) {
+	gameID := r.PathValue("id")
+	if !validGameID(gameID) {
+		http.Error(w, "game ID must be numeric", http.StatusBadRequest)
+		return
+	}
+	cancelled, err := a.queue.CancelGame(r.Context(), gameID)
+	if err != nil {
+		log.Printf("ERROR: admin cancel game %s: %v", gameID, err)
+		http.Error(w, "failed to cancel game", http.StatusBadGateway)
+		return
+	}
+	if cancelled == 0 {
+		http.Error(w, "game is not tracked", http.StatusNotFound)
+		return
+	}
+	log.Printf("Admin cancelled tracking of game %s (%d tasks)", gameID, cancelled)
+	writeJSON(w, http.StatusOK, map[string]interface{}{"gameId": gameID, "cancelled": cancelled})
+}
+
+func validGameID(id string) bool {
+	if id == "" {
+		return false
+	}
+	_, err := strconv.ParseUint(id, 10, 64)
+	return err == nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
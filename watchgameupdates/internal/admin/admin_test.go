@@ -0,0 +1,222 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"watchgameupdates/internal/models"
+	"watchgameupdates/internal/queue"
+	"watchgameupdates/internal/schedule"
+)
+
+const testToken = "s3cret"
+
+type enqueued struct {
+	payload   models.Payload
+	deliverAt time.Time
+}
+
+type fakeQueue struct {
+	loops     []queue.GameLoop
+	enqueued  []enqueued
+	cancelled []string
+	err       error
+}
+
+func (q *fakeQueue) Enqueue(_ context.Context, p models.Payload, at time.Time) error {
+	if q.err != nil {
+		return q.err
+	}
+	q.enqueued = append(q.enqueued, enqueued{p, at})
+	return nil
+}
+
+func (q *fakeQueue) ListGames(context.Context) ([]queue.GameLoop, error) {
+	return q.loops, q.err
+}
+
+func (q *fakeQueue) CancelGame(_ context.Context, gameID string) (int, error) {
+	if q.err != nil {
+		return 0, q.err
+	}
+	n := 0
+	for _, l := range q.loops {
+		if l.GameID == gameID {
+			n++
+		}
+	}
+	if n > 0 {
+		q.cancelled = append(q.cancelled, gameID)
+	}
+	return n, nil
+}
+
+type fakeGames map[string]schedule.ScheduleGame
+
+func (f fakeGames) FetchGame(_ context.Context, id string) (schedule.ScheduleGame, error) {
+	g, ok := f[id]
+	if !ok {
+		return schedule.ScheduleGame{}, fmt.Errorf("game %s: %w", id, schedule.ErrGameNotFound)
+	}
+	return g, nil
+}
+
+var testNow = time.Date(2025, 10, 8, 22, 0, 0, 0, time.UTC)
+
+func newTestAPI(q *fakeQueue) http.Handler {
+	games := fakeGames{
+		"2025020010": {ID: 2025020010, GameDate: "2025-10-08", StartTimeUTC: "2025-10-08T23:00:00Z", GameState: "FUT",
+			HomeTeam: models.Team{Abbrev: "BOS"}, AwayTeam: models.Team{Abbrev: "NYR"}},
+		"2025020011": {ID: 2025020011, GameDate: "2025-10-08", StartTimeUTC: "2025-10-08T21:00:00Z", GameState: "LIVE",
+			HomeTeam: models.Team{Abbrev: "TOR"}, AwayTeam: models.Team{Abbrev: "MTL"}},
+		"2025020001": {ID: 2025020001, GameDate: "2025-10-07", StartTimeUTC: "2025-10-07T23:00:00Z", GameState: "OFF"},
+	}
+	api := New(q, games, testToken, 5)
+	api.now = func() time.Time { return testNow }
+	mux := http.NewServeMux()
+	for pattern, h := range api.Routes() {
+		mux.Handle(pattern, h)
+	}
+	return mux
+}
+
+func do(h http.Handler, method, path, body string, auth bool) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if auth {
+		req.Header.Set("Authorization", "Bearer "+testToken)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAuthorization(t *testing.T) {
+	h := newTestAPI(&fakeQueue{})
+	for _, header := range []string{"", "Bearer wrong", testToken, "Basic " + testToken} {
+		req := httptest.NewRequest(http.MethodGet, GamesPath, nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("Authorization %q: status = %d, want 401", header, rec.Code)
+		}
+	}
+}
+
+func TestListGames(t *testing.T) {
+	next := testNow.Add(time.Minute)
+	q := &fakeQueue{loops: []queue.GameLoop{
+		{TaskID: "t1", GameID: "2025020010", State: queue.StateScheduled, NextRun: next, LastPlay: "faceoff", ShouldNotify: true},
+	}}
+	rec := do(newTestAPI(q), http.MethodGet, GamesPath, "", true)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+	var got []queue.GameLoop
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(got) != 1 || got[0].LastPlay != "faceoff" || !got[0].NextRun.Equal(next) || !got[0].ShouldNotify {
+		t.Errorf("games = %+v", got)
+	}
+
+	if rec := do(newTestAPI(&fakeQueue{}), http.MethodGet, GamesPath, "", true); strings.TrimSpace(rec.Body.String()) != "[]" {
+		t.Errorf("empty queue body = %s, want []", rec.Body)
+	}
+}
+
+func TestStartGame(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		loops         []queue.GameLoop
+		wantStatus    int
+		wantDeliverAt time.Time
+		wantNotify    bool
+		wantCycle     int
+	}{
+		{"future game starts at puck drop", `{"gameId":"2025020010"}`, nil, http.StatusCreated,
+			time.Date(2025, 10, 8, 23, 0, 0, 0, time.UTC), true, 0},
+		{"live game starts now", `{"gameId":"2025020011","shouldNotify":false}`, nil, http.StatusCreated,
+			testNow, false, 3600},
+		{"finished game", `{"gameId":"2025020001"}`, nil, http.StatusConflict, time.Time{}, false, 0},
+		{"already tracked", `{"gameId":"2025020010"}`, []queue.GameLoop{{TaskID: "t1", GameID: "2025020010"}}, http.StatusConflict, time.Time{}, false, 0},
+		{"unknown game", `{"gameId":"2025029999"}`, nil, http.StatusNotFound, time.Time{}, false, 0},
+		{"non-numeric id", `{"gameId":"abc"}`, nil, http.StatusBadRequest, time.Time{}, false, 0},
+		{"malformed body", `{`, nil, http.StatusBadRequest, time.Time{}, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := &fakeQueue{loops: tt.loops}
+			rec := do(newTestAPI(q), http.MethodPost, GamesPath, tt.body, true)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantStatus != http.StatusCreated {
+				if len(q.enqueued) != 0 {
+					t.Errorf("enqueued %d tasks, want none", len(q.enqueued))
+				}
+				return
+			}
+			if len(q.enqueued) != 1 {
+				t.Fatalf("enqueued %d tasks, want 1", len(q.enqueued))
+			}
+			e := q.enqueued[0]
+			if !e.deliverAt.Equal(tt.wantDeliverAt) {
+				t.Errorf("deliverAt = %v, want %v", e.deliverAt, tt.wantDeliverAt)
+			}
+			wantEnd := tt.wantDeliverAt.Add(5 * time.Hour).Format(time.RFC3339)
+			if e.payload.ExecutionEnd == nil || *e.payload.ExecutionEnd != wantEnd {
+				t.Errorf("execution end = %v, want %s", e.payload.ExecutionEnd, wantEnd)
+			}
+			if *e.payload.ShouldNotify != tt.wantNotify || e.payload.Game.HomeTeam.Abbrev == "" {
+				t.Errorf("payload = %+v", e.payload)
+			}
+			if e.payload.Cycle != tt.wantCycle {
+				t.Errorf("cycle = %d, want %d", e.payload.Cycle, tt.wantCycle)
+			}
+		})
+	}
+}
+
+func TestCancelGame(t *testing.T) {
+	q := &fakeQueue{loops: []queue.GameLoop{{TaskID: "t1", GameID: "2025020010"}}}
+	h := newTestAPI(q)
+
+	rec := do(h, http.MethodDelete, "/admin/games/2025020010", "", true)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"cancelled":1`) {
+		t.Errorf("status = %d, body = %s", rec.Code, rec.Body)
+	}
+	if len(q.cancelled) != 1 || q.cancelled[0] != "2025020010" {
+		t.Errorf("cancelled = %v", q.cancelled)
+	}
+
+	if rec := do(h, http.MethodDelete, "/admin/games/2025020099", "", true); rec.Code != http.StatusNotFound {
+		t.Errorf("untracked game: status = %d, want 404", rec.Code)
+	}
+	if rec := do(h, http.MethodDelete, "/admin/games/abc", "", true); rec.Code != http.StatusBadRequest {
+		t.Errorf("non-numeric id: status = %d, want 400", rec.Code)
+	}
+}
+
+func TestQueueErrors(t *testing.T) {
+	h := newTestAPI(&fakeQueue{err: errors.New("redis down")})
+	for _, r := range []struct{ method, path, body string }{
+		{http.MethodGet, GamesPath, ""},
+		{http.MethodPost, GamesPath, `{"gameId":"2025020010"}`},
+		{http.MethodDelete, "/admin/games/2025020010", ""},
+	} {
+		if rec := do(h, r.method, r.path, r.body, true); rec.Code != http.StatusBadGateway {
+			t.Errorf("%s %s: status = %d, want 502", r.method, r.path, rec.Code)
+		}
+	}
+}
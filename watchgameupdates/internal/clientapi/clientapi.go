@@ -0,0 +1,113 @@
+// Package clientapi guards the registration endpoints apps and browsers call
+// to sign up for pushes (push-to-start tokens, Web Push subscriptions). Those
+// endpoints are public, and every registration makes the server send pushes
+// later, so each one is
+//
+//   - rate limited per client address (CLIENT_API_RATE_LIMIT requests per
+//     minute, default 10; 0 turns the limit off), and
+//   - when CLIENT_API_TOKEN is set, only served with
+//     "Authorization: Bearer $CLIENT_API_TOKEN".
+//
+// The stores behind the endpoints also cap how many registrations they hold.
+package clientapi
+
+import (
+	"crypto/subtle"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultRateLimit is the per-client requests per minute when
+// CLIENT_API_RATE_LIMIT is unset.
+const defaultRateLimit = 10
+
+const rateWindow = time.Minute
+
+// Guard authorizes and rate limits requests to a registration endpoint. A nil
+// or zero Guard allows everything.
+type Guard struct {
+	token string
+	limit int // requests per client per rateWindow; 0 = unlimited
+	now   func() time.Time
+
+	mu          sync.Mutex
+	windowStart time.Time
+	counts      map[string]int // client address → requests this window
+}
+
+// FromEnv builds a Guard from CLIENT_API_TOKEN and CLIENT_API_RATE_LIMIT.
+func FromEnv() *Guard {
+	limit := defaultRateLimit
+	if v := os.Getenv("CLIENT_API_RATE_LIMIT"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			log.Printf("WARN: invalid CLIENT_API_RATE_LIMIT %q, using %d", v, defaultRateLimit)
+		} else {
+			limit = n
+		}
+	}
+	return New(os.Getenv("CLIENT_API_TOKEN"), limit)
+}
+
+// New returns a Guard requiring token (none if empty) and allowing limit
+// requests per client per minute (unlimited if 0).
+func New(token string, limit int) *Guard {
+	return &Guard{token: token, limit: limit, now: time.Now}
+}
+
+// Wrap returns h behind the guard: 401 without the token, 429 over the limit.
+func (g *Guard) Wrap(h http.Handler) http.Handler {
+	if g == nil {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if g.token != "" {
+			got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(g.token)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="client"`)
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+		if !g.allow(clientAddr(r)) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(rateWindow.Seconds())))
+			http.Error(w, "too many requests", http.StatusTooManyRequests)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// allow counts a request from client in the current fixed window and reports
+// whether it is within the limit. Counts are dropped when the window rolls
+// over, so memory is bounded by the clients seen in one minute.
+func (g *Guard) allow(client string) bool {
+	if g.limit <= 0 {
+		return true
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := g.now()
+	if g.counts == nil || now.Sub(g.windowStart) >= rateWindow {
+		g.windowStart = now
+		g.counts = map[string]int{}
+	}
+	g.counts[client]++
+	return g.counts[client] <= g.limit
+}
+
+// clientAddr is the remote IP without the port. Forwarding headers are not
+// trusted, as a client can set them to anything.
+func clientAddr(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
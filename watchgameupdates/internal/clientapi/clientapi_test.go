@@ -0,0 +1,89 @@
+package clientapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNoContent)
+})
+
+func do(h http.Handler, remote, auth string) int {
+	req := httptest.NewRequest(http.MethodPost, "/register", nil)
+	req.RemoteAddr = remote
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestGuard_RequiresToken(t *testing.T) {
+	h := New("secret", 0).Wrap(okHandler)
+	for _, tt := range []struct {
+		auth string
+		want int
+	}{
+		{"", http.StatusUnauthorized},
+		{"Bearer wrong", http.StatusUnauthorized},
+		{"secret", http.StatusUnauthorized},
+		{"Bearer secret", http.StatusNoContent},
+	} {
+		if got := do(h, "192.0.2.1:1234", tt.auth); got != tt.want {
+			t.Errorf("Authorization %q: status %d, want %d", tt.auth, got, tt.want)
+		}
+	}
+}
+
+func TestGuard_NoTokenConfigured(t *testing.T) {
+	if got := do(New("", 0).Wrap(okHandler), "192.0.2.1:1234", ""); got != http.StatusNoContent {
+		t.Errorf("status %d, want %d", got, http.StatusNoContent)
+	}
+}
+
+func TestGuard_RateLimitsPerClient(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	g := New("", 2)
+	g.now = func() time.Time { return now }
+	h := g.Wrap(okHandler)
+
+	for i, want := range []int{http.StatusNoContent, http.StatusNoContent, http.StatusTooManyRequests} {
+		if got := do(h, "192.0.2.1:1000", ""); got != want {
+			t.Fatalf("request %d: status %d, want %d", i+1, got, want)
+		}
+	}
+	if got := do(h, "192.0.2.2:1000", ""); got != http.StatusNoContent {
+		t.Errorf("other client: status %d, want %d", got, http.StatusNoContent)
+	}
+	if got := do(h, "192.0.2.1:2000", ""); got != http.StatusTooManyRequests {
+		t.Errorf("same client, other port: status %d, want %d", got, http.StatusTooManyRequests)
+	}
+
+	now = now.Add(rateWindow)
+	if got := do(h, "192.0.2.1:1000", ""); got != http.StatusNoContent {
+		t.Errorf("next window: status %d, want %d", got, http.StatusNoContent)
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("CLIENT_API_TOKEN", "tok")
+	t.Setenv("CLIENT_API_RATE_LIMIT", "")
+	g := FromEnv()
+	if g.token != "tok" || g.limit != defaultRateLimit {
+		t.Errorf("FromEnv() = token %q limit %d, want tok/%d", g.token, g.limit, defaultRateLimit)
+	}
+
+	t.Setenv("CLIENT_API_RATE_LIMIT", "0")
+	if g := FromEnv(); g.limit != 0 {
+		t.Errorf("CLIENT_API_RATE_LIMIT=0: limit %d, want 0", g.limit)
+	}
+
+	t.Setenv("CLIENT_API_RATE_LIMIT", "abc")
+	if g := FromEnv(); g.limit != defaultRateLimit {
+		t.Errorf("invalid CLIENT_API_RATE_LIMIT: limit %d, want %d", g.limit, defaultRateLimit)
+	}
+}
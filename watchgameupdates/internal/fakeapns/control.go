@@ -0,0 +1,41 @@
+package fakeapns
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Handler returns s wrapped with the /_fake/ control endpoints described in
+// the package comment. The control endpoints need no APNs authentication.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/_fake/requests", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			w.Header().Set("content-type", "application/json")
+			json.NewEncoder(w).Encode(s.Requests())
+		case http.MethodDelete:
+			s.Reset()
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.Header().Set("Allow", "GET, DELETE")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+	mux.HandleFunc("/_fake/responses", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", "POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var responses []Response
+		if err := json.NewDecoder(r.Body).Decode(&responses); err != nil {
+			http.Error(w, "body must be a JSON array of responses", http.StatusBadRequest)
+			return
+		}
+		s.Script(responses...)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.Handle("/", s)
+	return mux
+}
@@ -0,0 +1,402 @@
+// Package fakeapns is a local stand-in for Apple Push Notification service,
+// used to exercise the Live Activity notifier end to end without Apple.
+//
+// It serves the same routes the notifier calls:
+//
+//	POST   /4/broadcasts/apps/{bundleID}    broadcast push (apns-channel-id)
+//	POST   /3/device/{token}                push-to-start
+//	POST   /1/apps/{bundleID}/channels      create broadcast channel
+//	GET    /1/apps/{bundleID}/all-channels  list broadcast channels
+//	DELETE /1/apps/{bundleID}/channels      delete broadcast channel
+//
+// Every request is authenticated the way APNs does it: the ES256 provider JWT
+// must verify against the configured .p8 key, carry the configured key and
+// team IDs, and be less than an hour old. Push requests are also checked for
+// the headers APNs requires. Failures are answered with APNs' status codes and
+// {"reason": "..."} bodies.
+//
+// Tests drive the Server directly (it is an http.Handler; serve it over TLS
+// with HTTP/2 enabled). The cmd/fakeapns binary exposes the same control
+// surface over HTTP for docker-compose runs:
+//
+//	GET    /_fake/requests   recorded requests (JSON)
+//	DELETE /_fake/requests   clear recorded requests and scripted responses
+//	POST   /_fake/responses  queue scripted responses (JSON array of Response)
+package fakeapns
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// tokenMaxAge mirrors APNs: provider tokens older than an hour are rejected.
+	tokenMaxAge = time.Hour
+	// maxPayloadSize is the APNs limit for Live Activity payloads.
+	maxPayloadSize = 4096
+)
+
+// Request kinds recorded by the server.
+const (
+	KindBroadcast     = "broadcast"
+	KindDevice        = "device"
+	KindCreateChannel = "create-channel"
+	KindListChannels  = "list-channels"
+	KindDeleteChannel = "delete-channel"
+)
+
+// Options configures a Server.
+type Options struct {
+	AuthKey  string // base64-encoded .p8 contents, same format as APNS_AUTH_KEY
+	KeyID    string
+	TeamID   string
+	BundleID string
+}
+
+// Response is a scripted reply. Target restricts it to one channel ID or device
+// token; an empty Target matches the next push to anything.
+type Response struct {
+	Status int    `json:"status"`
+	Reason string `json:"reason,omitempty"`
+	Target string `json:"target,omitempty"`
+}
+
+// Request is one request the server received, with the status it answered.
+type Request struct {
+	Kind      string          `json:"kind"`
+	Method    string          `json:"method"`
+	Path      string          `json:"path"`
+	Proto     int             `json:"proto"`
+	Target    string          `json:"target,omitempty"` // channel ID or device token
+	Headers   http.Header     `json:"headers"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+	Status    int             `json:"status"`
+	Reason    string          `json:"reason,omitempty"`
+	Timestamp time.Time       `json:"timestamp"`
+}
+
+// Server is a fake APNs endpoint. It is safe for concurrent use.
+type Server struct {
+	opts Options
+	key  *ecdsa.PublicKey
+
+	mu       sync.Mutex
+	requests []Request
+	script   []Response
+	channels map[string]bool
+	nextID   int
+}
+
+// New returns a Server that accepts JWTs signed with opts.AuthKey.
+func New(opts Options) (*Server, error) {
+	key, err := parsePublicKey(opts.AuthKey)
+	if err != nil {
+		return nil, err
+	}
+	return &Server{opts: opts, key: key, channels: map[string]bool{}}, nil
+}
+
+// Script queues responses. Each push consumes the first queued response whose
+// Target matches it; pushes with no matching response succeed.
+func (s *Server) Script(responses ...Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.script = append(s.script, responses...)
+}
+
+// Requests returns a copy of every request received so far.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// Pushes returns the recorded requests of the given kind.
+func (s *Server) Pushes(kind string) []Request {
+	var out []Request
+	for _, r := range s.Requests() {
+		if r.Kind == kind {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// Reset clears recorded requests and scripted responses.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = nil
+	s.script = nil
+}
+
+// AddChannel registers an existing broadcast channel ID, as if it had been
+// created in App Store Connect.
+func (s *Server) AddChannel(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.channels[id] = true
+}
+
+// ServeHTTP implements the APNs routes listed in the package comment.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxPayloadSize+1))
+	if err != nil {
+		http.Error(w, "read body", http.StatusBadRequest)
+		return
+	}
+
+	rec := Request{
+		Method:    r.Method,
+		Path:      r.URL.Path,
+		Proto:     r.ProtoMajor,
+		Headers:   r.Header.Clone(),
+		Timestamp: time.Now(),
+	}
+	if json.Valid(body) {
+		rec.Payload = body
+	}
+
+	status, reason := s.route(r, &rec, body)
+	rec.Status, rec.Reason = status, reason
+
+	s.mu.Lock()
+	s.requests = append(s.requests, rec)
+	s.mu.Unlock()
+
+	if status == http.StatusOK || status == http.StatusCreated || status == http.StatusNoContent {
+		if rec.Kind == KindCreateChannel {
+			w.Header().Set("apns-channel-id", rec.Target)
+		}
+		w.WriteHeader(status)
+		if rec.Kind == KindListChannels {
+			json.NewEncoder(w).Encode(map[string][]string{"channels": s.channelIDs()})
+		}
+		return
+	}
+	writeReason(w, status, reason)
+}
+
+// route classifies and validates the request, and returns the status and
+// APNs reason to answer with.
+func (s *Server) route(r *http.Request, rec *Request, body []byte) (int, string) {
+	path := r.URL.Path
+	switch {
+	case strings.HasPrefix(path, "/4/broadcasts/apps/") && r.Method == http.MethodPost:
+		rec.Kind = KindBroadcast
+		rec.Target = r.Header.Get("apns-channel-id")
+		if status, reason := s.checkAuth(r); status != 0 {
+			return status, reason
+		}
+		if strings.TrimPrefix(path, "/4/broadcasts/apps/") != s.opts.BundleID {
+			return http.StatusBadRequest, "BadTopic"
+		}
+		if rec.Target == "" {
+			return http.StatusBadRequest, "MissingChannelId"
+		}
+		if _, err := strconv.ParseInt(r.Header.Get("apns-expiration"), 10, 64); err != nil {
+			return http.StatusBadRequest, "BadExpirationDate"
+		}
+		if status, reason := checkPush(r, body); status != 0 {
+			return status, reason
+		}
+		return s.scripted(rec.Target, http.StatusOK)
+
+	case strings.HasPrefix(path, "/3/device/") && r.Method == http.MethodPost:
+		rec.Kind = KindDevice
+		rec.Target = strings.TrimPrefix(path, "/3/device/")
+		if status, reason := s.checkAuth(r); status != 0 {
+			return status, reason
+		}
+		if r.Header.Get("apns-topic") != s.opts.BundleID+".push-type.liveactivity" {
+			return http.StatusBadRequest, "BadTopic"
+		}
+		if rec.Target == "" {
+			return http.StatusBadRequest, "BadDeviceToken"
+		}
+		if status, reason := checkPush(r, body); status != 0 {
+			return status, reason
+		}
+		return s.scripted(rec.Target, http.StatusOK)
+
+	case path == "/1/apps/"+s.opts.BundleID+"/channels" && r.Method == http.MethodPost:
+		rec.Kind = KindCreateChannel
+		if status, reason := s.checkAuth(r); status != 0 {
+			return status, reason
+		}
+		if status, reason := s.scripted("", http.StatusCreated); status != http.StatusCreated {
+			return status, reason
+		}
+		rec.Target = s.createChannel()
+		return http.StatusCreated, ""
+
+	case path == "/1/apps/"+s.opts.BundleID+"/all-channels" && r.Method == http.MethodGet:
+		rec.Kind = KindListChannels
+		if status, reason := s.checkAuth(r); status != 0 {
+			return status, reason
+		}
+		return s.scripted("", http.StatusOK)
+
+	case path == "/1/apps/"+s.opts.BundleID+"/channels" && r.Method == http.MethodDelete:
+		rec.Kind = KindDeleteChannel
+		rec.Target = r.Header.Get("apns-channel-id")
+		if status, reason := s.checkAuth(r); status != 0 {
+			return status, reason
+		}
+		if status, reason := s.scripted(rec.Target, http.StatusNoContent); status != http.StatusNoContent {
+			return status, reason
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if !s.channels[rec.Target] {
+			return http.StatusNotFound, "ChannelNotRegistered"
+		}
+		delete(s.channels, rec.Target)
+		return http.StatusNoContent, ""
+
+	default:
+		return http.StatusNotFound, "BadPath"
+	}
+}
+
+// scripted pops the first queued response matching target, or returns ok.
+func (s *Server) scripted(target string, ok int) (int, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, resp := range s.script {
+		if resp.Target == "" || resp.Target == target {
+			s.script = append(s.script[:i], s.script[i+1:]...)
+			return resp.Status, resp.Reason
+		}
+	}
+	return ok, ""
+}
+
+func (s *Server) createChannel() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	id := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("fake-channel-%d", s.nextID)))
+	s.channels[id] = true
+	return id
+}
+
+func (s *Server) channelIDs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := make([]string, 0, len(s.channels))
+	for id := range s.channels {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// checkAuth validates the provider JWT and returns a zero status if it is good.
+func (s *Server) checkAuth(r *http.Request) (int, string) {
+	auth := r.Header.Get("authorization")
+	if !strings.HasPrefix(auth, "bearer ") {
+		return http.StatusForbidden, "MissingProviderToken"
+	}
+	parts := strings.Split(strings.TrimPrefix(auth, "bearer "), ".")
+	if len(parts) != 3 {
+		return http.StatusForbidden, "InvalidProviderToken"
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	var claims struct {
+		Iss string `json:"iss"`
+		Iat int64  `json:"iat"`
+	}
+	if decodeSegment(parts[0], &header) != nil || decodeSegment(parts[1], &claims) != nil {
+		return http.StatusForbidden, "InvalidProviderToken"
+	}
+	if header.Alg != "ES256" || header.Kid != s.opts.KeyID || claims.Iss != s.opts.TeamID {
+		return http.StatusForbidden, "InvalidProviderToken"
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || len(sig) != 64 {
+		return http.StatusForbidden, "InvalidProviderToken"
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	rInt := new(big.Int).SetBytes(sig[:32])
+	sInt := new(big.Int).SetBytes(sig[32:])
+	if !ecdsa.Verify(s.key, digest[:], rInt, sInt) {
+		return http.StatusForbidden, "InvalidProviderToken"
+	}
+
+	if time.Since(time.Unix(claims.Iat, 0)) > tokenMaxAge {
+		return http.StatusForbidden, "ExpiredProviderToken"
+	}
+	return 0, ""
+}
+
+// checkPush validates the headers and body common to every Live Activity push.
+func checkPush(r *http.Request, body []byte) (int, string) {
+	if r.Header.Get("apns-push-type") != "liveactivity" {
+		return http.StatusBadRequest, "InvalidPushType"
+	}
+	if p := r.Header.Get("apns-priority"); p != "" && p != "5" && p != "10" {
+		return http.StatusBadRequest, "BadPriority"
+	}
+	if len(body) == 0 {
+		return http.StatusBadRequest, "PayloadEmpty"
+	}
+	if len(body) > maxPayloadSize {
+		return http.StatusRequestEntityTooLarge, "PayloadTooLarge"
+	}
+	if !json.Valid(body) {
+		return http.StatusBadRequest, "BadPayload"
+	}
+	return 0, ""
+}
+
+func writeReason(w http.ResponseWriter, status int, reason string) {
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"reason": reason})
+}
+
+func decodeSegment(seg string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// parsePublicKey extracts the verification key from a base64-encoded .p8.
+func parsePublicKey(authKey string) (*ecdsa.PublicKey, error) {
+	decoded, err := base64.StdEncoding.DecodeString(authKey)
+	if err != nil {
+		return nil, fmt.Errorf("base64-decode auth key: %w", err)
+	}
+	block, _ := pem.Decode(decoded)
+	if block == nil {
+		return nil, fmt.Errorf("auth key has no PEM block")
+	}
+	raw, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse PKCS8 auth key: %w", err)
+	}
+	priv, ok := raw.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("auth key is not an EC private key")
+	}
+	return &priv.PublicKey, nil
+}
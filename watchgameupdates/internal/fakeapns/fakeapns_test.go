@@ -0,0 +1,202 @@
+package fakeapns
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testBundle = "me.test.app"
+
+// testKey returns a P-256 key and its base64 .p8 encoding.
+func testKey(t *testing.T) (*ecdsa.PrivateKey, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+	return key, base64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+}
+
+// signJWT builds an APNs provider token.
+func signJWT(t *testing.T, key *ecdsa.PrivateKey, kid, iss string, iat time.Time) string {
+	t.Helper()
+	enc := func(v interface{}) string {
+		b, _ := json.Marshal(v)
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+	msg := enc(map[string]string{"alg": "ES256", "kid": kid}) + "." + enc(map[string]interface{}{"iss": iss, "iat": iat.Unix()})
+	digest := sha256.Sum256([]byte(msg))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+	return msg + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func newTestServer(t *testing.T) (*Server, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, p8 := testKey(t)
+	s, err := New(Options{AuthKey: p8, KeyID: "KID", TeamID: "TID", BundleID: testBundle})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return s, key
+}
+
+func broadcastRequest(jwt string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/4/broadcasts/apps/"+testBundle, strings.NewReader(`{"aps":{}}`))
+	req.Header.Set("authorization", "bearer "+jwt)
+	req.Header.Set("apns-push-type", "liveactivity")
+	req.Header.Set("apns-channel-id", "chan-1")
+	req.Header.Set("apns-expiration", "0")
+	req.Header.Set("apns-priority", "10")
+	return req
+}
+
+func reasonOf(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+	var body struct {
+		Reason string `json:"reason"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &body)
+	return body.Reason
+}
+
+func TestServer_Validation(t *testing.T) {
+	s, key := newTestServer(t)
+	wrongKey, _ := testKey(t)
+
+	good := signJWT(t, key, "KID", "TID", time.Now())
+	tests := []struct {
+		name       string
+		mutate     func(r *http.Request)
+		wantStatus int
+		wantReason string
+	}{
+		{"ok", func(r *http.Request) {}, http.StatusOK, ""},
+		{"no token", func(r *http.Request) { r.Header.Del("authorization") }, http.StatusForbidden, "MissingProviderToken"},
+		{"wrong signing key", func(r *http.Request) {
+			r.Header.Set("authorization", "bearer "+signJWT(t, wrongKey, "KID", "TID", time.Now()))
+		}, http.StatusForbidden, "InvalidProviderToken"},
+		{"wrong key id", func(r *http.Request) {
+			r.Header.Set("authorization", "bearer "+signJWT(t, key, "OTHER", "TID", time.Now()))
+		}, http.StatusForbidden, "InvalidProviderToken"},
+		{"expired token", func(r *http.Request) {
+			r.Header.Set("authorization", "bearer "+signJWT(t, key, "KID", "TID", time.Now().Add(-2*time.Hour)))
+		}, http.StatusForbidden, "ExpiredProviderToken"},
+		{"wrong push type", func(r *http.Request) { r.Header.Set("apns-push-type", "alert") }, http.StatusBadRequest, "InvalidPushType"},
+		{"missing channel", func(r *http.Request) { r.Header.Del("apns-channel-id") }, http.StatusBadRequest, "MissingChannelId"},
+		{"missing expiration", func(r *http.Request) { r.Header.Del("apns-expiration") }, http.StatusBadRequest, "BadExpirationDate"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := broadcastRequest(good)
+			tt.mutate(req)
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := reasonOf(t, rec); got != tt.wantReason {
+				t.Errorf("reason = %q, want %q", got, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestServer_ScriptedResponses(t *testing.T) {
+	s, key := newTestServer(t)
+	jwt := signJWT(t, key, "KID", "TID", time.Now())
+	s.Script(
+		Response{Status: http.StatusGone, Reason: "ChannelNotRegistered", Target: "other"},
+		Response{Status: http.StatusTooManyRequests, Reason: "TooManyRequests"},
+	)
+
+	statuses := []int{}
+	for i := 0; i < 2; i++ {
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, broadcastRequest(jwt))
+		statuses = append(statuses, rec.Code)
+	}
+	if statuses[0] != http.StatusTooManyRequests || statuses[1] != http.StatusOK {
+		t.Errorf("statuses = %v, want [429 200] (targeted 410 must not match chan-1)", statuses)
+	}
+
+	pushes := s.Pushes(KindBroadcast)
+	if len(pushes) != 2 || pushes[0].Target != "chan-1" || string(pushes[0].Payload) != `{"aps":{}}` {
+		t.Errorf("recorded pushes = %+v", pushes)
+	}
+}
+
+func TestServer_ChannelManagement(t *testing.T) {
+	s, key := newTestServer(t)
+	jwt := signJWT(t, key, "KID", "TID", time.Now())
+	do := func(method, path, channelID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("authorization", "bearer "+jwt)
+		if channelID != "" {
+			req.Header.Set("apns-channel-id", channelID)
+		}
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do(http.MethodPost, "/1/apps/"+testBundle+"/channels", "")
+	id := rec.Header().Get("apns-channel-id")
+	if rec.Code != http.StatusCreated || id == "" {
+		t.Fatalf("create: status %d id %q", rec.Code, id)
+	}
+	rec = do(http.MethodGet, "/1/apps/"+testBundle+"/all-channels", "")
+	if !strings.Contains(rec.Body.String(), id) {
+		t.Errorf("list = %s, want it to contain %s", rec.Body.String(), id)
+	}
+	if rec := do(http.MethodDelete, "/1/apps/"+testBundle+"/channels", id); rec.Code != http.StatusNoContent {
+		t.Errorf("delete status = %d, want 204", rec.Code)
+	}
+	if rec := do(http.MethodDelete, "/1/apps/"+testBundle+"/channels", id); rec.Code != http.StatusNotFound {
+		t.Errorf("second delete status = %d, want 404", rec.Code)
+	}
+}
+
+func TestHandler_ControlEndpoints(t *testing.T) {
+	s, key := newTestServer(t)
+	h := s.Handler()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/_fake/responses", strings.NewReader(`[{"status":500}]`)))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("script status = %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, broadcastRequest(signJWT(t, key, "KID", "TID", time.Now())))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("push status = %d, want scripted 500", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/_fake/requests", nil))
+	var got []Request
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil || len(got) != 1 || got[0].Status != 500 {
+		t.Errorf("requests = %s (%v)", rec.Body.String(), err)
+	}
+}
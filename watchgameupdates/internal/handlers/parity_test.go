@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"watchgameupdates/config"
+	"watchgameupdates/internal/models"
+	"watchgameupdates/internal/notification"
+	"watchgameupdates/internal/services"
+	"watchgameupdates/internal/tasks"
+)
+
+// recordingQueue is a services.GameEnqueuer that records the checks it is
+// given and how far ahead each was scheduled.
+type recordingQueue struct {
+	payloads []models.Payload
+	delays   []time.Duration
+}
+
+func (q *recordingQueue) Enqueue(_ context.Context, payload models.Payload, deliverAt time.Time) error {
+	q.payloads = append(q.payloads, payload)
+	q.delays = append(q.delays, time.Until(deliverAt).Round(time.Second))
+	return nil
+}
+
+// runHTTPMode delivers payload to the Cloud Tasks handler.
+func runHTTPMode(t *testing.T, cfg *config.Config, payload models.Payload) *recordingQueue {
+	t.Helper()
+	q := &recordingQueue{}
+	body, _ := json.Marshal(payload)
+	r := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	WatchGameUpdatesHandler(w, r, cfg, services.NewRescheduler(q), &services.HTTPGameDataFetcher{},
+		notification.NewServiceWithNotificationFlag(true), nil, payload)
+	if w.Code != http.StatusOK {
+		t.Fatalf("HTTP mode answered %d: %s", w.Code, w.Body)
+	}
+	return q
+}
+
+// runWorkerMode delivers payload to the asynq handler.
+func runWorkerMode(t *testing.T, cfg *config.Config, payload models.Payload) *recordingQueue {
+	t.Helper()
+	q := &recordingQueue{}
+	task, err := tasks.NewWatchGameUpdatesTask(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tasks.NewWatchGameUpdatesHandler(cfg, services.NewRescheduler(q)).ProcessTask(context.Background(), task); err != nil {
+		t.Fatalf("worker mode: %v", err)
+	}
+	return q
+}
+
+// TestReschedulingParity runs the same game check through HTTP mode and
+// worker mode and expects both to schedule the same next check.
+func TestReschedulingParity(t *testing.T) {
+	t.Setenv("NOTIFIERS", "")
+	cfg := &config.Config{MessageIntervalSeconds: 30, FastIntervalSeconds: 10, PeriodEndIntervalSeconds: 1200}
+	end := time.Now().Add(3 * time.Hour).UTC().Format(time.RFC3339)
+
+	tests := []struct {
+		name      string
+		feed      string
+		csv       string
+		wantDelay time.Duration // 0 = no next check
+	}{
+		{
+			name:      "play",
+			feed:      `{"gameState":"LIVE","clock":{"secondsRemaining":900,"running":true},"plays":[{"typeDescKey":"faceoff","periodDescriptor":{"number":2,"periodType":"REG"}}]}`,
+			wantDelay: 30 * time.Second,
+		},
+		{
+			name:      "close late game",
+			feed:      `{"gameState":"LIVE","clock":{"secondsRemaining":180,"running":true},"homeTeam":{"score":2},"awayTeam":{"score":1},"plays":[{"typeDescKey":"faceoff","periodDescriptor":{"number":3,"periodType":"REG"}}]}`,
+			wantDelay: 10 * time.Second,
+		},
+		{
+			name:      "period end",
+			feed:      `{"gameState":"LIVE","plays":[{"typeDescKey":"period-end","periodDescriptor":{"number":1,"periodType":"REG"}}]}`,
+			csv:       "id,homeTeamGoals\n1,0\n",
+			wantDelay: 1200 * time.Second,
+		},
+		{
+			name:      "data error",
+			feed:      `{"gameState":"LIVE","plays":[{"typeDescKey":"period-end","periodDescriptor":{"number":1,"periodType":"REG"}}]}`,
+			csv:       "id,homeTeamGoals\n1,Tkachuk 6'2\" wrister\n",
+			wantDelay: services.ParseErrorRetryInterval,
+		},
+		{
+			name: "game over",
+			feed: `{"gameState":"OFF","plays":[{"typeDescKey":"game-end","periodDescriptor":{"number":3,"periodType":"REG"}}]}`,
+			csv:  "id,homeTeamGoals\n1,0\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pbp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(tt.feed))
+			}))
+			defer pbp.Close()
+			t.Setenv("PLAYBYPLAY_API_BASE_URL", pbp.URL)
+			stats := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(tt.csv))
+			}))
+			defer stats.Close()
+			t.Setenv("STATS_API_BASE_URL", stats.URL)
+
+			payload := models.Payload{
+				Game: models.Game{
+					ID:       "2025020001",
+					HomeTeam: models.Team{Abbrev: "CAR", CommonName: map[string]string{"default": "Hurricanes"}},
+					AwayTeam: models.Team{Abbrev: "FLA", CommonName: map[string]string{"default": "Panthers"}},
+				},
+				ExecutionEnd: &end,
+				Cycle:        2,
+			}
+
+			modes := map[string]*recordingQueue{
+				"http":   runHTTPMode(t, cfg, payload),
+				"worker": runWorkerMode(t, cfg, payload),
+			}
+			for mode, q := range modes {
+				if tt.wantDelay == 0 {
+					if len(q.payloads) != 0 {
+						t.Errorf("%s mode scheduled %d checks, want none", mode, len(q.payloads))
+					}
+					continue
+				}
+				if len(q.payloads) != 1 {
+					t.Fatalf("%s mode scheduled %d checks, want 1", mode, len(q.payloads))
+				}
+				if q.delays[0] != tt.wantDelay {
+					t.Errorf("%s mode delay = %s, want %s", mode, q.delays[0], tt.wantDelay)
+				}
+			}
+			if tt.wantDelay == 0 {
+				return
+			}
+			httpNext, _ := json.Marshal(modes["http"].payloads[0])
+			workerNext, _ := json.Marshal(modes["worker"].payloads[0])
+			if !bytes.Equal(httpNext, workerNext) {
+				t.Errorf("next payloads differ:\n http:   %s\n worker: %s", httpNext, workerNext)
+			}
+			if next := modes["worker"].payloads[0]; next.Cycle != payload.Cycle+1 {
+				t.Errorf("next Cycle = %d, want %d", next.Cycle, payload.Cycle+1)
+			}
+		})
+	}
+}
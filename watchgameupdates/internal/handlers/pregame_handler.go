@@ -0,0 +1,19 @@
+package handlers
+
+import (
+	"net/http"
+
+	"watchgameupdates/internal/models"
+	"watchgameupdates/internal/notification"
+	"watchgameupdates/internal/services"
+)
+
+// PregameHandler handles a pregame Cloud Task. It always answers 200 so Cloud
+// Tasks does not retry: a late pregame message is worse than none.
+func PregameHandler(
+	w http.ResponseWriter,
+	r *http.Request,
+	notificationService *notification.Service,
+	payload models.Payload) {
+	services.ProcessPregame(r.Context(), notificationService, payload)
+}
@@ -1,9 +1,6 @@
 package handlers
 
 import (
-	"context"
-	"encoding/json"
-	"fmt"
 	"io"
 	"log"
 	"net/http"
@@ -13,17 +10,18 @@ import (
 	"watchgameupdates/internal/models"
 	"watchgameupdates/internal/notification"
 	"watchgameupdates/internal/services"
-	"watchgameupdates/internal/tasks"
-
-	taskspb "cloud.google.com/go/cloudtasks/apiv2/cloudtaskspb"
-	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+// WatchGameUpdatesHandler handles a game check delivered by Cloud Tasks and
+// schedules the next one through rescheduler, as worker mode does.
 func WatchGameUpdatesHandler(
 	w http.ResponseWriter,
 	r *http.Request,
+	cfg *config.Config,
+	rescheduler *services.Rescheduler,
 	fetcher services.GameDataFetcher,
 	notificationService *notification.Service,
+	games services.GameLookup,
 	payload models.Payload) {
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
@@ -47,83 +45,27 @@ func WatchGameUpdatesHandler(
 	processor := &services.GameProcessor{
 		Fetcher:             fetcher,
 		NotificationService: notificationService,
+		Config:              cfg,
+		Games:               games,
 	}
-	result := processor.ProcessGameUpdate(payload)
 
-	if result.ShouldReschedule {
-		cfg := config.LoadConfig()
-		interval := services.RescheduleInterval(result.LastPlay, result.MaxPeriods, cfg)
-		if result.RetryAfterDataError {
-			// MoneyPuck data was unparseable and nothing was sent; ignore the
-			// play-type interval and retry soon to pick up the corrected file.
-			interval = services.ParseErrorRetryInterval
-		}
-		if err := scheduleNextCheck(payload, interval); err != nil {
-			log.Printf("Failed to schedule next check: %v", err)
-			http.Error(w, "Failed to schedule next check", http.StatusInternalServerError)
-			return
+	check := processor.RevalidateSchedule(r.Context(), payload, time.Now())
+	switch check.Check {
+	case services.GamePostponed:
+		return
+	case services.GameMoved:
+		if err := rescheduler.Requeue(r.Context(), check); err != nil {
+			log.Printf("Failed to re-enqueue moved game: %v", err)
+			http.Error(w, "Failed to re-enqueue moved game", http.StatusInternalServerError)
 		}
+		return
 	}
-}
-
-// scheduleNextCheck creates a Cloud Task to reschedule the next game check
-func scheduleNextCheck(payload models.Payload, interval time.Duration) error {
-	cfg := config.LoadConfig()
-
-	tasksCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	tasksClient, err := tasks.NewCloudTasksClient(tasksCtx, cfg)
-	if err != nil {
-		log.Fatalf("failed to create tasks client: %v", err)
-	}
-	defer tasksClient.Close()
-
-	scheduleTime := timestamppb.New(time.Now().Add(interval))
-
-	payloadJSON, err := json.Marshal(payload)
-	if err != nil {
-		return fmt.Errorf("failed to marshal reschedule payload: %w", err)
-	}
-
-	projectID := cfg.ProjectID
-	location := cfg.LocationID
-	queueName := cfg.QueueID
-
-	queuePath := fmt.Sprintf("projects/%s/locations/%s/queues/%s", projectID, location, queueName)
-
-	if payload.ExecutionEnd != nil {
-		log.Printf("Max execution time for game %s is set to %s", payload.Game.ID, *payload.ExecutionEnd)
-	} else {
-		log.Printf("Max execution time for game %s is not set", payload.Game.ID)
-	}
-
-	task := &taskspb.Task{
-		MessageType: &taskspb.Task_HttpRequest{
-			HttpRequest: &taskspb.HttpRequest{
-				HttpMethod: taskspb.HttpMethod_POST,
-				Url:        cfg.HandlerAddress,
-				Headers: map[string]string{
-					"Content-Type": "application/json",
-				},
-				Body: payloadJSON,
-			},
-		},
-		ScheduleTime: scheduleTime,
-	}
-
-	req := &taskspb.CreateTaskRequest{
-		Parent: queuePath,
-		Task:   task,
-	}
+	payload = check.Payload
 
-	log.Printf("Sending task creation request in %ds for game %s to Cloud Tasks queue %s", int(interval.Seconds()), payload.Game.ID, queuePath)
+	result := processor.ProcessGameUpdate(payload)
 
-	_, err = tasksClient.CreateTask(tasksCtx, req)
-	if err != nil {
-		return fmt.Errorf("failed to create reschedule task: %w", err)
+	if err := rescheduler.Reschedule(r.Context(), payload, result); err != nil {
+		log.Printf("Failed to schedule next check: %v", err)
+		http.Error(w, "Failed to schedule next check", http.StatusInternalServerError)
 	}
-
-	log.Printf("Successfully scheduled next check task for game %s at %v", payload.Game.ID, scheduleTime.AsTime().Format(time.RFC3339))
-	return nil
 }
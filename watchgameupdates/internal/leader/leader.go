@@ -0,0 +1,94 @@
+// Package leader elects one leader among replicas with a Redis lock:
+//
+//	SET {key} {token} NX PX {ttl}   acquire
+//	PEXPIRE {key} {ttl}             renew, only while GET {key} == token
+//	DEL {key}                       release, only while GET {key} == token
+//
+// The token is unique per process, so a replica never renews or releases a
+// lock that expired and was taken over by another one.
+package leader
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisLock is a lease on key, held for ttl unless renewed.
+type RedisLock struct {
+	client *redis.Client
+	key    string
+	token  string
+	ttl    time.Duration
+}
+
+// NewRedisLock creates a lock on key. ttl bounds how long a crashed leader
+// keeps the lock; renew well within it.
+func NewRedisLock(client *redis.Client, key string, ttl time.Duration) *RedisLock {
+	return &RedisLock{client: client, key: key, token: newToken(), ttl: ttl}
+}
+
+// TTL returns the lease duration.
+func (l *RedisLock) TTL() time.Duration {
+	return l.ttl
+}
+
+// Acquire takes the lock if it is free and reports whether this process holds
+// it, which is also true if it already did.
+func (l *RedisLock) Acquire(ctx context.Context) (bool, error) {
+	ok, err := l.client.SetNX(ctx, l.key, l.token, l.ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("redis SET %s NX: %w", l.key, err)
+	}
+	if ok {
+		return true, nil
+	}
+	return l.Renew(ctx)
+}
+
+var renewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// Renew extends the lease and reports whether this process still holds the
+// lock.
+func (l *RedisLock) Renew(ctx context.Context) (bool, error) {
+	n, err := renewScript.Run(ctx, l.client, []string{l.key}, l.token, l.ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, fmt.Errorf("redis renew %s: %w", l.key, err)
+	}
+	return n == 1, nil
+}
+
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// Release gives the lock up if this process holds it, so another replica can
+// take over without waiting for the lease to expire.
+func (l *RedisLock) Release(ctx context.Context) error {
+	if err := releaseScript.Run(ctx, l.client, []string{l.key}, l.token).Err(); err != nil {
+		return fmt.Errorf("redis release %s: %w", l.key, err)
+	}
+	return nil
+}
+
+// newToken identifies this process as the lock holder: hostname (the pod
+// name in Kubernetes) for debugging, plus random bytes for uniqueness.
+func newToken() string {
+	host, _ := os.Hostname()
+	b := make([]byte, 8)
+	rand.Read(b)
+	return host + "-" + hex.EncodeToString(b)
+}
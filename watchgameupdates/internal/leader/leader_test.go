@@ -0,0 +1,81 @@
+package leader
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestLocks(t *testing.T) (*miniredis.Miniredis, *RedisLock, *RedisLock) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return mr, NewRedisLock(client, "scheduler:leader", 30*time.Second), NewRedisLock(client, "scheduler:leader", 30*time.Second)
+}
+
+func TestRedisLock_SingleLeader(t *testing.T) {
+	ctx := context.Background()
+	_, a, b := newTestLocks(t)
+
+	if ok, err := a.Acquire(ctx); err != nil || !ok {
+		t.Fatalf("a.Acquire = %v, %v; want leader", ok, err)
+	}
+	if ok, err := a.Acquire(ctx); err != nil || !ok {
+		t.Errorf("a.Acquire again = %v, %v; want still leader", ok, err)
+	}
+	if ok, err := b.Acquire(ctx); err != nil || ok {
+		t.Errorf("b.Acquire = %v, %v; want follower", ok, err)
+	}
+	if ok, _ := b.Renew(ctx); ok {
+		t.Error("b.Renew succeeded on a lock it does not hold")
+	}
+
+	if err := b.Release(ctx); err != nil {
+		t.Fatalf("b.Release: %v", err)
+	}
+	if ok, _ := a.Renew(ctx); !ok {
+		t.Error("a lost the lock to a release by b")
+	}
+
+	if err := a.Release(ctx); err != nil {
+		t.Fatalf("a.Release: %v", err)
+	}
+	if ok, err := b.Acquire(ctx); err != nil || !ok {
+		t.Errorf("b.Acquire after release = %v, %v; want leader", ok, err)
+	}
+}
+
+func TestRedisLock_ExpiredLeaseIsTakenOver(t *testing.T) {
+	ctx := context.Background()
+	mr, a, b := newTestLocks(t)
+
+	if ok, _ := a.Acquire(ctx); !ok {
+		t.Fatal("a should acquire")
+	}
+	mr.FastForward(31 * time.Second)
+
+	if ok, err := b.Acquire(ctx); err != nil || !ok {
+		t.Fatalf("b.Acquire after expiry = %v, %v; want leader", ok, err)
+	}
+	if ok, _ := a.Renew(ctx); ok {
+		t.Error("a renewed a lease that expired and was taken over")
+	}
+}
+
+func TestRedisLock_RenewExtendsLease(t *testing.T) {
+	ctx := context.Background()
+	mr, a, _ := newTestLocks(t)
+
+	a.Acquire(ctx)
+	mr.FastForward(20 * time.Second)
+	if ok, _ := a.Renew(ctx); !ok {
+		t.Fatal("renew failed")
+	}
+	if ttl := mr.TTL("scheduler:leader"); ttl != 30*time.Second {
+		t.Errorf("TTL after renew = %v, want 30s", ttl)
+	}
+}
@@ -0,0 +1,38 @@
+package leader
+
+import (
+	"context"
+	"time"
+)
+
+// SoleLock is the lock of a process that runs alone, such as -mode=standalone:
+// it is always held, so the process is always the leader.
+type SoleLock struct {
+	ttl time.Duration
+}
+
+// NewSoleLock returns a lock that is always held. ttl only sets how often a
+// leader renews it.
+func NewSoleLock(ttl time.Duration) *SoleLock {
+	return &SoleLock{ttl: ttl}
+}
+
+// TTL returns the lease duration.
+func (l *SoleLock) TTL() time.Duration {
+	return l.ttl
+}
+
+// Acquire always succeeds.
+func (l *SoleLock) Acquire(context.Context) (bool, error) {
+	return true, nil
+}
+
+// Renew always succeeds.
+func (l *SoleLock) Renew(context.Context) (bool, error) {
+	return true, nil
+}
+
+// Release is a no-op.
+func (l *SoleLock) Release(context.Context) error {
+	return nil
+}
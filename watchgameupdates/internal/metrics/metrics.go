@@ -0,0 +1,259 @@
+// Package metrics holds the Prometheus metrics every run mode serves on
+// /metrics. The counters and histograms are updated where the work happens
+// (the game processor, the fetchers, the notifiers); the gauges that describe
+// a queue are read from it at scrape time, once the run mode registers it
+// with WatchGameLoops or WatchAsynqQueue. Those gauges are queue-wide: every
+// process on the same queue reports the same value.
+package metrics
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "watchgameupdates"
+
+// scrapeTimeout bounds the queue reads a scrape triggers.
+const scrapeTimeout = 10 * time.Second
+
+// gameLoopsCacheTTL is how long a game loop count is reused. Counting lists
+// the whole queue, which should not happen on every scrape of every instance.
+const gameLoopsCacheTTL = time.Minute
+
+// gamePollsIdleTTL is how long a game's game_polls_total series outlives its
+// last check in this process. Loops that end normally drop theirs at once
+// (ForgetGame); this bounds the ones that were cancelled, postponed, or
+// continued on another instance.
+const gamePollsIdleTTL = 6 * time.Hour
+
+// Registry holds every metric in this package plus the Go runtime and
+// process collectors.
+var Registry = prometheus.NewRegistry()
+
+var (
+	// GamePolls counts game checks per game. Update it through CountGamePoll
+	// and ForgetGame, which keep the game_id series to the games being
+	// tracked.
+	GamePolls = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "game_polls_total",
+		Help:      "Game checks run, by game.",
+	}, []string{"game_id"})
+
+	// PlaysSeen counts the last play of each check by play type
+	// (typeDescKey).
+	PlaysSeen = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "plays_seen_total",
+		Help:      "Last play type seen by game checks.",
+	}, []string{"type"})
+
+	// PlayByPlayFetchFailures counts play-by-play fetches that returned no
+	// data, by the step that failed: request, status, read or decode.
+	PlayByPlayFetchFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "playbyplay_fetch_failures_total",
+		Help:      "Failed NHL play-by-play fetches, by failed step.",
+	}, []string{"reason"})
+
+	// MoneyPuckFetches counts MoneyPuck CSV requests by HTTP status, or
+	// "error" when no response arrived.
+	MoneyPuckFetches = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "moneypuck_fetches_total",
+		Help:      "MoneyPuck CSV requests, by HTTP status.",
+	}, []string{"status"})
+
+	// MoneyPuckFetchDuration is the latency of MoneyPuck CSV requests.
+	MoneyPuckFetchDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "moneypuck_fetch_duration_seconds",
+		Help:      "Latency of MoneyPuck CSV requests.",
+		Buckets:   prometheus.DefBuckets,
+	})
+
+	// MoneyPuckCSVParseErrors counts checks that got a MoneyPuck CSV which
+	// failed to parse (services.ErrCSVParse) and were retried.
+	MoneyPuckCSVParseErrors = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "moneypuck_csv_parse_errors_total",
+		Help:      "MoneyPuck CSVs that failed to parse.",
+	})
+
+	// Notifications counts notifications by notifier and result, sent or
+	// failed. A notification that timed out counts as failed.
+	Notifications = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "notifications_total",
+		Help:      "Notifications sent or failed, by notifier.",
+	}, []string{"notifier", "result"})
+
+	// APNsRequests counts APNs requests by HTTP status, or "error" when no
+	// response arrived.
+	APNsRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "apns_requests_total",
+		Help:      "APNs push requests, by HTTP status.",
+	}, []string{"status"})
+
+	// APNsRequestDuration is the latency of APNs requests.
+	APNsRequestDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "apns_request_duration_seconds",
+		Help:      "Latency of APNs push requests.",
+		Buckets:   prometheus.DefBuckets,
+	})
+
+	// RescheduleIntervals is the delay chosen for each rescheduled check, by
+	// the polling rule that chose it (services.PollDecision.Reason), or
+	// "parse-error" for a retry after ErrCSVParse.
+	RescheduleIntervals = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "reschedule_interval_seconds",
+		Help:      "Delay until the next check of a game, by polling rule.",
+		Buckets:   []float64{5, 10, 15, 30, 60, 120, 300, 600, 1800, 3600},
+	}, []string{"reason"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		GamePolls,
+		PlaysSeen,
+		PlayByPlayFetchFailures,
+		MoneyPuckFetches,
+		MoneyPuckFetchDuration,
+		MoneyPuckCSVParseErrors,
+		Notifications,
+		APNsRequests,
+		APNsRequestDuration,
+		RescheduleIntervals,
+	)
+}
+
+// gamePolls tracks when each game in GamePolls was last checked.
+var gamePolls = struct {
+	sync.Mutex
+	last map[string]time.Time
+	now  func() time.Time
+}{last: map[string]time.Time{}, now: time.Now}
+
+// CountGamePoll counts one check of gameID, and drops the series of games not
+// checked for gamePollsIdleTTL.
+func CountGamePoll(gameID string) {
+	gamePolls.Lock()
+	defer gamePolls.Unlock()
+	now := gamePolls.now()
+	for id, last := range gamePolls.last {
+		if now.Sub(last) >= gamePollsIdleTTL {
+			GamePolls.DeleteLabelValues(id)
+			delete(gamePolls.last, id)
+		}
+	}
+	gamePolls.last[gameID] = now
+	GamePolls.WithLabelValues(gameID).Inc()
+}
+
+// ForgetGame drops gameID's GamePolls series once its game loop has ended.
+func ForgetGame(gameID string) {
+	gamePolls.Lock()
+	defer gamePolls.Unlock()
+	GamePolls.DeleteLabelValues(gameID)
+	delete(gamePolls.last, gameID)
+}
+
+// Handler serves Registry in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// WatchGameLoops registers the active_game_loops gauge, read from count, e.g.
+// the length of a queue's ListGames, at most once per gameLoopsCacheTTL. Call
+// it once per process.
+func WatchGameLoops(count func(ctx context.Context) (int, error)) {
+	Registry.MustRegister(newGameLoopsCollector(count))
+}
+
+func newGameLoopsCollector(count func(ctx context.Context) (int, error)) *gameLoopsCollector {
+	return &gameLoopsCollector{
+		count: count,
+		desc: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "active_game_loops"),
+			"Game loops with a check queued or running.", nil, nil),
+		now: time.Now,
+	}
+}
+
+type gameLoopsCollector struct {
+	count func(ctx context.Context) (int, error)
+	desc  *prometheus.Desc
+	now   func() time.Time
+
+	mu       sync.Mutex
+	cached   int
+	cachedAt time.Time // zero until the first successful count
+}
+
+func (c *gameLoopsCollector) Describe(ch chan<- *prometheus.Desc) { ch <- c.desc }
+
+func (c *gameLoopsCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if now := c.now(); c.cachedAt.IsZero() || now.Sub(c.cachedAt) >= gameLoopsCacheTTL {
+		ctx, cancel := context.WithTimeout(context.Background(), scrapeTimeout)
+		defer cancel()
+		n, err := c.count(ctx)
+		if err != nil {
+			// Leave the gauge out rather than report a made-up zero or a
+			// stale count.
+			log.Printf("metrics: failed to count game loops: %v", err)
+			return
+		}
+		c.cached, c.cachedAt = n, now
+	}
+	ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(c.cached))
+}
+
+// WatchAsynqQueue registers the asynq_queue_tasks gauge, the number of tasks
+// in an asynq queue by state, read from depth on every scrape. Call it once
+// per process.
+func WatchAsynqQueue(queue string, depth func(ctx context.Context) (map[string]int, error)) {
+	Registry.MustRegister(newQueueDepthCollector(queue, depth))
+}
+
+func newQueueDepthCollector(queue string, depth func(ctx context.Context) (map[string]int, error)) *queueDepthCollector {
+	return &queueDepthCollector{
+		queue: queue,
+		depth: depth,
+		desc: prometheus.NewDesc(prometheus.BuildFQName(namespace, "asynq", "queue_tasks"),
+			"Tasks in the asynq queue, by state.", []string{"queue", "state"}, nil),
+	}
+}
+
+type queueDepthCollector struct {
+	queue string
+	depth func(ctx context.Context) (map[string]int, error)
+	desc  *prometheus.Desc
+}
+
+func (c *queueDepthCollector) Describe(ch chan<- *prometheus.Desc) { ch <- c.desc }
+
+func (c *queueDepthCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), scrapeTimeout)
+	defer cancel()
+	depth, err := c.depth(ctx)
+	if err != nil {
+		log.Printf("metrics: failed to read asynq queue %s: %v", c.queue, err)
+		return
+	}
+	for state, n := range depth {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(n), c.queue, state)
+	}
+}
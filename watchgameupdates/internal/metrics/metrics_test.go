@@ -0,0 +1,114 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestHandler_ServesMetrics(t *testing.T) {
+	CountGamePoll("2025020001")
+	Notifications.WithLabelValues("discord", "sent").Inc()
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(rec.Body)
+
+	for _, want := range []string{
+		`watchgameupdates_game_polls_total{game_id="2025020001"}`,
+		`watchgameupdates_notifications_total{notifier="discord",result="sent"}`,
+		"# TYPE watchgameupdates_moneypuck_fetch_duration_seconds histogram",
+		"go_goroutines",
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("/metrics is missing %s", want)
+		}
+	}
+}
+
+func TestGamePolls_DropsEndedAndIdleGames(t *testing.T) {
+	GamePolls.Reset()
+	now := time.Now()
+	gamePolls.now = func() time.Time { return now }
+	t.Cleanup(func() { gamePolls.now = time.Now })
+
+	CountGamePoll("1")
+	CountGamePoll("1")
+	CountGamePoll("2")
+	CountGamePoll("3")
+	if got := testutil.ToFloat64(GamePolls.WithLabelValues("1")); got != 2 {
+		t.Errorf("polls of game 1 = %v, want 2", got)
+	}
+
+	ForgetGame("2")
+	now = now.Add(gamePollsIdleTTL)
+	CountGamePoll("1")
+	// Game 2 ended and game 3 went idle; only game 1 is left.
+	if n := testutil.CollectAndCount(GamePolls); n != 1 {
+		t.Errorf("GamePolls has %d series, want 1", n)
+	}
+	if got := testutil.ToFloat64(GamePolls.WithLabelValues("1")); got != 1 {
+		t.Errorf("polls of game 1 = %v, want 1 after its idle series was dropped", got)
+	}
+}
+
+func TestGameLoopsCollector(t *testing.T) {
+	c := newGameLoopsCollector(func(context.Context) (int, error) { return 3, nil })
+	want := `
+# HELP watchgameupdates_active_game_loops Game loops with a check queued or running.
+# TYPE watchgameupdates_active_game_loops gauge
+watchgameupdates_active_game_loops 3
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGameLoopsCollector_OmitsGaugeOnError(t *testing.T) {
+	c := newGameLoopsCollector(func(context.Context) (int, error) { return 0, errors.New("queue unavailable") })
+	if n := testutil.CollectAndCount(c); n != 0 {
+		t.Errorf("collected %d metrics when the queue could not be read, want 0", n)
+	}
+}
+
+func TestGameLoopsCollector_CachesCount(t *testing.T) {
+	counts := 0
+	c := newGameLoopsCollector(func(context.Context) (int, error) {
+		counts++
+		return counts, nil
+	})
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	for range 3 {
+		if got := testutil.ToFloat64(c); got != 1 {
+			t.Errorf("gauge = %v within the cache TTL, want the first count 1", got)
+		}
+	}
+	now = now.Add(gameLoopsCacheTTL)
+	if got := testutil.ToFloat64(c); got != 2 {
+		t.Errorf("gauge = %v after the cache TTL, want a fresh count 2", got)
+	}
+}
+
+func TestQueueDepthCollector(t *testing.T) {
+	c := newQueueDepthCollector("default", func(context.Context) (map[string]int, error) {
+		return map[string]int{"scheduled": 12, "active": 2, "pending": 0}, nil
+	})
+	want := `
+# HELP watchgameupdates_asynq_queue_tasks Tasks in the asynq queue, by state.
+# TYPE watchgameupdates_asynq_queue_tasks gauge
+watchgameupdates_asynq_queue_tasks{queue="default",state="active"} 2
+watchgameupdates_asynq_queue_tasks{queue="default",state="pending"} 0
+watchgameupdates_asynq_queue_tasks{queue="default",state="scheduled"} 12
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want)); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,166 @@
+// Package migrate moves pending tasks from the Cloud Tasks queue to the Redis
+// queue, for the Phase 2 cutover in docs/redis-queue-migration-plan.md.
+//
+// Every task keeps its payload and schedule time, and its deterministic task
+// ID, so a rerun after a partial failure does not start a second loop.
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"watchgameupdates/internal/models"
+	"watchgameupdates/internal/queue"
+	"watchgameupdates/internal/tasks"
+)
+
+// Source is the queue tasks are moved from; queue.CloudTasksQueue.
+type Source interface {
+	ListTasks(ctx context.Context) ([]queue.CloudTask, error)
+	DeleteTask(ctx context.Context, name string) error
+}
+
+// Target is the queue tasks are moved to; queue.RedisQueue. A task that is
+// already there counts as enqueued.
+type Target interface {
+	Enqueue(ctx context.Context, payload models.Payload, deliverAt time.Time) error
+	EnqueuePregame(ctx context.Context, payload models.Payload, deliverAt time.Time) error
+}
+
+// Options controls a migration run.
+type Options struct {
+	// DryRun lists and decodes the tasks and reports what would be moved,
+	// without writing to either queue.
+	DryRun bool
+	// Delete removes each original from Cloud Tasks once it is in Redis.
+	Delete bool
+}
+
+// Outcomes reported per task.
+const (
+	OutcomeMigrated     = "migrated"
+	OutcomeWouldMigrate = "would migrate"
+	OutcomeSkipped      = "skipped"
+	OutcomeFailed       = "failed"
+)
+
+// Result is what happened to one Cloud Tasks task.
+type Result struct {
+	Task         string    `json:"task"`
+	Type         string    `json:"type"`
+	GameID       string    `json:"gameId,omitempty"`
+	Matchup      string    `json:"matchup,omitempty"`
+	State        string    `json:"state"`
+	ScheduleTime time.Time `json:"scheduleTime"`
+	Outcome      string    `json:"outcome"`
+	Deleted      bool      `json:"deleted"`
+	Error        string    `json:"error,omitempty"`
+}
+
+// Report is the outcome of a run.
+type Report struct {
+	DryRun   bool     `json:"dryRun"`
+	Delete   bool     `json:"delete"`
+	Listed   int      `json:"listed"`
+	Migrated int      `json:"migrated"` // or would be, in a dry run
+	Skipped  int      `json:"skipped"`
+	Failed   int      `json:"failed"`
+	Deleted  int      `json:"deleted"`
+	Results  []Result `json:"results"`
+}
+
+// Run moves every pending task from src to dst. Tasks Cloud Tasks is
+// delivering right now are skipped: their handler may still schedule the next
+// check in Cloud Tasks, so stop the HTTP handler and run again. An error is
+// returned only when src cannot be listed; per-task failures are in the
+// report.
+func Run(ctx context.Context, src Source, dst Target, opts Options) (*Report, error) {
+	pending, err := src.ListTasks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{DryRun: opts.DryRun, Delete: opts.Delete, Listed: len(pending)}
+	for _, task := range pending {
+		r := Result{
+			Task:         task.Name,
+			Type:         task.Type,
+			GameID:       task.Payload.Game.ID,
+			State:        task.State,
+			ScheduleTime: task.ScheduleTime,
+		}
+		if task.Payload.Game.ID != "" {
+			r.Matchup = task.Payload.Game.AwayTeam.Abbrev + " @ " + task.Payload.Game.HomeTeam.Abbrev
+		}
+
+		switch {
+		case task.Err != nil:
+			r.Outcome, r.Error = OutcomeSkipped, task.Err.Error()
+		case task.State == queue.StateActive:
+			r.Outcome, r.Error = OutcomeSkipped, "being delivered; stop the HTTP handler and run again"
+		case opts.DryRun:
+			r.Outcome = OutcomeWouldMigrate
+		default:
+			if err := enqueue(ctx, dst, task); err != nil {
+				r.Outcome, r.Error = OutcomeFailed, err.Error()
+				break
+			}
+			r.Outcome = OutcomeMigrated
+			if opts.Delete {
+				if err := src.DeleteTask(ctx, task.Name); err != nil {
+					// The copy in Redis stands; a rerun re-enqueues it as a
+					// duplicate and retries the delete.
+					r.Error = err.Error()
+				} else {
+					r.Deleted = true
+				}
+			}
+		}
+
+		switch r.Outcome {
+		case OutcomeMigrated, OutcomeWouldMigrate:
+			report.Migrated++
+		case OutcomeSkipped:
+			report.Skipped++
+		case OutcomeFailed:
+			report.Failed++
+		}
+		if r.Deleted {
+			report.Deleted++
+		}
+		report.Results = append(report.Results, r)
+	}
+	return report, nil
+}
+
+func enqueue(ctx context.Context, dst Target, task queue.CloudTask) error {
+	if task.Type == tasks.TypePregame {
+		return dst.EnqueuePregame(ctx, task.Payload, task.ScheduleTime)
+	}
+	return dst.Enqueue(ctx, task.Payload, task.ScheduleTime)
+}
+
+// Write prints the report as a table followed by a summary line.
+func (r *Report) Write(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "OUTCOME\tTYPE\tGAME\tMATCHUP\tSTATE\tSCHEDULED\tDELETED\tTASK\tERROR")
+	for _, res := range r.Results {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%t\t%s\t%s\n",
+			res.Outcome, res.Type, res.GameID, res.Matchup, res.State,
+			res.ScheduleTime.Format(time.RFC3339), res.Deleted, res.Task, res.Error)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	verb := "migrated"
+	if r.DryRun {
+		verb = "would migrate"
+	}
+	_, err := fmt.Fprintf(w, "\n%d tasks listed: %s %d, skipped %d, failed %d, deleted %d\n",
+		r.Listed, verb, r.Migrated, r.Skipped, r.Failed, r.Deleted)
+	return err
+}
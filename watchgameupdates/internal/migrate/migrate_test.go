@@ -0,0 +1,201 @@
+package migrate
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"watchgameupdates/internal/models"
+	"watchgameupdates/internal/queue"
+	"watchgameupdates/internal/tasks"
+)
+
+type fakeSource struct {
+	tasks     []queue.CloudTask
+	listErr   error
+	deleteErr map[string]error
+	deleted   []string
+}
+
+func (s *fakeSource) ListTasks(context.Context) ([]queue.CloudTask, error) {
+	return s.tasks, s.listErr
+}
+
+func (s *fakeSource) DeleteTask(_ context.Context, name string) error {
+	if err := s.deleteErr[name]; err != nil {
+		return err
+	}
+	s.deleted = append(s.deleted, name)
+	return nil
+}
+
+type enqueued struct {
+	taskType  string
+	gameID    string
+	deliverAt time.Time
+}
+
+type fakeTarget struct {
+	got  []enqueued
+	fail map[string]error // by game ID
+}
+
+func (t *fakeTarget) Enqueue(_ context.Context, p models.Payload, at time.Time) error {
+	return t.add(tasks.TypeWatchGameUpdates, p, at)
+}
+
+func (t *fakeTarget) EnqueuePregame(_ context.Context, p models.Payload, at time.Time) error {
+	return t.add(tasks.TypePregame, p, at)
+}
+
+func (t *fakeTarget) add(taskType string, p models.Payload, at time.Time) error {
+	if err := t.fail[p.Game.ID]; err != nil {
+		return err
+	}
+	t.got = append(t.got, enqueued{taskType, p.Game.ID, at})
+	return nil
+}
+
+var scheduled = time.Date(2025, 10, 8, 23, 0, 0, 0, time.UTC)
+
+func cloudTask(name, taskType, gameID, state string) queue.CloudTask {
+	return queue.CloudTask{
+		Name:         "projects/p/locations/l/queues/q/tasks/" + name,
+		Type:         taskType,
+		State:        state,
+		ScheduleTime: scheduled,
+		Payload: models.Payload{Game: models.Game{
+			ID:       gameID,
+			HomeTeam: models.Team{Abbrev: "BOS"},
+			AwayTeam: models.Team{Abbrev: "NYR"},
+		}},
+	}
+}
+
+func testSource() *fakeSource {
+	bad := cloudTask("junk", tasks.TypeWatchGameUpdates, "", queue.StatePending)
+	bad.Err = errors.New("body is not a game payload")
+	return &fakeSource{tasks: []queue.CloudTask{
+		cloudTask("game-1-0-3", tasks.TypeWatchGameUpdates, "1", queue.StateScheduled),
+		cloudTask("pregame-2-0", tasks.TypePregame, "2", queue.StateScheduled),
+		cloudTask("game-3-0-7", tasks.TypeWatchGameUpdates, "3", queue.StateActive),
+		cloudTask("game-4-0-1", tasks.TypeWatchGameUpdates, "4", queue.StateRetry),
+		bad,
+	}}
+}
+
+func outcomes(r *Report) map[string]string {
+	m := map[string]string{}
+	for _, res := range r.Results {
+		m[res.Task[strings.LastIndex(res.Task, "/")+1:]] = res.Outcome
+	}
+	return m
+}
+
+func TestRun_MovesTasksWithTheirScheduleTime(t *testing.T) {
+	src, dst := testSource(), &fakeTarget{}
+	report, err := Run(context.Background(), src, dst, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []enqueued{
+		{tasks.TypeWatchGameUpdates, "1", scheduled},
+		{tasks.TypePregame, "2", scheduled},
+		{tasks.TypeWatchGameUpdates, "4", scheduled},
+	}
+	if len(dst.got) != len(want) {
+		t.Fatalf("enqueued %+v, want %+v", dst.got, want)
+	}
+	for i := range want {
+		if dst.got[i] != want[i] {
+			t.Errorf("enqueue %d = %+v, want %+v", i, dst.got[i], want[i])
+		}
+	}
+
+	wantOutcomes := map[string]string{
+		"game-1-0-3":  OutcomeMigrated,
+		"pregame-2-0": OutcomeMigrated,
+		"game-3-0-7":  OutcomeSkipped, // being delivered
+		"game-4-0-1":  OutcomeMigrated,
+		"junk":        OutcomeSkipped,
+	}
+	got := outcomes(report)
+	for task, outcome := range wantOutcomes {
+		if got[task] != outcome {
+			t.Errorf("%s outcome = %q, want %q", task, got[task], outcome)
+		}
+	}
+	if report.Listed != 5 || report.Migrated != 3 || report.Skipped != 2 || report.Failed != 0 || report.Deleted != 0 {
+		t.Errorf("report counts = %+v", report)
+	}
+	if len(src.deleted) != 0 {
+		t.Errorf("deleted %v without -delete", src.deleted)
+	}
+}
+
+func TestRun_DryRunWritesNothing(t *testing.T) {
+	src, dst := testSource(), &fakeTarget{}
+	report, err := Run(context.Background(), src, dst, Options{DryRun: true, Delete: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dst.got) != 0 || len(src.deleted) != 0 {
+		t.Errorf("dry run enqueued %+v and deleted %v", dst.got, src.deleted)
+	}
+	if got := outcomes(report)["game-1-0-3"]; got != OutcomeWouldMigrate {
+		t.Errorf("outcome = %q, want %q", got, OutcomeWouldMigrate)
+	}
+	if report.Migrated != 3 {
+		t.Errorf("Migrated = %d, want the 3 tasks that would move", report.Migrated)
+	}
+}
+
+func TestRun_DeletesOnlyMovedTasks(t *testing.T) {
+	src := testSource()
+	src.deleteErr = map[string]error{src.tasks[1].Name: errors.New("permission denied")}
+	dst := &fakeTarget{fail: map[string]error{"4": errors.New("redis down")}}
+
+	report, err := Run(context.Background(), src, dst, Options{Delete: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(src.deleted) != 1 || src.deleted[0] != src.tasks[0].Name {
+		t.Errorf("deleted %v, want only the first game check", src.deleted)
+	}
+	if report.Failed != 1 || report.Deleted != 1 {
+		t.Errorf("report counts = %+v, want 1 failed and 1 deleted", report)
+	}
+	for _, res := range report.Results {
+		if res.GameID == "2" && (res.Outcome != OutcomeMigrated || res.Deleted || res.Error == "") {
+			t.Errorf("pregame result = %+v, want migrated with the delete error", res)
+		}
+	}
+}
+
+func TestRun_ListError(t *testing.T) {
+	src := &fakeSource{listErr: errors.New("unavailable")}
+	if _, err := Run(context.Background(), src, &fakeTarget{}, Options{}); err == nil {
+		t.Error("Run() succeeded although the source could not be listed")
+	}
+}
+
+func TestReport_Write(t *testing.T) {
+	report, err := Run(context.Background(), testSource(), &fakeTarget{}, Options{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := report.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{"NYR @ BOS", "would migrate", "5 tasks listed: would migrate 3, skipped 2, failed 0, deleted 0"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report missing %q:\n%s", want, out)
+		}
+	}
+}
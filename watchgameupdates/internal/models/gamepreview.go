@@ -0,0 +1,57 @@
+package models
+
+// GamePreview is the pregame part of the gamecenter landing response.
+type GamePreview struct {
+	GameState    string          `json:"gameState,omitempty"` // FUT, PRE, LIVE, ..., PPD
+	StartTimeUTC string          `json:"startTimeUTC,omitempty"`
+	Venue        LocalizedName   `json:"venue"`
+	TVBroadcasts []TVBroadcast   `json:"tvBroadcasts,omitempty"`
+	HomeTeam     PreviewTeam     `json:"homeTeam"`
+	AwayTeam     PreviewTeam     `json:"awayTeam"`
+	Matchup      *PreviewMatchup `json:"matchup,omitempty"`
+	SeriesStatus *SeriesStatus   `json:"seriesStatus,omitempty"` // playoff games only
+}
+
+type TVBroadcast struct {
+	Network     string `json:"network"`
+	CountryCode string `json:"countryCode"`
+}
+
+type PreviewTeam struct {
+	Abbrev string `json:"abbrev"`
+	Record string `json:"record,omitempty"` // e.g. "10-5-2"
+}
+
+// PreviewMatchup carries the season leaders the landing page compares before
+// a game; the first goalie listed is the probable starter.
+type PreviewMatchup struct {
+	GoalieComparison struct {
+		HomeTeam GoalieLeaders `json:"homeTeam"`
+		AwayTeam GoalieLeaders `json:"awayTeam"`
+	} `json:"goalieComparison"`
+}
+
+type GoalieLeaders struct {
+	Leaders []PreviewGoalie `json:"leaders"`
+}
+
+type PreviewGoalie struct {
+	Name   LocalizedName `json:"name"`
+	Record string        `json:"record,omitempty"`
+}
+
+// Goalies returns the probable home and away starting goalies, "" when the
+// preview does not name one.
+func (p GamePreview) Goalies() (home, away string) {
+	if p.Matchup == nil {
+		return "", ""
+	}
+	return firstGoalie(p.Matchup.GoalieComparison.HomeTeam), firstGoalie(p.Matchup.GoalieComparison.AwayTeam)
+}
+
+func firstGoalie(side GoalieLeaders) string {
+	if len(side.Leaders) == 0 {
+		return ""
+	}
+	return side.Leaders[0].Name.Default
+}
@@ -8,16 +8,50 @@ type Team struct {
 	Abbrev                   string            `json:"abbrev"`
 }
 
+// NHL gameType values.
+const (
+	GameTypePreseason = 1
+	GameTypeRegular   = 2
+	GameTypePlayoff   = 3
+	GameTypeAllStar   = 4
+)
+
 type Game struct {
 	ID        string `json:"id"`
 	GameDate  string `json:"gameDate"`
 	StartTime string `json:"startTimeUTC"`
 	HomeTeam  Team   `json:"homeTeam"`
 	AwayTeam  Team   `json:"awayTeam"`
+	// GameType is the NHL gameType (GameTypeRegular, GameTypePlayoff, ...);
+	// 0 when the enqueuer did not know it.
+	GameType int `json:"gameType,omitempty"`
+	// Series is the playoff series before this game; nil outside the playoffs.
+	Series *SeriesStatus `json:"seriesStatus,omitempty"`
+}
+
+// IsPlayoff reports whether the game is known to be a playoff game.
+func (g Game) IsPlayoff() bool {
+	return g.GameType == GameTypePlayoff || g.Series != nil
 }
 
 type Payload struct {
 	Game         Game    `json:"game"`
 	ExecutionEnd *string `json:"execution_end,omitempty"`
 	ShouldNotify *bool   `json:"should_notify,omitempty"`
+	// LastPlay is the play type seen by the check that scheduled this task, so
+	// pending tasks show where each game loop is. Empty for the first check.
+	LastPlay string `json:"last_play,omitempty"`
+	// Cycle numbers the checks of a game loop: 0 for the first, incremented on
+	// every reschedule. Together with the game ID and start time it gives each
+	// check a deterministic task ID, so duplicate enqueues collide.
+	Cycle int `json:"cycle,omitempty"`
+	// Poll is what the polling policy remembered at the check that scheduled
+	// this task; nil for the first check.
+	Poll *PollState `json:"poll,omitempty"`
+}
+
+// PollState is the polling policy's memory between the checks of a game loop.
+type PollState struct {
+	ClockSeconds  int `json:"clock_seconds"`  // game clock at the previous check
+	StoppedChecks int `json:"stopped_checks"` // consecutive checks that found the clock stopped at ClockSeconds
 }
@@ -5,10 +5,23 @@ type PeriodDescriptor struct {
 	PeriodType string `json:"periodType"`
 }
 
+// PlayDetails carries the per-play fields the backend uses. The feed sends many
+// more (coordinates, shot type, assists); they are ignored.
+type PlayDetails struct {
+	EventOwnerTeamID int `json:"eventOwnerTeamId,omitempty"`
+	ScoringPlayerID  int `json:"scoringPlayerId,omitempty"`
+}
+
 type Play struct {
 	TypeDescKey      string           `json:"typeDescKey"`
 	Period           int              `json:"period"`
 	PeriodDescriptor PeriodDescriptor `json:"periodDescriptor"`
 	TimeInPeriod     string           `json:"timeInPeriod"`
 	TimeRemaining    string           `json:"timeRemaining"`
+	Details          *PlayDetails     `json:"details,omitempty"`
+
+	// Resolved from Details against the response's teams and roster by
+	// FetchPlayByPlay; empty when the feed did not identify them.
+	EventTeamAbbrev string `json:"-"`
+	ScorerName      string `json:"-"`
 }
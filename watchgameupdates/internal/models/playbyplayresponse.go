@@ -1,6 +1,68 @@
 package models
 
 type PlayByPlayResponse struct {
-	Plays      []Play `json:"plays"`
-	MaxPeriods *int   `json:"maxPeriods,omitempty"`
+	Plays        []Play       `json:"plays"`
+	MaxPeriods   *int         `json:"maxPeriods,omitempty"`
+	GameState    string       `json:"gameState,omitempty"` // FUT, PRE, LIVE, CRIT, OFF, FINAL
+	StartTimeUTC string       `json:"startTimeUTC,omitempty"`
+	Clock        *GameClock   `json:"clock,omitempty"`
+	HomeTeam     TeamSummary  `json:"homeTeam"`
+	AwayTeam     TeamSummary  `json:"awayTeam"`
+	RosterSpots  []RosterSpot `json:"rosterSpots,omitempty"`
+}
+
+type TeamSummary struct {
+	ID     int    `json:"id"`
+	Abbrev string `json:"abbrev"`
+	Score  int    `json:"score"`
+}
+
+// GameClock is the feed's game clock. During an intermission it counts down
+// the intermission instead of the period.
+type GameClock struct {
+	TimeRemaining    string `json:"timeRemaining"`
+	SecondsRemaining int    `json:"secondsRemaining"`
+	Running          bool   `json:"running"`
+	InIntermission   bool   `json:"inIntermission"`
+}
+
+// LocalizedName is the feed's {"default": "...", "fr": "..."} name object.
+type LocalizedName struct {
+	Default string `json:"default"`
+}
+
+type RosterSpot struct {
+	TeamID    int           `json:"teamId"`
+	PlayerID  int           `json:"playerId"`
+	FirstName LocalizedName `json:"firstName"`
+	LastName  LocalizedName `json:"lastName"`
+}
+
+// TeamAbbrev returns the tricode for teamID, or "" if it is neither side.
+func (r *PlayByPlayResponse) TeamAbbrev(teamID int) string {
+	switch {
+	case teamID == 0:
+		return ""
+	case teamID == r.HomeTeam.ID:
+		return r.HomeTeam.Abbrev
+	case teamID == r.AwayTeam.ID:
+		return r.AwayTeam.Abbrev
+	}
+	return ""
+}
+
+// PlayerName returns "First Last" for playerID, or "" if they are not on the roster.
+func (r *PlayByPlayResponse) PlayerName(playerID int) string {
+	if playerID == 0 {
+		return ""
+	}
+	for _, s := range r.RosterSpots {
+		if s.PlayerID == playerID {
+			if s.FirstName.Default == "" {
+				return s.LastName.Default
+			}
+			return s.FirstName.Default + " " + s.LastName.Default
+		}
+	}
+	return ""
 }
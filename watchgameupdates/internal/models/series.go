@@ -0,0 +1,106 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// FinalRound is the playoff round of the Stanley Cup Final.
+const FinalRound = 4
+
+// PlayoffWindowExtension is the step by which a playoff game's execution
+// window grows while the game is still being played: playoff overtimes are
+// not capped, so a fixed GameMaxDurationHours can end tracking mid-game.
+const PlayoffWindowExtension = time.Hour
+
+// SeriesStatus is the playoff series state the NHL schedule and landing
+// endpoints attach to playoff games, as of before the game.
+type SeriesStatus struct {
+	Round                int    `json:"round"`
+	SeriesTitle          string `json:"seriesTitle,omitempty"` // e.g. "1st Round"
+	NeededToWin          int    `json:"neededToWin"`
+	TopSeedTeamAbbrev    string `json:"topSeedTeamAbbrev"`
+	TopSeedWins          int    `json:"topSeedWins"`
+	BottomSeedTeamAbbrev string `json:"bottomSeedTeamAbbrev"`
+	BottomSeedWins       int    `json:"bottomSeedWins"`
+	GameNumberOfSeries   int    `json:"gameNumberOfSeries,omitempty"`
+}
+
+// Summary describes the series: "BOS leads 2-1", "Series tied 2-2" or, once
+// decided, "BOS wins series 4-2".
+func (s SeriesStatus) Summary() string {
+	top, bottom := s.TopSeedWins, s.BottomSeedWins
+	switch {
+	case s.Clinched() && top > bottom:
+		return fmt.Sprintf("%s wins series %d-%d", s.TopSeedTeamAbbrev, top, bottom)
+	case s.Clinched():
+		return fmt.Sprintf("%s wins series %d-%d", s.BottomSeedTeamAbbrev, bottom, top)
+	case top > bottom:
+		return fmt.Sprintf("%s leads %d-%d", s.TopSeedTeamAbbrev, top, bottom)
+	case bottom > top:
+		return fmt.Sprintf("%s leads %d-%d", s.BottomSeedTeamAbbrev, bottom, top)
+	default:
+		return fmt.Sprintf("Series tied %d-%d", top, bottom)
+	}
+}
+
+// Clinched reports whether either team has won the series.
+func (s SeriesStatus) Clinched() bool {
+	return s.NeededToWin > 0 && (s.TopSeedWins >= s.NeededToWin || s.BottomSeedWins >= s.NeededToWin)
+}
+
+// ClinchMessage announces a decided series: "FLA wins the Stanley Cup 4-3"
+// in the Final, "BOS wins the 1st Round 4-2" otherwise. "" while undecided.
+func (s SeriesStatus) ClinchMessage() string {
+	winner := s.Winner()
+	if winner == "" {
+		return ""
+	}
+	wins, losses := s.TopSeedWins, s.BottomSeedWins
+	if winner == s.BottomSeedTeamAbbrev {
+		wins, losses = losses, wins
+	}
+	switch {
+	case s.Round == FinalRound:
+		return fmt.Sprintf("%s wins the Stanley Cup %d-%d", winner, wins, losses)
+	case s.SeriesTitle != "":
+		return fmt.Sprintf("%s wins the %s %d-%d", winner, s.SeriesTitle, wins, losses)
+	default:
+		return s.Summary()
+	}
+}
+
+// Winner returns the team that won the series, "" while it is undecided.
+func (s SeriesStatus) Winner() string {
+	switch {
+	case !s.Clinched():
+		return ""
+	case s.TopSeedWins > s.BottomSeedWins:
+		return s.TopSeedTeamAbbrev
+	default:
+		return s.BottomSeedTeamAbbrev
+	}
+}
+
+// Record returns the series after a game won by winnerAbbrev. A team that is
+// not in the series leaves it unchanged.
+func (s SeriesStatus) Record(winnerAbbrev string) SeriesStatus {
+	switch winnerAbbrev {
+	case s.TopSeedTeamAbbrev:
+		s.TopSeedWins++
+	case s.BottomSeedTeamAbbrev:
+		s.BottomSeedWins++
+	}
+	return s
+}
+
+// ExtendPlayoffWindow pushes end out by whole PlayoffWindowExtension steps
+// until it is after until. An end already after until is returned unchanged,
+// so repeated calls within one window agree on the result.
+func ExtendPlayoffWindow(end, until time.Time) time.Time {
+	if end.After(until) {
+		return end
+	}
+	steps := until.Sub(end)/PlayoffWindowExtension + 1
+	return end.Add(steps * PlayoffWindowExtension)
+}
@@ -0,0 +1,122 @@
+// Package contentstate is the game model every client renders. The Live
+// Activity content-state, the FCM data map and the game state snapshot are all
+// built from a State, so iOS, Android and the web show the same game:
+//
+//	{
+//	  "sport": "nhl", "homeTeam": "BOS", "awayTeam": "NYR",
+//	  "homeScore": 2, "awayScore": 1, "homeXG": 2.4, "awayXG": 1.8,
+//	  "gameState": "14:32 left, 2nd period",
+//	  "eventType": "goal", "eventDetail": "Brad Marchand", "eventTeam": "BOS",
+//	  "seriesStatus": "BOS leads 2-1"   // playoff games only
+//	}
+package contentstate
+
+import (
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	. "watchgameupdates/internal/notification"
+)
+
+// A state goes stale once the next scheduled check is overdue
+// (nextCheckSeconds, e.g. 20 minutes across an intermission) plus StaleMargin
+// for task latency. StaleOffset is the fallback when the caller did not
+// announce a next check.
+const (
+	StaleOffset = 90 * time.Second
+	StaleMargin = 60 * time.Second
+)
+
+type State struct {
+	Sport       string  `json:"sport"`
+	HomeTeam    string  `json:"homeTeam"`
+	AwayTeam    string  `json:"awayTeam"`
+	HomeScore   int     `json:"homeScore"`
+	AwayScore   int     `json:"awayScore"`
+	HomeXG      float64 `json:"homeXG"`
+	AwayXG      float64 `json:"awayXG"`
+	GameState   string  `json:"gameState"`
+	EventType   string  `json:"eventType"`   // "goal"|"penalty"|"period_end"|""
+	EventDetail string  `json:"eventDetail"` // goal scorer name when known; "" otherwise
+	EventTeam   string  `json:"eventTeam"`   // scoring/penalised team tricode; "" if unknown
+	// SeriesStatus is the playoff series ("BOS leads 2-1"), counting this
+	// game once it is final; omitted outside the playoffs.
+	SeriesStatus string `json:"seriesStatus,omitempty"`
+}
+
+// FromRequest builds the state of the game described by req.
+func FromRequest(req NotificationRequest) State {
+	eventType, eventTeam := ClassifyEvent(req.Data["lastPlayType"], req.Data)
+	eventDetail := ""
+	if eventType == "goal" {
+		eventDetail = req.Data["scorerName"]
+	}
+
+	return State{
+		Sport:        "nhl",
+		HomeTeam:     strings.ToUpper(req.Data["homeTeamAbbrev"]),
+		AwayTeam:     strings.ToUpper(req.Data["awayTeamAbbrev"]),
+		HomeScore:    ParseIntSafe(req.Data["homeTeamGoals"]),
+		AwayScore:    ParseIntSafe(req.Data["awayTeamGoals"]),
+		HomeXG:       SafeXG(req.Data["homeTeamExpectedGoals"]),
+		AwayXG:       SafeXG(req.Data["awayTeamExpectedGoals"]),
+		GameState:    req.Data["gameState"],
+		EventType:    eventType,
+		EventDetail:  eventDetail,
+		EventTeam:    eventTeam,
+		SeriesStatus: req.Data["seriesStatus"],
+	}
+}
+
+// StaleAfter returns how long a state built from data stays current: until
+// the next check announced in nextCheckSeconds is overdue, not a fixed 90s,
+// so an intermission doesn't dim the activity.
+func StaleAfter(data map[string]string) time.Duration {
+	if secs, err := strconv.Atoi(data["nextCheckSeconds"]); err == nil && secs > 0 {
+		return time.Duration(secs)*time.Second + StaleMargin
+	}
+	return StaleOffset
+}
+
+// ClassifyEvent returns (eventType, eventTeam) for the current play.
+func ClassifyEvent(playType string, data map[string]string) (eventType, eventTeam string) {
+	switch playType {
+	case "goal":
+		return "goal", strings.ToUpper(data["eventTeamAbbrev"])
+	case "penalty":
+		return "penalty", strings.ToUpper(data["eventTeamAbbrev"])
+	case "period-end":
+		return "period_end", ""
+	case "game-end":
+		return "period_end", ""
+	default:
+		return "", ""
+	}
+}
+
+// SafeXG parses an xG string and guards against NaN/Inf.
+// The value passes through exactly as sourced — no rounding. Display
+// formatting is the client's responsibility (see CLAUDE.md).
+func SafeXG(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		log.Printf("WARN: invalid xG value %q, defaulting to 0", s)
+		return 0
+	}
+	return v
+}
+
+// ParseIntSafe parses a count, defaulting to 0.
+func ParseIntSafe(s string) int {
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0
+	}
+	return v
+}
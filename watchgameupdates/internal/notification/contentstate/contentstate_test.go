@@ -0,0 +1,139 @@
+package contentstate
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	. "watchgameupdates/internal/notification"
+)
+
+func TestFromRequest(t *testing.T) {
+	req := NotificationRequest{Data: map[string]string{
+		"homeTeamGoals":         "2",
+		"awayTeamGoals":         "x",
+		"homeTeamExpectedGoals": "2.4",
+		"awayTeamExpectedGoals": "NaN",
+		"homeTeamAbbrev":        "bos",
+		"awayTeamAbbrev":        "NYR",
+		"gameState":             "14:32 left, 2nd period",
+		"lastPlayType":          "goal",
+		"eventTeamAbbrev":       "bos",
+		"scorerName":            "Brad Marchand",
+		"seriesStatus":          "BOS leads 2-1",
+	}}
+	want := State{
+		Sport: "nhl", HomeTeam: "BOS", AwayTeam: "NYR",
+		HomeScore: 2, AwayScore: 0, HomeXG: 2.4, AwayXG: 0,
+		GameState: "14:32 left, 2nd period",
+		EventType: "goal", EventDetail: "Brad Marchand", EventTeam: "BOS",
+		SeriesStatus: "BOS leads 2-1",
+	}
+	if got := FromRequest(req); got != want {
+		t.Errorf("FromRequest() = %+v, want %+v", got, want)
+	}
+
+	req.Data["lastPlayType"] = "penalty"
+	if got := FromRequest(req).EventDetail; got != "" {
+		t.Errorf("penalty EventDetail = %q, want empty", got)
+	}
+}
+
+func TestStaleAfter(t *testing.T) {
+	tests := map[string]time.Duration{
+		"":     StaleOffset,
+		"60":   60*time.Second + StaleMargin,
+		"1200": 1200*time.Second + StaleMargin,
+		"soon": StaleOffset,
+		"0":    StaleOffset,
+	}
+	for in, want := range tests {
+		if got := StaleAfter(map[string]string{"nextCheckSeconds": in}); got != want {
+			t.Errorf("StaleAfter(nextCheckSeconds=%q) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+// ClassifyEvent
+
+func TestClassifyEvent_Goal(t *testing.T) {
+	et, team := ClassifyEvent("goal", map[string]string{"eventTeamAbbrev": "bos"})
+	if et != "goal" {
+		t.Errorf("want goal, got %q", et)
+	}
+	if team != "BOS" {
+		t.Errorf("want BOS (uppercased), got %q", team)
+	}
+}
+
+func TestClassifyEvent_PeriodEnd(t *testing.T) {
+	et, team := ClassifyEvent("period-end", nil)
+	if et != "period_end" {
+		t.Errorf("want period_end, got %q", et)
+	}
+	if team != "" {
+		t.Errorf("want empty team for period-end, got %q", team)
+	}
+}
+
+func TestClassifyEvent_GameEnd(t *testing.T) {
+	et, _ := ClassifyEvent("game-end", nil)
+	if et != "period_end" {
+		t.Errorf("game-end should map to period_end, got %q", et)
+	}
+}
+
+func TestClassifyEvent_Unknown(t *testing.T) {
+	et, team := ClassifyEvent("blocked-shot", map[string]string{})
+	if et != "" || team != "" {
+		t.Errorf("unknown play should produce empty fields, got type=%q team=%q", et, team)
+	}
+}
+
+// SafeXG
+
+func TestSafeXG_NaN(t *testing.T) {
+	if got := SafeXG("NaN"); got != 0 {
+		t.Errorf("SafeXG(NaN) = %v, want 0", got)
+	}
+}
+
+func TestSafeXG_Inf(t *testing.T) {
+	if got := SafeXG("Inf"); got != 0 {
+		t.Errorf("SafeXG(Inf) = %v, want 0", got)
+	}
+}
+
+func TestSafeXG_NegInf(t *testing.T) {
+	if got := SafeXG("-Inf"); got != 0 {
+		t.Errorf("SafeXG(-Inf) = %v, want 0", got)
+	}
+}
+
+func TestSafeXG_Empty(t *testing.T) {
+	if got := SafeXG(""); got != 0 {
+		t.Errorf("SafeXG(\"\") = %v, want 0", got)
+	}
+}
+
+func TestSafeXG_ValidFloat(t *testing.T) {
+	if got := SafeXG("2.456"); got != 2.456 {
+		t.Errorf("SafeXG(2.456) = %v, want 2.456 (exact passthrough, no rounding)", got)
+	}
+}
+
+func TestSafeXG_NoRounding(t *testing.T) {
+	// The client owns display formatting; the backend must not round.
+	for in, want := range map[string]float64{"0.76": 0.76, "0.38": 0.38, "1.05": 1.05} {
+		if got := SafeXG(in); got != want {
+			t.Errorf("SafeXG(%s) = %v, want %v (exact passthrough)", in, got, want)
+		}
+	}
+}
+
+func TestSafeXG_ActualNaN(t *testing.T) {
+	v := math.NaN()
+	if !math.IsNaN(v) {
+		t.Fatal("sanity: math.NaN() is not NaN")
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"os"
 	"time"
 
+	"watchgameupdates/internal/models"
+
 	"github.com/bwmarrin/discordgo"
 	"github.com/google/uuid"
 )
@@ -57,6 +59,12 @@ func (d *DiscordNotifier) GetRequiredDataKeys() []string {
 	return d.requiredDataKeys
 }
 
+// GetOptionalDataKeys implements OptionalDataKeyProvider: the playoff series
+// fields are only present for playoff games.
+func (d *DiscordNotifier) GetOptionalDataKeys() []string {
+	return []string{"seriesStatus", "seriesClinched"}
+}
+
 // SendNotification sends a single notification to Discord
 func (d *DiscordNotifier) SendNotification(ctx context.Context, message string) (<-chan NotificationResult, error) {
 	resultChan := make(chan NotificationResult, 1)
@@ -100,6 +108,20 @@ func (d *DiscordNotifier) SendNotification(ctx context.Context, message string)
 	return resultChan, nil
 }
 
+// NotifyPregame implements PregameNotifier with a game-starting-soon message.
+func (d *DiscordNotifier) NotifyPregame(ctx context.Context, game models.Game, preview models.GamePreview) error {
+	resultChan, err := d.SendNotification(ctx, FormatPregameMessage(game, preview, time.Now()))
+	if err != nil {
+		return err
+	}
+	select {
+	case result := <-resultChan:
+		return result.Error
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // Close cleanly shuts down the Discord notifier
 func (d *DiscordNotifier) Close() error {
 	if d.session != nil {
@@ -131,6 +153,11 @@ func (d *DiscordNotifier) FormatMessage(req NotificationRequest) string {
 	if hasAwayXG {
 		message += "• " + req.Team2ID + ": " + awayXG + " xG\n"
 	}
+	if clinched, ok := req.Data["seriesClinched"]; ok {
+		message += "🏆 " + clinched + "!\n"
+	} else if series, ok := req.Data["seriesStatus"]; ok {
+		message += "• Series: " + series + "\n"
+	}
 
 	message += "\n*Notification sent at " + time.Now().Format("15:04:05 MST") + "*"
 	return message
@@ -168,3 +168,33 @@ func assertMessageContent(t *testing.T, message string, tc formatMessageTestCase
 		}
 	}
 }
+
+func TestDiscordNotifier_FormatMessage_Series(t *testing.T) {
+	discordNotifier := &DiscordNotifier{}
+	base := map[string]string{"homeTeamGoals": "3", "awayTeamGoals": "2", "gameState": "Final"}
+
+	t.Run("SeriesStatus", func(t *testing.T) {
+		data := map[string]string{"seriesStatus": "FLA leads 3-2"}
+		for k, v := range base {
+			data[k] = v
+		}
+		message := discordNotifier.FormatMessage(NotificationRequest{Team1ID: "FLA", Team2ID: "EDM", Data: data})
+		if !strings.Contains(message, "• Series: FLA leads 3-2\n") {
+			t.Errorf("expected the series line, got: %s", message)
+		}
+	})
+
+	t.Run("Clinched", func(t *testing.T) {
+		data := map[string]string{"seriesStatus": "FLA wins series 4-2", "seriesClinched": "FLA wins the Stanley Cup 4-2"}
+		for k, v := range base {
+			data[k] = v
+		}
+		message := discordNotifier.FormatMessage(NotificationRequest{Team1ID: "FLA", Team2ID: "EDM", Data: data})
+		if !strings.Contains(message, "🏆 FLA wins the Stanley Cup 4-2!\n") {
+			t.Errorf("expected the clinch announcement, got: %s", message)
+		}
+		if strings.Contains(message, "• Series:") {
+			t.Errorf("expected the announcement to replace the series line, got: %s", message)
+		}
+	})
+}
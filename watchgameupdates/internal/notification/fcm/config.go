@@ -0,0 +1,94 @@
+package fcm
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	defaultEndpoint    = "https://fcm.googleapis.com"
+	defaultTokenURL    = "https://oauth2.googleapis.com/token"
+	defaultTopicPrefix = "nhl-team-"
+)
+
+// Config holds FCM HTTP v1 credentials and routing.
+type Config struct {
+	Account     serviceAccount // parsed service-account key
+	ProjectID   string         // Firebase project; FCM_PROJECT_ID, else the key's project_id
+	Endpoint    string         // scheme://host for messages:send; FCM_ENDPOINT (local stand-ins may use http)
+	TokenURL    string         // OAuth2 token endpoint; FCM_TOKEN_URL, else the key's token_uri
+	TopicPrefix string         // topic per team is prefix + tricode; FCM_TOPIC_PREFIX (default "nhl-team-")
+}
+
+// serviceAccount is the subset of a Google service-account JSON key we use.
+type serviceAccount struct {
+	ProjectID    string `json:"project_id"`
+	PrivateKeyID string `json:"private_key_id"`
+	PrivateKey   string `json:"private_key"`
+	ClientEmail  string `json:"client_email"`
+	TokenURI     string `json:"token_uri"`
+}
+
+// LoadConfig reads FCM config from the environment. The service-account key
+// comes from FCM_CREDENTIALS (base64-encoded JSON, container-friendly like
+// APNS_AUTH_KEY) or FCM_CREDENTIALS_FILE (path to the JSON file).
+func LoadConfig() (*Config, error) {
+	raw, err := credentialsFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	var account serviceAccount
+	if err := json.Unmarshal(raw, &account); err != nil {
+		return nil, fmt.Errorf("parse FCM service account JSON: %w", err)
+	}
+	if account.ClientEmail == "" || account.PrivateKey == "" {
+		return nil, fmt.Errorf("FCM service account JSON must contain client_email and private_key")
+	}
+
+	projectID := getEnvOrDefault("FCM_PROJECT_ID", account.ProjectID)
+	if projectID == "" {
+		return nil, fmt.Errorf("FCM_PROJECT_ID is not set and the service account has no project_id")
+	}
+
+	tokenURL := account.TokenURI
+	if tokenURL == "" {
+		tokenURL = defaultTokenURL
+	}
+
+	return &Config{
+		Account:     account,
+		ProjectID:   projectID,
+		Endpoint:    strings.TrimRight(getEnvOrDefault("FCM_ENDPOINT", defaultEndpoint), "/"),
+		TokenURL:    getEnvOrDefault("FCM_TOKEN_URL", tokenURL),
+		TopicPrefix: getEnvOrDefault("FCM_TOPIC_PREFIX", defaultTopicPrefix),
+	}, nil
+}
+
+func credentialsFromEnv() ([]byte, error) {
+	if encoded := os.Getenv("FCM_CREDENTIALS"); encoded != "" {
+		raw, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("base64-decode FCM_CREDENTIALS: %w (value must be the base64-encoded service account JSON)", err)
+		}
+		return raw, nil
+	}
+	if path := os.Getenv("FCM_CREDENTIALS_FILE"); path != "" {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read FCM_CREDENTIALS_FILE: %w", err)
+		}
+		return raw, nil
+	}
+	return nil, fmt.Errorf("required env var FCM_CREDENTIALS or FCM_CREDENTIALS_FILE is not set")
+}
+
+func getEnvOrDefault(key, defaultVal string) string {
+	if val := os.Getenv(key); val != "" {
+		return val
+	}
+	return defaultVal
+}
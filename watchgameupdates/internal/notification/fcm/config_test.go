@@ -0,0 +1,93 @@
+package fcm
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func setCredentials(t *testing.T, account serviceAccount) {
+	t.Helper()
+	raw, _ := json.Marshal(account)
+	t.Setenv("FCM_CREDENTIALS", base64.StdEncoding.EncodeToString(raw))
+	t.Setenv("FCM_CREDENTIALS_FILE", "")
+	t.Setenv("FCM_PROJECT_ID", "")
+	t.Setenv("FCM_ENDPOINT", "")
+	t.Setenv("FCM_TOKEN_URL", "")
+	t.Setenv("FCM_TOPIC_PREFIX", "")
+}
+
+func TestLoadConfig_Defaults(t *testing.T) {
+	account, _ := testAccount(t, "")
+	setCredentials(t, account)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if cfg.ProjectID != "demo-project" || cfg.Endpoint != defaultEndpoint || cfg.TokenURL != defaultTokenURL || cfg.TopicPrefix != "nhl-team-" {
+		t.Errorf("cfg = %+v, want project from key and default endpoint, token URL and prefix", cfg)
+	}
+}
+
+func TestLoadConfig_Overrides(t *testing.T) {
+	account, _ := testAccount(t, "https://oauth2.example/token")
+	setCredentials(t, account)
+	t.Setenv("FCM_PROJECT_ID", "other-project")
+	t.Setenv("FCM_ENDPOINT", "http://fcm-standin:8080/")
+	t.Setenv("FCM_TOPIC_PREFIX", "dev-team-")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if cfg.ProjectID != "other-project" || cfg.Endpoint != "http://fcm-standin:8080" || cfg.TopicPrefix != "dev-team-" {
+		t.Errorf("cfg = %+v, want overrides applied", cfg)
+	}
+	if cfg.TokenURL != "https://oauth2.example/token" {
+		t.Errorf("TokenURL = %q, want the key's token_uri", cfg.TokenURL)
+	}
+}
+
+func TestLoadConfig_CredentialsFile(t *testing.T) {
+	account, _ := testAccount(t, "")
+	setCredentials(t, account)
+	raw, _ := json.Marshal(account)
+	path := filepath.Join(t.TempDir(), "sa.json")
+	if err := os.WriteFile(path, raw, 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("FCM_CREDENTIALS", "")
+	t.Setenv("FCM_CREDENTIALS_FILE", path)
+
+	if _, err := LoadConfig(); err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+}
+
+func TestLoadConfig_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+	}{
+		{"no credentials", map[string]string{"FCM_CREDENTIALS": ""}},
+		{"not base64", map[string]string{"FCM_CREDENTIALS": "%%%"}},
+		{"not json", map[string]string{"FCM_CREDENTIALS": base64.StdEncoding.EncodeToString([]byte("nope"))}},
+		{"missing key", map[string]string{"FCM_CREDENTIALS": base64.StdEncoding.EncodeToString([]byte(`{"client_email":"a@b"}`))}},
+		{"missing project", map[string]string{"FCM_CREDENTIALS": base64.StdEncoding.EncodeToString([]byte(`{"client_email":"a@b","private_key":"k"}`))}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			account, _ := testAccount(t, "")
+			setCredentials(t, account)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			if _, err := LoadConfig(); err == nil {
+				t.Error("want error")
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package fcm
+
+// formatter converts a NotificationRequest into the FCM dispatch envelope.
+//
+// FormatMessage output shape (parsed by SendNotification):
+//
+//   {
+//     "topics": ["nhl-team-BOS", "nhl-team-NYR"],
+//     "android": {"priority": "HIGH", "ttl": "150s", "collapse_key": "nhl-BOS-NYR"},
+//     "data": {
+//       "timestamp":   "1234567890",
+//       "sport":       "nhl",
+//       "homeTeam":    "BOS",
+//       "awayTeam":    "NYR",
+//       "homeScore":   "2",
+//       "awayScore":   "1",
+//       "homeXG":      "2.4",
+//       "awayXG":      "1.8",
+//       "gameState":   "14:32 left, 2nd period",
+//       "eventType":   "goal",           // "goal"|"penalty"|"period_end"|""
+//       "eventDetail": "Brad Marchand",  // goal scorer when known; else ""
+//       "eventTeam":   "BOS",
+//       "seriesStatus": "BOS leads 2-1"  // playoff games only, omitted otherwise
+//     }
+//   }
+//
+// data mirrors the Live Activity content-state key for key so the Android and
+// iOS clients render the same game model; FCM requires every data value to be
+// a string. Messages are data-only: the app decides how to render them.
+//
+// ttl follows the next scheduled check like the Live Activity stale date, and
+// collapse_key lets FCM drop an undelivered update once a newer one arrives.
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	. "watchgameupdates/internal/notification"
+	"watchgameupdates/internal/notification/contentstate"
+	"watchgameupdates/internal/teams"
+)
+
+// dispatchEnvelope is what FormatMessage returns and SendNotification parses.
+type dispatchEnvelope struct {
+	Topics  []string          `json:"topics"`
+	Android androidConfig     `json:"android"`
+	Data    map[string]string `json:"data"`
+}
+
+type androidConfig struct {
+	Priority    string `json:"priority"`
+	TTL         string `json:"ttl"`
+	CollapseKey string `json:"collapse_key,omitempty"`
+}
+
+// BuildDispatchMessage produces the JSON string for FormatMessage.
+func BuildDispatchMessage(req NotificationRequest, topicPrefix string) (string, error) {
+	home := strings.ToUpper(req.Data["homeTeamAbbrev"])
+	away := strings.ToUpper(req.Data["awayTeamAbbrev"])
+
+	var topics []string
+	for _, team := range []string{home, away} {
+		if !teams.IsKnown(team) || (len(topics) > 0 && topics[0] == topicPrefix+team) {
+			continue
+		}
+		topics = append(topics, topicPrefix+team)
+	}
+	if len(topics) == 0 {
+		return "", fmt.Errorf("no FCM topics for %q or %q", home, away)
+	}
+
+	env := dispatchEnvelope{
+		Topics: topics,
+		Android: androidConfig{
+			Priority:    "HIGH",
+			TTL:         ttl(req.Data),
+			CollapseKey: "nhl-" + home + "-" + away,
+		},
+		Data: buildData(req, time.Now()),
+	}
+	b, err := json.Marshal(env)
+	if err != nil {
+		return "", fmt.Errorf("marshal FCM dispatch envelope: %w", err)
+	}
+	return string(b), nil
+}
+
+// buildData flattens the game's contentstate.State into strings.
+func buildData(req NotificationRequest, now time.Time) map[string]string {
+	cs := contentstate.FromRequest(req)
+	data := map[string]string{
+		"timestamp":   strconv.FormatInt(now.Unix(), 10),
+		"sport":       cs.Sport,
+		"homeTeam":    cs.HomeTeam,
+		"awayTeam":    cs.AwayTeam,
+		"homeScore":   strconv.Itoa(cs.HomeScore),
+		"awayScore":   strconv.Itoa(cs.AwayScore),
+		"homeXG":      formatXG(cs.HomeXG),
+		"awayXG":      formatXG(cs.AwayXG),
+		"gameState":   cs.GameState,
+		"eventType":   cs.EventType,
+		"eventDetail": cs.EventDetail,
+		"eventTeam":   cs.EventTeam,
+	}
+	if cs.SeriesStatus != "" {
+		data["seriesStatus"] = cs.SeriesStatus
+	}
+	return data
+}
+
+// ttl is contentstate.StaleAfter in FCM's duration format ("150s").
+func ttl(data map[string]string) string {
+	return fmt.Sprintf("%ds", int(contentstate.StaleAfter(data).Seconds()))
+}
+
+// formatXG passes an xG value through unrounded.
+func formatXG(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
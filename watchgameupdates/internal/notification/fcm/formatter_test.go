@@ -0,0 +1,182 @@
+package fcm
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"testing"
+
+	. "watchgameupdates/internal/notification"
+	"watchgameupdates/internal/notification/contentstate"
+	"watchgameupdates/internal/notification/liveactivity"
+)
+
+func baseReq() NotificationRequest {
+	return NotificationRequest{
+		Team1ID: "Bruins",
+		Team2ID: "Rangers",
+		Data: map[string]string{
+			"homeTeamGoals":         "2",
+			"awayTeamGoals":         "1",
+			"homeTeamExpectedGoals": "2.4",
+			"awayTeamExpectedGoals": "1.8",
+			"homeTeamAbbrev":        "BOS",
+			"awayTeamAbbrev":        "NYR",
+			"gameState":             "14:32 left, 2nd period",
+			"lastPlayType":          "goal",
+			"eventTeamAbbrev":       "BOS",
+			"scorerName":            "Brad Marchand",
+		},
+	}
+}
+
+func mustEnvelope(t *testing.T, req NotificationRequest) dispatchEnvelope {
+	t.Helper()
+	msg, err := BuildDispatchMessage(req, defaultTopicPrefix)
+	if err != nil {
+		t.Fatalf("BuildDispatchMessage: %v", err)
+	}
+	var env dispatchEnvelope
+	if err := json.Unmarshal([]byte(msg), &env); err != nil {
+		t.Fatalf("unmarshal envelope: %v", err)
+	}
+	return env
+}
+
+// TestBuildData_MirrorsLiveActivityContentState keeps the Android data payload
+// in step with the iOS content-state: same keys, same values as strings.
+func TestBuildData_MirrorsLiveActivityContentState(t *testing.T) {
+	t.Setenv("APNS_CHANNEL_STORE", "")
+	store, err := liveactivity.NewChannelStore(&liveactivity.Config{})
+	if err != nil {
+		t.Fatalf("NewChannelStore: %v", err)
+	}
+
+	playoff := baseReq()
+	playoff.Data["seriesStatus"] = "BOS leads 2-1"
+
+	tests := []struct {
+		name string
+		req  NotificationRequest
+	}{
+		{"regular season", baseReq()},
+		{"playoff", playoff},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := liveactivity.BuildDispatchMessage(tt.req, store, liveactivity.AlertConfig{})
+			if err != nil {
+				t.Fatalf("liveactivity.BuildDispatchMessage: %v", err)
+			}
+			var apns struct {
+				Payload struct {
+					APS struct {
+						ContentState map[string]interface{} `json:"content-state"`
+					} `json:"aps"`
+				} `json:"payload"`
+			}
+			if err := json.Unmarshal([]byte(msg), &apns); err != nil {
+				t.Fatalf("unmarshal APNs envelope: %v", err)
+			}
+
+			data := mustEnvelope(t, tt.req).Data
+			for key, want := range apns.Payload.APS.ContentState {
+				got, ok := data[key]
+				if !ok {
+					t.Errorf("FCM data is missing content-state key %q", key)
+					continue
+				}
+				if got != fmt.Sprint(want) {
+					t.Errorf("data[%q] = %q, content-state has %v", key, got, want)
+				}
+			}
+			for key := range data {
+				if _, ok := apns.Payload.APS.ContentState[key]; !ok && key != "timestamp" {
+					t.Errorf("FCM data has key %q that the content-state lacks", key)
+				}
+			}
+		})
+	}
+}
+
+// TestOptionalDataKeys_IncludeSeriesStatus checks the notifier asks the
+// service for the series, which the content state carries in the playoffs.
+func TestOptionalDataKeys_IncludeSeriesStatus(t *testing.T) {
+	if !slices.Contains((&FCMNotifier{}).GetOptionalDataKeys(), "seriesStatus") {
+		t.Error("optional data keys do not include seriesStatus")
+	}
+}
+
+func TestBuildDispatchMessage_Topics(t *testing.T) {
+	tests := []struct {
+		name       string
+		home, away string
+		want       []string
+		wantErr    bool
+	}{
+		{"both teams", "BOS", "nyr", []string{"nhl-team-BOS", "nhl-team-NYR"}, false},
+		{"same team once", "BOS", "BOS", []string{"nhl-team-BOS"}, false},
+		{"unknown team skipped", "BOS", "XXX", []string{"nhl-team-BOS"}, false},
+		{"no known team", "", "XXX", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := baseReq()
+			req.Data["homeTeamAbbrev"] = tt.home
+			req.Data["awayTeamAbbrev"] = tt.away
+			msg, err := BuildDispatchMessage(req, defaultTopicPrefix)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			var env dispatchEnvelope
+			json.Unmarshal([]byte(msg), &env)
+			if fmt.Sprint(env.Topics) != fmt.Sprint(tt.want) {
+				t.Errorf("topics = %v, want %v", env.Topics, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildDispatchMessage_TTLFollowsNextCheck(t *testing.T) {
+	tests := []struct {
+		nextCheck string
+		want      string
+	}{
+		{"", "90s"},
+		{"60", "120s"},
+		{"1200", "1260s"},
+		{"soon", "90s"},
+	}
+	for _, tt := range tests {
+		req := baseReq()
+		if tt.nextCheck != "" {
+			req.Data["nextCheckSeconds"] = tt.nextCheck
+		}
+		if got := mustEnvelope(t, req).Android.TTL; got != tt.want {
+			t.Errorf("nextCheckSeconds=%q: ttl = %q, want %q", tt.nextCheck, got, tt.want)
+		}
+	}
+}
+
+func TestBuildData_ScorerOnlyOnGoals(t *testing.T) {
+	req := baseReq()
+	if got := mustEnvelope(t, req).Data["eventDetail"]; got != "Brad Marchand" {
+		t.Errorf("goal eventDetail = %q, want Brad Marchand", got)
+	}
+	req.Data["lastPlayType"] = "penalty"
+	if got := mustEnvelope(t, req).Data["eventDetail"]; got != "" {
+		t.Errorf("penalty eventDetail = %q, want empty", got)
+	}
+}
+
+func TestFormatXG(t *testing.T) {
+	tests := map[string]string{"": "0", "2.4": "2.4", "1.2345": "1.2345", "NaN": "0", "+Inf": "0", "junk": "0"}
+	for in, want := range tests {
+		if got := formatXG(contentstate.SafeXG(in)); got != want {
+			t.Errorf("formatXG(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,280 @@
+// Package fcm sends game updates to Android clients through Firebase Cloud
+// Messaging HTTP v1, one topic per team (e.g. nhl-team-BOS).
+//
+// Dispatch flow (called by notification.Service in a goroutine per notifier):
+//
+//	FormatMessage(req) → JSON dispatch envelope {topics, android, data}
+//	SendNotification(ctx, envelope) → one messages:send per topic (goroutines)
+//	    POST {FCM_ENDPOINT}/v1/projects/{project}/messages:send
+//	    authorization: Bearer {OAuth2 access token from the service account}
+//
+// 429 and 5xx responses are retried with backoff; a 401 fetches a new access
+// token and retries once.
+package fcm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	. "watchgameupdates/internal/notification"
+)
+
+const (
+	httpTimeout       = 10 * time.Second
+	maxRetries        = 3
+	retryDelay        = time.Second
+	responseBodyLimit = 1024
+)
+
+var requiredDataKeys = []string{
+	"homeTeamGoals",
+	"awayTeamGoals",
+	"homeTeamExpectedGoals",
+	"awayTeamExpectedGoals",
+	"gameState",
+	"homeTeamAbbrev",
+	"awayTeamAbbrev",
+	"lastPlayType",
+}
+
+var optionalDataKeys = []string{
+	"eventTeamAbbrev",
+	"scorerName",
+	"nextCheckSeconds", // drives the message TTL
+	"seriesStatus",     // playoff games only
+}
+
+// FCMNotifier implements notification.Notifier.
+type FCMNotifier struct {
+	http        *http.Client
+	tokens      *tokenSource
+	sendURL     string
+	topicPrefix string
+}
+
+// New creates an FCMNotifier from environment config.
+func New() (*FCMNotifier, error) {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	return newNotifier(cfg, &http.Client{Timeout: httpTimeout})
+}
+
+func newNotifier(cfg *Config, httpClient *http.Client) (*FCMNotifier, error) {
+	tokens, err := newTokenSource(httpClient, cfg.Account, cfg.TokenURL)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("FCM notifier initialized: project=%s endpoint=%s topics=%s{TEAM}", cfg.ProjectID, cfg.Endpoint, cfg.TopicPrefix)
+	return &FCMNotifier{
+		http:        httpClient,
+		tokens:      tokens,
+		sendURL:     fmt.Sprintf("%s/v1/projects/%s/messages:send", cfg.Endpoint, cfg.ProjectID),
+		topicPrefix: cfg.TopicPrefix,
+	}, nil
+}
+
+func (n *FCMNotifier) GetRequiredDataKeys() []string {
+	return requiredDataKeys
+}
+
+// GetOptionalDataKeys implements notification.OptionalDataKeyProvider.
+func (n *FCMNotifier) GetOptionalDataKeys() []string {
+	return optionalDataKeys
+}
+
+// FormatMessage builds the dispatch envelope (topics + message) as JSON.
+func (n *FCMNotifier) FormatMessage(req NotificationRequest) string {
+	msg, err := BuildDispatchMessage(req, n.topicPrefix)
+	if err != nil {
+		log.Printf("ERROR: FCM FormatMessage: %v", err)
+		return ""
+	}
+	return msg
+}
+
+// SendNotification parses the dispatch envelope from FormatMessage and sends
+// one message per topic.
+// AcceptsMessage implements notification.MessageFilter: only dispatch
+// envelopes from FormatMessage are sent, so plain-text messages (scheduler
+// summaries, postponements) are skipped rather than counted as failures.
+func (n *FCMNotifier) AcceptsMessage(message string) bool {
+	var env dispatchEnvelope
+	return json.Unmarshal([]byte(message), &env) == nil
+}
+
+func (n *FCMNotifier) SendNotification(ctx context.Context, message string) (<-chan NotificationResult, error) {
+	resultChan := make(chan NotificationResult, 1)
+
+	if message == "" {
+		close(resultChan)
+		return resultChan, fmt.Errorf("empty dispatch message")
+	}
+	var env dispatchEnvelope
+	if err := json.Unmarshal([]byte(message), &env); err != nil {
+		close(resultChan)
+		return resultChan, fmt.Errorf("parse dispatch envelope: %w", err)
+	}
+	if len(env.Topics) == 0 {
+		close(resultChan)
+		return resultChan, fmt.Errorf("dispatch envelope has no topics")
+	}
+
+	id := uuid.New().String()
+	go func() {
+		defer close(resultChan)
+		err := n.sendToAll(ctx, env)
+		resultChan <- NotificationResult{
+			ID:        id,
+			Success:   err == nil,
+			Error:     err,
+			Timestamp: time.Now(),
+		}
+	}()
+	return resultChan, nil
+}
+
+// sendToAll sends to every topic in parallel; returns the first error.
+func (n *FCMNotifier) sendToAll(ctx context.Context, env dispatchEnvelope) error {
+	errs := make(chan error, len(env.Topics))
+	for _, topic := range env.Topics {
+		go func(topic string) {
+			errs <- n.sendWithRetry(ctx, topic, env)
+		}(topic)
+	}
+
+	var firstErr error
+	for range env.Topics {
+		if err := <-errs; err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// fcmMessage is the messages:send request body.
+type fcmMessage struct {
+	Message struct {
+		Topic   string            `json:"topic"`
+		Data    map[string]string `json:"data"`
+		Android androidConfig     `json:"android"`
+	} `json:"message"`
+}
+
+func (n *FCMNotifier) sendWithRetry(ctx context.Context, topic string, env dispatchEnvelope) error {
+	var msg fcmMessage
+	msg.Message.Topic = topic
+	msg.Message.Data = env.Data
+	msg.Message.Android = env.Android
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("marshal FCM message: %w", err)
+	}
+
+	delay := retryDelay
+	var lastErr error
+	for attempt := range maxRetries {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(delay):
+				delay *= 2
+			}
+		}
+
+		err := n.send(ctx, topic, body)
+		if err == nil {
+			return nil
+		}
+		var re *retryableError
+		if !errors.As(err, &re) {
+			return err
+		}
+		lastErr = err
+		log.Printf("WARN: FCM retryable error topic=%s attempt=%d/%d: %v", topic, attempt+1, maxRetries, err)
+	}
+	return fmt.Errorf("FCM send failed after %d attempts: %w", maxRetries, lastErr)
+}
+
+// send performs one messages:send, re-fetching the access token once if FCM
+// answers 401.
+func (n *FCMNotifier) send(ctx context.Context, topic string, body []byte) error {
+	for attempt := 0; ; attempt++ {
+		token, err := n.tokens.Token(ctx)
+		if err != nil {
+			return fmt.Errorf("get FCM access token: %w", err)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.sendURL, bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("build request: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+
+		start := time.Now()
+		resp, err := n.http.Do(req)
+		latencyMs := time.Since(start).Milliseconds()
+		if err != nil {
+			log.Printf("FCM send error: topic=%s latency_ms=%d err=%v", topic, latencyMs, err)
+			return fmt.Errorf("FCM send: %w", err)
+		}
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, responseBodyLimit))
+		resp.Body.Close()
+		log.Printf("FCM send: topic=%s status=%d latency_ms=%d", topic, resp.StatusCode, latencyMs)
+
+		switch {
+		case resp.StatusCode == http.StatusOK:
+			return nil
+		case resp.StatusCode == http.StatusUnauthorized && attempt == 0:
+			log.Printf("WARN: FCM send: topic=%s access token rejected, fetching a new one", topic)
+			n.tokens.Invalidate(token)
+			continue
+		case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+			return &retryableError{status: resp.StatusCode, reason: errorStatus(respBody)}
+		default:
+			return fmt.Errorf("FCM send unexpected status %d: %s", resp.StatusCode, errorStatus(respBody))
+		}
+	}
+}
+
+// errorStatus extracts the status and message from a Google API error body
+// ({"error":{"status":"INVALID_ARGUMENT","message":"..."}}), falling back to
+// the raw body.
+func errorStatus(body []byte) string {
+	var parsed struct {
+		Error struct {
+			Status  string `json:"status"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &parsed); err == nil && parsed.Error.Status != "" {
+		return parsed.Error.Status + ": " + parsed.Error.Message
+	}
+	return string(body)
+}
+
+// retryableError signals the caller should retry with backoff.
+type retryableError struct {
+	status int
+	reason string
+}
+
+func (e *retryableError) Error() string {
+	return fmt.Sprintf("FCM status %d: %s", e.status, e.reason)
+}
+
+func (n *FCMNotifier) Close() error {
+	return nil
+}
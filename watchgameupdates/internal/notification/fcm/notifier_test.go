@@ -0,0 +1,201 @@
+package fcm
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// testAccount returns a service account with a fresh RSA key and its public half.
+func testAccount(t *testing.T, tokenURL string) (serviceAccount, *rsa.PublicKey) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate RSA key: %v", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+	return serviceAccount{
+		ProjectID:    "demo-project",
+		PrivateKeyID: "kid-1",
+		PrivateKey:   string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		ClientEmail:  "push@demo-project.iam.gserviceaccount.com",
+		TokenURI:     tokenURL,
+	}, &key.PublicKey
+}
+
+// fakeFCM serves the OAuth2 token endpoint and messages:send. sendStatuses
+// scripts the first send responses; later sends answer 200.
+type fakeFCM struct {
+	mu           sync.Mutex
+	tokenCalls   int
+	sends        []fcmMessage
+	auths        []string
+	sendStatuses []int
+}
+
+func (f *fakeFCM) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.URL.Path {
+	case "/token":
+		r.ParseForm()
+		if r.Form.Get("grant_type") != "urn:ietf:params:oauth:grant-type:jwt-bearer" || r.Form.Get("assertion") == "" {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		f.tokenCalls++
+		fmt.Fprintf(w, `{"access_token":"tok-%d","expires_in":3600,"token_type":"Bearer"}`, f.tokenCalls)
+	case "/v1/projects/demo-project/messages:send":
+		var msg fcmMessage
+		json.NewDecoder(r.Body).Decode(&msg)
+		f.sends = append(f.sends, msg)
+		f.auths = append(f.auths, r.Header.Get("Authorization"))
+		if len(f.sendStatuses) > 0 {
+			status := f.sendStatuses[0]
+			f.sendStatuses = f.sendStatuses[1:]
+			w.WriteHeader(status)
+			fmt.Fprintf(w, `{"error":{"code":%d,"status":"SCRIPTED","message":"scripted"}}`, status)
+			return
+		}
+		fmt.Fprint(w, `{"name":"projects/demo-project/messages/1"}`)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func newTestNotifier(t *testing.T, statuses ...int) (*FCMNotifier, *fakeFCM) {
+	t.Helper()
+	fake := &fakeFCM{sendStatuses: statuses}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	account, _ := testAccount(t, srv.URL+"/token")
+	n, err := newNotifier(&Config{
+		Account:     account,
+		ProjectID:   "demo-project",
+		Endpoint:    srv.URL,
+		TokenURL:    srv.URL + "/token",
+		TopicPrefix: defaultTopicPrefix,
+	}, srv.Client())
+	if err != nil {
+		t.Fatalf("newNotifier: %v", err)
+	}
+	return n, fake
+}
+
+func send(t *testing.T, n *FCMNotifier) error {
+	t.Helper()
+	msg := n.FormatMessage(baseReq())
+	if msg == "" {
+		t.Fatal("FormatMessage returned empty message")
+	}
+	results, err := n.SendNotification(context.Background(), msg)
+	if err != nil {
+		t.Fatalf("SendNotification: %v", err)
+	}
+	return (<-results).Error
+}
+
+func TestSendNotification_OneMessagePerTeamTopic(t *testing.T) {
+	n, fake := newTestNotifier(t)
+	if err := send(t, n); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+
+	topics := map[string]bool{}
+	for _, m := range fake.sends {
+		topics[m.Message.Topic] = true
+		if m.Message.Data["homeTeam"] != "BOS" || m.Message.Data["homeScore"] != "2" {
+			t.Errorf("data = %v, want BOS home with score 2", m.Message.Data)
+		}
+		if m.Message.Android.Priority != "HIGH" {
+			t.Errorf("android priority = %q, want HIGH", m.Message.Android.Priority)
+		}
+	}
+	if len(fake.sends) != 2 || !topics["nhl-team-BOS"] || !topics["nhl-team-NYR"] {
+		t.Errorf("topics = %v, want nhl-team-BOS and nhl-team-NYR", topics)
+	}
+	if fake.tokenCalls != 1 {
+		t.Errorf("token exchanges = %d, want 1 (cached across sends)", fake.tokenCalls)
+	}
+	for _, a := range fake.auths {
+		if a != "Bearer tok-1" {
+			t.Errorf("authorization = %q, want Bearer tok-1", a)
+		}
+	}
+}
+
+func TestSend_UnauthorizedRefreshesTokenOnce(t *testing.T) {
+	n, fake := newTestNotifier(t, http.StatusUnauthorized)
+	body := []byte(`{"message":{"topic":"nhl-team-BOS"}}`)
+
+	if err := n.send(context.Background(), "nhl-team-BOS", body); err != nil {
+		t.Fatalf("want success after token refresh, got %v", err)
+	}
+	if fake.tokenCalls != 2 || len(fake.auths) != 2 || fake.auths[1] != "Bearer tok-2" {
+		t.Errorf("token calls = %d, auths = %v; want a second token on retry", fake.tokenCalls, fake.auths)
+	}
+}
+
+func TestSendWithRetry(t *testing.T) {
+	tests := []struct {
+		name      string
+		statuses  []int
+		wantErr   bool
+		wantSends int
+	}{
+		{"ok", nil, false, 1},
+		{"retry after 503", []int{http.StatusServiceUnavailable}, false, 2},
+		{"retry after 429", []int{http.StatusTooManyRequests}, false, 2},
+		{"bad request is terminal", []int{http.StatusBadRequest}, true, 1},
+		{"not found is terminal", []int{http.StatusNotFound}, true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, fake := newTestNotifier(t, tt.statuses...)
+			err := n.sendWithRetry(context.Background(), "nhl-team-BOS", dispatchEnvelope{Data: map[string]string{}})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(fake.sends) != tt.wantSends {
+				t.Errorf("sends = %d, want %d", len(fake.sends), tt.wantSends)
+			}
+			if tt.wantErr && !strings.Contains(err.Error(), "SCRIPTED") {
+				t.Errorf("err = %v, want the API error status", err)
+			}
+		})
+	}
+}
+
+func TestSendNotification_InvalidEnvelope(t *testing.T) {
+	n, _ := newTestNotifier(t)
+	for _, msg := range []string{"", "not-json", `{"topics":[]}`} {
+		if _, err := n.SendNotification(context.Background(), msg); err == nil {
+			t.Errorf("SendNotification(%q): want error", msg)
+		}
+	}
+}
+
+func TestAcceptsMessage_OnlyEnvelopes(t *testing.T) {
+	n, _ := newTestNotifier(t)
+	for _, msg := range []string{"", "Scheduled 3 games for 2025-01-01", "Game 2025020001 was postponed"} {
+		if n.AcceptsMessage(msg) {
+			t.Errorf("AcceptsMessage(%q) = true, want false", msg)
+		}
+	}
+	if msg := n.FormatMessage(baseReq()); !n.AcceptsMessage(msg) {
+		t.Errorf("AcceptsMessage(FormatMessage()) = false, want true")
+	}
+}
@@ -0,0 +1,178 @@
+package fcm
+
+// OAuth2 service-account flow (RFC 7523 JWT bearer grant):
+//
+//   sign JWT → POST token_uri → cache access token until 5 min before expiry
+//   │
+//   ├── header:  {"alg":"RS256","typ":"JWT","kid":private_key_id}
+//   ├── claims:  {"iss":client_email,"scope":firebase.messaging,"aud":token_uri,"iat","exp":iat+1h}
+//   └── body:    grant_type=urn:ietf:params:oauth:grant-type:jwt-bearer&assertion={jwt}
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	messagingScope     = "https://www.googleapis.com/auth/firebase.messaging"
+	assertionLifetime  = time.Hour
+	tokenRefreshMargin = 5 * time.Minute
+	tokenBodyLimit     = 4096
+)
+
+type tokenSource struct {
+	http     *http.Client
+	key      *rsa.PrivateKey
+	keyID    string
+	email    string
+	tokenURL string
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+func newTokenSource(httpClient *http.Client, account serviceAccount, tokenURL string) (*tokenSource, error) {
+	key, err := parseRSAKey(account.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("parse FCM service account key: %w", err)
+	}
+	return &tokenSource{
+		http:     httpClient,
+		key:      key,
+		keyID:    account.PrivateKeyID,
+		email:    account.ClientEmail,
+		tokenURL: tokenURL,
+	}, nil
+}
+
+// Token returns a cached access token, exchanging a fresh assertion when the
+// current one is within tokenRefreshMargin of expiry.
+func (s *tokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && time.Until(s.expiresAt) > tokenRefreshMargin {
+		return s.token, nil
+	}
+
+	assertion, err := s.assertion(time.Now())
+	if err != nil {
+		return "", err
+	}
+	token, lifetime, err := s.exchange(ctx, assertion)
+	if err != nil {
+		return "", err
+	}
+	s.token = token
+	s.expiresAt = time.Now().Add(lifetime)
+	return token, nil
+}
+
+// Invalidate drops token so the next Token call fetches a new one. It is a
+// no-op if token has already been replaced.
+func (s *tokenSource) Invalidate(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token == token {
+		s.token = ""
+	}
+}
+
+func (s *tokenSource) assertion(now time.Time) (string, error) {
+	header, err := base64JSON(map[string]string{"alg": "RS256", "typ": "JWT", "kid": s.keyID})
+	if err != nil {
+		return "", err
+	}
+	claims, err := base64JSON(map[string]interface{}{
+		"iss":   s.email,
+		"scope": messagingScope,
+		"aud":   s.tokenURL,
+		"iat":   now.Unix(),
+		"exp":   now.Add(assertionLifetime).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	msg := header + "." + claims
+	digest := sha256.Sum256([]byte(msg))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("rsa sign: %w", err)
+	}
+	return msg + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+func (s *tokenSource) exchange(ctx context.Context, assertion string) (string, time.Duration, error) {
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, fmt.Errorf("build token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return "", 0, fmt.Errorf("FCM token exchange: %w", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, tokenBodyLimit))
+	if resp.StatusCode != http.StatusOK {
+		return "", 0, fmt.Errorf("FCM token exchange status %d: %s", resp.StatusCode, body)
+	}
+
+	var parsed struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &parsed); err != nil || parsed.AccessToken == "" {
+		return "", 0, fmt.Errorf("FCM token exchange: no access_token in response")
+	}
+	return parsed.AccessToken, time.Duration(parsed.ExpiresIn) * time.Second, nil
+}
+
+// parseRSAKey decodes the PEM private_key of a service-account JSON key.
+func parseRSAKey(pemStr string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(pemStr))
+	if block == nil {
+		return nil, fmt.Errorf("private_key is not PEM")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	raw, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse private key: %w", err)
+	}
+	key, ok := raw.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private_key is not an RSA key")
+	}
+	return key, nil
+}
+
+func base64JSON(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("marshal JWT part: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,37 @@
+package gamestream
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// Config selects where snapshots are kept and how updates are fanned out.
+type Config struct {
+	Store         string // "memory" (default) or "redis"; GAMESTREAM_STORE
+	RedisAddress  string // for the "redis" store; shares REDIS_ADDRESS/REDIS_PASSWORD/REDIS_DB with the queue
+	RedisPassword string
+	RedisDB       int
+}
+
+// LoadConfig reads game stream config from the environment.
+func LoadConfig() (*Config, error) {
+	cfg := &Config{
+		Store:         os.Getenv("GAMESTREAM_STORE"),
+		RedisAddress:  os.Getenv("REDIS_ADDRESS"),
+		RedisPassword: os.Getenv("REDIS_PASSWORD"),
+	}
+	if cfg.Store == "" {
+		cfg.Store = "memory"
+	}
+	if cfg.RedisAddress == "" {
+		cfg.RedisAddress = "localhost:6379"
+	}
+	if v, err := strconv.Atoi(os.Getenv("REDIS_DB")); err == nil && v >= 0 {
+		cfg.RedisDB = v
+	}
+	if cfg.Store != "memory" && cfg.Store != "redis" {
+		return nil, fmt.Errorf("unknown GAMESTREAM_STORE %q (want memory or redis)", cfg.Store)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,26 @@
+package gamestream
+
+import "testing"
+
+func TestLoadConfig(t *testing.T) {
+	t.Setenv("REDIS_ADDRESS", "")
+	t.Setenv("REDIS_DB", "")
+
+	t.Setenv("GAMESTREAM_STORE", "")
+	cfg, err := LoadConfig()
+	if err != nil || cfg.Store != "memory" || cfg.RedisAddress != "localhost:6379" {
+		t.Errorf("defaults = %+v, %v; want memory store and default Redis address", cfg, err)
+	}
+
+	t.Setenv("GAMESTREAM_STORE", "redis")
+	t.Setenv("REDIS_DB", "2")
+	cfg, err = LoadConfig()
+	if err != nil || cfg.Store != "redis" || cfg.RedisDB != 2 {
+		t.Errorf("redis = %+v, %v; want redis store on DB 2", cfg, err)
+	}
+
+	t.Setenv("GAMESTREAM_STORE", "postgres")
+	if _, err := LoadConfig(); err == nil {
+		t.Error("want error for unknown store")
+	}
+}
@@ -0,0 +1,246 @@
+// Package gamestream keeps the latest content state of every tracked game and
+// serves it to clients that have no push channel of their own: an iOS Live
+// Activity started mid-game, or the web client.
+//
+//	GET /games/{id}/state   → 200 Snapshot JSON, or 404 before the first update
+//	GET /games/{id}/stream  → text/event-stream; an "event: state" with the
+//	                          current snapshot, then one per update
+//
+// It is registered as a notifier so it sees exactly the updates the push
+// notifiers receive; notifiers.New always registers it, independent of
+// NOTIFIERS. Games polled with ShouldNotify=false therefore have no snapshot.
+// Plain-text messages (scheduler summaries, postponements) are not snapshots
+// and are skipped, see AcceptsMessage.
+package gamestream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	. "watchgameupdates/internal/notification"
+)
+
+// Route patterns for the snapshot and stream endpoints.
+const (
+	StatePath  = "GET /games/{id}/state"
+	StreamPath = "GET /games/{id}/stream"
+)
+
+// heartbeatInterval keeps idle streams open through proxies and load
+// balancers that drop silent connections (Cloud Run, nginx).
+const heartbeatInterval = 25 * time.Second
+
+var requiredDataKeys = []string{
+	"homeTeamGoals",
+	"awayTeamGoals",
+	"homeTeamExpectedGoals",
+	"awayTeamExpectedGoals",
+	"gameState",
+	"homeTeamAbbrev",
+	"awayTeamAbbrev",
+	"lastPlayType",
+}
+
+// optionalDataKeys are set by the service and processor rather than fetched
+// from MoneyPuck.
+var optionalDataKeys = []string{
+	"gameId",
+	"eventTeamAbbrev",
+	"scorerName",
+	"nextCheckSeconds",
+	"seriesStatus",
+}
+
+// GameStreamNotifier implements notification.Notifier by storing each update
+// as its game's snapshot.
+type GameStreamNotifier struct {
+	store Store
+}
+
+// New creates a GameStreamNotifier from environment config.
+func New() (*GameStreamNotifier, error) {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	store, err := NewStore(cfg)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("GameStream initialized: store=%s", cfg.Store)
+	return newNotifier(store), nil
+}
+
+func newNotifier(store Store) *GameStreamNotifier {
+	return &GameStreamNotifier{store: store}
+}
+
+func (n *GameStreamNotifier) GetRequiredDataKeys() []string {
+	return requiredDataKeys
+}
+
+// GetOptionalDataKeys implements notification.OptionalDataKeyProvider.
+func (n *GameStreamNotifier) GetOptionalDataKeys() []string {
+	return optionalDataKeys
+}
+
+// FormatMessage builds the game's snapshot as JSON.
+func (n *GameStreamNotifier) FormatMessage(req NotificationRequest) string {
+	snap, err := BuildSnapshot(req, time.Now())
+	if err != nil {
+		log.Printf("ERROR: GameStream FormatMessage: %v", err)
+		return ""
+	}
+	b, err := json.Marshal(snap)
+	if err != nil {
+		log.Printf("ERROR: GameStream FormatMessage: %v", err)
+		return ""
+	}
+	return string(b)
+}
+
+// AcceptsMessage implements notification.MessageFilter: only snapshots from
+// FormatMessage are stored.
+func (n *GameStreamNotifier) AcceptsMessage(message string) bool {
+	_, err := parseSnapshot(message)
+	return err == nil
+}
+
+// SendNotification stores the snapshot from FormatMessage and publishes it to
+// the game's stream subscribers.
+func (n *GameStreamNotifier) SendNotification(ctx context.Context, message string) (<-chan NotificationResult, error) {
+	resultChan := make(chan NotificationResult, 1)
+
+	snap, err := parseSnapshot(message)
+	if err != nil {
+		close(resultChan)
+		return resultChan, err
+	}
+
+	err = n.store.Put(ctx, snap)
+	resultChan <- NotificationResult{
+		ID:        uuid.New().String(),
+		Success:   err == nil,
+		Error:     err,
+		Timestamp: time.Now(),
+	}
+	close(resultChan)
+	return resultChan, nil
+}
+
+// parseSnapshot decodes a FormatMessage result.
+func parseSnapshot(message string) (Snapshot, error) {
+	var snap Snapshot
+	if message == "" {
+		return snap, fmt.Errorf("empty snapshot message")
+	}
+	if err := json.Unmarshal([]byte(message), &snap); err != nil {
+		return snap, fmt.Errorf("parse snapshot: %w", err)
+	}
+	if snap.GameID == "" {
+		return snap, fmt.Errorf("snapshot has no gameId")
+	}
+	return snap, nil
+}
+
+// Routes exposes the snapshot and stream endpoints.
+func (n *GameStreamNotifier) Routes() map[string]http.Handler {
+	return map[string]http.Handler{
+		StatePath:  http.HandlerFunc(n.serveState),
+		StreamPath: http.HandlerFunc(n.serveStream),
+	}
+}
+
+func (n *GameStreamNotifier) serveState(w http.ResponseWriter, r *http.Request) {
+	gameID := r.PathValue("id")
+	snap, ok, err := n.store.Get(r.Context(), gameID)
+	if err != nil {
+		log.Printf("ERROR: read game state for %s: %v", gameID, err)
+		http.Error(w, "failed to read game state", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		http.Error(w, "no state for game", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(snap)
+}
+
+func (n *GameStreamNotifier) serveStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	gameID := r.PathValue("id")
+	ctx := r.Context()
+
+	// Subscribe before reading the current snapshot so an update landing in
+	// between is not lost; at worst the client sees the same state twice.
+	updates, err := n.store.Subscribe(ctx, gameID)
+	if err != nil {
+		log.Printf("ERROR: subscribe to game %s: %v", gameID, err)
+		http.Error(w, "failed to subscribe", http.StatusInternalServerError)
+		return
+	}
+	current, haveCurrent, err := n.store.Get(ctx, gameID)
+	if err != nil {
+		log.Printf("ERROR: read game state for %s: %v", gameID, err)
+		http.Error(w, "failed to read game state", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if haveCurrent {
+		if err := writeEvent(w, current); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case snap, ok := <-updates:
+			if !ok {
+				return
+			}
+			if err := writeEvent(w, snap); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+// writeEvent writes snap as one server-sent event.
+func writeEvent(w http.ResponseWriter, snap Snapshot) error {
+	b, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: state\ndata: %s\n\n", b)
+	return err
+}
+
+func (n *GameStreamNotifier) Close() error {
+	return nil
+}
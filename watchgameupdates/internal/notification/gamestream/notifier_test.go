@@ -0,0 +1,152 @@
+package gamestream
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestServer(t *testing.T) (*GameStreamNotifier, *httptest.Server) {
+	t.Helper()
+	n := newNotifier(newMemoryStore())
+	mux := http.NewServeMux()
+	for pattern, h := range n.Routes() {
+		mux.Handle(pattern, h)
+	}
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return n, srv
+}
+
+func notify(t *testing.T, n *GameStreamNotifier, mutate func(map[string]string)) {
+	t.Helper()
+	req := baseReq()
+	if mutate != nil {
+		mutate(req.Data)
+	}
+	results, err := n.SendNotification(context.Background(), n.FormatMessage(req))
+	if err != nil {
+		t.Fatalf("SendNotification: %v", err)
+	}
+	if res := <-results; !res.Success {
+		t.Fatalf("SendNotification result: %v", res.Error)
+	}
+}
+
+func TestServeState(t *testing.T) {
+	n, srv := newTestServer(t)
+
+	resp, err := http.Get(srv.URL + "/games/2024020123/state")
+	if err != nil {
+		t.Fatalf("GET state: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("status before any update = %d, want 404", resp.StatusCode)
+	}
+
+	notify(t, n, nil)
+	resp, err = http.Get(srv.URL + "/games/2024020123/state")
+	if err != nil {
+		t.Fatalf("GET state: %v", err)
+	}
+	defer resp.Body.Close()
+	var snap Snapshot
+	if err := json.NewDecoder(resp.Body).Decode(&snap); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	cs := snap.ContentState
+	if resp.StatusCode != http.StatusOK || cs.HomeTeam != "BOS" || cs.HomeScore != 2 || cs.AwayScore != 1 || cs.EventDetail != "Brad Marchand" {
+		t.Errorf("status %d, snapshot = %+v", resp.StatusCode, snap)
+	}
+
+	resp, err = http.Post(srv.URL+"/games/2024020123/state", "application/json", nil)
+	if err != nil {
+		t.Fatalf("POST state: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("POST status = %d, want 405", resp.StatusCode)
+	}
+}
+
+// readEvent reads one server-sent event's data line, skipping comments.
+func readEvent(t *testing.T, r *bufio.Reader) Snapshot {
+	t.Helper()
+	var event, data string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read stream: %v", err)
+		}
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		case line == "" && data != "":
+			if event != "state" {
+				t.Errorf("event = %q, want state", event)
+			}
+			var snap Snapshot
+			if err := json.Unmarshal([]byte(data), &snap); err != nil {
+				t.Fatalf("event data: %v", err)
+			}
+			return snap
+		}
+	}
+}
+
+func TestServeStream_CurrentStateThenUpdates(t *testing.T) {
+	n, srv := newTestServer(t)
+	notify(t, n, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/games/2024020123/stream", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET stream: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want text/event-stream", ct)
+	}
+	r := bufio.NewReader(resp.Body)
+
+	if snap := readEvent(t, r); snap.ContentState.HomeScore != 2 {
+		t.Errorf("first event = %+v, want the current 2-1 state", snap)
+	}
+
+	notify(t, n, func(d map[string]string) { d["awayTeamGoals"] = "2"; d["eventTeamAbbrev"] = "NYR" })
+	if snap := readEvent(t, r); snap.ContentState.AwayScore != 2 || snap.ContentState.EventTeam != "NYR" {
+		t.Errorf("update event = %+v, want the 2-2 equaliser", snap)
+	}
+}
+
+func TestSendNotification_InvalidMessage(t *testing.T) {
+	n := newNotifier(newMemoryStore())
+	for _, msg := range []string{"", "not-json", `{"contentState":{}}`} {
+		if _, err := n.SendNotification(context.Background(), msg); err == nil {
+			t.Errorf("SendNotification(%q): want error", msg)
+		}
+	}
+}
+
+func TestAcceptsMessage_OnlySnapshots(t *testing.T) {
+	n := newNotifier(newMemoryStore())
+	for _, msg := range []string{"", "Scheduled 3 games for 2025-01-01", `{"contentState":{}}`} {
+		if n.AcceptsMessage(msg) {
+			t.Errorf("AcceptsMessage(%q) = true, want false", msg)
+		}
+	}
+	if msg := n.FormatMessage(baseReq()); !n.AcceptsMessage(msg) {
+		t.Errorf("AcceptsMessage(FormatMessage()) = false, want true")
+	}
+}
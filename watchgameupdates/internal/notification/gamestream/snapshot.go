@@ -0,0 +1,51 @@
+package gamestream
+
+// Snapshot is the latest known state of one game, as served by
+// GET /games/{id}/state and each event of GET /games/{id}/stream:
+//
+//	{
+//	  "gameId":    "2024020123",
+//	  "updatedAt": 1234567890,
+//	  "staleDate": 1234567980,       // next scheduled check + margin
+//	  "contentState": {              // contentstate.State, as in the Live Activity content-state
+//	    "sport": "nhl", "homeTeam": "BOS", "awayTeam": "NYR",
+//	    "homeScore": 2, "awayScore": 1, "homeXG": 2.4, "awayXG": 1.8,
+//	    "gameState": "14:32 left, 2nd period",
+//	    "eventType": "goal", "eventDetail": "Brad Marchand", "eventTeam": "BOS",
+//	    "seriesStatus": "BOS leads 2-1"   // playoff games only
+//	  }
+//	}
+//
+// contentState decodes directly into the iOS ContentState, so an activity
+// started mid-game can show the real score instead of 0-0.
+
+import (
+	"fmt"
+	"time"
+
+	. "watchgameupdates/internal/notification"
+	"watchgameupdates/internal/notification/contentstate"
+)
+
+type Snapshot struct {
+	GameID       string             `json:"gameId"`
+	UpdatedAt    int64              `json:"updatedAt"`
+	StaleDate    int64              `json:"staleDate"`
+	ContentState contentstate.State `json:"contentState"`
+}
+
+// BuildSnapshot converts a notification request into the snapshot stored for
+// its game. The game ID comes from the "gameId" data key.
+func BuildSnapshot(req NotificationRequest, now time.Time) (Snapshot, error) {
+	gameID := req.Data["gameId"]
+	if gameID == "" {
+		return Snapshot{}, fmt.Errorf("notification data has no gameId")
+	}
+
+	return Snapshot{
+		GameID:       gameID,
+		UpdatedAt:    now.Unix(),
+		StaleDate:    now.Add(contentstate.StaleAfter(req.Data)).Unix(),
+		ContentState: contentstate.FromRequest(req),
+	}, nil
+}
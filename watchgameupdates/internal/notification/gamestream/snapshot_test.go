@@ -0,0 +1,134 @@
+package gamestream
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+
+	. "watchgameupdates/internal/notification"
+	"watchgameupdates/internal/notification/contentstate"
+	"watchgameupdates/internal/notification/liveactivity"
+)
+
+func baseReq() NotificationRequest {
+	return NotificationRequest{
+		Team1ID: "Bruins",
+		Team2ID: "Rangers",
+		Data: map[string]string{
+			"gameId":                "2024020123",
+			"homeTeamGoals":         "2",
+			"awayTeamGoals":         "1",
+			"homeTeamExpectedGoals": "2.4",
+			"awayTeamExpectedGoals": "1.8",
+			"homeTeamAbbrev":        "BOS",
+			"awayTeamAbbrev":        "NYR",
+			"gameState":             "14:32 left, 2nd period",
+			"lastPlayType":          "goal",
+			"eventTeamAbbrev":       "BOS",
+			"scorerName":            "Brad Marchand",
+		},
+	}
+}
+
+// TestBuildSnapshot_MatchesLiveActivityContentState keeps the snapshot in step
+// with the iOS content-state so the app can decode it as-is.
+func TestBuildSnapshot_MatchesLiveActivityContentState(t *testing.T) {
+	t.Setenv("APNS_CHANNEL_STORE", "")
+	store, err := liveactivity.NewChannelStore(&liveactivity.Config{})
+	if err != nil {
+		t.Fatalf("NewChannelStore: %v", err)
+	}
+
+	playoff := baseReq()
+	playoff.Data["seriesStatus"] = "BOS leads 2-1"
+
+	tests := []struct {
+		name string
+		req  NotificationRequest
+	}{
+		{"regular season", baseReq()},
+		{"playoff", playoff},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := liveactivity.BuildDispatchMessage(tt.req, store, liveactivity.AlertConfig{})
+			if err != nil {
+				t.Fatalf("liveactivity.BuildDispatchMessage: %v", err)
+			}
+			var apns struct {
+				Payload struct {
+					APS struct {
+						ContentState map[string]interface{} `json:"content-state"`
+					} `json:"aps"`
+				} `json:"payload"`
+			}
+			if err := json.Unmarshal([]byte(msg), &apns); err != nil {
+				t.Fatalf("unmarshal APNs envelope: %v", err)
+			}
+
+			snap, err := BuildSnapshot(tt.req, time.Now())
+			if err != nil {
+				t.Fatalf("BuildSnapshot: %v", err)
+			}
+			b, _ := json.Marshal(snap.ContentState)
+			var got map[string]interface{}
+			json.Unmarshal(b, &got)
+
+			want := apns.Payload.APS.ContentState
+			if len(got) != len(want) {
+				t.Errorf("content state keys = %v, want %v", got, want)
+			}
+			for key, w := range want {
+				if got[key] != w {
+					t.Errorf("%s = %v, want %v", key, got[key], w)
+				}
+			}
+		})
+	}
+}
+
+// TestOptionalDataKeys_IncludeSeriesStatus checks the notifier asks the
+// service for the series, which the content state carries in the playoffs.
+func TestOptionalDataKeys_IncludeSeriesStatus(t *testing.T) {
+	if !slices.Contains((&GameStreamNotifier{}).GetOptionalDataKeys(), "seriesStatus") {
+		t.Error("optional data keys do not include seriesStatus")
+	}
+}
+
+func TestBuildSnapshot_StaleDate(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	tests := []struct {
+		name      string
+		nextCheck string
+		want      time.Duration
+	}{
+		{"default", "", contentstate.StaleOffset},
+		{"intermission", "1200", 1200*time.Second + contentstate.StaleMargin},
+		{"invalid", "soon", contentstate.StaleOffset},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := baseReq()
+			req.Data["nextCheckSeconds"] = tt.nextCheck
+			snap, err := BuildSnapshot(req, now)
+			if err != nil {
+				t.Fatalf("BuildSnapshot: %v", err)
+			}
+			if got := time.Duration(snap.StaleDate-now.Unix()) * time.Second; got != tt.want {
+				t.Errorf("stale after %v, want %v", got, tt.want)
+			}
+			if snap.UpdatedAt != now.Unix() || snap.GameID != "2024020123" {
+				t.Errorf("snapshot = %+v", snap)
+			}
+		})
+	}
+}
+
+func TestBuildSnapshot_RequiresGameID(t *testing.T) {
+	req := baseReq()
+	delete(req.Data, "gameId")
+	if _, err := BuildSnapshot(req, time.Now()); err == nil {
+		t.Error("want error without gameId")
+	}
+}
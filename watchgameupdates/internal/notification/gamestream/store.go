@@ -0,0 +1,208 @@
+package gamestream
+
+// Snapshot stores keep the latest Snapshot per game and fan every update out
+// to stream subscribers:
+//
+//	memory — in-process; fine for a single replica (Cloud Run http mode)
+//	redis  — key gamestream:state:{id} plus pub/sub channel
+//	         gamestream:updates:{id} on REDIS_ADDRESS, so any worker replica
+//	         can serve any game
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// snapshotTTL bounds how long a finished game's snapshot is kept.
+const snapshotTTL = 12 * time.Hour
+
+// subscriberBuffer is how many updates a slow stream client may lag behind
+// before older ones are dropped in favour of the latest.
+const subscriberBuffer = 4
+
+// Store keeps the latest snapshot per game and publishes updates.
+type Store interface {
+	Put(ctx context.Context, snap Snapshot) error
+	// Get returns the latest snapshot for gameID; ok is false when none is known.
+	Get(ctx context.Context, gameID string) (snap Snapshot, ok bool, err error)
+	// Subscribe delivers every snapshot Put for gameID after it returns. The
+	// channel is closed once ctx is done.
+	Subscribe(ctx context.Context, gameID string) (<-chan Snapshot, error)
+}
+
+// NewStore builds the Store selected by cfg.Store.
+func NewStore(cfg *Config) (Store, error) {
+	switch cfg.Store {
+	case "", "memory":
+		return newMemoryStore(), nil
+	case "redis":
+		client := redis.NewClient(&redis.Options{
+			Addr:     cfg.RedisAddress,
+			Password: cfg.RedisPassword,
+			DB:       cfg.RedisDB,
+		})
+		return newRedisStore(client), nil
+	default:
+		return nil, fmt.Errorf("unknown GAMESTREAM_STORE %q (want memory or redis)", cfg.Store)
+	}
+}
+
+// deliver hands snap to a subscriber without blocking the publisher. When the
+// buffer is full the oldest pending update is dropped: stream clients only
+// need the latest state.
+func deliver(ch chan Snapshot, snap Snapshot) {
+	for {
+		select {
+		case ch <- snap:
+			return
+		default:
+		}
+		select {
+		case <-ch:
+		default:
+		}
+	}
+}
+
+type memoryStore struct {
+	mu     sync.Mutex
+	latest map[string]Snapshot
+	subs   map[string]map[chan Snapshot]struct{}
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		latest: map[string]Snapshot{},
+		subs:   map[string]map[chan Snapshot]struct{}{},
+	}
+}
+
+func (s *memoryStore) Put(_ context.Context, snap Snapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Evict finished games so a long-running process doesn't grow forever.
+	cutoff := time.Now().Add(-snapshotTTL).Unix()
+	for id, old := range s.latest {
+		if old.UpdatedAt < cutoff {
+			delete(s.latest, id)
+		}
+	}
+
+	s.latest[snap.GameID] = snap
+	for ch := range s.subs[snap.GameID] {
+		deliver(ch, snap)
+	}
+	return nil
+}
+
+func (s *memoryStore) Get(_ context.Context, gameID string) (Snapshot, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snap, ok := s.latest[gameID]
+	return snap, ok, nil
+}
+
+func (s *memoryStore) Subscribe(ctx context.Context, gameID string) (<-chan Snapshot, error) {
+	ch := make(chan Snapshot, subscriberBuffer)
+	s.mu.Lock()
+	if s.subs[gameID] == nil {
+		s.subs[gameID] = map[chan Snapshot]struct{}{}
+	}
+	s.subs[gameID][ch] = struct{}{}
+	s.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.subs[gameID], ch)
+		if len(s.subs[gameID]) == 0 {
+			delete(s.subs, gameID)
+		}
+		close(ch)
+	}()
+	return ch, nil
+}
+
+type redisStore struct {
+	client *redis.Client
+}
+
+func newRedisStore(client *redis.Client) *redisStore {
+	return &redisStore{client: client}
+}
+
+func stateKey(gameID string) string   { return "gamestream:state:" + gameID }
+func updatesKey(gameID string) string { return "gamestream:updates:" + gameID }
+
+func (s *redisStore) Put(ctx context.Context, snap Snapshot) error {
+	b, err := json.Marshal(snap)
+	if err != nil {
+		return fmt.Errorf("marshal snapshot: %w", err)
+	}
+	_, err = s.client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.Set(ctx, stateKey(snap.GameID), b, snapshotTTL)
+		p.Publish(ctx, updatesKey(snap.GameID), b)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("store snapshot for game %s: %w", snap.GameID, err)
+	}
+	return nil
+}
+
+func (s *redisStore) Get(ctx context.Context, gameID string) (Snapshot, bool, error) {
+	b, err := s.client.Get(ctx, stateKey(gameID)).Bytes()
+	if err == redis.Nil {
+		return Snapshot{}, false, nil
+	}
+	if err != nil {
+		return Snapshot{}, false, fmt.Errorf("read snapshot for game %s: %w", gameID, err)
+	}
+	var snap Snapshot
+	if err := json.Unmarshal(b, &snap); err != nil {
+		return Snapshot{}, false, fmt.Errorf("parse snapshot for game %s: %w", gameID, err)
+	}
+	return snap, true, nil
+}
+
+func (s *redisStore) Subscribe(ctx context.Context, gameID string) (<-chan Snapshot, error) {
+	pubsub := s.client.Subscribe(ctx, updatesKey(gameID))
+	// Wait for the subscription to be confirmed so no update published after
+	// Subscribe returns is missed.
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, fmt.Errorf("subscribe to game %s: %w", gameID, err)
+	}
+
+	ch := make(chan Snapshot, subscriberBuffer)
+	go func() {
+		defer close(ch)
+		defer pubsub.Close()
+		msgs := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-msgs:
+				if !ok {
+					return
+				}
+				var snap Snapshot
+				if err := json.Unmarshal([]byte(msg.Payload), &snap); err != nil {
+					log.Printf("WARN: dropping malformed game stream update for %s: %v", gameID, err)
+					continue
+				}
+				deliver(ch, snap)
+			}
+		}
+	}()
+	return ch, nil
+}
@@ -0,0 +1,99 @@
+package gamestream
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"watchgameupdates/internal/notification/contentstate"
+)
+
+// exerciseStore runs the contract every Store must meet.
+func exerciseStore(t *testing.T, s Store) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if _, ok, err := s.Get(ctx, "1"); ok || err != nil {
+		t.Fatalf("empty store: Get = ok %v, err %v; want not found", ok, err)
+	}
+
+	updates, err := s.Subscribe(ctx, "1")
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	first := Snapshot{GameID: "1", UpdatedAt: time.Now().Unix(), ContentState: contentstate.State{HomeScore: 1}}
+	if err := s.Put(ctx, first); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := s.Put(ctx, Snapshot{GameID: "2", UpdatedAt: time.Now().Unix()}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	if got, ok, err := s.Get(ctx, "1"); !ok || err != nil || got.ContentState.HomeScore != 1 {
+		t.Errorf("Get(1) = %+v, %v, %v; want the stored snapshot", got, ok, err)
+	}
+	select {
+	case got := <-updates:
+		if got.GameID != "1" || got.ContentState.HomeScore != 1 {
+			t.Errorf("update = %+v, want game 1's snapshot", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("subscriber did not receive the update")
+	}
+	select {
+	case got := <-updates:
+		t.Errorf("subscriber for game 1 received %+v", got)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	cancel()
+	select {
+	case _, ok := <-updates:
+		if ok {
+			t.Error("received an update after cancel, want the channel closed")
+		}
+	case <-time.After(2 * time.Second):
+		t.Error("channel not closed after the subscription context ended")
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	exerciseStore(t, newMemoryStore())
+}
+
+func TestRedisStore(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	exerciseStore(t, newRedisStore(client))
+
+	if ttl := mr.TTL(stateKey("1")); ttl <= 0 || ttl > snapshotTTL {
+		t.Errorf("snapshot TTL = %v, want up to %v", ttl, snapshotTTL)
+	}
+}
+
+func TestMemoryStore_EvictsOldSnapshots(t *testing.T) {
+	s := newMemoryStore()
+	ctx := context.Background()
+	s.Put(ctx, Snapshot{GameID: "old", UpdatedAt: time.Now().Add(-snapshotTTL - time.Minute).Unix()})
+	s.Put(ctx, Snapshot{GameID: "new", UpdatedAt: time.Now().Unix()})
+
+	if _, ok, _ := s.Get(ctx, "old"); ok {
+		t.Error("snapshot older than snapshotTTL was kept")
+	}
+	if _, ok, _ := s.Get(ctx, "new"); !ok {
+		t.Error("current snapshot missing")
+	}
+}
+
+func TestDeliver_KeepsLatestWhenFull(t *testing.T) {
+	ch := make(chan Snapshot, 1)
+	deliver(ch, Snapshot{GameID: "1", UpdatedAt: 1})
+	deliver(ch, Snapshot{GameID: "1", UpdatedAt: 2})
+	if got := <-ch; got.UpdatedAt != 2 {
+		t.Errorf("buffered update = %d, want the latest (2)", got.UpdatedAt)
+	}
+}
@@ -10,17 +10,40 @@ package liveactivity
 //   apns-priority:   10
 //   apns-expiration: {unix timestamp}
 //   authorization:   bearer {jwt}
+//
+// Push-to-start goes to a single device instead of a channel:
+//
+// Endpoint: POST /3/device/{pushToStartToken}
+// Required headers:
+//   apns-push-type: liveactivity
+//   apns-topic:     {bundleID}.push-type.liveactivity
+//   apns-priority:  10
+//   authorization:  bearer {jwt}
+//
+// Error responses carry {"reason": "..."}. Besides retrying 429/500/503:
+//   410                           channel or token gone → errChannelGone / errTokenGone
+//   403 ExpiredProviderToken      re-sign the JWT and retry once
+//   403 BadEnvironmentKeyInToken  open the breaker; every later push fails fast
+//                                 with errBreakerOpen until the process restarts
 
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"os"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
+
+	"watchgameupdates/internal/metrics"
 )
 
 const (
@@ -36,11 +59,20 @@ const (
 	apnsResponseBodyLimit = 512
 )
 
+// APNs error reasons handled specially.
+const (
+	reasonExpiredProviderToken     = "ExpiredProviderToken"
+	reasonBadEnvironmentKeyInToken = "BadEnvironmentKeyInToken"
+)
+
 type apnsClient struct {
 	http     *http.Client
 	signer   *jwtSigner
 	host     string
 	bundleID string
+
+	// breakerOpen is set once APNs rejects the key for this environment.
+	breakerOpen atomic.Bool
 }
 
 func newAPNsClient(cfg *Config) (*apnsClient, error) {
@@ -49,8 +81,9 @@ func newAPNsClient(cfg *Config) (*apnsClient, error) {
 		return nil, fmt.Errorf("init JWT signer: %w", err)
 	}
 
-	httpClient := &http.Client{
-		Timeout: apnsHTTPTimeout,
+	httpClient, err := newHTTPClient(cfg)
+	if err != nil {
+		return nil, err
 	}
 
 	return &apnsClient{
@@ -61,53 +94,206 @@ func newAPNsClient(cfg *Config) (*apnsClient, error) {
 	}, nil
 }
 
+// newHTTPClient returns the HTTP/2 client used for APNs. By default it trusts
+// the system roots; APNS_CA_FILE or APNS_INSECURE_SKIP_VERIFY point it at a
+// local stand-in such as cmd/fakeapns instead.
+func newHTTPClient(cfg *Config) (*http.Client, error) {
+	if cfg.CAFile == "" && !cfg.InsecureSkipVerify {
+		return &http.Client{Timeout: apnsHTTPTimeout}, nil
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify}
+	if cfg.CAFile != "" {
+		pemBytes, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read APNS_CA_FILE: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pemBytes) {
+			return nil, fmt.Errorf("APNS_CA_FILE %s contains no PEM certificates", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	// A custom TLS config disables Go's automatic HTTP/2 upgrade; APNs needs it.
+	transport.ForceAttemptHTTP2 = true
+	return &http.Client{Timeout: apnsHTTPTimeout, Transport: transport}, nil
+}
+
 // Push sends a broadcast Live Activity push to the given channel ID.
 // channelID is the raw base64 value stored in channels.go — passed as-is in the apns-channel-id header.
+// A 410 means the channel was deleted on APNs; it is reported as errChannelGone
+// so the caller can stop pushing to it.
 func (c *apnsClient) Push(ctx context.Context, channelID string, payload []byte) error {
-	jwt, err := c.signer.Token()
+	url := fmt.Sprintf("https://%s/4/broadcasts/apps/%s", c.host, c.bundleID)
+	headers := map[string]string{
+		"apns-channel-id": channelID,
+		"apns-expiration": apnsExpiration,
+	}
+
+	status, reason, err := c.send(ctx, url, headers, payload, "channel="+channelID)
 	if err != nil {
-		return fmt.Errorf("get JWT: %w", err)
+		return err
 	}
 
-	url := fmt.Sprintf("https://%s/4/broadcasts/apps/%s", c.host, c.bundleID)
+	switch status {
+	case http.StatusOK:
+		return nil
+	case http.StatusGone:
+		return errChannelGone
+	default:
+		return statusError(status, reason)
+	}
+}
+
+// PushToDevice sends a push-to-start Live Activity push to a single device.
+// token is the hex push-to-start token the app received from ActivityKit.
+// A 410 means the token is no longer valid; it is reported as errTokenGone so
+// the caller can forget it.
+func (c *apnsClient) PushToDevice(ctx context.Context, token string, payload []byte) error {
+	url := fmt.Sprintf("https://%s/3/device/%s", c.host, token)
+	headers := map[string]string{
+		"apns-topic": c.bundleID + ".push-type.liveactivity",
+	}
+
+	status, reason, err := c.send(ctx, url, headers, payload, "token="+shortToken(token))
+	if err != nil {
+		return err
+	}
 
+	switch status {
+	case http.StatusOK:
+		return nil
+	case http.StatusGone:
+		return errTokenGone
+	default:
+		return statusError(status, reason)
+	}
+}
+
+// send performs an authenticated APNs request and returns the status and the
+// error reason (empty on success). It handles the provider-token 403s itself:
+// an expired token is re-signed and the request retried once, and a key for
+// the wrong environment opens the breaker. logTarget identifies the
+// destination in log lines (channel or device).
+func (c *apnsClient) send(ctx context.Context, url string, headers map[string]string, payload []byte, logTarget string) (int, string, error) {
+	for attempt := 0; ; attempt++ {
+		if c.breakerOpen.Load() {
+			return 0, "", errBreakerOpen
+		}
+
+		jwt, err := c.signer.Token()
+		if err != nil {
+			return 0, "", fmt.Errorf("get JWT: %w", err)
+		}
+
+		status, body, err := c.do(ctx, url, jwt, headers, payload, logTarget)
+		if err != nil {
+			return 0, "", err
+		}
+		if status == http.StatusOK {
+			return status, "", nil
+		}
+
+		reason := responseReason(body)
+		if status == http.StatusForbidden {
+			switch reason {
+			case reasonExpiredProviderToken:
+				if attempt == 0 {
+					log.Printf("WARN: APNs push: %s provider token expired, re-signing and retrying", logTarget)
+					c.signer.Invalidate(jwt)
+					continue
+				}
+			case reasonBadEnvironmentKeyInToken:
+				if c.breakerOpen.CompareAndSwap(false, true) {
+					log.Printf("ERROR: APNs push: %s rejected with %s; disabling Live Activity pushes", logTarget, reason)
+				}
+				return 0, "", errBreakerOpen
+			}
+		}
+		return status, reason, nil
+	}
+}
+
+// do performs one APNs request and returns the status and a bounded prefix of
+// the response body.
+func (c *apnsClient) do(ctx context.Context, url, jwt string, headers map[string]string, payload []byte, logTarget string) (int, []byte, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
 	if err != nil {
-		return fmt.Errorf("build request: %w", err)
+		return 0, nil, fmt.Errorf("build request: %w", err)
 	}
 	req.Header.Set("authorization", "bearer "+jwt)
 	req.Header.Set("apns-push-type", "liveactivity")
-	req.Header.Set("apns-channel-id", channelID)
 	req.Header.Set("apns-priority", apnsPriority)
-	req.Header.Set("apns-expiration", apnsExpiration)
 	req.Header.Set("content-type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
 
 	start := time.Now()
 	resp, err := c.http.Do(req)
-	latencyMs := time.Since(start).Milliseconds()
+	latency := time.Since(start)
+	latencyMs := latency.Milliseconds()
+	metrics.APNsRequestDuration.Observe(latency.Seconds())
 
 	if err != nil {
-		log.Printf("APNs push error: channel=%s latency_ms=%d err=%v", channelID, latencyMs, err)
-		return fmt.Errorf("APNs push: %w", err)
+		log.Printf("APNs push error: %s latency_ms=%d err=%v", logTarget, latencyMs, err)
+		metrics.APNsRequests.WithLabelValues("error").Inc()
+		return 0, nil, fmt.Errorf("APNs push: %w", err)
 	}
 	defer resp.Body.Close()
+	metrics.APNsRequests.WithLabelValues(strconv.Itoa(resp.StatusCode)).Inc()
 
 	body, _ := io.ReadAll(io.LimitReader(resp.Body, apnsResponseBodyLimit))
-	log.Printf("APNs push: channel=%s status=%d latency_ms=%d", channelID, resp.StatusCode, latencyMs)
+	log.Printf("APNs push: %s status=%d latency_ms=%d", logTarget, resp.StatusCode, latencyMs)
+	return resp.StatusCode, body, nil
+}
 
-	switch resp.StatusCode {
-	case http.StatusOK:
-		return nil
-	case http.StatusGone:
-		log.Printf("APNs push: channel %s is gone (410), dropping", channelID)
-		return nil
-	case http.StatusTooManyRequests:
-		return &retryableError{status: resp.StatusCode, body: string(body)}
-	case http.StatusInternalServerError, http.StatusServiceUnavailable:
-		return &retryableError{status: resp.StatusCode, body: string(body)}
+// responseReason extracts the reason from an APNs error body
+// ({"reason":"BadDeviceToken"}), falling back to the raw body.
+func responseReason(body []byte) string {
+	var parsed struct {
+		Reason string `json:"reason"`
+	}
+	if err := json.Unmarshal(body, &parsed); err == nil && parsed.Reason != "" {
+		return parsed.Reason
+	}
+	return strings.TrimSpace(string(body))
+}
+
+// statusError maps a non-success APNs status to a retryable or terminal error.
+func statusError(status int, reason string) error {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusServiceUnavailable:
+		return &retryableError{status: status, body: reason}
 	default:
-		return fmt.Errorf("APNs push unexpected status %d: %s", resp.StatusCode, body)
+		return fmt.Errorf("APNs push unexpected status %d: %s", status, reason)
+	}
+}
+
+var (
+	// errTokenGone is returned by PushToDevice when APNs reports the device
+	// token is no longer valid (410).
+	errTokenGone = errors.New("APNs device token is no longer valid")
+
+	// errChannelGone is returned by Push when APNs reports the broadcast
+	// channel no longer exists (410).
+	errChannelGone = errors.New("APNs broadcast channel no longer exists")
+
+	// errBreakerOpen is returned for every push once APNs has answered 403
+	// BadEnvironmentKeyInToken: the auth key does not belong to APNS_HOST's
+	// environment, so nothing will succeed until the config is fixed.
+	errBreakerOpen = errors.New("APNs pushes disabled: auth key rejected for this environment (BadEnvironmentKeyInToken)")
+)
+
+// shortToken truncates a device token for logging.
+func shortToken(token string) string {
+	if len(token) <= 8 {
+		return token
 	}
+	return token[:8] + "…"
 }
 
 // retryableError signals the caller should retry with backoff.
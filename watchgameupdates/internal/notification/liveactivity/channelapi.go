@@ -0,0 +1,150 @@
+package liveactivity
+
+// APNs broadcast channel management API.
+//
+//   Create: POST   /1/apps/{bundleID}/channels      → 201, apns-channel-id response header
+//   List:   GET    /1/apps/{bundleID}/all-channels  → 200, {"channels": ["<id>", ...]}
+//   Delete: DELETE /1/apps/{bundleID}/channels      → 204, apns-channel-id request header
+//
+// Hosts (not the push hosts):
+//   development: api-manage-broadcast.sandbox.push.apple.com:2195
+//   production:  api-manage-broadcast.push.apple.com:2196
+//
+// Authentication uses the same provider JWT as push.
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const (
+	manageHostProduction  = "api-manage-broadcast.push.apple.com:2196"
+	manageHostDevelopment = "api-manage-broadcast.sandbox.push.apple.com:2195"
+
+	// messageStoragePolicyNone matches the channels minted in App Store Connect
+	// ("No Message Stored"); see apnsExpiration for why pushes rely on it.
+	messageStoragePolicyNone = 0
+)
+
+// ChannelManager creates, lists and deletes broadcast channels for the app.
+type ChannelManager struct {
+	http     *http.Client
+	signer   *jwtSigner
+	host     string
+	bundleID string
+}
+
+// NewChannelManager builds a ChannelManager for the environment in cfg.
+func NewChannelManager(cfg *Config) (*ChannelManager, error) {
+	signer, err := newJWTSigner(cfg.AuthKey, cfg.KeyID, cfg.TeamID)
+	if err != nil {
+		return nil, fmt.Errorf("init JWT signer: %w", err)
+	}
+	httpClient, err := newHTTPClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &ChannelManager{
+		http:     httpClient,
+		signer:   signer,
+		host:     cfg.ManageHost,
+		bundleID: cfg.Topic,
+	}, nil
+}
+
+// Create mints a new Live Activity broadcast channel and returns its ID.
+func (m *ChannelManager) Create(ctx context.Context) (string, error) {
+	body, _ := json.Marshal(map[string]interface{}{
+		"message-storage-policy": messageStoragePolicyNone,
+		"push-type":              "LiveActivity",
+	})
+	resp, err := m.do(ctx, http.MethodPost, "/channels", "", body)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return "", manageStatusError("create channel", resp)
+	}
+	id := resp.Header.Get("apns-channel-id")
+	if id == "" {
+		return "", fmt.Errorf("create channel: response has no apns-channel-id header")
+	}
+	return id, nil
+}
+
+// List returns every channel ID that exists for the app in this environment.
+func (m *ChannelManager) List(ctx context.Context) ([]string, error) {
+	resp, err := m.do(ctx, http.MethodGet, "/all-channels", "", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, manageStatusError("list channels", resp)
+	}
+	var out struct {
+		Channels []string `json:"channels"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("decode channel list: %w", err)
+	}
+	return out.Channels, nil
+}
+
+// Delete removes channelID. Deleting a channel that no longer exists is not an error.
+func (m *ChannelManager) Delete(ctx context.Context, channelID string) error {
+	resp, err := m.do(ctx, http.MethodDelete, "/channels", channelID, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNoContent, http.StatusOK, http.StatusNotFound:
+		return nil
+	default:
+		return manageStatusError("delete channel "+channelID, resp)
+	}
+}
+
+func (m *ChannelManager) do(ctx context.Context, method, path, channelID string, body []byte) (*http.Response, error) {
+	jwt, err := m.signer.Token()
+	if err != nil {
+		return nil, fmt.Errorf("get JWT: %w", err)
+	}
+
+	url := fmt.Sprintf("https://%s/1/apps/%s%s", m.host, m.bundleID, path)
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("authorization", "bearer "+jwt)
+	if channelID != "" {
+		req.Header.Set("apns-channel-id", channelID)
+	}
+	if body != nil {
+		req.Header.Set("content-type", "application/json")
+	}
+
+	resp, err := m.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("APNs channel management %s %s: %w", method, path, err)
+	}
+	return resp, nil
+}
+
+func manageStatusError(op string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, apnsResponseBodyLimit))
+	return fmt.Errorf("%s: unexpected status %d: %s", op, resp.StatusCode, body)
+}
@@ -1,5 +1,53 @@
 package liveactivity
 
+// Team → broadcast channel mappings.
+//
+// The backend resolves a team's channel through a ChannelStore at push time.
+// Stores are scoped to one APNs environment (development or production) and
+// selected by APNS_CHANNEL_STORE:
+//
+//   builtin (default) — the compiled-in maps below; read-only
+//   file              — JSON file at APNS_CHANNEL_STORE_FILE (local use)
+//   redis             — hash liveactivity:channels:{env} on REDIS_ADDRESS
+//
+// When APNs answers 410 for a channel the notifier marks it inactive: it is
+// moved out of the active map into the store's inactive record, so no replica
+// pushes to it again, and `apnschannels sync` mints a replacement. The builtin
+// store cannot record this, so only the running process skips the channel and
+// a restart pushes to it again; the notifier warns about this at startup.
+//
+// Mutable stores are populated by `apnschannels sync`, which adopts the
+// compiled-in IDs when APNs still knows them (so already-subscribed devices
+// keep working) and creates channels through the APNs channel management API
+// for every team that has none.
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// channelLookupTimeout bounds a single store lookup on the push path.
+const channelLookupTimeout = 5 * time.Second
+
+// ChannelStore persists team → broadcast channel IDs for one APNs environment.
+type ChannelStore interface {
+	// Channel returns the channel ID for tricode, or "" if none is recorded.
+	Channel(ctx context.Context, tricode string) (string, error)
+	SetChannel(ctx context.Context, tricode, channelID string) error
+	DeleteChannel(ctx context.Context, tricode string) error
+	// Channels returns every recorded tricode → channel ID mapping.
+	Channels(ctx context.Context) (map[string]string, error)
+	// MarkInactive records that APNs reported channelID gone. If it is still
+	// tricode's active channel it is removed from Channel and Channels until
+	// SetChannel records a replacement.
+	MarkInactive(ctx context.Context, tricode, channelID string) error
+	// InactiveChannels returns tricode → channel ID for channels marked gone
+	// and not yet replaced.
+	InactiveChannels(ctx context.Context) (map[string]string, error)
+}
+
 // debugChannels maps NHL team tricodes to APNs broadcast channel IDs created in
 // the Development environment (App Store Connect → Push Notifications → Broadcast → Development).
 // Use these with sandbox APNs and debug/simulator builds.
@@ -45,9 +93,10 @@ var debugChannels = map[string]string{
 // Source of truth: the iOS repo (github.com/FirepowerApp/ios) at
 // main:Firepower/NHLTeams.swift (prodChannelIds). Devices subscribe to these
 // exact IDs, so the backend replicates that map verbatim. IDs are opaque tokens
-// minted by App Store Connect and are NOT derivable from the tricode. When iOS
-// mints or rotates a channel, re-copy the value here. TestReplicateAll32ProdChannels
-// enforces that every team stays populated.
+// minted by App Store Connect and are NOT derivable from the tricode. This map
+// is the default (builtin) store and the seed `apnschannels sync` adopts from;
+// channels minted or rotated after that live in the configured ChannelStore.
+// TestReplicateAll32ProdChannels enforces that every team stays populated.
 var prodChannels = map[string]string{
 	"ANA": "UhYmnn8mEfEAAOYc0Q7CQQ==",
 	"BOS": "+YMYln8mEfEAADq3u4xYzw==",
@@ -83,15 +132,64 @@ var prodChannels = map[string]string{
 	"WSH": "DMyFbX8qEfEAAJYcSnrw9A==",
 }
 
-// channelForTeam looks up the APNs broadcast channel ID for a tricode.
-// Returns ("", false) if the team is unknown or its channel ID is not populated.
-func channelForTeam(tricode string, useDevChannels bool) (string, bool) {
-	m := prodChannels
+// builtinChannels returns the compiled-in map for an environment. sync uses it
+// to adopt IDs that devices are already subscribed to.
+func builtinChannels(useDevChannels bool) map[string]string {
 	if useDevChannels {
-		m = debugChannels
+		return debugChannels
+	}
+	return prodChannels
+}
+
+// builtinChannelStore serves the compiled-in map. It reads the map on every
+// lookup, so it always reflects the package variables.
+type builtinChannelStore struct {
+	useDevChannels bool
+}
+
+func (s builtinChannelStore) Channel(_ context.Context, tricode string) (string, error) {
+	return builtinChannels(s.useDevChannels)[tricode], nil
+}
+
+func (s builtinChannelStore) SetChannel(context.Context, string, string) error {
+	return fmt.Errorf("builtin channel map is read-only; set APNS_CHANNEL_STORE=file or redis")
+}
+
+func (s builtinChannelStore) DeleteChannel(context.Context, string) error {
+	return fmt.Errorf("builtin channel map is read-only; set APNS_CHANNEL_STORE=file or redis")
+}
+
+func (s builtinChannelStore) MarkInactive(context.Context, string, string) error {
+	return fmt.Errorf("builtin channel map is read-only; set APNS_CHANNEL_STORE=file or redis")
+}
+
+func (s builtinChannelStore) InactiveChannels(context.Context) (map[string]string, error) {
+	return map[string]string{}, nil
+}
+
+func (s builtinChannelStore) Channels(context.Context) (map[string]string, error) {
+	out := map[string]string{}
+	for tri, id := range builtinChannels(s.useDevChannels) {
+		if id != "" {
+			out[tri] = id
+		}
+	}
+	return out, nil
+}
+
+// channelForTeam looks up the APNs broadcast channel ID for a tricode in store.
+// Returns ("", false) if the team is unknown, its channel ID is not populated,
+// or the lookup fails.
+func channelForTeam(store ChannelStore, tricode string) (string, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), channelLookupTimeout)
+	defer cancel()
+
+	id, err := store.Channel(ctx, tricode)
+	if err != nil {
+		log.Printf("ERROR: channel lookup for %s: %v", tricode, err)
+		return "", false
 	}
-	id, known := m[tricode]
-	if !known || id == "" {
+	if id == "" {
 		return "", false
 	}
 	return id, true
@@ -1,18 +1,16 @@
 package liveactivity
 
-import "testing"
+import (
+	"testing"
+
+	"watchgameupdates/internal/teams"
+)
 
 // allTricodes is the full NHL roster the backend must be able to notify. It
-// mirrors the 32-team set in the iOS source of truth
-// (github.com/FirepowerApp/ios, main:Firepower/NHLTeams.swift). Keeping it
-// explicit here means a team can never silently drop out of the prod map
-// without this test failing.
-var allTricodes = []string{
-	"ANA", "BOS", "BUF", "CAR", "CBJ", "CGY", "CHI", "COL",
-	"DAL", "DET", "EDM", "FLA", "LAK", "MIN", "MTL", "NJD",
-	"NSH", "NYI", "NYR", "OTT", "PHI", "PIT", "SEA", "SJS",
-	"STL", "TBL", "TOR", "UTA", "VAN", "VGK", "WPG", "WSH",
-}
+// comes from the shared team registry, which mirrors the iOS source of truth.
+// Keeping the check here means a team can never silently drop out of the prod
+// map without this test failing.
+var allTricodes = teams.Tricodes
 
 // TestReplicateAll32ProdChannels enforces the core invariant of this design:
 // every one of the 32 teams resolves to a non-empty production channel ID, so
@@ -24,7 +22,7 @@ func TestReplicateAll32ProdChannels(t *testing.T) {
 		t.Fatalf("prodChannels has %d entries, want %d", len(prodChannels), len(allTricodes))
 	}
 	for _, tri := range allTricodes {
-		id, ok := channelForTeam(tri, false)
+		id, ok := channelForTeam(builtinChannelStore{}, tri)
 		if !ok || id == "" {
 			t.Errorf("team %s has no production channel ID; every team must be populated so the scheduler filter is the only guard", tri)
 		}
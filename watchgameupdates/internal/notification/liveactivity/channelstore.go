@@ -0,0 +1,234 @@
+package liveactivity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// NewChannelStore builds the ChannelStore selected by cfg.ChannelStore for the
+// environment in cfg.UseDevChannels.
+func NewChannelStore(cfg *Config) (ChannelStore, error) {
+	env := channelEnvName(cfg.UseDevChannels)
+	switch cfg.ChannelStore {
+	case "", "builtin":
+		return builtinChannelStore{useDevChannels: cfg.UseDevChannels}, nil
+	case "file":
+		if cfg.ChannelStoreFile == "" {
+			return nil, fmt.Errorf("APNS_CHANNEL_STORE=file requires APNS_CHANNEL_STORE_FILE")
+		}
+		return newFileChannelStore(cfg.ChannelStoreFile, env), nil
+	case "redis":
+		client := redis.NewClient(&redis.Options{
+			Addr:     cfg.RedisAddress,
+			Password: cfg.RedisPassword,
+			DB:       cfg.RedisDB,
+		})
+		return newRedisChannelStore(client, env), nil
+	default:
+		return nil, fmt.Errorf("unknown APNS_CHANNEL_STORE %q (want builtin, file or redis)", cfg.ChannelStore)
+	}
+}
+
+// fileChannelStore keeps mappings for every environment in one JSON file:
+//
+//	{"production": {"BOS": "<id>", ...}, "production:inactive": {...}, "development": {...}}
+//
+// The file is re-read on each call so edits by the sync CLI are picked up by a
+// running backend without a restart. Both write it, so every update holds an
+// flock on {path}.lock across its read-modify-write; readers need none, as
+// the file is replaced by rename.
+type fileChannelStore struct {
+	mu   sync.Mutex
+	path string
+	env  string
+}
+
+func newFileChannelStore(path, env string) *fileChannelStore {
+	return &fileChannelStore{path: path, env: env}
+}
+
+func (s *fileChannelStore) Channel(_ context.Context, tricode string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	all, err := s.load()
+	if err != nil {
+		return "", err
+	}
+	return all[s.env][tricode], nil
+}
+
+func (s *fileChannelStore) SetChannel(_ context.Context, tricode, channelID string) error {
+	return s.update(func(active, inactive map[string]string) {
+		active[tricode] = channelID
+		delete(inactive, tricode)
+	})
+}
+
+func (s *fileChannelStore) DeleteChannel(_ context.Context, tricode string) error {
+	return s.update(func(active, _ map[string]string) { delete(active, tricode) })
+}
+
+func (s *fileChannelStore) MarkInactive(_ context.Context, tricode, channelID string) error {
+	return s.update(func(active, inactive map[string]string) {
+		if active[tricode] == channelID {
+			delete(active, tricode)
+		}
+		inactive[tricode] = channelID
+	})
+}
+
+func (s *fileChannelStore) Channels(_ context.Context) (map[string]string, error) {
+	return s.snapshot(s.env)
+}
+
+func (s *fileChannelStore) InactiveChannels(_ context.Context) (map[string]string, error) {
+	return s.snapshot(s.env + inactiveSuffix)
+}
+
+// inactiveSuffix names the file key or redis hash holding inactive channels.
+const inactiveSuffix = ":inactive"
+
+func (s *fileChannelStore) snapshot(key string) (map[string]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	all, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	out := map[string]string{}
+	for k, v := range all[key] {
+		out[k] = v
+	}
+	return out, nil
+}
+
+func (s *fileChannelStore) update(fn func(active, inactive map[string]string)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := lockFile(s.path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	all, err := s.load()
+	if err != nil {
+		return err
+	}
+	inactiveKey := s.env + inactiveSuffix
+	for _, k := range []string{s.env, inactiveKey} {
+		if all[k] == nil {
+			all[k] = map[string]string{}
+		}
+	}
+	fn(all[s.env], all[inactiveKey])
+	if len(all[inactiveKey]) == 0 {
+		delete(all, inactiveKey)
+	}
+
+	data, err := json.MarshalIndent(all, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal channel store: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("write channel store: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
+
+func (s *fileChannelStore) load() (map[string]map[string]string, error) {
+	all := map[string]map[string]string{}
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return all, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read channel store %s: %w", s.path, err)
+	}
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, fmt.Errorf("parse channel store %s: %w", s.path, err)
+	}
+	return all, nil
+}
+
+// redisChannelStore keeps one hash per environment: liveactivity:channels:{env}
+// with tricode fields and channel ID values, plus
+// liveactivity:channels:{env}:inactive for channels marked gone.
+type redisChannelStore struct {
+	client      *redis.Client
+	key         string
+	inactiveKey string
+}
+
+func newRedisChannelStore(client *redis.Client, env string) *redisChannelStore {
+	key := "liveactivity:channels:" + env
+	return &redisChannelStore{client: client, key: key, inactiveKey: key + inactiveSuffix}
+}
+
+func (s *redisChannelStore) Channel(ctx context.Context, tricode string) (string, error) {
+	id, err := s.client.HGet(ctx, s.key, tricode).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("redis HGET %s %s: %w", s.key, tricode, err)
+	}
+	return id, nil
+}
+
+func (s *redisChannelStore) SetChannel(ctx context.Context, tricode, channelID string) error {
+	_, err := s.client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.HSet(ctx, s.key, tricode, channelID)
+		p.HDel(ctx, s.inactiveKey, tricode)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("redis HSET %s %s: %w", s.key, tricode, err)
+	}
+	return nil
+}
+
+// markInactiveScript moves a channel to the inactive hash, removing it from
+// the active one only if it has not been replaced in the meantime.
+var markInactiveScript = redis.NewScript(`
+if redis.call("HGET", KEYS[1], ARGV[1]) == ARGV[2] then
+	redis.call("HDEL", KEYS[1], ARGV[1])
+end
+redis.call("HSET", KEYS[2], ARGV[1], ARGV[2])
+return 1
+`)
+
+func (s *redisChannelStore) MarkInactive(ctx context.Context, tricode, channelID string) error {
+	if err := markInactiveScript.Run(ctx, s.client, []string{s.key, s.inactiveKey}, tricode, channelID).Err(); err != nil {
+		return fmt.Errorf("redis mark %s %s inactive: %w", s.key, tricode, err)
+	}
+	return nil
+}
+
+func (s *redisChannelStore) InactiveChannels(ctx context.Context) (map[string]string, error) {
+	all, err := s.client.HGetAll(ctx, s.inactiveKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("redis HGETALL %s: %w", s.inactiveKey, err)
+	}
+	return all, nil
+}
+
+func (s *redisChannelStore) DeleteChannel(ctx context.Context, tricode string) error {
+	if err := s.client.HDel(ctx, s.key, tricode).Err(); err != nil {
+		return fmt.Errorf("redis HDEL %s %s: %w", s.key, tricode, err)
+	}
+	return nil
+}
+
+func (s *redisChannelStore) Channels(ctx context.Context) (map[string]string, error) {
+	all, err := s.client.HGetAll(ctx, s.key).Result()
+	if err != nil {
+		return nil, fmt.Errorf("redis HGETALL %s: %w", s.key, err)
+	}
+	return all, nil
+}
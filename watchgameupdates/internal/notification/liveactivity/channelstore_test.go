@@ -0,0 +1,199 @@
+package liveactivity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// exerciseChannelStore runs the read/write contract every writable store must meet.
+func exerciseChannelStore(t *testing.T, s ChannelStore) {
+	t.Helper()
+	ctx := context.Background()
+
+	if id, err := s.Channel(ctx, "BOS"); err != nil || id != "" {
+		t.Fatalf("empty store: Channel = %q, %v; want empty, nil", id, err)
+	}
+	if err := s.SetChannel(ctx, "BOS", "chan-BOS"); err != nil {
+		t.Fatalf("SetChannel: %v", err)
+	}
+	if err := s.SetChannel(ctx, "NYR", "chan-NYR"); err != nil {
+		t.Fatalf("SetChannel: %v", err)
+	}
+	if id, _ := s.Channel(ctx, "BOS"); id != "chan-BOS" {
+		t.Errorf("Channel(BOS) = %q, want chan-BOS", id)
+	}
+	if err := s.DeleteChannel(ctx, "NYR"); err != nil {
+		t.Fatalf("DeleteChannel: %v", err)
+	}
+	all, err := s.Channels(ctx)
+	if err != nil {
+		t.Fatalf("Channels: %v", err)
+	}
+	if len(all) != 1 || all["BOS"] != "chan-BOS" {
+		t.Errorf("Channels = %v, want only BOS", all)
+	}
+
+	// A gone channel leaves the active map; a replacement clears the record.
+	if err := s.MarkInactive(ctx, "BOS", "chan-BOS"); err != nil {
+		t.Fatalf("MarkInactive: %v", err)
+	}
+	if id, _ := s.Channel(ctx, "BOS"); id != "" {
+		t.Errorf("Channel(BOS) after MarkInactive = %q, want empty", id)
+	}
+	if inactive, err := s.InactiveChannels(ctx); err != nil || inactive["BOS"] != "chan-BOS" {
+		t.Errorf("InactiveChannels = %v, %v; want BOS → chan-BOS", inactive, err)
+	}
+	if err := s.SetChannel(ctx, "BOS", "chan-BOS-2"); err != nil {
+		t.Fatalf("SetChannel: %v", err)
+	}
+	if inactive, _ := s.InactiveChannels(ctx); len(inactive) != 0 {
+		t.Errorf("InactiveChannels after replacement = %v, want empty", inactive)
+	}
+
+	// Marking a channel that was already replaced keeps the replacement.
+	if err := s.MarkInactive(ctx, "BOS", "chan-BOS"); err != nil {
+		t.Fatalf("MarkInactive: %v", err)
+	}
+	if id, _ := s.Channel(ctx, "BOS"); id != "chan-BOS-2" {
+		t.Errorf("Channel(BOS) = %q, want replacement chan-BOS-2 kept", id)
+	}
+}
+
+func TestFileChannelStore(t *testing.T) {
+	exerciseChannelStore(t, newFileChannelStore(filepath.Join(t.TempDir(), "channels.json"), "production"))
+}
+
+func TestFileChannelStore_EnvironmentsAreSeparate(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "channels.json")
+	prod := newFileChannelStore(path, "production")
+	dev := newFileChannelStore(path, "development")
+
+	prod.SetChannel(ctx, "BOS", "prod-BOS")
+	dev.SetChannel(ctx, "BOS", "dev-BOS")
+
+	if id, _ := prod.Channel(ctx, "BOS"); id != "prod-BOS" {
+		t.Errorf("production BOS = %q, want prod-BOS", id)
+	}
+	if id, _ := dev.Channel(ctx, "BOS"); id != "dev-BOS" {
+		t.Errorf("development BOS = %q, want dev-BOS", id)
+	}
+}
+
+func TestFileChannelStore_PicksUpExternalEdits(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "channels.json")
+	s := newFileChannelStore(path, "production")
+	if err := os.WriteFile(path, []byte(`{"production":{"TOR":"chan-TOR"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if id, _ := s.Channel(context.Background(), "TOR"); id != "chan-TOR" {
+		t.Errorf("Channel(TOR) = %q, want chan-TOR from edited file", id)
+	}
+}
+
+func TestFileChannelStore_ConcurrentWritersKeepEveryEntry(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "channels.json")
+	// Two stores on one file stand in for the backend and the sync CLI: they
+	// share no mutex, only the file lock.
+	stores := []*fileChannelStore{newFileChannelStore(path, "production"), newFileChannelStore(path, "production")}
+
+	const writes = 40
+	var wg sync.WaitGroup
+	for i := range writes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tricode := fmt.Sprintf("T%02d", i)
+			if err := stores[i%2].SetChannel(ctx, tricode, "chan-"+tricode); err != nil {
+				t.Errorf("SetChannel(%s): %v", tricode, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	all, err := stores[0].Channels(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != writes {
+		t.Errorf("store holds %d channels after %d concurrent writes", len(all), writes)
+	}
+}
+
+func TestRedisChannelStore(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	exerciseChannelStore(t, newRedisChannelStore(client, "development"))
+
+	if got := mr.HGet("liveactivity:channels:development", "BOS"); got != "chan-BOS-2" {
+		t.Errorf("redis hash BOS = %q, want chan-BOS-2", got)
+	}
+}
+
+func TestBuiltinChannelStore_ReadOnly(t *testing.T) {
+	s := builtinChannelStore{}
+	if err := s.SetChannel(context.Background(), "BOS", "x"); err == nil {
+		t.Error("want SetChannel on builtin store to fail")
+	}
+	if id, ok := channelForTeam(s, "BOS"); !ok || id != prodChannels["BOS"] {
+		t.Errorf("channelForTeam(BOS) = %q, %v; want compiled-in ID", id, ok)
+	}
+}
+
+func TestNewChannelStore(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{"default", Config{}, false},
+		{"builtin", Config{ChannelStore: "builtin"}, false},
+		{"file", Config{ChannelStore: "file", ChannelStoreFile: "/tmp/x.json"}, false},
+		{"file without path", Config{ChannelStore: "file"}, true},
+		{"redis", Config{ChannelStore: "redis", RedisAddress: "localhost:6379"}, false},
+		{"unknown", Config{ChannelStore: "etcd"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewChannelStore(&tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestServeChannels(t *testing.T) {
+	store := newFileChannelStore(filepath.Join(t.TempDir(), "channels.json"), "production")
+	store.SetChannel(context.Background(), "BOS", "chan-BOS")
+	n := &LiveActivityNotifier{channels: store}
+
+	rec := httptest.NewRecorder()
+	n.Routes()[ChannelsPath].ServeHTTP(rec, httptest.NewRequest(http.MethodGet, ChannelsPath, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	var got map[string]string
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil || got["BOS"] != "chan-BOS" {
+		t.Errorf("body = %s (%v), want BOS → chan-BOS", rec.Body.String(), err)
+	}
+
+	rec = httptest.NewRecorder()
+	n.Routes()[ChannelsPath].ServeHTTP(rec, httptest.NewRequest(http.MethodPost, ChannelsPath, nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST status = %d, want 405", rec.Code)
+	}
+}
@@ -0,0 +1,147 @@
+package liveactivity
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+)
+
+// ChannelAPI is the subset of ChannelManager used by SyncChannels.
+type ChannelAPI interface {
+	Create(ctx context.Context) (string, error)
+	List(ctx context.Context) ([]string, error)
+	Delete(ctx context.Context, channelID string) error
+}
+
+// SyncOptions controls SyncChannels.
+type SyncOptions struct {
+	// Seed maps tricodes to IDs worth adopting before minting new channels
+	// (normally the compiled-in map, which devices already subscribe to).
+	Seed map[string]string
+	// Prune deletes APNs channels that no team maps to after the sync.
+	Prune bool
+	// DryRun reports what would change without calling Create/Delete or
+	// writing the store.
+	DryRun bool
+}
+
+// SyncAction is what SyncChannels did (or would do) for one team.
+type SyncAction string
+
+const (
+	SyncKept    SyncAction = "kept"    // store already held a live channel
+	SyncAdopted SyncAction = "adopted" // seed ID still exists on APNs; recorded in store
+	SyncCreated SyncAction = "created" // new channel minted
+	SyncFailed  SyncAction = "failed"
+)
+
+// SyncResult reports the outcome for one team.
+type SyncResult struct {
+	Team      string
+	ChannelID string
+	Action    SyncAction
+	Err       error
+}
+
+// SyncReport is the outcome of SyncChannels.
+type SyncReport struct {
+	Teams  []SyncResult
+	Pruned []string // channel IDs deleted (or to be deleted on a dry run)
+}
+
+// Failed returns the number of teams that could not be synced.
+func (r SyncReport) Failed() int {
+	n := 0
+	for _, t := range r.Teams {
+		if t.Action == SyncFailed {
+			n++
+		}
+	}
+	return n
+}
+
+// SyncChannels makes sure every team in teams maps to a channel that exists on
+// APNs. For each team it keeps the stored channel if APNs still lists it,
+// otherwise adopts the seed ID if APNs lists that, otherwise creates a new
+// channel. Per-team failures are recorded in the report; the returned error is
+// only for failures that stop the whole sync (listing channels).
+func SyncChannels(ctx context.Context, api ChannelAPI, store ChannelStore, teams []string, opts SyncOptions) (SyncReport, error) {
+	var report SyncReport
+
+	existingIDs, err := api.List(ctx)
+	if err != nil {
+		return report, fmt.Errorf("list APNs channels: %w", err)
+	}
+	existing := make(map[string]bool, len(existingIDs))
+	for _, id := range existingIDs {
+		existing[id] = true
+	}
+
+	stored, err := store.Channels(ctx)
+	if err != nil {
+		return report, fmt.Errorf("read channel store: %w", err)
+	}
+
+	inUse := map[string]bool{}
+	for _, team := range teams {
+		res := syncTeam(ctx, api, store, team, stored[team], opts, existing)
+		if res.ChannelID != "" {
+			inUse[res.ChannelID] = true
+		}
+		if res.Err != nil {
+			log.Printf("Channel sync %s: %v", team, res.Err)
+		} else {
+			log.Printf("Channel sync %s: %s %s", team, res.Action, res.ChannelID)
+		}
+		report.Teams = append(report.Teams, res)
+	}
+
+	if opts.Prune {
+		for _, id := range existingIDs {
+			if inUse[id] {
+				continue
+			}
+			if !opts.DryRun {
+				if err := api.Delete(ctx, id); err != nil {
+					log.Printf("Channel sync: failed to prune %s: %v", id, err)
+					continue
+				}
+			}
+			report.Pruned = append(report.Pruned, id)
+		}
+		sort.Strings(report.Pruned)
+	}
+
+	return report, nil
+}
+
+func syncTeam(ctx context.Context, api ChannelAPI, store ChannelStore, team, storedID string, opts SyncOptions, existing map[string]bool) SyncResult {
+	if storedID != "" && existing[storedID] {
+		return SyncResult{Team: team, ChannelID: storedID, Action: SyncKept}
+	}
+
+	id, action := "", SyncCreated
+	if seed := opts.Seed[team]; seed != "" && existing[seed] {
+		id, action = seed, SyncAdopted
+	} else if !opts.DryRun {
+		created, err := api.Create(ctx)
+		if err != nil {
+			return SyncResult{Team: team, Action: SyncFailed, Err: fmt.Errorf("create channel: %w", err)}
+		}
+		id = created
+	}
+
+	if !opts.DryRun {
+		if err := store.SetChannel(ctx, team, id); err != nil {
+			return SyncResult{Team: team, ChannelID: id, Action: SyncFailed, Err: fmt.Errorf("store channel: %w", err)}
+		}
+	}
+	return SyncResult{Team: team, ChannelID: id, Action: action}
+}
+
+// BuiltinSeed returns the compiled-in channel map for the environment in cfg,
+// for use as SyncOptions.Seed.
+func BuiltinSeed(cfg *Config) map[string]string {
+	return builtinChannels(cfg.UseDevChannels)
+}
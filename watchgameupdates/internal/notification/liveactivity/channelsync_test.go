@@ -0,0 +1,158 @@
+package liveactivity
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeChannelAPI is an in-memory ChannelAPI.
+type fakeChannelAPI struct {
+	existing  []string
+	created   []string
+	deleted   []string
+	createErr error
+}
+
+func (f *fakeChannelAPI) Create(context.Context) (string, error) {
+	if f.createErr != nil {
+		return "", f.createErr
+	}
+	id := fmt.Sprintf("new-%d", len(f.created)+1)
+	f.created = append(f.created, id)
+	return id, nil
+}
+
+func (f *fakeChannelAPI) List(context.Context) ([]string, error) { return f.existing, nil }
+
+func (f *fakeChannelAPI) Delete(_ context.Context, id string) error {
+	f.deleted = append(f.deleted, id)
+	return nil
+}
+
+func TestSyncChannels(t *testing.T) {
+	ctx := context.Background()
+	store := newFileChannelStore(filepath.Join(t.TempDir(), "channels.json"), "production")
+	store.SetChannel(ctx, "BOS", "live-BOS")  // still on APNs → kept
+	store.SetChannel(ctx, "NYR", "stale-NYR") // deleted on APNs → replaced
+
+	api := &fakeChannelAPI{existing: []string{"live-BOS", "seed-TOR", "orphan"}}
+	opts := SyncOptions{
+		Seed:  map[string]string{"TOR": "seed-TOR", "NYR": "seed-NYR"},
+		Prune: true,
+	}
+
+	report, err := SyncChannels(ctx, api, store, []string{"BOS", "TOR", "NYR"}, opts)
+	if err != nil {
+		t.Fatalf("SyncChannels: %v", err)
+	}
+
+	want := map[string]SyncAction{"BOS": SyncKept, "TOR": SyncAdopted, "NYR": SyncCreated}
+	for _, r := range report.Teams {
+		if r.Action != want[r.Team] {
+			t.Errorf("%s: action = %s, want %s", r.Team, r.Action, want[r.Team])
+		}
+	}
+
+	all, _ := store.Channels(ctx)
+	if all["BOS"] != "live-BOS" || all["TOR"] != "seed-TOR" || all["NYR"] != "new-1" {
+		t.Errorf("store after sync = %v", all)
+	}
+	if len(api.deleted) != 1 || api.deleted[0] != "orphan" {
+		t.Errorf("pruned = %v, want [orphan]", api.deleted)
+	}
+}
+
+func TestSyncChannels_DryRunWritesNothing(t *testing.T) {
+	ctx := context.Background()
+	store := newFileChannelStore(filepath.Join(t.TempDir(), "channels.json"), "production")
+	api := &fakeChannelAPI{existing: []string{"orphan"}}
+
+	report, err := SyncChannels(ctx, api, store, []string{"BOS"}, SyncOptions{Prune: true, DryRun: true})
+	if err != nil {
+		t.Fatalf("SyncChannels: %v", err)
+	}
+	if report.Teams[0].Action != SyncCreated {
+		t.Errorf("action = %s, want %s", report.Teams[0].Action, SyncCreated)
+	}
+	if len(report.Pruned) != 1 {
+		t.Errorf("want orphan reported for pruning, got %v", report.Pruned)
+	}
+	if len(api.created) != 0 || len(api.deleted) != 0 {
+		t.Errorf("dry run called APNs: created %v, deleted %v", api.created, api.deleted)
+	}
+	if all, _ := store.Channels(ctx); len(all) != 0 {
+		t.Errorf("dry run wrote store: %v", all)
+	}
+}
+
+func TestSyncChannels_CreateFailureIsPerTeam(t *testing.T) {
+	ctx := context.Background()
+	store := newFileChannelStore(filepath.Join(t.TempDir(), "channels.json"), "production")
+	api := &fakeChannelAPI{existing: []string{"live-BOS"}, createErr: errors.New("429")}
+	store.SetChannel(ctx, "BOS", "live-BOS")
+
+	report, err := SyncChannels(ctx, api, store, []string{"BOS", "TOR"}, SyncOptions{})
+	if err != nil {
+		t.Fatalf("SyncChannels: %v", err)
+	}
+	if report.Failed() != 1 {
+		t.Errorf("Failed() = %d, want 1", report.Failed())
+	}
+}
+
+func TestChannelManager(t *testing.T) {
+	var methods []string
+	srv := newHTTP2Server(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method+" "+r.URL.Path)
+		if !strings.HasPrefix(r.Header.Get("authorization"), "bearer ") {
+			t.Errorf("%s %s: missing bearer token", r.Method, r.URL.Path)
+		}
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/1/apps/me.test.app/channels":
+			body, _ := io.ReadAll(r.Body)
+			var req map[string]interface{}
+			json.Unmarshal(body, &req)
+			if req["push-type"] != "LiveActivity" {
+				t.Errorf("push-type = %v, want LiveActivity", req["push-type"])
+			}
+			w.Header().Set("apns-channel-id", "minted")
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodGet && r.URL.Path == "/1/apps/me.test.app/all-channels":
+			w.Write([]byte(`{"channels":["a","b"]}`))
+		case r.Method == http.MethodDelete && r.URL.Path == "/1/apps/me.test.app/channels":
+			if r.Header.Get("apns-channel-id") != "a" {
+				t.Errorf("delete apns-channel-id = %q, want a", r.Header.Get("apns-channel-id"))
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	signer, err := newJWTSigner(testP8Key(t), "KID", "TID")
+	if err != nil {
+		t.Fatalf("newJWTSigner: %v", err)
+	}
+	m := &ChannelManager{http: srv.Client(), signer: signer, host: srv.Listener.Addr().String(), bundleID: "me.test.app"}
+	ctx := context.Background()
+
+	if id, err := m.Create(ctx); err != nil || id != "minted" {
+		t.Errorf("Create = %q, %v; want minted", id, err)
+	}
+	if ids, err := m.List(ctx); err != nil || len(ids) != 2 {
+		t.Errorf("List = %v, %v; want [a b]", ids, err)
+	}
+	if err := m.Delete(ctx, "a"); err != nil {
+		t.Errorf("Delete: %v", err)
+	}
+	if len(methods) != 3 {
+		t.Errorf("want 3 requests, got %v", methods)
+	}
+}
@@ -3,6 +3,7 @@ package liveactivity
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 )
 
@@ -14,9 +15,43 @@ type Config struct {
 	Topic          string // bundle ID, e.g. com.blakenelson.Firepower
 	Host           string // api.push.apple.com or api.sandbox.push.apple.com
 	UseDevChannels bool   // true → use debugChannels (development APNs env); set via APNS_CHANNEL_ENV=development
+
+	// Push-to-start
+	AttributesType  string // ActivityAttributes type name in the iOS app; APNS_ATTRIBUTES_TYPE
+	StartTokensFile string // optional JSON file persisting push-to-start tokens; APNS_START_TOKENS_FILE
+	MaxStartTokens  int    // push-to-start tokens stored at most; APNS_MAX_START_TOKENS (default defaultMaxStartTokens)
+
+	// Goal and final alerts
+	AlertsEnabled bool   // attach a lock-screen alert to goal and final pushes; APNS_ALERTS (default true)
+	AlertSound    string // alert sound name; APNS_ALERT_SOUND (default "default", "none" = silent)
+
+	// Broadcast channel management
+	ManageHost       string // channel management API host:port, derived from the channel env; APNS_MANAGE_HOST overrides
+	ChannelStore     string // "builtin" (default), "file" or "redis"; APNS_CHANNEL_STORE
+	ChannelStoreFile string // JSON file for the "file" store; APNS_CHANNEL_STORE_FILE
+	RedisAddress     string // for the "redis" store; shares REDIS_ADDRESS/REDIS_PASSWORD/REDIS_DB with the queue
+	RedisPassword    string
+	RedisDB          int
+
+	// Local stand-in (cmd/fakeapns). Setting either one also allows APNS_HOST
+	// to name a host outside Apple's.
+	CAFile             string // PEM bundle to trust instead of system roots; APNS_CA_FILE
+	InsecureSkipVerify bool   // skip TLS verification entirely; APNS_INSECURE_SKIP_VERIFY=true
 }
 
+// defaultAttributesType is the ActivityAttributes struct the iOS app declares
+// for the game Live Activity. Push-to-start must name it in attributes-type.
+const defaultAttributesType = "GameActivityAttributes"
+
+// LoadConfig reads APNs config for the environment named by APNS_CHANNEL_ENV.
 func LoadConfig() (*Config, error) {
+	return LoadConfigForEnvironment(os.Getenv("APNS_CHANNEL_ENV"))
+}
+
+// LoadConfigForEnvironment reads APNs config with the channel environment given
+// explicitly ("development" or anything else for production), for tools that
+// take the environment as a flag.
+func LoadConfigForEnvironment(channelEnv string) (*Config, error) {
 	vars := map[string]string{
 		"APNS_TEAM_ID":  os.Getenv("APNS_TEAM_ID"),
 		"APNS_KEY_ID":   os.Getenv("APNS_KEY_ID"),
@@ -33,20 +68,28 @@ func LoadConfig() (*Config, error) {
 	// and the APNs host must belong to the same environment. If they disagree, APNs
 	// rejects the push with 403 BadEnvironmentKeyInToken. APNS_CHANNEL_ENV is the
 	// single source of truth; the host is derived from it. APNS_HOST may still be
-	// set as an explicit override (e.g. a test proxy), but it must match the
-	// channel environment or we fail fast at startup rather than at push time.
-	useDevChannels := strings.EqualFold(os.Getenv("APNS_CHANNEL_ENV"), "development")
+	// set as an explicit override, but it must match the channel environment or
+	// we fail fast at startup rather than at push time. The exception is a local
+	// stand-in: with APNS_CA_FILE or APNS_INSECURE_SKIP_VERIFY set, any non-Apple
+	// host is accepted.
+	useDevChannels := strings.EqualFold(channelEnv, "development")
 	expectedHost := "api.push.apple.com"
+	manageHost := manageHostProduction
 	if useDevChannels {
 		expectedHost = "api.sandbox.push.apple.com"
+		manageHost = manageHostDevelopment
 	}
 
+	caFile := os.Getenv("APNS_CA_FILE")
+	insecure, _ := strconv.ParseBool(os.Getenv("APNS_INSECURE_SKIP_VERIFY"))
+	standIn := caFile != "" || insecure
+
 	host := os.Getenv("APNS_HOST")
-	switch host {
-	case "":
+	switch {
+	case host == "":
 		host = expectedHost
-	case expectedHost:
-		// explicit override agrees with channel environment
+	case host == expectedHost, standIn && !strings.Contains(host, "push.apple.com"):
+		// explicit override agrees with channel environment, or targets a stand-in
 	default:
 		return nil, fmt.Errorf(
 			"APNS_HOST %q does not match APNS_CHANNEL_ENV (%s channels expect host %q); "+
@@ -61,6 +104,23 @@ func LoadConfig() (*Config, error) {
 		Topic:          vars["APNS_TOPIC"],
 		Host:           host,
 		UseDevChannels: useDevChannels,
+
+		AttributesType:  getEnvOrDefault("APNS_ATTRIBUTES_TYPE", defaultAttributesType),
+		StartTokensFile: os.Getenv("APNS_START_TOKENS_FILE"),
+		MaxStartTokens:  maxStartTokensFromEnv(),
+
+		AlertsEnabled: alertsEnabledFromEnv(),
+		AlertSound:    alertSoundFromEnv(),
+
+		ManageHost:       getEnvOrDefault("APNS_MANAGE_HOST", manageHost),
+		ChannelStore:     strings.ToLower(getEnvOrDefault("APNS_CHANNEL_STORE", "builtin")),
+		ChannelStoreFile: os.Getenv("APNS_CHANNEL_STORE_FILE"),
+		RedisAddress:     getEnvOrDefault("REDIS_ADDRESS", "localhost:6379"),
+		RedisPassword:    os.Getenv("REDIS_PASSWORD"),
+		RedisDB:          redisDBFromEnv(),
+
+		CAFile:             caFile,
+		InsecureSkipVerify: insecure,
 	}, nil
 }
 
@@ -70,3 +130,45 @@ func channelEnvName(useDevChannels bool) string {
 	}
 	return "production"
 }
+
+func getEnvOrDefault(key, defaultVal string) string {
+	if val := os.Getenv(key); val != "" {
+		return val
+	}
+	return defaultVal
+}
+
+// redisDBFromEnv parses REDIS_DB the same way config.LoadConfig does: invalid
+// or negative values fall back to 0.
+func redisDBFromEnv() int {
+	if v, err := strconv.Atoi(os.Getenv("REDIS_DB")); err == nil && v >= 0 {
+		return v
+	}
+	return 0
+}
+
+// maxStartTokensFromEnv parses APNS_MAX_START_TOKENS; unset or invalid means
+// defaultMaxStartTokens.
+func maxStartTokensFromEnv() int {
+	if v, err := strconv.Atoi(os.Getenv("APNS_MAX_START_TOKENS")); err == nil && v > 0 {
+		return v
+	}
+	return defaultMaxStartTokens
+}
+
+// alertsEnabledFromEnv parses APNS_ALERTS; unset or invalid means enabled.
+func alertsEnabledFromEnv() bool {
+	if v, err := strconv.ParseBool(os.Getenv("APNS_ALERTS")); err == nil {
+		return v
+	}
+	return true
+}
+
+// alertSoundFromEnv returns APNS_ALERT_SOUND, mapping "none" to a silent alert.
+func alertSoundFromEnv() string {
+	sound := getEnvOrDefault("APNS_ALERT_SOUND", "default")
+	if strings.EqualFold(sound, "none") {
+		return ""
+	}
+	return sound
+}
@@ -92,3 +92,65 @@ func TestLoadConfig_HostChannelEnvMismatchRejected(t *testing.T) {
 		t.Fatal("expected error for sandbox host with production channels, got nil")
 	}
 }
+
+func TestLoadConfig_StandInHostAllowedWithCustomTLS(t *testing.T) {
+	t.Setenv("APNS_TEAM_ID", "TEAMID1234")
+	t.Setenv("APNS_KEY_ID", "KEYID12345")
+	t.Setenv("APNS_AUTH_KEY", "dummykey")
+	t.Setenv("APNS_TOPIC", "me.blakenelson.firepower")
+	t.Setenv("APNS_CHANNEL_ENV", "")
+	t.Setenv("APNS_HOST", "fakeapns:8443")
+
+	if _, err := LoadConfig(); err == nil {
+		t.Fatal("expected non-Apple host to be rejected without APNS_CA_FILE/APNS_INSECURE_SKIP_VERIFY")
+	}
+
+	t.Setenv("APNS_INSECURE_SKIP_VERIFY", "true")
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Host != "fakeapns:8443" || !cfg.InsecureSkipVerify {
+		t.Errorf("want stand-in host with insecure TLS, got host=%q insecure=%v", cfg.Host, cfg.InsecureSkipVerify)
+	}
+
+	// A custom CA must not be a way around the environment check for Apple hosts.
+	t.Setenv("APNS_HOST", "api.sandbox.push.apple.com")
+	if _, err := LoadConfig(); err == nil {
+		t.Error("expected sandbox host with production channels to be rejected even with custom TLS")
+	}
+}
+
+func TestLoadConfig_Alerts(t *testing.T) {
+	tests := []struct {
+		name        string
+		alerts      string
+		sound       string
+		wantEnabled bool
+		wantSound   string
+	}{
+		{"defaults", "", "", true, "default"},
+		{"disabled", "false", "", false, "default"},
+		{"custom sound", "true", "goal_horn.caf", true, "goal_horn.caf"},
+		{"silent", "", "none", true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("APNS_TEAM_ID", "TEAMID1234")
+			t.Setenv("APNS_KEY_ID", "KEYID12345")
+			t.Setenv("APNS_AUTH_KEY", "dummykey")
+			t.Setenv("APNS_TOPIC", "me.blakenelson.firepower")
+			t.Setenv("APNS_HOST", "")
+			t.Setenv("APNS_ALERTS", tt.alerts)
+			t.Setenv("APNS_ALERT_SOUND", tt.sound)
+
+			cfg, err := LoadConfig()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.AlertsEnabled != tt.wantEnabled || cfg.AlertSound != tt.wantSound {
+				t.Errorf("alerts=%v sound=%q, want %v %q", cfg.AlertsEnabled, cfg.AlertSound, tt.wantEnabled, tt.wantSound)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package liveactivity
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"watchgameupdates/internal/fakeapns"
+	. "watchgameupdates/internal/notification"
+)
+
+// newFakeAPNs starts the fakeapns stand-in over HTTP/2 TLS and configures the
+// environment so New() builds a notifier that trusts it via APNS_CA_FILE.
+func newFakeAPNs(t *testing.T) *fakeapns.Server {
+	t.Helper()
+	p8 := testP8Key(t)
+	fake, err := fakeapns.New(fakeapns.Options{AuthKey: p8, KeyID: "KID", TeamID: "TID", BundleID: "me.test.app"})
+	if err != nil {
+		t.Fatalf("fakeapns.New: %v", err)
+	}
+	srv := httptest.NewUnstartedServer(fake)
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(caFile, caPEM, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("APNS_TEAM_ID", "TID")
+	t.Setenv("APNS_KEY_ID", "KID")
+	t.Setenv("APNS_AUTH_KEY", p8)
+	t.Setenv("APNS_TOPIC", "me.test.app")
+	t.Setenv("APNS_CHANNEL_ENV", "")
+	t.Setenv("APNS_HOST", srv.Listener.Addr().String())
+	t.Setenv("APNS_CA_FILE", caFile)
+	return fake
+}
+
+// send formats and dispatches baseReq through n and returns the push result.
+func send(t *testing.T, n *LiveActivityNotifier) NotificationResult {
+	t.Helper()
+	msg := n.FormatMessage(baseReq())
+	if msg == "" {
+		t.Fatal("FormatMessage returned empty message")
+	}
+	results, err := n.SendNotification(context.Background(), msg)
+	if err != nil {
+		t.Fatalf("SendNotification: %v", err)
+	}
+	return <-results
+}
+
+func TestEndToEnd_BroadcastThroughFakeAPNs(t *testing.T) {
+	fake := newFakeAPNs(t)
+	n, err := New()
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	if res := send(t, n); !res.Success {
+		t.Fatalf("push failed: %v", res.Error)
+	}
+
+	pushes := fake.Pushes(fakeapns.KindBroadcast)
+	if len(pushes) != 2 {
+		t.Fatalf("want 2 broadcast pushes (home + away), got %d", len(pushes))
+	}
+	got := map[string]bool{}
+	for _, p := range pushes {
+		if p.Status != http.StatusOK {
+			t.Errorf("push to %s answered %d %s", p.Target, p.Status, p.Reason)
+		}
+		if p.Proto != 2 {
+			t.Errorf("want HTTP/2, got HTTP/%d", p.Proto)
+		}
+		got[p.Target] = true
+
+		var payload apnsPayload
+		if err := json.Unmarshal(p.Payload, &payload); err != nil {
+			t.Fatalf("recorded payload is not an APNs payload: %v", err)
+		}
+		if payload.APS.ContentState.HomeTeam != "BOS" {
+			t.Errorf("content-state homeTeam = %q, want BOS", payload.APS.ContentState.HomeTeam)
+		}
+	}
+	if !got[prodChannels["BOS"]] || !got[prodChannels["NYR"]] {
+		t.Errorf("pushed to %v, want the BOS and NYR production channels", got)
+	}
+}
+
+func TestEndToEnd_ScriptedFailures(t *testing.T) {
+	tests := []struct {
+		name        string
+		script      []fakeapns.Response
+		wantSuccess bool
+		wantPushes  int
+	}{
+		{"retry after 500", []fakeapns.Response{{Status: 500, Reason: "InternalServerError"}}, true, 3},
+		{"retry after 429", []fakeapns.Response{{Status: 429, Reason: "TooManyRequests"}}, true, 3},
+		{"gone channel dropped", []fakeapns.Response{{Status: 410, Reason: "ChannelNotRegistered"}}, true, 2},
+		{"forbidden is terminal", []fakeapns.Response{{Status: 403, Reason: "InvalidProviderToken"}}, false, 2},
+		{"expired token re-signed", []fakeapns.Response{{Status: 403, Reason: "ExpiredProviderToken"}}, true, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeAPNs(t)
+			n, err := New()
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			fake.Script(tt.script...)
+
+			res := send(t, n)
+			if res.Success != tt.wantSuccess {
+				t.Errorf("success = %v, want %v (err %v)", res.Success, tt.wantSuccess, res.Error)
+			}
+			if got := len(fake.Pushes(fakeapns.KindBroadcast)); got != tt.wantPushes {
+				t.Errorf("pushes = %d, want %d", got, tt.wantPushes)
+			}
+		})
+	}
+}
+
+func TestEndToEnd_GoneChannelNotPushedAgain(t *testing.T) {
+	fake := newFakeAPNs(t)
+	n, err := New()
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	fake.Script(fakeapns.Response{Status: 410, Reason: "ChannelNotRegistered", Target: prodChannels["NYR"]})
+
+	send(t, n)
+	send(t, n)
+
+	for _, p := range fake.Pushes(fakeapns.KindBroadcast)[2:] {
+		if p.Target == prodChannels["NYR"] {
+			t.Errorf("pushed to the gone NYR channel again")
+		}
+	}
+	if got := len(fake.Pushes(fakeapns.KindBroadcast)); got != 3 {
+		t.Errorf("pushes = %d, want 3 (2 + BOS only)", got)
+	}
+}
+
+func TestEndToEnd_BadEnvironmentKeyStopsPushes(t *testing.T) {
+	fake := newFakeAPNs(t)
+	n, err := New()
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	fake.Script(
+		fakeapns.Response{Status: 403, Reason: "BadEnvironmentKeyInToken"},
+		fakeapns.Response{Status: 403, Reason: "BadEnvironmentKeyInToken"},
+	)
+
+	if res := send(t, n); res.Success {
+		t.Fatal("want failure once APNs rejects the key environment")
+	}
+	before := len(fake.Pushes(fakeapns.KindBroadcast))
+	if res := send(t, n); res.Success {
+		t.Error("want pushes to keep failing while the breaker is open")
+	}
+	if after := len(fake.Pushes(fakeapns.KindBroadcast)); after != before {
+		t.Errorf("pushes went from %d to %d, want none while the breaker is open", before, after)
+	}
+}
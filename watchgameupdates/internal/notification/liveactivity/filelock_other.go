@@ -0,0 +1,9 @@
+//go:build !unix
+
+package liveactivity
+
+// lockFile is a no-op where flock is unavailable: the file channel store is
+// then safe with a single writing process only.
+func lockFile(string) (func(), error) {
+	return func() {}, nil
+}
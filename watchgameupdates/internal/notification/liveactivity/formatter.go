@@ -10,7 +10,7 @@ package liveactivity
 //       "aps": {
 //         "timestamp":  1234567890,
 //         "event":      "update",         // always — even on game-end, see below
-//         "stale-date": 1234568890,       // now + 90s, always — no special-casing
+//         "stale-date": 1234568890,       // next scheduled check + margin, see staleDate
 //         "content-state": {
 //           "sport":       "nhl",
 //           "homeTeam":    "BOS",
@@ -21,60 +21,92 @@ package liveactivity
 //           "awayXG":      1.8,
 //           "gameState":   "14:32 left, 2nd period",
 //           "eventType":   "goal",       // "goal"|"penalty"|"period_end"|"" (empty = no event)
-//           "eventDetail": "",           // scorer name when pipeline lands; empty until then
-//           "eventTeam":   "BOS"         // tricode of team that caused the event
-//         }
+//           "eventDetail": "Brad Marchand", // goal scorer when the feed names one; else ""
+//           "eventTeam":   "BOS",        // tricode of team that caused the event
+//           "seriesStatus": "BOS leads 2-1" // playoff games only, omitted otherwise
+//         },
+//         "alert": {...},                // goal and final pushes only, when alerts are enabled
+//         "relevance-score": 100         // goal and final pushes only
 //       }
 //     }
 //   }
+//
+// Ordinary updates (xG, shots, penalties, period ends) stay silent. A goal or
+// the final push carries an alert so the lock screen lights up, and a
+// relevance-score so the game sorts above other activities. See AlertConfig.
+//
+// BuildPregamePayload produces the update sent to the team channels before
+// puck drop: a zero score with gameState "Pregame", stale once the game is due
+// to start.
+//
+// BuildStartPayload produces the push-to-start variant for a single device:
+// the same aps block with event:"start", plus attributes-type, attributes,
+// input-push-channel (the team's broadcast channel) and an alert.
 
 import (
 	"encoding/json"
 	"fmt"
 	"log"
-	"math"
-	"strconv"
 	"strings"
 	"time"
 
 	. "watchgameupdates/internal/notification"
+	"watchgameupdates/internal/notification/contentstate"
 )
 
-// staleDateOffset applies to every push, including the final one. A game-end
-// push is not special at this layer: it is an ordinary content-state update
-// whose gameState happens to read "Final", exactly like any other moment's
-// gameState reads "14:32 left, 2nd period". The client alone decides what a
-// Final gameState means for the activity's lifecycle (see
-// LiveActivityManager.endIfFinal) — this backend never sends event:"end",
-// since a push with event:"end" is applied by the OS directly with no app
-// code in the loop, foreclosing the client's ability to decide anything.
-const staleDateOffset = 90 * time.Second
+// Relevance scores for alerting pushes. iOS orders concurrent Live Activities
+// by the most recent score; only relative values matter.
+const (
+	goalRelevanceScore  = 100
+	finalRelevanceScore = 50
+)
+
+// AlertConfig controls the alert attached to goal and final pushes.
+// The zero value sends every push silently.
+type AlertConfig struct {
+	Enabled bool
+	Sound   string // APNs sound name; "" for a silent alert
+}
 
 // dispatchEnvelope is what FormatMessage returns and SendNotification parses.
 type dispatchEnvelope struct {
 	Channels []string        `json:"channels"`
 	Payload  json.RawMessage `json:"payload"`
+	// ChannelPayloads replaces Payload for the channels it lists: on a goal
+	// the conceding team's channel gets the update without the alert.
+	ChannelPayloads map[string]json.RawMessage `json:"channelPayloads,omitempty"`
 }
 
-type contentState struct {
-	Sport       string  `json:"sport"`
-	HomeTeam    string  `json:"homeTeam"`
-	AwayTeam    string  `json:"awayTeam"`
-	HomeScore   int     `json:"homeScore"`
-	AwayScore   int     `json:"awayScore"`
-	HomeXG      float64 `json:"homeXG"`
-	AwayXG      float64 `json:"awayXG"`
-	GameState   string  `json:"gameState"`
-	EventType   string  `json:"eventType"`   // "goal"|"penalty"|"period_end"|""
-	EventDetail string  `json:"eventDetail"` // scorer name when available; "" until then
-	EventTeam   string  `json:"eventTeam"`   // scoring/penalised team tricode; "" if unknown
+type apsEnvelope struct {
+	Timestamp    int64              `json:"timestamp"`
+	Event        string             `json:"event"`
+	StaleDate    *int64             `json:"stale-date,omitempty"`
+	ContentState contentstate.State `json:"content-state"`
+
+	// Goal and final pushes only (plus Alert below).
+	RelevanceScore *float64 `json:"relevance-score,omitempty"`
+
+	// Push-to-start only (event:"start").
+	AttributesType   string              `json:"attributes-type,omitempty"`
+	Attributes       *activityAttributes `json:"attributes,omitempty"`
+	InputPushChannel string              `json:"input-push-channel,omitempty"`
+
+	// Push-to-start, and goal/final updates when alerts are enabled.
+	Alert *apsAlert `json:"alert,omitempty"`
 }
 
-type apsEnvelope struct {
-	Timestamp    int64        `json:"timestamp"`
-	Event        string       `json:"event"`
-	StaleDate    *int64       `json:"stale-date,omitempty"`
-	ContentState contentState `json:"content-state"`
+// activityAttributes mirrors the static ActivityAttributes of the iOS game
+// activity. They are fixed for the lifetime of the activity.
+type activityAttributes struct {
+	GameID   string `json:"gameId"`
+	HomeTeam string `json:"homeTeam"`
+	AwayTeam string `json:"awayTeam"`
+}
+
+type apsAlert struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	Sound string `json:"sound,omitempty"`
 }
 
 type apnsPayload struct {
@@ -82,15 +114,12 @@ type apnsPayload struct {
 }
 
 // BuildDispatchMessage produces the JSON string for FormatMessage.
-// useDevChannels selects sandbox APNs channel IDs (debug builds) vs production.
-func BuildDispatchMessage(req NotificationRequest, useDevChannels bool) (string, error) {
-	cs, err := buildContentState(req)
-	if err != nil {
-		return "", err
-	}
-
+// channels resolves team channel IDs for the notifier's APNs environment;
+// alerts controls whether goal and final pushes carry an alert.
+func BuildDispatchMessage(req NotificationRequest, channels ChannelStore, alerts AlertConfig) (string, error) {
+	cs := contentstate.FromRequest(req)
 	now := time.Now().Unix()
-	ts := now + int64(staleDateOffset.Seconds())
+	ts := staleDate(now, req.Data)
 
 	aps := apsEnvelope{
 		Timestamp:    now,
@@ -98,6 +127,8 @@ func BuildDispatchMessage(req NotificationRequest, useDevChannels bool) (string,
 		StaleDate:    &ts,
 		ContentState: cs,
 	}
+	silent := aps
+	applyAlert(&aps, req.Data, alerts)
 
 	payloadBytes, err := json.Marshal(apnsPayload{APS: aps})
 	if err != nil {
@@ -107,16 +138,28 @@ func BuildDispatchMessage(req NotificationRequest, useDevChannels bool) (string,
 	homeAbbrev := strings.ToUpper(req.Data["homeTeamAbbrev"])
 	awayAbbrev := strings.ToUpper(req.Data["awayTeamAbbrev"])
 
-	channels := channelsForTeams(homeAbbrev, awayAbbrev, useDevChannels)
-	if len(channels) == 0 {
+	channelIDs := channelsForTeams(channels, homeAbbrev, awayAbbrev)
+	if len(channelIDs) == 0 {
 		return "", fmt.Errorf("no channel IDs registered for %s or %s", homeAbbrev, awayAbbrev)
 	}
 
 	env := dispatchEnvelope{
-		Channels: channels,
+		Channels: channelIDs,
 		Payload:  json.RawMessage(payloadBytes),
 	}
 
+	// A goal alerts the scoring team's fans only. When the feed did not say
+	// who scored, both channels keep the alert.
+	if conceding := concedingTeam(cs, homeAbbrev, awayAbbrev); conceding != "" {
+		if id, ok := channelForTeam(channels, conceding); ok {
+			silentBytes, err := json.Marshal(apnsPayload{APS: silent})
+			if err != nil {
+				return "", fmt.Errorf("marshal APNs payload: %w", err)
+			}
+			env.ChannelPayloads = map[string]json.RawMessage{id: silentBytes}
+		}
+	}
+
 	b, err := json.Marshal(env)
 	if err != nil {
 		return "", fmt.Errorf("marshal dispatch envelope: %w", err)
@@ -124,78 +167,157 @@ func BuildDispatchMessage(req NotificationRequest, useDevChannels bool) (string,
 	return string(b), nil
 }
 
-func buildContentState(req NotificationRequest) (contentState, error) {
-	homeScore := parseIntSafe(req.Data["homeTeamGoals"])
-	awayScore := parseIntSafe(req.Data["awayTeamGoals"])
-
-	eventType, eventTeam := classifyEvent(req.Data["lastPlayType"], req.Data)
-
-	return contentState{
-		Sport:       "nhl",
-		HomeTeam:    strings.ToUpper(req.Data["homeTeamAbbrev"]),
-		AwayTeam:    strings.ToUpper(req.Data["awayTeamAbbrev"]),
-		HomeScore:   homeScore,
-		AwayScore:   awayScore,
-		HomeXG:      safeXG(req.Data["homeTeamExpectedGoals"]),
-		AwayXG:      safeXG(req.Data["awayTeamExpectedGoals"]),
-		GameState:   req.Data["gameState"],
-		EventType:   eventType,
-		EventDetail: "", // scorer name: empty until the play-by-play pipeline lands (TODO)
-		EventTeam:   eventTeam,
-	}, nil
-}
-
-// classifyEvent returns (eventType, eventTeam) for the current play.
-// eventDetail (scorer name) is a separate TODO — see planning/todos.md.
-func classifyEvent(playType string, data map[string]string) (eventType, eventTeam string) {
-	switch playType {
-	case "goal":
-		return "goal", strings.ToUpper(data["eventTeamAbbrev"])
-	case "penalty":
-		return "penalty", strings.ToUpper(data["eventTeamAbbrev"])
-	case "period-end":
-		return "period_end", ""
-	case "game-end":
-		return "period_end", ""
+// concedingTeam returns the team scored on when cs is a goal by one of the two
+// teams, "" otherwise.
+func concedingTeam(cs contentstate.State, homeAbbrev, awayAbbrev string) string {
+	if cs.EventType != "goal" || homeAbbrev == awayAbbrev {
+		return ""
+	}
+	switch cs.EventTeam {
+	case homeAbbrev:
+		return awayAbbrev
+	case awayAbbrev:
+		return homeAbbrev
 	default:
-		return "", ""
+		return ""
 	}
 }
 
-// safeXG parses an xG string and guards against NaN/Inf.
-// The value passes through exactly as sourced — no rounding. Display
-// formatting is the iOS client's responsibility (see CLAUDE.md).
-func safeXG(s string) float64 {
-	if s == "" {
-		return 0
+// staleDate returns when the content state goes stale: once the next check is
+// overdue (contentstate.StaleAfter), not a fixed 90s, so an intermission
+// doesn't dim the activity.
+//
+// Stale dates apply to every push, including the final one. A game-end
+// push is not special at this layer: it is an ordinary content-state update
+// whose gameState happens to read "Final", exactly like any other moment's
+// gameState reads "14:32 left, 2nd period". The client alone decides what a
+// Final gameState means for the activity's lifecycle (see
+// LiveActivityManager.endIfFinal) — this backend never sends event:"end",
+// since a push with event:"end" is applied by the OS directly with no app
+// code in the loop, foreclosing the client's ability to decide anything.
+func staleDate(now int64, data map[string]string) int64 {
+	return now + int64(contentstate.StaleAfter(data).Seconds())
+}
+
+// BuildStartPayload produces the APNs payload for a push-to-start. The started
+// activity subscribes itself to channelID (input-push-channel), so every later
+// broadcast update for that team reaches it without the app being opened.
+func BuildStartPayload(req NotificationRequest, gameID, attributesType, channelID string) ([]byte, error) {
+	cs := contentstate.FromRequest(req)
+	now := time.Now().Unix()
+	ts := staleDate(now, req.Data)
+
+	aps := apsEnvelope{
+		Timestamp:      now,
+		Event:          "start",
+		StaleDate:      &ts,
+		ContentState:   cs,
+		AttributesType: attributesType,
+		Attributes: &activityAttributes{
+			GameID:   gameID,
+			HomeTeam: cs.HomeTeam,
+			AwayTeam: cs.AwayTeam,
+		},
+		InputPushChannel: channelID,
+		// iOS only shows a push-to-start activity when the payload carries an alert.
+		Alert: &apsAlert{
+			Title: fmt.Sprintf("%s @ %s", cs.AwayTeam, cs.HomeTeam),
+			Body:  "The game is about to start",
+		},
 	}
-	v, err := strconv.ParseFloat(s, 64)
-	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
-		log.Printf("WARN: invalid xG value %q, defaulting to 0", s)
-		return 0
+
+	b, err := json.Marshal(apnsPayload{APS: aps})
+	if err != nil {
+		return nil, fmt.Errorf("marshal APNs start payload: %w", err)
 	}
-	return v
+	return b, nil
 }
 
-func parseIntSafe(s string) int {
-	v, err := strconv.Atoi(s)
+// pregameGameState is the content-state gameState of the pregame push.
+const pregameGameState = "Pregame"
+
+// BuildPregamePayload produces the APNs payload for the pregame broadcast: a
+// content state with gameState "Pregame" and no score, stale shortly after
+// start so the activity dims if the first check never arrives.
+func BuildPregamePayload(homeAbbrev, awayAbbrev string, start time.Time) ([]byte, error) {
+	now := time.Now().Unix()
+	stale := start.Add(contentstate.StaleMargin).Unix()
+	if stale < now {
+		stale = now + int64(contentstate.StaleOffset.Seconds())
+	}
+
+	aps := apsEnvelope{
+		Timestamp: now,
+		Event:     "update",
+		StaleDate: &stale,
+		ContentState: contentstate.State{
+			Sport:     "nhl",
+			HomeTeam:  strings.ToUpper(homeAbbrev),
+			AwayTeam:  strings.ToUpper(awayAbbrev),
+			GameState: pregameGameState,
+		},
+	}
+
+	b, err := json.Marshal(apnsPayload{APS: aps})
 	if err != nil {
-		return 0
+		return nil, fmt.Errorf("marshal APNs pregame payload: %w", err)
+	}
+	return b, nil
+}
+
+// applyAlert sets the relevance score on goal and final pushes and, when
+// alerts are enabled, an alert naming the scorer if the feed identified one.
+// A playoff final adds the series, or announces the series win. Every other
+// push is left silent, as is the conceding team's copy of a goal push (see
+// BuildDispatchMessage).
+func applyAlert(aps *apsEnvelope, data map[string]string, alerts AlertConfig) {
+	cs := aps.ContentState
+	score := fmt.Sprintf("%s %d – %s %d", cs.AwayTeam, cs.AwayScore, cs.HomeTeam, cs.HomeScore)
+
+	var alert apsAlert
+	var relevance float64
+	switch data["lastPlayType"] {
+	case "goal":
+		relevance = goalRelevanceScore
+		alert.Title = "Goal!"
+		if cs.EventTeam != "" {
+			alert.Title = "Goal! " + cs.EventTeam
+		}
+		alert.Body = score
+		if cs.EventDetail != "" {
+			alert.Body = cs.EventDetail + " scores. " + score
+		}
+	case "game-end":
+		relevance = finalRelevanceScore
+		alert.Title = "Final"
+		alert.Body = score
+		if clinched := data["seriesClinched"]; clinched != "" {
+			alert.Body = score + ". " + clinched + "!"
+		} else if cs.SeriesStatus != "" {
+			alert.Body = score + ". " + cs.SeriesStatus
+		}
+	default:
+		return
+	}
+
+	aps.RelevanceScore = &relevance
+	if alerts.Enabled {
+		alert.Sound = alerts.Sound
+		aps.Alert = &alert
 	}
-	return v
 }
 
 // channelsForTeams returns the APNs broadcast channel IDs for the two teams.
-// Teams whose channel ID has not been populated in channels.go are skipped.
-func channelsForTeams(homeAbbrev, awayAbbrev string, useDevChannels bool) []string {
+// Teams with no channel ID in the store are skipped.
+func channelsForTeams(store ChannelStore, homeAbbrev, awayAbbrev string) []string {
 	var channels []string
-	if id, ok := channelForTeam(homeAbbrev, useDevChannels); ok {
+	if id, ok := channelForTeam(store, homeAbbrev); ok {
 		channels = append(channels, id)
 	} else {
 		log.Printf("WARN: no channel ID for home team %s, skipping", homeAbbrev)
 	}
 	if awayAbbrev != "" && awayAbbrev != homeAbbrev {
-		if id, ok := channelForTeam(awayAbbrev, useDevChannels); ok {
+		if id, ok := channelForTeam(store, awayAbbrev); ok {
 			channels = append(channels, id)
 		} else {
 			log.Printf("WARN: no channel ID for away team %s, skipping", awayAbbrev)
@@ -2,11 +2,11 @@ package liveactivity
 
 import (
 	"encoding/json"
-	"math"
 	"testing"
 	"time"
 
 	. "watchgameupdates/internal/notification"
+	"watchgameupdates/internal/notification/contentstate"
 )
 
 // withChannels temporarily sets channel IDs in the prod or dev map for the duration
@@ -53,7 +53,7 @@ func baseReq() NotificationRequest {
 
 func TestBuildDispatchMessage_HappyPath(t *testing.T) {
 	withChannels(t, map[string]string{"BOS": "chan-BOS", "NYR": "chan-NYR"}, false, func() {
-		msg, err := BuildDispatchMessage(baseReq(), false)
+		msg, err := BuildDispatchMessage(baseReq(), builtinChannelStore{}, AlertConfig{})
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -168,7 +168,7 @@ func TestBuildDispatchMessage_GameEnded(t *testing.T) {
 		req.Data["gameState"] = "Final"
 		req.Data["lastPlayType"] = "game-end"
 
-		msg, err := BuildDispatchMessage(req, false)
+		msg, err := BuildDispatchMessage(req, builtinChannelStore{}, AlertConfig{})
 		after := time.Now().Unix()
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
@@ -196,12 +196,12 @@ func TestBuildDispatchMessage_GameEnded(t *testing.T) {
 		if payload.APS.StaleDate == nil {
 			t.Fatal("want non-nil stale-date for game-end")
 		}
-		wantMin := before + int64(staleDateOffset.Seconds())
-		wantMax := after + int64(staleDateOffset.Seconds())
+		wantMin := before + int64(contentstate.StaleOffset.Seconds())
+		wantMax := after + int64(contentstate.StaleOffset.Seconds())
 		got := *payload.APS.StaleDate
 		if got < wantMin || got > wantMax {
 			t.Errorf("stale-date = %d, want within [%d, %d] (now + %s) — game-end should use the same offset as any other push",
-				got, wantMin, wantMax, staleDateOffset)
+				got, wantMin, wantMax, contentstate.StaleOffset)
 		}
 	})
 }
@@ -211,7 +211,7 @@ func TestBuildDispatchMessage_NoChannelsRegistered(t *testing.T) {
 	// The prod map now populates every team (see TestReplicateAll32ProdChannels),
 	// so force BOS/NYR empty to exercise the skip-empty defense.
 	withChannels(t, map[string]string{"BOS": "", "NYR": ""}, false, func() {
-		_, err := BuildDispatchMessage(baseReq(), false)
+		_, err := BuildDispatchMessage(baseReq(), builtinChannelStore{}, AlertConfig{})
 		if err == nil {
 			t.Fatal("want error when no channel IDs are registered, got nil")
 		}
@@ -220,7 +220,7 @@ func TestBuildDispatchMessage_NoChannelsRegistered(t *testing.T) {
 
 func TestBuildDispatchMessage_OneChannelRegistered(t *testing.T) {
 	withChannels(t, map[string]string{"BOS": "chan-BOS", "NYR": ""}, false, func() {
-		msg, err := BuildDispatchMessage(baseReq(), false)
+		msg, err := BuildDispatchMessage(baseReq(), builtinChannelStore{}, AlertConfig{})
 		if err != nil {
 			t.Fatalf("unexpected error when one channel registered: %v", err)
 		}
@@ -239,7 +239,7 @@ func TestBuildDispatchMessage_OneChannelRegistered(t *testing.T) {
 
 func TestBuildDispatchMessage_DevChannels(t *testing.T) {
 	withChannels(t, map[string]string{"BOS": "dev-chan-BOS"}, true, func() {
-		msg, err := BuildDispatchMessage(baseReq(), true)
+		msg, err := BuildDispatchMessage(baseReq(), builtinChannelStore{useDevChannels: true}, AlertConfig{})
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -251,90 +251,6 @@ func TestBuildDispatchMessage_DevChannels(t *testing.T) {
 	})
 }
 
-// classifyEvent unit tests
-
-func TestClassifyEvent_Goal(t *testing.T) {
-	et, team := classifyEvent("goal", map[string]string{"eventTeamAbbrev": "bos"})
-	if et != "goal" {
-		t.Errorf("want goal, got %q", et)
-	}
-	if team != "BOS" {
-		t.Errorf("want BOS (uppercased), got %q", team)
-	}
-}
-
-func TestClassifyEvent_PeriodEnd(t *testing.T) {
-	et, team := classifyEvent("period-end", nil)
-	if et != "period_end" {
-		t.Errorf("want period_end, got %q", et)
-	}
-	if team != "" {
-		t.Errorf("want empty team for period-end, got %q", team)
-	}
-}
-
-func TestClassifyEvent_GameEnd(t *testing.T) {
-	et, _ := classifyEvent("game-end", nil)
-	if et != "period_end" {
-		t.Errorf("game-end should map to period_end, got %q", et)
-	}
-}
-
-func TestClassifyEvent_Unknown(t *testing.T) {
-	et, team := classifyEvent("blocked-shot", map[string]string{})
-	if et != "" || team != "" {
-		t.Errorf("unknown play should produce empty fields, got type=%q team=%q", et, team)
-	}
-}
-
-// Existing tests preserved
-
-func TestSafeXG_NaN(t *testing.T) {
-	if got := safeXG("NaN"); got != 0 {
-		t.Errorf("safeXG(NaN) = %v, want 0", got)
-	}
-}
-
-func TestSafeXG_Inf(t *testing.T) {
-	if got := safeXG("Inf"); got != 0 {
-		t.Errorf("safeXG(Inf) = %v, want 0", got)
-	}
-}
-
-func TestSafeXG_NegInf(t *testing.T) {
-	if got := safeXG("-Inf"); got != 0 {
-		t.Errorf("safeXG(-Inf) = %v, want 0", got)
-	}
-}
-
-func TestSafeXG_Empty(t *testing.T) {
-	if got := safeXG(""); got != 0 {
-		t.Errorf("safeXG(\"\") = %v, want 0", got)
-	}
-}
-
-func TestSafeXG_ValidFloat(t *testing.T) {
-	if got := safeXG("2.456"); got != 2.456 {
-		t.Errorf("safeXG(2.456) = %v, want 2.456 (exact passthrough, no rounding)", got)
-	}
-}
-
-func TestSafeXG_NoRounding(t *testing.T) {
-	// The client owns display formatting; the backend must not round.
-	for in, want := range map[string]float64{"0.76": 0.76, "0.38": 0.38, "1.05": 1.05} {
-		if got := safeXG(in); got != want {
-			t.Errorf("safeXG(%s) = %v, want %v (exact passthrough)", in, got, want)
-		}
-	}
-}
-
-func TestSafeXG_ActualNaN(t *testing.T) {
-	v := math.NaN()
-	if !math.IsNaN(v) {
-		t.Fatal("sanity: math.NaN() is not NaN")
-	}
-}
-
 func TestBuildDispatchMessage_MissingScores(t *testing.T) {
 	withChannels(t, map[string]string{"BOS": "chan-BOS", "NYR": "chan-NYR"}, false, func() {
 		req := baseReq()
@@ -349,7 +265,7 @@ func TestBuildDispatchMessage_MissingScores(t *testing.T) {
 
 func TestChannelsForTeams_BothRegistered(t *testing.T) {
 	withChannels(t, map[string]string{"BOS": "chan-BOS", "NYR": "chan-NYR"}, false, func() {
-		ch := channelsForTeams("BOS", "NYR", false)
+		ch := channelsForTeams(builtinChannelStore{}, "BOS", "NYR")
 		if len(ch) != 2 {
 			t.Fatalf("want 2 channels, got %d", len(ch))
 		}
@@ -362,7 +278,7 @@ func TestChannelsForTeams_BothRegistered(t *testing.T) {
 func TestChannelsForTeams_NoneRegistered(t *testing.T) {
 	// Force BOS/NYR empty: the real prod map now populates every team.
 	withChannels(t, map[string]string{"BOS": "", "NYR": ""}, false, func() {
-		ch := channelsForTeams("BOS", "NYR", false)
+		ch := channelsForTeams(builtinChannelStore{}, "BOS", "NYR")
 		if len(ch) != 0 {
 			t.Errorf("want 0 channels when none registered, got %d", len(ch))
 		}
@@ -371,7 +287,7 @@ func TestChannelsForTeams_NoneRegistered(t *testing.T) {
 
 func TestChannelsForTeams_SameTeam(t *testing.T) {
 	withChannels(t, map[string]string{"BOS": "chan-BOS"}, false, func() {
-		ch := channelsForTeams("BOS", "BOS", false)
+		ch := channelsForTeams(builtinChannelStore{}, "BOS", "BOS")
 		if len(ch) != 1 {
 			t.Errorf("same-team edge case: want 1 channel, got %d", len(ch))
 		}
@@ -382,14 +298,14 @@ func TestChannelsForTeams_SameTeam(t *testing.T) {
 
 func mustBuild(t *testing.T, req NotificationRequest, useDevChannels bool) string {
 	t.Helper()
-	msg, err := BuildDispatchMessage(req, useDevChannels)
+	msg, err := BuildDispatchMessage(req, builtinChannelStore{useDevChannels: useDevChannels}, AlertConfig{})
 	if err != nil {
 		t.Fatalf("BuildDispatchMessage: %v", err)
 	}
 	return msg
 }
 
-func unmarshalCS(t *testing.T, msg string) contentState {
+func unmarshalCS(t *testing.T, msg string) contentstate.State {
 	t.Helper()
 	var env dispatchEnvelope
 	if err := json.Unmarshal([]byte(msg), &env); err != nil {
@@ -401,3 +317,296 @@ func unmarshalCS(t *testing.T, msg string) contentState {
 	}
 	return payload.APS.ContentState
 }
+
+// Alert tests
+
+func unmarshalAPS(t *testing.T, msg string) apsEnvelope {
+	t.Helper()
+	var env dispatchEnvelope
+	if err := json.Unmarshal([]byte(msg), &env); err != nil {
+		t.Fatalf("unmarshal envelope: %v", err)
+	}
+	var payload apnsPayload
+	if err := json.Unmarshal(env.Payload, &payload); err != nil {
+		t.Fatalf("unmarshal payload: %v", err)
+	}
+	return payload.APS
+}
+
+func TestBuildDispatchMessage_Alerts(t *testing.T) {
+	enabled := AlertConfig{Enabled: true, Sound: "default"}
+	tests := []struct {
+		name          string
+		data          map[string]string
+		alerts        AlertConfig
+		wantAlert     bool
+		wantTitle     string
+		wantBody      string
+		wantRelevance float64 // 0 = no relevance-score
+	}{
+		{
+			name:          "goal names scorer",
+			data:          map[string]string{"lastPlayType": "goal", "scorerName": "Brad Marchand"},
+			alerts:        enabled,
+			wantAlert:     true,
+			wantTitle:     "Goal! BOS",
+			wantBody:      "Brad Marchand scores. NYR 1 – BOS 2",
+			wantRelevance: goalRelevanceScore,
+		},
+		{
+			name:          "goal without scorer",
+			data:          map[string]string{"lastPlayType": "goal"},
+			alerts:        enabled,
+			wantAlert:     true,
+			wantTitle:     "Goal! BOS",
+			wantBody:      "NYR 1 – BOS 2",
+			wantRelevance: goalRelevanceScore,
+		},
+		{
+			name:          "final",
+			data:          map[string]string{"lastPlayType": "game-end", "gameState": "Final"},
+			alerts:        enabled,
+			wantAlert:     true,
+			wantTitle:     "Final",
+			wantBody:      "NYR 1 – BOS 2",
+			wantRelevance: finalRelevanceScore,
+		},
+		{
+			name:          "playoff final adds the series",
+			data:          map[string]string{"lastPlayType": "game-end", "seriesStatus": "BOS leads 3-2"},
+			alerts:        enabled,
+			wantAlert:     true,
+			wantTitle:     "Final",
+			wantBody:      "NYR 1 – BOS 2. BOS leads 3-2",
+			wantRelevance: finalRelevanceScore,
+		},
+		{
+			name:          "series-clinching final",
+			data:          map[string]string{"lastPlayType": "game-end", "seriesStatus": "BOS wins series 4-2", "seriesClinched": "BOS wins the 1st Round 4-2"},
+			alerts:        enabled,
+			wantAlert:     true,
+			wantTitle:     "Final",
+			wantBody:      "NYR 1 – BOS 2. BOS wins the 1st Round 4-2!",
+			wantRelevance: finalRelevanceScore,
+		},
+		{
+			name:          "goal with alerts disabled keeps relevance only",
+			data:          map[string]string{"lastPlayType": "goal"},
+			alerts:        AlertConfig{},
+			wantRelevance: goalRelevanceScore,
+		},
+		{
+			name:   "xG update stays silent",
+			data:   map[string]string{"lastPlayType": "shot-on-goal"},
+			alerts: enabled,
+		},
+		{
+			name:   "penalty stays silent",
+			data:   map[string]string{"lastPlayType": "penalty"},
+			alerts: enabled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withChannels(t, map[string]string{"BOS": "chan-BOS", "NYR": "chan-NYR"}, false, func() {
+				req := baseReq()
+				for k, v := range tt.data {
+					req.Data[k] = v
+				}
+				msg, err := BuildDispatchMessage(req, builtinChannelStore{}, tt.alerts)
+				if err != nil {
+					t.Fatalf("BuildDispatchMessage: %v", err)
+				}
+				aps := unmarshalAPS(t, msg)
+
+				if (aps.Alert != nil) != tt.wantAlert {
+					t.Fatalf("alert = %+v, want present=%v", aps.Alert, tt.wantAlert)
+				}
+				if tt.wantAlert {
+					if aps.Alert.Title != tt.wantTitle || aps.Alert.Body != tt.wantBody {
+						t.Errorf("alert = %q / %q, want %q / %q", aps.Alert.Title, aps.Alert.Body, tt.wantTitle, tt.wantBody)
+					}
+					if aps.Alert.Sound != "default" {
+						t.Errorf("sound = %q, want default", aps.Alert.Sound)
+					}
+				}
+
+				switch {
+				case tt.wantRelevance == 0 && aps.RelevanceScore != nil:
+					t.Errorf("want no relevance-score, got %v", *aps.RelevanceScore)
+				case tt.wantRelevance != 0 && (aps.RelevanceScore == nil || *aps.RelevanceScore != tt.wantRelevance):
+					t.Errorf("relevance-score = %v, want %v", aps.RelevanceScore, tt.wantRelevance)
+				}
+			})
+		})
+	}
+}
+
+// channelAPS returns the aps block the envelope in msg sends to channel.
+func channelAPS(t *testing.T, msg, channel string) apsEnvelope {
+	t.Helper()
+	var env dispatchEnvelope
+	if err := json.Unmarshal([]byte(msg), &env); err != nil {
+		t.Fatalf("unmarshal envelope: %v", err)
+	}
+	raw := env.Payload
+	if p, ok := env.ChannelPayloads[channel]; ok {
+		raw = p
+	}
+	var payload apnsPayload
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		t.Fatalf("unmarshal payload: %v", err)
+	}
+	return payload.APS
+}
+
+func TestBuildDispatchMessage_GoalAlertsScoringTeamOnly(t *testing.T) {
+	for _, tt := range []struct {
+		scorer, scoringChan, concedingChan string
+	}{
+		{"BOS", "chan-BOS", "chan-NYR"},
+		{"NYR", "chan-NYR", "chan-BOS"},
+	} {
+		t.Run(tt.scorer, func(t *testing.T) {
+			withChannels(t, map[string]string{"BOS": "chan-BOS", "NYR": "chan-NYR"}, false, func() {
+				req := baseReq()
+				req.Data["eventTeamAbbrev"] = tt.scorer
+				msg, err := BuildDispatchMessage(req, builtinChannelStore{}, AlertConfig{Enabled: true, Sound: "default"})
+				if err != nil {
+					t.Fatalf("BuildDispatchMessage: %v", err)
+				}
+
+				t.Run("scoring side", func(t *testing.T) {
+					aps := channelAPS(t, msg, tt.scoringChan)
+					if aps.Alert == nil || aps.Alert.Title != "Goal! "+tt.scorer || aps.Alert.Sound != "default" {
+						t.Errorf("alert = %+v, want a goal alert with sound", aps.Alert)
+					}
+					if aps.RelevanceScore == nil || *aps.RelevanceScore != goalRelevanceScore {
+						t.Errorf("relevance-score = %v, want %v", aps.RelevanceScore, goalRelevanceScore)
+					}
+				})
+
+				t.Run("conceding side", func(t *testing.T) {
+					aps := channelAPS(t, msg, tt.concedingChan)
+					if aps.Alert != nil {
+						t.Errorf("alert = %+v, want none", aps.Alert)
+					}
+					if aps.RelevanceScore != nil {
+						t.Errorf("relevance-score = %v, want none", *aps.RelevanceScore)
+					}
+					if aps.ContentState.EventType != "goal" || aps.ContentState.HomeScore != 2 || aps.ContentState.AwayScore != 1 {
+						t.Errorf("content-state = %+v, want the goal and score", aps.ContentState)
+					}
+				})
+			})
+		})
+	}
+}
+
+func TestBuildDispatchMessage_GoalWithoutScoringTeamAlertsBoth(t *testing.T) {
+	withChannels(t, map[string]string{"BOS": "chan-BOS", "NYR": "chan-NYR"}, false, func() {
+		req := baseReq()
+		delete(req.Data, "eventTeamAbbrev")
+		msg, err := BuildDispatchMessage(req, builtinChannelStore{}, AlertConfig{Enabled: true})
+		if err != nil {
+			t.Fatalf("BuildDispatchMessage: %v", err)
+		}
+		for _, ch := range []string{"chan-BOS", "chan-NYR"} {
+			if aps := channelAPS(t, msg, ch); aps.Alert == nil {
+				t.Errorf("%s: want the goal alert", ch)
+			}
+		}
+	})
+}
+
+func TestBuildDispatchMessage_ScorerInEventDetail(t *testing.T) {
+	withChannels(t, map[string]string{"BOS": "chan-BOS", "NYR": "chan-NYR"}, false, func() {
+		req := baseReq()
+		req.Data["scorerName"] = "Brad Marchand"
+		if cs := unmarshalCS(t, mustBuild(t, req, false)); cs.EventDetail != "Brad Marchand" {
+			t.Errorf("want eventDetail=Brad Marchand, got %q", cs.EventDetail)
+		}
+
+		req.Data["lastPlayType"] = "penalty"
+		if cs := unmarshalCS(t, mustBuild(t, req, false)); cs.EventDetail != "" {
+			t.Errorf("scorer must only be reported on goals, got %q", cs.EventDetail)
+		}
+	})
+}
+
+func TestBuildDispatchMessage_SeriesStatus(t *testing.T) {
+	withChannels(t, map[string]string{"BOS": "chan-BOS", "NYR": "chan-NYR"}, false, func() {
+		req := baseReq()
+		if cs := unmarshalCS(t, mustBuild(t, req, false)); cs.SeriesStatus != "" {
+			t.Errorf("want no seriesStatus outside the playoffs, got %q", cs.SeriesStatus)
+		}
+
+		req.Data["seriesStatus"] = "Series tied 2-2"
+		if cs := unmarshalCS(t, mustBuild(t, req, false)); cs.SeriesStatus != "Series tied 2-2" {
+			t.Errorf("want seriesStatus=Series tied 2-2, got %q", cs.SeriesStatus)
+		}
+	})
+}
+
+func TestBuildDispatchMessage_StaleDateFollowsNextCheck(t *testing.T) {
+	tests := []struct {
+		name       string
+		nextCheck  string
+		wantOffset time.Duration
+	}{
+		{"no next check announced", "", contentstate.StaleOffset},
+		{"regular poll", "60", 60*time.Second + contentstate.StaleMargin},
+		{"intermission", "1200", 1200*time.Second + contentstate.StaleMargin},
+		{"garbage falls back", "soon", contentstate.StaleOffset},
+		{"zero falls back", "0", contentstate.StaleOffset},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withChannels(t, map[string]string{"BOS": "chan-BOS", "NYR": "chan-NYR"}, false, func() {
+				req := baseReq()
+				if tt.nextCheck != "" {
+					req.Data["nextCheckSeconds"] = tt.nextCheck
+				}
+				before := time.Now().Unix()
+				aps := unmarshalAPS(t, mustBuild(t, req, false))
+				after := time.Now().Unix()
+
+				if aps.StaleDate == nil {
+					t.Fatal("want non-nil stale-date")
+				}
+				offset := int64(tt.wantOffset.Seconds())
+				if got := *aps.StaleDate; got < before+offset || got > after+offset {
+					t.Errorf("stale-date = %d, want now + %s", got, tt.wantOffset)
+				}
+			})
+		})
+	}
+}
+
+func TestBuildPregamePayload(t *testing.T) {
+	start := time.Now().Add(30 * time.Minute).Truncate(time.Second)
+	b, err := BuildPregamePayload("car", "fla", start)
+	if err != nil {
+		t.Fatalf("BuildPregamePayload: %v", err)
+	}
+	var payload apnsPayload
+	if err := json.Unmarshal(b, &payload); err != nil {
+		t.Fatalf("unmarshal payload: %v", err)
+	}
+
+	aps := payload.APS
+	if aps.Event != "update" {
+		t.Errorf("event = %q, want update", aps.Event)
+	}
+	cs := aps.ContentState
+	if cs.GameState != "Pregame" || cs.HomeTeam != "CAR" || cs.AwayTeam != "FLA" || cs.HomeScore != 0 || cs.AwayScore != 0 {
+		t.Errorf("content state = %+v, want a 0-0 Pregame state for FLA @ CAR", cs)
+	}
+	if want := start.Add(contentstate.StaleMargin).Unix(); aps.StaleDate == nil || *aps.StaleDate != want {
+		t.Errorf("stale-date = %v, want %d (start + margin)", aps.StaleDate, want)
+	}
+	if aps.Alert != nil {
+		t.Error("pregame push should be silent")
+	}
+}
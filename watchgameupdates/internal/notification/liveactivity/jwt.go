@@ -9,6 +9,8 @@ package liveactivity
 //   └── signature: ES256(header.payload, p8_key)
 //
 // Apple accepts tokens up to 1 hour old. We refresh at 50 min to stay safe.
+// If APNs still answers 403 ExpiredProviderToken (e.g. clock skew), the client
+// calls Invalidate and retries once with a freshly signed token.
 
 import (
 	"crypto/ecdsa"
@@ -60,6 +62,17 @@ func (s *jwtSigner) Token() (string, error) {
 	return token, nil
 }
 
+// Invalidate drops token so the next Token call signs a new one. It is a no-op
+// if token has already been replaced, so concurrent pushes that all saw the
+// same rejected token trigger a single re-sign.
+func (s *jwtSigner) Invalidate(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token == token {
+		s.token = ""
+	}
+}
+
 func (s *jwtSigner) buildToken() (string, error) {
 	header, err := base64JSON(map[string]string{
 		"alg": "ES256",
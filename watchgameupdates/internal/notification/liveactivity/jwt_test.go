@@ -86,3 +86,18 @@ func TestJWTSigner_RefreshesTokenAfterInterval(t *testing.T) {
 		t.Error("want new token after refresh interval elapsed, got same token")
 	}
 }
+
+func TestJWTSigner_Invalidate(t *testing.T) {
+	signer, _ := newJWTSigner(testP8Key(t), "KID", "TID")
+	tok1, _ := signer.Token()
+
+	signer.Invalidate("some-older-token")
+	if tok, _ := signer.Token(); tok != tok1 {
+		t.Error("invalidating a stale token must keep the current one")
+	}
+
+	signer.Invalidate(tok1)
+	if tok2, _ := signer.Token(); tok2 == tok1 {
+		t.Error("want a freshly signed token after Invalidate")
+	}
+}
@@ -8,17 +8,39 @@ package liveactivity
 //   SendNotification(ctx, envelope) → parses channels + payload
 //       ├── Push to nhl-team-HOME channel (goroutine)
 //       └── Push to nhl-team-AWAY channel (goroutine)
+//
+// Push-to-start flow (called by notification.Service.SendGameStart on polls
+// while the game is starting or live):
+//
+//   NotifyGameStart(game, data) → once per game ID
+//       └── for each team: event:"start" push to every registered device token
+//           following that team, with input-push-channel = team channel
+//
+// Pregame flow (called by notification.Service.SendPregame from the pregame
+// task, before puck drop):
+//
+//   NotifyPregame(game, preview) → "Pregame" content state to both team channels
+//
+// APNs failures that need a person (a channel answering 410, an auth key for
+// the wrong environment) are raised through opsalert as well as logged.
 
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 
+	"watchgameupdates/internal/clientapi"
+	"watchgameupdates/internal/models"
 	. "watchgameupdates/internal/notification"
+	"watchgameupdates/internal/opsalert"
 )
 
 const (
@@ -39,10 +61,33 @@ var requiredDataKeys = []string{
 	"lastPlayType",
 }
 
+var optionalDataKeys = []string{
+	"eventTeamAbbrev",
+	"scorerName",
+	"nextCheckSeconds", // drives the stale date
+	"seriesStatus",     // playoff games only
+	"seriesClinched",
+}
+
 // LiveActivityNotifier implements notification.Notifier.
 type LiveActivityNotifier struct {
-	client         *apnsClient
-	useDevChannels bool
+	client   *apnsClient
+	channels ChannelStore
+	alerts   AlertConfig
+	ops      opsalert.Alerter
+
+	goneMu       sync.Mutex
+	goneChannels map[string]bool // channel IDs APNs answered 410 for; never pushed again
+	breakerAlert sync.Once
+
+	startTokens    *startTokenStore
+	guard          *clientapi.Guard // on push-to-start token registration
+	attributesType string
+	startedMu      sync.Mutex
+	// startedGames maps a game ID to when its push-to-start went out. A zero
+	// time marks a push in flight. Delivered games are also kept in
+	// startTokens, which seeds the map on startup.
+	startedGames map[string]time.Time
 }
 
 // New creates a LiveActivityNotifier from environment config.
@@ -58,17 +103,48 @@ func New() (*LiveActivityNotifier, error) {
 		return nil, fmt.Errorf("create APNs client: %w", err)
 	}
 
-	log.Printf("LiveActivity notifier initialized: host=%s topic=%s channels=%s", cfg.Host, cfg.Topic, channelEnvName(cfg.UseDevChannels))
-	return &LiveActivityNotifier{client: client, useDevChannels: cfg.UseDevChannels}, nil
+	channels, err := NewChannelStore(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("create channel store: %w", err)
+	}
+
+	if _, ok := channels.(builtinChannelStore); ok {
+		log.Printf("WARN: APNS_CHANNEL_STORE=builtin cannot persist channels APNs reports gone (410); " +
+			"they are pushed to again after every restart. Use file or redis to keep them inactive.")
+	}
+
+	tokens, err := newStartTokenStore(cfg.StartTokensFile)
+	if err != nil {
+		return nil, fmt.Errorf("load push-to-start tokens: %w", err)
+	}
+	tokens.max = cfg.MaxStartTokens
+
+	log.Printf("LiveActivity notifier initialized: host=%s topic=%s channels=%s store=%s alerts=%v sound=%q",
+		cfg.Host, cfg.Topic, channelEnvName(cfg.UseDevChannels), cfg.ChannelStore, cfg.AlertsEnabled, cfg.AlertSound)
+	return &LiveActivityNotifier{
+		client:         client,
+		channels:       channels,
+		alerts:         AlertConfig{Enabled: cfg.AlertsEnabled, Sound: cfg.AlertSound},
+		ops:            opsalert.FromEnv(),
+		startTokens:    tokens,
+		guard:          clientapi.FromEnv(),
+		attributesType: cfg.AttributesType,
+		startedGames:   tokens.StartedGames(),
+	}, nil
 }
 
 func (n *LiveActivityNotifier) GetRequiredDataKeys() []string {
 	return requiredDataKeys
 }
 
+// GetOptionalDataKeys implements notification.OptionalDataKeyProvider.
+func (n *LiveActivityNotifier) GetOptionalDataKeys() []string {
+	return optionalDataKeys
+}
+
 // FormatMessage builds the dispatch envelope (channels + APNs payload) as JSON.
 func (n *LiveActivityNotifier) FormatMessage(req NotificationRequest) string {
-	msg, err := BuildDispatchMessage(req, n.useDevChannels)
+	msg, err := BuildDispatchMessage(req, n.channels, n.alerts)
 	if err != nil {
 		log.Printf("ERROR: LiveActivity FormatMessage: %v", err)
 		return ""
@@ -77,6 +153,14 @@ func (n *LiveActivityNotifier) FormatMessage(req NotificationRequest) string {
 }
 
 // SendNotification parses the dispatch envelope from FormatMessage and pushes to all channels.
+// AcceptsMessage implements notification.MessageFilter: only dispatch
+// envelopes from FormatMessage are sent, so plain-text messages (scheduler
+// summaries, postponements) are skipped rather than counted as failures.
+func (n *LiveActivityNotifier) AcceptsMessage(message string) bool {
+	var env dispatchEnvelope
+	return json.Unmarshal([]byte(message), &env) == nil
+}
+
 func (n *LiveActivityNotifier) SendNotification(ctx context.Context, message string) (<-chan NotificationResult, error) {
 	resultChan := make(chan NotificationResult, 1)
 
@@ -95,13 +179,18 @@ func (n *LiveActivityNotifier) SendNotification(ctx context.Context, message str
 		return resultChan, fmt.Errorf("dispatch envelope has no channels")
 	}
 
-	payload := []byte(env.Payload)
-	channels := env.Channels
+	payloads := make(map[string][]byte, len(env.Channels))
+	for _, ch := range env.Channels {
+		payloads[ch] = env.Payload
+		if p, ok := env.ChannelPayloads[ch]; ok {
+			payloads[ch] = p
+		}
+	}
 	id := uuid.New().String()
 
 	go func() {
 		defer close(resultChan)
-		err := n.pushToAll(ctx, channels, payload)
+		err := n.pushEach(ctx, payloads)
 		resultChan <- NotificationResult{
 			ID:        id,
 			Success:   err == nil,
@@ -115,16 +204,26 @@ func (n *LiveActivityNotifier) SendNotification(ctx context.Context, message str
 
 // pushToAll pushes payload to all channels in parallel; returns first error encountered.
 func (n *LiveActivityNotifier) pushToAll(ctx context.Context, channels []string, payload []byte) error {
-	log.Printf("APNs dispatch: channels=%v payload=%s", channels, payload)
-	errs := make(chan error, len(channels))
+	payloads := make(map[string][]byte, len(channels))
 	for _, ch := range channels {
-		go func(ch string) {
+		payloads[ch] = payload
+	}
+	return n.pushEach(ctx, payloads)
+}
+
+// pushEach pushes each channel its own payload in parallel; returns first
+// error encountered.
+func (n *LiveActivityNotifier) pushEach(ctx context.Context, payloads map[string][]byte) error {
+	errs := make(chan error, len(payloads))
+	for ch, payload := range payloads {
+		log.Printf("APNs dispatch: channel=%s payload=%s", ch, payload)
+		go func(ch string, payload []byte) {
 			errs <- n.pushWithRetry(ctx, ch, payload)
-		}(ch)
+		}(ch, payload)
 	}
 
 	var firstErr error
-	for range channels {
+	for range payloads {
 		if err := <-errs; err != nil && firstErr == nil {
 			firstErr = err
 		}
@@ -133,6 +232,98 @@ func (n *LiveActivityNotifier) pushToAll(ctx context.Context, channels []string,
 }
 
 func (n *LiveActivityNotifier) pushWithRetry(ctx context.Context, channelToken string, payload []byte) error {
+	if n.isGone(channelToken) {
+		log.Printf("APNs push: channel %s is inactive (410 earlier), skipping", channelToken)
+		return nil
+	}
+	err := withRetry(ctx, "ch="+channelToken, func() error {
+		return n.client.Push(ctx, channelToken, payload)
+	})
+	switch {
+	case errors.Is(err, errChannelGone):
+		n.deactivateChannel(ctx, channelToken)
+		return nil
+	case errors.Is(err, errBreakerOpen):
+		n.alertBreakerOpen(ctx)
+	}
+	return err
+}
+
+func (n *LiveActivityNotifier) isGone(channelID string) bool {
+	n.goneMu.Lock()
+	defer n.goneMu.Unlock()
+	return n.goneChannels[channelID]
+}
+
+// deactivateChannel stops pushing to a channel APNs answered 410 for. This
+// process skips it from now on; the channel store records it as inactive so
+// other replicas and restarts skip it too (the builtin store is read-only, so
+// there only this process learns). Operators are alerted to run
+// `apnschannels sync`, which mints a replacement.
+func (n *LiveActivityNotifier) deactivateChannel(ctx context.Context, channelID string) {
+	n.goneMu.Lock()
+	if n.goneChannels[channelID] {
+		n.goneMu.Unlock()
+		return
+	}
+	if n.goneChannels == nil {
+		n.goneChannels = map[string]bool{}
+	}
+	n.goneChannels[channelID] = true
+	n.goneMu.Unlock()
+
+	team := n.teamForChannel(ctx, channelID)
+	log.Printf("APNs push: channel %s (team %q) is gone (410), marking inactive", channelID, team)
+	persisted := "recorded as inactive in the channel store"
+	if team == "" {
+		persisted = "no team maps to it in the channel store"
+	} else if err := n.channels.MarkInactive(ctx, team, channelID); err != nil {
+		log.Printf("WARN: mark channel %s inactive: %v", channelID, err)
+		persisted = fmt.Sprintf("could not record it in the channel store (%v), skipped by this process only until it restarts", err)
+	}
+
+	n.raiseOps(ctx, "Live Activity channel gone",
+		fmt.Sprintf("APNs answered 410 for broadcast channel %s (team %s); %s. Run `apnschannels sync` to create a replacement.",
+			channelID, team, persisted))
+}
+
+// teamForChannel returns the tricode whose channel is channelID, or "".
+func (n *LiveActivityNotifier) teamForChannel(ctx context.Context, channelID string) string {
+	all, err := n.channels.Channels(ctx)
+	if err != nil {
+		log.Printf("ERROR: list channels: %v", err)
+		return ""
+	}
+	for team, id := range all {
+		if id == channelID {
+			return team
+		}
+	}
+	return ""
+}
+
+// alertBreakerOpen raises the breaker alert once per notifier.
+func (n *LiveActivityNotifier) alertBreakerOpen(ctx context.Context) {
+	n.breakerAlert.Do(func() {
+		n.raiseOps(ctx, "Live Activity pushes disabled",
+			"APNs answered 403 BadEnvironmentKeyInToken: APNS_AUTH_KEY is not valid for APNS_HOST's environment. "+
+				"All Live Activity pushes are stopped until the config is fixed and the service restarted.")
+	})
+}
+
+func (n *LiveActivityNotifier) raiseOps(ctx context.Context, subject, detail string) {
+	ops := n.ops
+	if ops == nil {
+		ops = opsalert.Log
+	}
+	if err := ops.Alert(ctx, subject, detail); err != nil {
+		log.Printf("ERROR: ops alert %q: %v", subject, err)
+	}
+}
+
+// withRetry calls push up to maxRetries times with exponential backoff while it
+// returns retryable errors. target identifies the destination in log lines.
+func withRetry(ctx context.Context, target string, push func() error) error {
 	delay := retryDelay
 	var lastErr error
 
@@ -146,7 +337,7 @@ func (n *LiveActivityNotifier) pushWithRetry(ctx context.Context, channelToken s
 			}
 		}
 
-		err := n.client.Push(ctx, channelToken, payload)
+		err := push()
 		if err == nil {
 			return nil
 		}
@@ -154,11 +345,181 @@ func (n *LiveActivityNotifier) pushWithRetry(ctx context.Context, channelToken s
 			return err
 		}
 		lastErr = err
-		log.Printf("WARN: APNs retryable error ch=%s attempt=%d/%d: %v", channelToken, attempt+1, maxRetries, err)
+		log.Printf("WARN: APNs retryable error %s attempt=%d/%d: %v", target, attempt+1, maxRetries, err)
 	}
 	return fmt.Errorf("APNs push failed after %d attempts: %w", maxRetries, lastErr)
 }
 
+// Routes exposes the push-to-start token registration endpoint and the
+// team → channel map the app subscribes with.
+func (n *LiveActivityNotifier) Routes() map[string]http.Handler {
+	return map[string]http.Handler{
+		StartTokensPath: n.guard.Wrap(n.startTokens),
+		ChannelsPath:    http.HandlerFunc(n.serveChannels),
+	}
+}
+
+// ChannelsPath is the HTTP route returning the current team → channel map.
+const ChannelsPath = "/liveactivity/channels"
+
+// serveChannels lets the iOS app fetch channel IDs at runtime instead of
+// compiling them in, so a synced or rotated channel needs no app release.
+func (n *LiveActivityNotifier) serveChannels(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	channels, err := n.channels.Channels(r.Context())
+	if err != nil {
+		log.Printf("ERROR: list channels: %v", err)
+		http.Error(w, "failed to list channels", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(channels)
+}
+
+// NotifyGameStart sends an event:"start" push to every device following either
+// team. Once a push for a game ID has been delivered to at least one device,
+// later calls for it are no-ops; a game with no registered devices, or whose
+// pushes all failed or went to tokens APNs reports gone, is tried again on the
+// next poll.
+func (n *LiveActivityNotifier) NotifyGameStart(ctx context.Context, game models.Game, data map[string]string) error {
+	if !n.claimStart(game.ID) {
+		return nil
+	}
+	sent, gone := 0, 0
+	defer func() { n.finishStart(game.ID, sent > 0) }()
+
+	req := NotificationRequest{Data: data}
+	var errs []error
+	for _, team := range uniqueTeams(data["homeTeamAbbrev"], data["awayTeamAbbrev"]) {
+		tokens := n.startTokens.TokensForTeam(team)
+		if len(tokens) == 0 {
+			continue
+		}
+		channelID, ok := channelForTeam(n.channels, team)
+		if !ok {
+			log.Printf("WARN: no channel ID for team %s, skipping push-to-start", team)
+			continue
+		}
+		payload, err := BuildStartPayload(req, game.ID, n.attributesType, channelID)
+		if err != nil {
+			return err
+		}
+		for _, token := range tokens {
+			err := n.pushStartWithRetry(ctx, token, payload)
+			switch {
+			case errors.Is(err, errTokenGone):
+				gone++
+			case err != nil:
+				errs = append(errs, fmt.Errorf("token %s: %w", shortToken(token), err))
+			default:
+				sent++
+			}
+		}
+	}
+
+	log.Printf("Push-to-start for game %s: sent=%d gone=%d failed=%d", game.ID, sent, gone, len(errs))
+	return errors.Join(errs...)
+}
+
+// startedRetention is how long a game stays marked as started. Every game is
+// over well within it, so dropping older entries keeps a long-running
+// process from growing the map all season.
+const startedRetention = 24 * time.Hour
+
+// claimStart reports whether the caller should send push-to-start for gameID,
+// marking it in flight so a concurrent poll does not send it too. Entries of
+// games started more than startedRetention ago are pruned first.
+func (n *LiveActivityNotifier) claimStart(gameID string) bool {
+	n.startedMu.Lock()
+	defer n.startedMu.Unlock()
+	for id, at := range n.startedGames {
+		if !at.IsZero() && time.Since(at) > startedRetention {
+			delete(n.startedGames, id)
+		}
+	}
+	if _, ok := n.startedGames[gameID]; ok {
+		return false
+	}
+	n.startedGames[gameID] = time.Time{}
+	return true
+}
+
+// finishStart records the outcome of a claimed push-to-start: started when at
+// least one device got it, released for the next poll otherwise.
+func (n *LiveActivityNotifier) finishStart(gameID string, delivered bool) {
+	n.startedMu.Lock()
+	defer n.startedMu.Unlock()
+	if delivered {
+		now := time.Now()
+		n.startedGames[gameID] = now
+		if err := n.startTokens.MarkStarted(gameID, now); err != nil {
+			log.Printf("ERROR: save push-to-start of game %s: %v", gameID, err)
+		}
+	} else {
+		delete(n.startedGames, gameID)
+	}
+}
+
+// NotifyPregame implements notification.PregameNotifier: it broadcasts a
+// "Pregame" content state to both team channels.
+func (n *LiveActivityNotifier) NotifyPregame(ctx context.Context, game models.Game, preview models.GamePreview) error {
+	startUTC := preview.StartTimeUTC
+	if startUTC == "" {
+		startUTC = game.StartTime
+	}
+	start, err := time.Parse(time.RFC3339, startUTC)
+	if err != nil {
+		start = time.Now()
+	}
+
+	payload, err := BuildPregamePayload(game.HomeTeam.Abbrev, game.AwayTeam.Abbrev, start)
+	if err != nil {
+		return err
+	}
+	channels := channelsForTeams(n.channels, strings.ToUpper(game.HomeTeam.Abbrev), strings.ToUpper(game.AwayTeam.Abbrev))
+	if len(channels) == 0 {
+		return fmt.Errorf("no channel IDs registered for %s or %s", game.HomeTeam.Abbrev, game.AwayTeam.Abbrev)
+	}
+	return n.pushToAll(ctx, channels, payload)
+}
+
+// pushStartWithRetry pushes payload to token. A token APNs reports gone (410)
+// is removed from the store and errTokenGone returned, so the caller can skip
+// it without counting the game as started.
+func (n *LiveActivityNotifier) pushStartWithRetry(ctx context.Context, token string, payload []byte) error {
+	err := withRetry(ctx, "token="+shortToken(token), func() error {
+		return n.client.PushToDevice(ctx, token, payload)
+	})
+	if errors.Is(err, errTokenGone) {
+		log.Printf("Push-to-start token %s is no longer valid (410), removing", shortToken(token))
+		if rmErr := n.startTokens.Remove(token); rmErr != nil {
+			log.Printf("ERROR: remove push-to-start token: %v", rmErr)
+		}
+		return err
+	}
+	if errors.Is(err, errBreakerOpen) {
+		n.alertBreakerOpen(ctx)
+	}
+	return err
+}
+
+// uniqueTeams returns the uppercased, non-empty, distinct tricodes in order.
+func uniqueTeams(home, away string) []string {
+	var out []string
+	for _, t := range []string{home, away} {
+		t = strings.ToUpper(t)
+		if t == "" || (len(out) > 0 && out[0] == t) {
+			continue
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
 func (n *LiveActivityNotifier) Close() error {
 	return nil
 }
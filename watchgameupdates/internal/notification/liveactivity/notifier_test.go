@@ -2,9 +2,15 @@ package liveactivity
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -85,6 +91,38 @@ func TestSendNotification_MultipleChannels(t *testing.T) {
 	}
 }
 
+func TestSendNotification_ChannelPayloadOverride(t *testing.T) {
+	var mu sync.Mutex
+	bodies := map[string]string{}
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies[r.Header.Get("apns-channel-id")] = string(body)
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	env, _ := json.Marshal(dispatchEnvelope{
+		Channels:        []string{"chan-1", "chan-2"},
+		Payload:         json.RawMessage(`{"aps":{"alert":{"title":"Goal!"}}}`),
+		ChannelPayloads: map[string]json.RawMessage{"chan-2": json.RawMessage(`{"aps":{}}`)},
+	})
+	ch, err := testNotifier(t, srv).SendNotification(context.Background(), string(env))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result := <-ch; !result.Success {
+		t.Fatalf("expected Success=true, got error: %v", result.Error)
+	}
+	if !strings.Contains(bodies["chan-1"], "Goal!") {
+		t.Errorf("chan-1 body = %s, want the shared payload", bodies["chan-1"])
+	}
+	if bodies["chan-2"] != `{"aps":{}}` {
+		t.Errorf("chan-2 body = %s, want its override", bodies["chan-2"])
+	}
+}
+
 func TestPushWithRetry_NonRetryableStopsImmediately(t *testing.T) {
 	calls := 0
 	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -176,3 +214,158 @@ func TestIsRetryable(t *testing.T) {
 		})
 	}
 }
+
+// recordingAlerter captures ops alerts.
+type recordingAlerter struct {
+	mu       sync.Mutex
+	subjects []string
+}
+
+func (a *recordingAlerter) Alert(_ context.Context, subject, _ string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.subjects = append(a.subjects, subject)
+	return nil
+}
+
+func (a *recordingAlerter) count() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.subjects)
+}
+
+func apnsReasonHandler(status int, reason string, calls *int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		w.WriteHeader(status)
+		fmt.Fprintf(w, `{"reason":%q}`, reason)
+	}
+}
+
+func TestPushWithRetry_GoneChannelMarkedInactive(t *testing.T) {
+	calls := 0
+	srv := httptest.NewTLSServer(apnsReasonHandler(http.StatusGone, "ChannelNotRegistered", &calls))
+	defer srv.Close()
+
+	ctx := context.Background()
+	store := newFileChannelStore(filepath.Join(t.TempDir(), "channels.json"), "production")
+	store.SetChannel(ctx, "BOS", "chan-BOS")
+	alerts := &recordingAlerter{}
+	n := testNotifier(t, srv)
+	n.channels = store
+	n.ops = alerts
+
+	for i := 0; i < 2; i++ {
+		if err := n.pushWithRetry(ctx, "chan-BOS", []byte(`{}`)); err != nil {
+			t.Fatalf("push %d: want 410 handled without error, got %v", i, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("APNs calls = %d, want 1 (gone channel must not be pushed again)", calls)
+	}
+	if alerts.count() != 1 {
+		t.Errorf("ops alerts = %v, want exactly one", alerts.subjects)
+	}
+	if id, _ := store.Channel(ctx, "BOS"); id != "" {
+		t.Errorf("active BOS channel = %q, want removed", id)
+	}
+	if inactive, _ := store.InactiveChannels(ctx); inactive["BOS"] != "chan-BOS" {
+		t.Errorf("inactive channels = %v, want BOS → chan-BOS", inactive)
+	}
+}
+
+func TestSend_ExpiredProviderTokenRetriesWithFreshToken(t *testing.T) {
+	var auths []string
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auths = append(auths, r.Header.Get("authorization"))
+		if len(auths) == 1 {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"reason":"ExpiredProviderToken"}`)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	if err := testNotifier(t, srv).pushWithRetry(context.Background(), "chan-1", []byte(`{}`)); err != nil {
+		t.Fatalf("want success after re-signing, got %v", err)
+	}
+	if len(auths) != 2 || auths[0] == auths[1] {
+		t.Errorf("want 2 requests with different tokens, got %d", len(auths))
+	}
+}
+
+func TestSend_ExpiredProviderTokenRetriesOnlyOnce(t *testing.T) {
+	calls := 0
+	srv := httptest.NewTLSServer(apnsReasonHandler(http.StatusForbidden, "ExpiredProviderToken", &calls))
+	defer srv.Close()
+
+	err := testNotifier(t, srv).pushWithRetry(context.Background(), "chan-1", []byte(`{}`))
+	if err == nil || !strings.Contains(err.Error(), "ExpiredProviderToken") {
+		t.Errorf("err = %v, want the ExpiredProviderToken reason", err)
+	}
+	if calls != 2 {
+		t.Errorf("calls = %d, want 2 (one re-signed retry)", calls)
+	}
+}
+
+func TestSend_BadEnvironmentKeyTripsBreaker(t *testing.T) {
+	calls := 0
+	srv := httptest.NewTLSServer(apnsReasonHandler(http.StatusForbidden, "BadEnvironmentKeyInToken", &calls))
+	defer srv.Close()
+
+	alerts := &recordingAlerter{}
+	n := testNotifier(t, srv)
+	n.ops = alerts
+	ctx := context.Background()
+
+	if err := n.pushWithRetry(ctx, "chan-1", []byte(`{}`)); !errors.Is(err, errBreakerOpen) {
+		t.Fatalf("err = %v, want errBreakerOpen", err)
+	}
+	if err := n.pushWithRetry(ctx, "chan-2", []byte(`{}`)); !errors.Is(err, errBreakerOpen) {
+		t.Errorf("second push err = %v, want errBreakerOpen", err)
+	}
+	if err := n.pushStartWithRetry(ctx, "abcdef0123456789", []byte(`{}`)); !errors.Is(err, errBreakerOpen) {
+		t.Errorf("push-to-start err = %v, want errBreakerOpen", err)
+	}
+	if calls != 1 {
+		t.Errorf("APNs calls = %d, want 1 (breaker short-circuits later pushes)", calls)
+	}
+	if alerts.count() != 1 {
+		t.Errorf("ops alerts = %v, want exactly one", alerts.subjects)
+	}
+}
+
+func TestResponseReason(t *testing.T) {
+	tests := []struct {
+		body string
+		want string
+	}{
+		{`{"reason":"BadDeviceToken"}`, "BadDeviceToken"},
+		{`{"reason":"Unregistered","timestamp":1700000000000}`, "Unregistered"},
+		{"upstream exploded\n", "upstream exploded"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := responseReason([]byte(tt.body)); got != tt.want {
+			t.Errorf("responseReason(%q) = %q, want %q", tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestAcceptsMessage_OnlyEnvelopes(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	n := testNotifier(t, srv)
+	for _, msg := range []string{"", "Scheduled 3 games for 2025-01-01", "Game 2025020001 was postponed"} {
+		if n.AcceptsMessage(msg) {
+			t.Errorf("AcceptsMessage(%q) = true, want false", msg)
+		}
+	}
+	if msg := testEnvelope(t, []string{"chan-1"}); !n.AcceptsMessage(msg) {
+		t.Errorf("AcceptsMessage(envelope) = false, want true")
+	}
+}
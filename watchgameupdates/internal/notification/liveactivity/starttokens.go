@@ -0,0 +1,274 @@
+package liveactivity
+
+// Push-to-start token registry.
+//
+// The iOS app receives a push-to-start token from ActivityKit
+// (Activity.pushToStartTokenUpdates) and registers it here together with the
+// teams the user follows:
+//
+//   POST   /liveactivity/start-tokens  {"token":"<hex>","teams":["BOS","NYR"]}
+//   DELETE /liveactivity/start-tokens  {"token":"<hex>"}
+//
+// When a tracked game begins, every token following either team receives an
+// event:"start" push. Re-registering a token replaces its team list, so the app
+// can simply POST its current follows on every launch.
+//
+// The store holds at most APNS_MAX_START_TOKENS tokens, and the route is
+// behind a clientapi.Guard. It also remembers which games push-to-start went
+// out for, so a restart does not start the same activities again.
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"watchgameupdates/internal/teams"
+)
+
+// StartTokensPath is the HTTP route for push-to-start token registration.
+const StartTokensPath = "/liveactivity/start-tokens"
+
+// Push-to-start tokens are hex strings: 64 characters today, up to 200 by
+// Apple's documentation. Anything else is refused, as the token becomes part of
+// the APNs request path.
+const (
+	minStartTokenLen = 64
+	maxStartTokenLen = 200
+)
+
+// defaultMaxStartTokens caps the store when APNS_MAX_START_TOKENS is unset.
+const defaultMaxStartTokens = 100000
+
+// errStoreFull is returned by Register for a new token once the store holds
+// its maximum.
+var errStoreFull = errors.New("push-to-start token limit reached")
+
+// startTokenStore maps push-to-start tokens to the teams they follow.
+// It is safe for concurrent use. When path is set, every change is written
+// through to a JSON file so registrations survive restarts:
+//
+//	{"tokens": {"<hex>": ["BOS", "NYR"]}, "started": {"2025020001": "<RFC 3339 time>"}}
+//
+// Files holding only the token map, as written before started games were
+// kept, still load.
+type startTokenStore struct {
+	mu      sync.RWMutex
+	tokens  map[string][]string  // token → followed team tricodes
+	started map[string]time.Time // game ID → when its push-to-start was delivered
+	path    string
+	max     int
+}
+
+// startTokenFile is the JSON file of a startTokenStore.
+type startTokenFile struct {
+	Tokens  map[string][]string  `json:"tokens"`
+	Started map[string]time.Time `json:"started,omitempty"`
+}
+
+func newStartTokenStore(path string) (*startTokenStore, error) {
+	s := &startTokenStore{
+		tokens:  map[string][]string{},
+		started: map[string]time.Time{},
+		path:    path,
+		max:     defaultMaxStartTokens,
+	}
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read start tokens file %s: %w", path, err)
+	}
+	var file startTokenFile
+	if err := json.Unmarshal(data, &file); err == nil && file.Tokens != nil {
+		s.tokens = file.Tokens
+		if file.Started != nil {
+			s.started = file.Started
+		}
+		return s, nil
+	}
+	if err := json.Unmarshal(data, &s.tokens); err != nil {
+		return nil, fmt.Errorf("parse start tokens file %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Register sets the followed teams for token, replacing any previous list. A
+// new token is refused with errStoreFull once the store is at its maximum.
+func (s *startTokenStore) Register(token string, teams []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.tokens[token]; !ok && len(s.tokens) >= s.max {
+		return errStoreFull
+	}
+	s.tokens[token] = teams
+	return s.saveLocked()
+}
+
+// Remove forgets token. Removing an unknown token is not an error.
+func (s *startTokenStore) Remove(token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.tokens[token]; !ok {
+		return nil
+	}
+	delete(s.tokens, token)
+	return s.saveLocked()
+}
+
+// TokensForTeam returns every token following tricode, sorted for stable output.
+func (s *startTokenStore) TokensForTeam(tricode string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out []string
+	for token, teams := range s.tokens {
+		for _, t := range teams {
+			if t == tricode {
+				out = append(out, token)
+				break
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// StartedGames returns a copy of the games push-to-start was delivered for.
+func (s *startTokenStore) StartedGames() map[string]time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make(map[string]time.Time, len(s.started))
+	for id, at := range s.started {
+		out[id] = at
+	}
+	return out
+}
+
+// MarkStarted records that push-to-start for gameID was delivered at at.
+// Games started more than startedRetention ago are dropped.
+func (s *startTokenStore) MarkStarted(gameID string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, t := range s.started {
+		if at.Sub(t) > startedRetention {
+			delete(s.started, id)
+		}
+	}
+	s.started[gameID] = at
+	return s.saveLocked()
+}
+
+func (s *startTokenStore) saveLocked() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(startTokenFile{Tokens: s.tokens, Started: s.started})
+	if err != nil {
+		return fmt.Errorf("marshal start tokens: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("write start tokens file: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
+
+type startTokenRequest struct {
+	Token string   `json:"token"`
+	Teams []string `json:"teams"`
+}
+
+// ServeHTTP implements the registration endpoint described at the top of this file.
+func (s *startTokenStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		w.Header().Set("Allow", "POST, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req startTokenRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.Token = strings.TrimSpace(req.Token)
+	if req.Token == "" {
+		http.Error(w, "token is required", http.StatusBadRequest)
+		return
+	}
+	if !validStartToken(req.Token) {
+		http.Error(w, fmt.Sprintf("token must be %d-%d hex characters", minStartTokenLen, maxStartTokenLen), http.StatusBadRequest)
+		return
+	}
+
+	if r.Method == http.MethodDelete {
+		if err := s.Remove(req.Token); err != nil {
+			log.Printf("ERROR: remove push-to-start token: %v", err)
+			http.Error(w, "failed to remove token", http.StatusInternalServerError)
+			return
+		}
+		log.Printf("Push-to-start token %s unregistered", shortToken(req.Token))
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	teams, err := normalizeTeams(req.Teams)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := s.Register(req.Token, teams); errors.Is(err, errStoreFull) {
+		log.Printf("WARN: push-to-start token %s refused: %d tokens stored", shortToken(req.Token), s.max)
+		http.Error(w, "token limit reached", http.StatusServiceUnavailable)
+		return
+	} else if err != nil {
+		log.Printf("ERROR: register push-to-start token: %v", err)
+		http.Error(w, "failed to register token", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("Push-to-start token %s registered for teams %v", shortToken(req.Token), teams)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// validStartToken reports whether token looks like an APNs device token.
+func validStartToken(token string) bool {
+	if len(token) < minStartTokenLen || len(token) > maxStartTokenLen {
+		return false
+	}
+	_, err := hex.DecodeString(token)
+	return err == nil
+}
+
+// normalizeTeams uppercases and deduplicates tricodes, rejecting any team that
+// is not part of the channel roster.
+func normalizeTeams(raw []string) ([]string, error) {
+	seen := map[string]bool{}
+	var out []string
+	for _, t := range raw {
+		tri := strings.ToUpper(strings.TrimSpace(t))
+		if tri == "" || seen[tri] {
+			continue
+		}
+		if !teams.IsKnown(tri) {
+			return nil, fmt.Errorf("unknown team %q", t)
+		}
+		seen[tri] = true
+		out = append(out, tri)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("at least one team is required")
+	}
+	return out, nil
+}
@@ -0,0 +1,402 @@
+package liveactivity
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"watchgameupdates/internal/models"
+)
+
+func TestStartTokenStore_RegisterReplacesTeams(t *testing.T) {
+	s, _ := newStartTokenStore("")
+	s.Register("tok-1", []string{"BOS", "NYR"})
+	s.Register("tok-1", []string{"TOR"})
+
+	if got := s.TokensForTeam("BOS"); len(got) != 0 {
+		t.Errorf("want no BOS tokens after re-register, got %v", got)
+	}
+	if got := s.TokensForTeam("TOR"); len(got) != 1 || got[0] != "tok-1" {
+		t.Errorf("want [tok-1] for TOR, got %v", got)
+	}
+}
+
+func TestStartTokenStore_PersistsToFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	s, err := newStartTokenStore(path)
+	if err != nil {
+		t.Fatalf("newStartTokenStore: %v", err)
+	}
+	if err := s.Register("tok-1", []string{"BOS"}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	reloaded, err := newStartTokenStore(path)
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if got := reloaded.TokensForTeam("BOS"); len(got) != 1 {
+		t.Errorf("want token to survive reload, got %v", got)
+	}
+}
+
+func TestStartTokenStore_PersistsStartedGames(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	s, err := newStartTokenStore(path)
+	if err != nil {
+		t.Fatalf("newStartTokenStore: %v", err)
+	}
+	now := time.Now()
+	s.MarkStarted("old", now.Add(-startedRetention-time.Hour))
+	if err := s.MarkStarted("2025020001", now); err != nil {
+		t.Fatalf("MarkStarted: %v", err)
+	}
+
+	reloaded, err := newStartTokenStore(path)
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	started := reloaded.StartedGames()
+	if at, ok := started["2025020001"]; !ok || !at.Equal(now) {
+		t.Errorf("started games = %v, want 2025020001 at %v", started, now)
+	}
+	if _, ok := started["old"]; ok {
+		t.Errorf("game started more than %s ago was kept", startedRetention)
+	}
+}
+
+func TestStartTokenStore_LoadsTokenOnlyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	if err := os.WriteFile(path, []byte(`{"`+hexToken1+`": ["BOS"]}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	s, err := newStartTokenStore(path)
+	if err != nil {
+		t.Fatalf("newStartTokenStore: %v", err)
+	}
+	if got := s.TokensForTeam("BOS"); len(got) != 1 || got[0] != hexToken1 {
+		t.Errorf("tokens for BOS = %v, want the token from the old file", got)
+	}
+	if err := s.MarkStarted("2025020001", time.Now()); err != nil {
+		t.Errorf("MarkStarted on an old file: %v", err)
+	}
+}
+
+// Device tokens as the app posts them: 64 hex characters.
+var (
+	hexToken1 = strings.Repeat("ab", 32)
+	hexToken2 = strings.Repeat("cd", 32)
+)
+
+func TestStartTokenHandler(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		want   int
+	}{
+		{"register", http.MethodPost, `{"token":"` + hexToken1 + `","teams":["bos","NYR"]}`, http.StatusNoContent},
+		{"unregister", http.MethodDelete, `{"token":"` + hexToken1 + `"}`, http.StatusNoContent},
+		{"missing token", http.MethodPost, `{"teams":["BOS"]}`, http.StatusBadRequest},
+		{"no teams", http.MethodPost, `{"token":"` + hexToken1 + `","teams":[]}`, http.StatusBadRequest},
+		{"unknown team", http.MethodPost, `{"token":"` + hexToken1 + `","teams":["XYZ"]}`, http.StatusBadRequest},
+		{"short token", http.MethodPost, `{"token":"abc","teams":["BOS"]}`, http.StatusBadRequest},
+		{"long token", http.MethodPost, `{"token":"` + strings.Repeat("ab", 101) + `","teams":["BOS"]}`, http.StatusBadRequest},
+		{"path in token", http.MethodPost, `{"token":"` + hexToken1 + `/../../x","teams":["BOS"]}`, http.StatusBadRequest},
+		{"query in token", http.MethodPost, `{"token":"` + hexToken1 + `?x=1","teams":["BOS"]}`, http.StatusBadRequest},
+		{"unregister non-hex token", http.MethodDelete, `{"token":"` + strings.Repeat("zz", 32) + `"}`, http.StatusBadRequest},
+		{"bad json", http.MethodPost, `not-json`, http.StatusBadRequest},
+		{"wrong method", http.MethodGet, ``, http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newStartTokenStore("")
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, httptest.NewRequest(tt.method, StartTokensPath, strings.NewReader(tt.body)))
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d (body %q)", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestStartTokenHandler_RefusesNewTokensWhenFull(t *testing.T) {
+	s, _ := newStartTokenStore("")
+	s.max = 1
+	post := func(body string) int {
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, StartTokensPath, strings.NewReader(body)))
+		return rec.Code
+	}
+
+	if got := post(`{"token":"` + hexToken1 + `","teams":["BOS"]}`); got != http.StatusNoContent {
+		t.Fatalf("first token: status %d, want %d", got, http.StatusNoContent)
+	}
+	if got := post(`{"token":"` + hexToken2 + `","teams":["BOS"]}`); got != http.StatusServiceUnavailable {
+		t.Errorf("second token: status %d, want %d", got, http.StatusServiceUnavailable)
+	}
+	if got := post(`{"token":"` + hexToken1 + `","teams":["NYR"]}`); got != http.StatusNoContent {
+		t.Errorf("re-registering a stored token: status %d, want %d", got, http.StatusNoContent)
+	}
+	if got := s.TokensForTeam("NYR"); len(got) != 1 || len(s.tokens) != 1 {
+		t.Errorf("tokens = %v, want only the first token following NYR", s.tokens)
+	}
+}
+
+func TestStartTokenHandler_NormalizesTeams(t *testing.T) {
+	s, _ := newStartTokenStore("")
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, StartTokensPath,
+		strings.NewReader(`{"token":"`+hexToken1+`","teams":[" bos ","BOS","nyr"]}`)))
+
+	if got := s.TokensForTeam("BOS"); len(got) != 1 {
+		t.Errorf("want lowercase/padded bos registered as BOS, got %v", got)
+	}
+	if got := s.tokens[hexToken1]; len(got) != 2 {
+		t.Errorf("want duplicates collapsed to 2 teams, got %v", got)
+	}
+}
+
+// startRequest is one push-to-start request captured by the HTTP/2 stand-in.
+type startRequest struct {
+	path    string
+	proto   int
+	headers http.Header
+	payload apnsPayload
+}
+
+func TestNotifyGameStart_PushesToFollowingDevices(t *testing.T) {
+	var mu sync.Mutex
+	var reqs []startRequest
+	srv := newHTTP2Server(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var p apnsPayload
+		json.Unmarshal(body, &p)
+		mu.Lock()
+		reqs = append(reqs, startRequest{path: r.URL.Path, proto: r.ProtoMajor, headers: r.Header, payload: p})
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	n := testNotifier(t, srv)
+	n.startTokens.Register("tok-bos", []string{"BOS"})
+	n.startTokens.Register("tok-tor", []string{"TOR"})
+
+	withChannels(t, map[string]string{"BOS": "chan-BOS", "NYR": "chan-NYR"}, false, func() {
+		game := models.Game{ID: "2025020001"}
+		data := map[string]string{"homeTeamAbbrev": "BOS", "awayTeamAbbrev": "NYR", "gameState": ""}
+		if err := n.NotifyGameStart(context.Background(), game, data); err != nil {
+			t.Fatalf("NotifyGameStart: %v", err)
+		}
+	})
+
+	if len(reqs) != 1 {
+		t.Fatalf("want 1 push (only the BOS follower), got %d", len(reqs))
+	}
+	got := reqs[0]
+	if got.proto != 2 {
+		t.Errorf("want HTTP/2 request, got HTTP/%d", got.proto)
+	}
+	if got.path != "/3/device/tok-bos" {
+		t.Errorf("path = %q, want /3/device/tok-bos", got.path)
+	}
+	if got.headers.Get("apns-push-type") != "liveactivity" {
+		t.Errorf("apns-push-type = %q, want liveactivity", got.headers.Get("apns-push-type"))
+	}
+	if got.headers.Get("apns-topic") != "me.test.app.push-type.liveactivity" {
+		t.Errorf("apns-topic = %q", got.headers.Get("apns-topic"))
+	}
+	if got.headers.Get("apns-channel-id") != "" {
+		t.Error("device push must not carry apns-channel-id")
+	}
+
+	aps := got.payload.APS
+	if aps.Event != "start" {
+		t.Errorf("event = %q, want start", aps.Event)
+	}
+	if aps.InputPushChannel != "chan-BOS" {
+		t.Errorf("input-push-channel = %q, want chan-BOS", aps.InputPushChannel)
+	}
+	if aps.AttributesType != defaultAttributesType {
+		t.Errorf("attributes-type = %q, want %q", aps.AttributesType, defaultAttributesType)
+	}
+	if aps.Attributes == nil || aps.Attributes.GameID != "2025020001" || aps.Attributes.HomeTeam != "BOS" {
+		t.Errorf("unexpected attributes: %+v", aps.Attributes)
+	}
+	if aps.Alert == nil {
+		t.Error("push-to-start needs an alert to be displayed")
+	}
+	if aps.ContentState.HomeTeam != "BOS" || aps.ContentState.AwayTeam != "NYR" {
+		t.Errorf("unexpected content-state teams: %+v", aps.ContentState)
+	}
+}
+
+func TestNotifyGameStart_OncePerGame(t *testing.T) {
+	calls := 0
+	srv := newHTTP2Server(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	n := testNotifier(t, srv)
+	n.startTokens.Register("tok-bos", []string{"BOS"})
+
+	withChannels(t, map[string]string{"BOS": "chan-BOS"}, false, func() {
+		game := models.Game{ID: "2025020001"}
+		data := map[string]string{"homeTeamAbbrev": "BOS", "awayTeamAbbrev": "NYR"}
+		n.NotifyGameStart(context.Background(), game, data)
+		n.NotifyGameStart(context.Background(), game, data)
+	})
+
+	if calls != 1 {
+		t.Errorf("want 1 push across repeated polls, got %d", calls)
+	}
+}
+
+func TestNotifyGameStart_GoneTokenIsRemoved(t *testing.T) {
+	var pushed []string
+	srv := newHTTP2Server(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pushed = append(pushed, r.URL.Path)
+		if strings.HasSuffix(r.URL.Path, "/tok-gone") {
+			w.WriteHeader(http.StatusGone)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	n := testNotifier(t, srv)
+	n.startTokens.Register("tok-gone", []string{"BOS"})
+
+	withChannels(t, map[string]string{"BOS": "chan-BOS"}, false, func() {
+		game := models.Game{ID: "1"}
+		data := map[string]string{"homeTeamAbbrev": "BOS", "awayTeamAbbrev": "NYR"}
+		if err := n.NotifyGameStart(context.Background(), game, data); err != nil {
+			t.Errorf("410 should not surface as an error, got %v", err)
+		}
+		if got := n.startTokens.TokensForTeam("BOS"); len(got) != 0 {
+			t.Errorf("want gone token removed, still have %v", got)
+		}
+
+		// Nothing was delivered, so the game is not started yet.
+		n.startTokens.Register("tok-new", []string{"BOS"})
+		n.NotifyGameStart(context.Background(), game, data)
+	})
+
+	if len(pushed) != 2 || !strings.HasSuffix(pushed[1], "/tok-new") {
+		t.Errorf("pushes = %v, want the gone token and then the new one", pushed)
+	}
+}
+
+func TestNotifyGameStart_RetriesAfterEveryPushFailed(t *testing.T) {
+	calls := 0
+	srv := newHTTP2Server(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"reason":"BadDeviceToken"}`))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	n := testNotifier(t, srv)
+	n.startTokens.Register("tok-bos", []string{"BOS"})
+
+	withChannels(t, map[string]string{"BOS": "chan-BOS"}, false, func() {
+		game := models.Game{ID: "2025020001"}
+		data := map[string]string{"homeTeamAbbrev": "BOS", "awayTeamAbbrev": "NYR"}
+		if err := n.NotifyGameStart(context.Background(), game, data); err == nil {
+			t.Error("want the failed push reported")
+		}
+		if err := n.NotifyGameStart(context.Background(), game, data); err != nil {
+			t.Errorf("second poll: %v", err)
+		}
+		n.NotifyGameStart(context.Background(), game, data)
+	})
+
+	if calls != 2 {
+		t.Errorf("want the failed push retried once and then no more, got %d pushes", calls)
+	}
+}
+
+func TestNotifyGameStart_NoDevicesDoesNotMarkStarted(t *testing.T) {
+	calls := 0
+	srv := newHTTP2Server(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	n := testNotifier(t, srv)
+	withChannels(t, map[string]string{"BOS": "chan-BOS"}, false, func() {
+		game := models.Game{ID: "2025020001"}
+		data := map[string]string{"homeTeamAbbrev": "BOS", "awayTeamAbbrev": "NYR"}
+		n.NotifyGameStart(context.Background(), game, data)
+
+		// A fan registers after the first poll of the game.
+		n.startTokens.Register("tok-bos", []string{"BOS"})
+		n.NotifyGameStart(context.Background(), game, data)
+	})
+
+	if calls != 1 {
+		t.Errorf("want the device registered mid-game to get push-to-start, got %d pushes", calls)
+	}
+}
+
+func TestNotifyGameStart_OncePerGameAcrossRestarts(t *testing.T) {
+	calls := 0
+	srv := newHTTP2Server(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusOK)
+	}))
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	newNotifier := func() *LiveActivityNotifier {
+		tokens, err := newStartTokenStore(path)
+		if err != nil {
+			t.Fatalf("newStartTokenStore: %v", err)
+		}
+		n := testNotifier(t, srv)
+		n.startTokens = tokens
+		n.startedGames = tokens.StartedGames()
+		return n
+	}
+
+	first := newNotifier()
+	first.startTokens.Register("tok-bos", []string{"BOS"})
+	withChannels(t, map[string]string{"BOS": "chan-BOS"}, false, func() {
+		game := models.Game{ID: "2025020001"}
+		data := map[string]string{"homeTeamAbbrev": "BOS", "awayTeamAbbrev": "NYR"}
+		first.NotifyGameStart(context.Background(), game, data)
+		newNotifier().NotifyGameStart(context.Background(), game, data)
+	})
+
+	if calls != 1 {
+		t.Errorf("want 1 push across a restart, got %d", calls)
+	}
+}
+
+func TestClaimStart_PrunesOldGames(t *testing.T) {
+	n := &LiveActivityNotifier{startedGames: map[string]time.Time{
+		"old":       time.Now().Add(-startedRetention - time.Minute),
+		"recent":    time.Now().Add(-time.Hour),
+		"in-flight": {},
+	}}
+
+	if !n.claimStart("new") {
+		t.Fatal("claimStart refused a game that never started")
+	}
+	if n.claimStart("recent") || n.claimStart("in-flight") {
+		t.Error("claimStart allowed a second push-to-start")
+	}
+	if _, ok := n.startedGames["old"]; ok {
+		t.Errorf("entry older than %s was not pruned", startedRetention)
+	}
+}
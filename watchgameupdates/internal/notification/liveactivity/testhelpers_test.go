@@ -8,6 +8,7 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"encoding/pem"
+	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
@@ -43,9 +44,23 @@ func testNotifier(t *testing.T, srv *httptest.Server) *LiveActivityNotifier {
 			host:     srv.Listener.Addr().String(),
 			bundleID: "me.test.app",
 		},
+		channels:       builtinChannelStore{},
+		startTokens:    &startTokenStore{tokens: map[string][]string{}, started: map[string]time.Time{}, max: defaultMaxStartTokens},
+		attributesType: defaultAttributesType,
+		startedGames:   map[string]time.Time{},
 	}
 }
 
+// newHTTP2Server starts a TLS test server that negotiates HTTP/2, like APNs.
+func newHTTP2Server(t *testing.T, h http.Handler) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewUnstartedServer(h)
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
 // testEnvelope builds a minimal valid dispatch envelope JSON string.
 func testEnvelope(t *testing.T, channels []string) string {
 	t.Helper()
@@ -10,7 +10,10 @@ import (
 	"strings"
 
 	"watchgameupdates/internal/notification"
+	"watchgameupdates/internal/notification/fcm"
+	"watchgameupdates/internal/notification/gamestream"
 	"watchgameupdates/internal/notification/liveactivity"
+	"watchgameupdates/internal/notification/webpush"
 )
 
 // New returns a notification.Service populated according to the NOTIFIERS env var.
@@ -19,9 +22,14 @@ import (
 func New(shouldNotify bool) *notification.Service {
 	svc := notification.NewServiceWithNotificationFlag(shouldNotify)
 
+	// The game state API is not a push channel; it is always on.
+	if n := tryGameStream(); n != nil {
+		svc.RegisterNotifier(n)
+	}
+
 	raw := os.Getenv("NOTIFIERS")
 	if raw == "" {
-		log.Printf("NOTIFIERS not set; no notifiers will be registered")
+		log.Printf("NOTIFIERS not set; no push notifiers will be registered")
 		return svc
 	}
 
@@ -36,6 +44,14 @@ func New(shouldNotify bool) *notification.Service {
 			if n := tryLiveActivity(); n != nil {
 				svc.RegisterNotifier(n)
 			}
+		case "fcm":
+			if n := tryFCM(); n != nil {
+				svc.RegisterNotifier(n)
+			}
+		case "webpush":
+			if n := tryWebPush(); n != nil {
+				svc.RegisterNotifier(n)
+			}
 		default:
 			log.Printf("Unknown notifier %q in NOTIFIERS; skipping", name)
 		}
@@ -44,6 +60,16 @@ func New(shouldNotify bool) *notification.Service {
 	return svc
 }
 
+func tryGameStream() notification.Notifier {
+	n, err := gamestream.New()
+	if err != nil {
+		log.Printf("GameStream not configured: %v", err)
+		return nil
+	}
+	log.Printf("GameStream registered")
+	return n
+}
+
 func tryDiscord() notification.Notifier {
 	cfg, err := notification.LoadDiscordConfigFromEnv()
 	if err != nil {
@@ -68,3 +94,23 @@ func tryLiveActivity() notification.Notifier {
 	log.Printf("LiveActivity notifier registered")
 	return n
 }
+
+func tryFCM() notification.Notifier {
+	n, err := fcm.New()
+	if err != nil {
+		log.Printf("FCM notifier not configured: %v", err)
+		return nil
+	}
+	log.Printf("FCM notifier registered")
+	return n
+}
+
+func tryWebPush() notification.Notifier {
+	n, err := webpush.New()
+	if err != nil {
+		log.Printf("WebPush notifier not configured: %v", err)
+		return nil
+	}
+	log.Printf("WebPush notifier registered")
+	return n
+}
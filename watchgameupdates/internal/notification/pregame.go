@@ -0,0 +1,87 @@
+package notification
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"watchgameupdates/internal/models"
+)
+
+// FormatPregameMessage builds the plain-text game-starting-soon message: the
+// countdown to puck drop, then the playoff series, records, probable goalies,
+// venue and broadcasts when the preview has them.
+func FormatPregameMessage(game models.Game, preview models.GamePreview, now time.Time) string {
+	away, home := game.AwayTeam.Abbrev, game.HomeTeam.Abbrev
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "🏒 %s @ %s", away, home)
+	startUTC := preview.StartTimeUTC
+	if startUTC == "" {
+		startUTC = game.StartTime
+	}
+	if start, err := time.Parse(time.RFC3339, startUTC); err == nil {
+		fmt.Fprintf(&b, " — %s (%s UTC)", countdown(start.Sub(now)), start.UTC().Format("3:04 PM"))
+	} else {
+		b.WriteString(" starts soon")
+	}
+	b.WriteString("\n")
+
+	series := preview.SeriesStatus
+	if series == nil {
+		series = game.Series
+	}
+	if series != nil {
+		fmt.Fprintf(&b, "• %s\n", seriesLine(*series))
+	}
+	if preview.AwayTeam.Record != "" && preview.HomeTeam.Record != "" {
+		fmt.Fprintf(&b, "• Records: %s %s, %s %s\n", away, preview.AwayTeam.Record, home, preview.HomeTeam.Record)
+	}
+	if homeGoalie, awayGoalie := preview.Goalies(); homeGoalie != "" || awayGoalie != "" {
+		fmt.Fprintf(&b, "• Probable goalies: %s (%s), %s (%s)\n", orTBD(awayGoalie), away, orTBD(homeGoalie), home)
+	}
+	if preview.Venue.Default != "" {
+		fmt.Fprintf(&b, "• Venue: %s\n", preview.Venue.Default)
+	}
+	if len(preview.TVBroadcasts) > 0 {
+		networks := make([]string, 0, len(preview.TVBroadcasts))
+		for _, tv := range preview.TVBroadcasts {
+			networks = append(networks, tv.Network)
+		}
+		fmt.Fprintf(&b, "• TV: %s\n", strings.Join(networks, ", "))
+	}
+	return b.String()
+}
+
+// seriesLine describes a playoff series before a game: "Game 3, BOS leads
+// 2-1", with the round when known.
+func seriesLine(series models.SeriesStatus) string {
+	line := series.Summary()
+	if series.GameNumberOfSeries > 0 {
+		line = fmt.Sprintf("Game %d, %s", series.GameNumberOfSeries, line)
+	}
+	if series.SeriesTitle != "" {
+		line = series.SeriesTitle + ": " + line
+	}
+	return line
+}
+
+// countdown phrases the time until puck drop, rounded to the minute.
+func countdown(d time.Duration) string {
+	minutes := int(d.Round(time.Minute).Minutes())
+	switch {
+	case minutes <= 0:
+		return "puck drop now"
+	case minutes < 60:
+		return fmt.Sprintf("puck drop in %d min", minutes)
+	default:
+		return fmt.Sprintf("puck drop in %dh%02d", minutes/60, minutes%60)
+	}
+}
+
+func orTBD(name string) string {
+	if name == "" {
+		return "TBD"
+	}
+	return name
+}
@@ -0,0 +1,97 @@
+package notification
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"watchgameupdates/internal/models"
+)
+
+func pregameGame() models.Game {
+	return models.Game{
+		ID:        "2024020500",
+		StartTime: "2025-01-10T00:00:00Z",
+		HomeTeam:  models.Team{Abbrev: "CAR"},
+		AwayTeam:  models.Team{Abbrev: "FLA"},
+	}
+}
+
+func TestFormatPregameMessage(t *testing.T) {
+	now := time.Date(2025, 1, 9, 23, 30, 0, 0, time.UTC)
+	preview := models.GamePreview{
+		Venue:        models.LocalizedName{Default: "Lenovo Center"},
+		TVBroadcasts: []models.TVBroadcast{{Network: "ESPN+"}, {Network: "SN"}},
+		HomeTeam:     models.PreviewTeam{Abbrev: "CAR", Record: "12-3-1"},
+		AwayTeam:     models.PreviewTeam{Abbrev: "FLA", Record: "10-5-2"},
+		Matchup:      &models.PreviewMatchup{},
+	}
+	preview.Matchup.GoalieComparison.HomeTeam.Leaders = []models.PreviewGoalie{{Name: models.LocalizedName{Default: "F. Andersen"}}}
+
+	got := FormatPregameMessage(pregameGame(), preview, now)
+	for _, want := range []string{
+		"FLA @ CAR — puck drop in 30 min (12:00 AM UTC)",
+		"Records: FLA 10-5-2, CAR 12-3-1",
+		"Probable goalies: TBD (FLA), F. Andersen (CAR)",
+		"Venue: Lenovo Center",
+		"TV: ESPN+, SN",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("message missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestFormatPregameMessage_NoPreview(t *testing.T) {
+	now := time.Date(2025, 1, 9, 22, 45, 0, 0, time.UTC)
+	got := FormatPregameMessage(pregameGame(), models.GamePreview{}, now)
+	if want := "🏒 FLA @ CAR — puck drop in 1h15 (12:00 AM UTC)\n"; got != want {
+		t.Errorf("message = %q, want %q", got, want)
+	}
+}
+
+func TestFormatPregameMessage_Series(t *testing.T) {
+	now := time.Date(2025, 1, 9, 23, 30, 0, 0, time.UTC)
+	game := pregameGame()
+	game.Series = &models.SeriesStatus{Round: 1, TopSeedTeamAbbrev: "CAR", TopSeedWins: 1, BottomSeedTeamAbbrev: "FLA", BottomSeedWins: 1, NeededToWin: 4}
+	preview := models.GamePreview{SeriesStatus: &models.SeriesStatus{
+		Round: 1, SeriesTitle: "1st Round", NeededToWin: 4, GameNumberOfSeries: 4,
+		TopSeedTeamAbbrev: "CAR", TopSeedWins: 1, BottomSeedTeamAbbrev: "FLA", BottomSeedWins: 2,
+	}}
+
+	if got, want := FormatPregameMessage(game, preview, now), "• 1st Round: Game 4, FLA leads 2-1\n"; !strings.Contains(got, want) {
+		t.Errorf("message missing the preview's series %q:\n%s", want, got)
+	}
+	if got, want := FormatPregameMessage(game, models.GamePreview{}, now), "• Series tied 1-1\n"; !strings.Contains(got, want) {
+		t.Errorf("message missing the scheduled series %q:\n%s", want, got)
+	}
+}
+
+type pregameNotifier struct {
+	mockNotifier
+	calls atomic.Int32
+}
+
+func (p *pregameNotifier) NotifyPregame(_ context.Context, _ models.Game, _ models.GamePreview) error {
+	p.calls.Add(1)
+	return nil
+}
+
+func TestSendPregame(t *testing.T) {
+	pn := &pregameNotifier{}
+	svc := NewServiceWithNotificationFlag(true)
+	svc.RegisterNotifier(&mockNotifier{})
+	svc.RegisterNotifier(pn)
+
+	svc.SendPregame(context.Background(), pregameGame(), models.GamePreview{})
+	if got := pn.calls.Load(); got != 1 {
+		t.Errorf("NotifyPregame called %d times, want 1", got)
+	}
+
+	svc.WithShouldNotify(false).SendPregame(context.Background(), pregameGame(), models.GamePreview{})
+	if got := pn.calls.Load(); got != 1 {
+		t.Errorf("NotifyPregame called with notifications disabled")
+	}
+}
@@ -2,10 +2,14 @@ package notification
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"net/http"
+	"strings"
 	"sync"
 	"time"
 
+	"watchgameupdates/internal/metrics"
 	. "watchgameupdates/internal/models"
 )
 
@@ -39,6 +43,7 @@ func (s *Service) SendGameEventNotifications(game Game, gameData map[string]stri
 	// Fields sourced from Game (not the MoneyPuck data map) — notifiers that
 	// declare these in GetRequiredDataKeys can read them from a single map.
 	enriched := map[string]string{
+		"gameId":         game.ID,
 		"homeTeamAbbrev": game.HomeTeam.Abbrev,
 		"awayTeamAbbrev": game.AwayTeam.Abbrev,
 	}
@@ -55,6 +60,13 @@ func (s *Service) SendGameEventNotifications(game Game, gameData map[string]stri
 				log.Printf("WARNING: Required data key '%s' not found in game data for notifier %d", key, i)
 			}
 		}
+		if op, ok := notifier.(OptionalDataKeyProvider); ok {
+			for _, key := range op.GetOptionalDataKeys() {
+				if val, ok := enriched[key]; ok {
+					data[key] = val
+				}
+			}
+		}
 
 		req := NotificationRequest{
 			Team1ID: game.HomeTeam.CommonName["default"],
@@ -66,6 +78,67 @@ func (s *Service) SendGameEventNotifications(game Game, gameData map[string]stri
 	}
 }
 
+// SendGameStart tells every notifier implementing GameStartNotifier that the
+// game is starting or underway. Notifiers without start behaviour are skipped.
+func (s *Service) SendGameStart(game Game, gameData map[string]string) {
+	if !s.shouldNotify {
+		return
+	}
+
+	enriched := map[string]string{
+		"gameId":         game.ID,
+		"homeTeamAbbrev": game.HomeTeam.Abbrev,
+		"awayTeamAbbrev": game.AwayTeam.Abbrev,
+	}
+	for k, v := range gameData {
+		enriched[k] = v
+	}
+
+	for i, notifier := range s.notifiers {
+		starter, ok := notifier.(GameStartNotifier)
+		if !ok {
+			continue
+		}
+		go func(n GameStartNotifier, idx int) {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			if err := n.NotifyGameStart(ctx, game, enriched); err != nil {
+				log.Printf("Notifier %d game start failed for game %s: %v", idx, game.ID, err)
+			}
+		}(starter, i)
+	}
+}
+
+// SendPregame tells every notifier implementing PregameNotifier that the game
+// starts soon and waits for them to finish. Notifiers without pregame
+// behaviour are skipped.
+func (s *Service) SendPregame(ctx context.Context, game Game, preview GamePreview) {
+	if !s.shouldNotify {
+		log.Printf("Notifications disabled for this service instance, skipping pregame notifications")
+		return
+	}
+
+	var wg sync.WaitGroup
+	for i, notifier := range s.notifiers {
+		pn, ok := notifier.(PregameNotifier)
+		if !ok {
+			continue
+		}
+		wg.Add(1)
+		go func(n PregameNotifier, idx int) {
+			defer wg.Done()
+			notifCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+			defer cancel()
+			err := n.NotifyPregame(notifCtx, game, preview)
+			if err != nil {
+				log.Printf("Notifier %d pregame failed for game %s: %v", idx, game.ID, err)
+			}
+			countNotification(n, err == nil)
+		}(pn, i)
+	}
+	wg.Wait()
+}
+
 func (s *Service) SendGameUpdate(homeTeam, awayTeam, homeXG, awayXG, homeGoals, awayGoals string) {
 	if !s.shouldNotify {
 		log.Printf("Notifications disabled for this service instance, skipping game update notifications")
@@ -101,6 +174,7 @@ func (s *Service) sendToNotifier(notifier Notifier, req NotificationRequest, ind
 	resultChan, err := notifier.SendNotification(ctx, message)
 	if err != nil {
 		log.Printf("Notifier %d failed to send notification: %v", index, err)
+		countNotification(notifier, false)
 		return
 	}
 
@@ -111,12 +185,34 @@ func (s *Service) sendToNotifier(notifier Notifier, req NotificationRequest, ind
 		} else {
 			log.Printf("Notifier %d notification sent successfully: %s", index, result.ID)
 		}
+		countNotification(notifier, result.Success)
 	case <-ctx.Done():
 		log.Printf("Notifier %d notification timed out", index)
+		countNotification(notifier, false)
 	}
 }
 
-// SendMessage sends a plain-text message to all configured notifiers and waits for completion.
+// countNotification records the result of one notification in
+// metrics.Notifications.
+func countNotification(notifier any, sent bool) {
+	result := "failed"
+	if sent {
+		result = "sent"
+	}
+	metrics.Notifications.WithLabelValues(notifierName(notifier), result).Inc()
+}
+
+// notifierName labels a notifier in metrics by its type, lower-cased and
+// without the Notifier suffix: *liveactivity.LiveActivityNotifier is
+// "liveactivity", matching its NOTIFIERS entry.
+func notifierName(notifier any) string {
+	name := fmt.Sprintf("%T", notifier)
+	name = name[strings.LastIndex(name, ".")+1:]
+	return strings.ToLower(strings.TrimSuffix(name, "Notifier"))
+}
+
+// SendMessage sends a plain-text message to all configured notifiers and waits
+// for completion. A MessageFilter that does not accept message is skipped.
 func (s *Service) SendMessage(ctx context.Context, message string) {
 	if !s.shouldNotify {
 		log.Printf("Notifications disabled for this service instance, skipping message")
@@ -130,6 +226,9 @@ func (s *Service) SendMessage(ctx context.Context, message string) {
 
 	var wg sync.WaitGroup
 	for i, notifier := range s.notifiers {
+		if f, ok := notifier.(MessageFilter); ok && !f.AcceptsMessage(message) {
+			continue
+		}
 		wg.Add(1)
 		go func(n Notifier, idx int) {
 			defer wg.Done()
@@ -139,6 +238,7 @@ func (s *Service) SendMessage(ctx context.Context, message string) {
 			resultChan, err := n.SendNotification(notifCtx, message)
 			if err != nil {
 				log.Printf("Notifier %d failed to send message: %v", idx, err)
+				countNotification(n, false)
 				return
 			}
 
@@ -149,8 +249,10 @@ func (s *Service) SendMessage(ctx context.Context, message string) {
 				} else {
 					log.Printf("Notifier %d message sent successfully: %s", idx, result.ID)
 				}
+				countNotification(n, result.Success)
 			case <-notifCtx.Done():
 				log.Printf("Notifier %d message timed out", idx)
+				countNotification(n, false)
 			}
 		}(notifier, i)
 	}
@@ -176,6 +278,19 @@ func (s *Service) RegisterNotifier(n Notifier) {
 	s.notifiers = append(s.notifiers, n)
 }
 
+// Routes collects the HTTP endpoints exposed by registered notifiers.
+func (s *Service) Routes() map[string]http.Handler {
+	routes := map[string]http.Handler{}
+	for _, notifier := range s.notifiers {
+		if rp, ok := notifier.(RouteProvider); ok {
+			for pattern, h := range rp.Routes() {
+				routes[pattern] = h
+			}
+		}
+	}
+	return routes
+}
+
 // WithShouldNotify returns a per-request view of this service with the given
 // notification flag. Notifier instances (and their JWT/connection state) are
 // shared with the original — call this on a long-lived service to avoid
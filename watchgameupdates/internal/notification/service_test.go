@@ -4,6 +4,11 @@ import (
 	"context"
 	"testing"
 	"time"
+
+	"watchgameupdates/internal/metrics"
+	"watchgameupdates/internal/models"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
 type mockNotifier struct {
@@ -49,3 +54,121 @@ func TestRegisterNotifier_MultipleNotifiers(t *testing.T) {
 		t.Errorf("expected both 'a' and 'b' in required keys, got %v", keys)
 	}
 }
+
+// optionalKeyNotifier records the request it is formatted with.
+type optionalKeyNotifier struct {
+	mockNotifier
+	optional []string
+	got      chan NotificationRequest
+}
+
+func (m *optionalKeyNotifier) GetOptionalDataKeys() []string { return m.optional }
+func (m *optionalKeyNotifier) FormatMessage(req NotificationRequest) string {
+	m.got <- req
+	return "mock"
+}
+
+func TestSendGameEventNotifications_PassesOptionalKeys(t *testing.T) {
+	n := &optionalKeyNotifier{
+		mockNotifier: mockNotifier{keys: []string{"homeTeamGoals"}},
+		optional:     []string{"scorerName", "eventTeamAbbrev"},
+		got:          make(chan NotificationRequest, 1),
+	}
+	svc := NewServiceWithNotificationFlag(true)
+	svc.RegisterNotifier(n)
+
+	svc.SendGameEventNotifications(models.Game{}, map[string]string{"homeTeamGoals": "2", "scorerName": "Brad Marchand"})
+
+	select {
+	case req := <-n.got:
+		if req.Data["scorerName"] != "Brad Marchand" {
+			t.Errorf("want optional key passed through, got %v", req.Data)
+		}
+		if _, ok := req.Data["eventTeamAbbrev"]; ok {
+			t.Error("absent optional key must not be added")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("notifier was not called")
+	}
+
+	for _, k := range svc.GetAllRequiredDataKeys() {
+		if k == "scorerName" {
+			t.Error("optional keys must not be added to the required (fetched) keys")
+		}
+	}
+}
+
+func TestSendGameEventNotifications_PassesGameID(t *testing.T) {
+	n := &optionalKeyNotifier{
+		optional: []string{"gameId"},
+		got:      make(chan NotificationRequest, 1),
+	}
+	svc := NewServiceWithNotificationFlag(true)
+	svc.RegisterNotifier(n)
+
+	svc.SendGameEventNotifications(models.Game{ID: "2024020123"}, map[string]string{})
+
+	select {
+	case req := <-n.got:
+		if req.Data["gameId"] != "2024020123" {
+			t.Errorf("gameId = %q, want the game's ID", req.Data["gameId"])
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("notifier was not called")
+	}
+}
+
+func TestNotifierName(t *testing.T) {
+	tests := []struct {
+		notifier any
+		want     string
+	}{
+		{&DiscordNotifier{}, "discord"},
+		{&mockNotifier{}, "mock"},
+	}
+	for _, tt := range tests {
+		if got := notifierName(tt.notifier); got != tt.want {
+			t.Errorf("notifierName(%T) = %q, want %q", tt.notifier, got, tt.want)
+		}
+	}
+}
+
+func TestSendMessage_CountsResultPerNotifier(t *testing.T) {
+	sent := metrics.Notifications.WithLabelValues("mock", "sent")
+	before := testutil.ToFloat64(sent)
+
+	svc := NewService()
+	svc.RegisterNotifier(&mockNotifier{})
+	svc.SendMessage(context.Background(), "hello")
+
+	if got := testutil.ToFloat64(sent) - before; got != 1 {
+		t.Errorf("mock notifications sent = %v, want 1", got)
+	}
+}
+
+// snapshotOnlyNotifier only accepts messages it formats itself.
+type snapshotOnlyNotifier struct {
+	mockNotifier
+	sent int
+}
+
+func (m *snapshotOnlyNotifier) AcceptsMessage(message string) bool { return message == "snapshot" }
+func (m *snapshotOnlyNotifier) SendNotification(ctx context.Context, message string) (<-chan NotificationResult, error) {
+	m.sent++
+	return m.mockNotifier.SendNotification(ctx, message)
+}
+
+func TestSendMessage_SkipsFilteredNotifiers(t *testing.T) {
+	svc := NewService()
+	n := &snapshotOnlyNotifier{}
+	svc.RegisterNotifier(n)
+
+	svc.SendMessage(context.Background(), "Scheduled 3 games")
+	if n.sent != 0 {
+		t.Errorf("plain-text message reached the filtered notifier %d times, want 0", n.sent)
+	}
+	svc.SendMessage(context.Background(), "snapshot")
+	if n.sent != 1 {
+		t.Errorf("accepted message sent %d times, want 1", n.sent)
+	}
+}
@@ -2,7 +2,10 @@ package notification
 
 import (
 	"context"
+	"net/http"
 	"time"
+
+	"watchgameupdates/internal/models"
 )
 
 type NotificationResult struct {
@@ -29,6 +32,43 @@ type Notifier interface {
 	Close() error
 }
 
+// GameStartNotifier is implemented by notifiers that act when a tracked game
+// begins (e.g. Live Activity push-to-start). It may be called on every poll
+// while the game is starting or live; implementations dedupe per game.
+type GameStartNotifier interface {
+	NotifyGameStart(ctx context.Context, game models.Game, data map[string]string) error
+}
+
+// PregameNotifier is implemented by notifiers that announce a game shortly
+// before puck drop (e.g. a Discord preview, a Live Activity "Pregame" state).
+// It is called once per game, by the pregame task.
+type PregameNotifier interface {
+	NotifyPregame(ctx context.Context, game models.Game, preview models.GamePreview) error
+}
+
+// RouteProvider is implemented by notifiers that expose client-facing HTTP
+// endpoints (e.g. device token registration). Keys are ServeMux patterns.
+type RouteProvider interface {
+	Routes() map[string]http.Handler
+}
+
+// MessageFilter is implemented by notifiers that only handle messages they
+// formatted themselves (e.g. the game state store, which takes snapshots, or
+// the push notifiers, which take dispatch envelopes).
+// SendMessage skips them for any other message, such as a scheduler summary.
+type MessageFilter interface {
+	AcceptsMessage(message string) bool
+}
+
+// OptionalDataKeyProvider is implemented by notifiers that use play-by-play
+// fields which are only present for some plays (e.g. the goal scorer), or
+// fields the service only sets on some requests (the delay until the next
+// check, the playoff series). They are passed through when available and never
+// fetched from MoneyPuck or warned about when absent.
+type OptionalDataKeyProvider interface {
+	GetOptionalDataKeys() []string
+}
+
 type NotifierConfig struct {
 	Config map[string]string
 }
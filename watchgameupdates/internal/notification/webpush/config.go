@@ -0,0 +1,53 @@
+package webpush
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Config holds VAPID credentials and subscription storage for Web Push.
+type Config struct {
+	VAPIDPrivateKey   string // base64url P-256 private key; WEBPUSH_VAPID_PRIVATE_KEY
+	Subject           string // contact for push services, mailto: or https:; WEBPUSH_SUBJECT
+	SubscriptionsFile string // optional JSON file persisting subscriptions; WEBPUSH_SUBSCRIPTIONS_FILE
+
+	// Registration limits
+	AllowedHosts     []string // push service hosts endpoints may point at; WEBPUSH_ALLOWED_HOSTS (default defaultPushHosts)
+	MaxSubscriptions int      // subscriptions stored at most; WEBPUSH_MAX_SUBSCRIPTIONS (default defaultMaxSubscriptions)
+}
+
+// LoadConfig reads Web Push config from the environment.
+func LoadConfig() (*Config, error) {
+	cfg := &Config{
+		VAPIDPrivateKey:   os.Getenv("WEBPUSH_VAPID_PRIVATE_KEY"),
+		Subject:           os.Getenv("WEBPUSH_SUBJECT"),
+		SubscriptionsFile: os.Getenv("WEBPUSH_SUBSCRIPTIONS_FILE"),
+		AllowedHosts:      defaultPushHosts,
+		MaxSubscriptions:  defaultMaxSubscriptions,
+	}
+	if v := os.Getenv("WEBPUSH_ALLOWED_HOSTS"); v != "" {
+		cfg.AllowedHosts = nil
+		for _, h := range strings.Split(v, ",") {
+			if h = strings.ToLower(strings.TrimSpace(h)); h != "" {
+				cfg.AllowedHosts = append(cfg.AllowedHosts, h)
+			}
+		}
+	}
+	if v := os.Getenv("WEBPUSH_MAX_SUBSCRIPTIONS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("WEBPUSH_MAX_SUBSCRIPTIONS must be a positive integer, got %q", v)
+		}
+		cfg.MaxSubscriptions = n
+	}
+	if cfg.VAPIDPrivateKey == "" {
+		return nil, fmt.Errorf("required env var WEBPUSH_VAPID_PRIVATE_KEY is not set")
+	}
+	// Push services (Mozilla, Apple) reject VAPID tokens without a contact.
+	if !strings.HasPrefix(cfg.Subject, "mailto:") && !strings.HasPrefix(cfg.Subject, "https://") {
+		return nil, fmt.Errorf("WEBPUSH_SUBJECT must be a mailto: or https:// contact, got %q", cfg.Subject)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,65 @@
+package webpush
+
+import "testing"
+
+func TestLoadConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		subject string
+		wantErr bool
+	}{
+		{"mailto subject", "k", "mailto:ops@example.com", false},
+		{"https subject", "k", "https://example.com/contact", false},
+		{"missing key", "", "mailto:ops@example.com", true},
+		{"missing subject", "k", "", true},
+		{"plain address subject", "k", "ops@example.com", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("WEBPUSH_VAPID_PRIVATE_KEY", tt.key)
+			t.Setenv("WEBPUSH_SUBJECT", tt.subject)
+			t.Setenv("WEBPUSH_SUBSCRIPTIONS_FILE", "/tmp/subs.json")
+			cfg, err := LoadConfig()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && cfg.SubscriptionsFile != "/tmp/subs.json" {
+				t.Errorf("SubscriptionsFile = %q", cfg.SubscriptionsFile)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_RegistrationLimits(t *testing.T) {
+	t.Setenv("WEBPUSH_VAPID_PRIVATE_KEY", "k")
+	t.Setenv("WEBPUSH_SUBJECT", "mailto:ops@example.com")
+
+	t.Setenv("WEBPUSH_ALLOWED_HOSTS", "")
+	t.Setenv("WEBPUSH_MAX_SUBSCRIPTIONS", "")
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if len(cfg.AllowedHosts) != len(defaultPushHosts) || cfg.MaxSubscriptions != defaultMaxSubscriptions {
+		t.Errorf("defaults = %v / %d, want %v / %d", cfg.AllowedHosts, cfg.MaxSubscriptions, defaultPushHosts, defaultMaxSubscriptions)
+	}
+
+	t.Setenv("WEBPUSH_ALLOWED_HOSTS", " Push.Example , *.push.example,")
+	t.Setenv("WEBPUSH_MAX_SUBSCRIPTIONS", "50")
+	cfg, err = LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if len(cfg.AllowedHosts) != 2 || cfg.AllowedHosts[0] != "push.example" || cfg.AllowedHosts[1] != "*.push.example" {
+		t.Errorf("AllowedHosts = %q, want [push.example *.push.example]", cfg.AllowedHosts)
+	}
+	if cfg.MaxSubscriptions != 50 {
+		t.Errorf("MaxSubscriptions = %d, want 50", cfg.MaxSubscriptions)
+	}
+
+	t.Setenv("WEBPUSH_MAX_SUBSCRIPTIONS", "0")
+	if _, err := LoadConfig(); err == nil {
+		t.Error("want an error for WEBPUSH_MAX_SUBSCRIPTIONS=0")
+	}
+}
@@ -0,0 +1,97 @@
+package webpush
+
+// Message encryption for Web Push (RFC 8291) in the aes128gcm content coding
+// (RFC 8188). The output is the complete request body:
+//
+//   salt (16) | record size (4, big endian) | key id length (1) | as_public (65) | ciphertext
+//
+// Key derivation, with ua_public/auth from the browser subscription and a fresh
+// application-server key pair and salt per message:
+//
+//   ecdh_secret = ECDH(as_private, ua_public)
+//   IKM   = HKDF(salt=auth, ikm=ecdh_secret, info="WebPush: info\x00"|ua_public|as_public, 32)
+//   CEK   = HKDF(salt=salt, ikm=IKM, info="Content-Encoding: aes128gcm\x00", 16)
+//   NONCE = HKDF(salt=salt, ikm=IKM, info="Content-Encoding: nonce\x00", 12)
+//
+// The payload fits one record, so the plaintext is payload|0x02 (last-record
+// delimiter, no padding).
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	recordSize = 4096
+	saltLen    = 16
+	// maxPayload keeps payload, delimiter and GCM tag inside one record.
+	maxPayload = recordSize - 1 - 16
+)
+
+// encrypt encrypts payload for a subscription with a fresh key pair and salt.
+func encrypt(payload []byte, keys subscriptionKeys) ([]byte, error) {
+	asPrivate, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("generate ephemeral key: %w", err)
+	}
+	salt := make([]byte, saltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("generate salt: %w", err)
+	}
+	return encryptWith(payload, keys, asPrivate, salt)
+}
+
+// encryptWith is encrypt with the ephemeral key and salt supplied, so the RFC
+// 8291 test vector can be reproduced.
+func encryptWith(payload []byte, keys subscriptionKeys, asPrivate *ecdh.PrivateKey, salt []byte) ([]byte, error) {
+	if len(payload) > maxPayload {
+		return nil, fmt.Errorf("payload is %d bytes, max %d", len(payload), maxPayload)
+	}
+	uaPublic, err := ecdh.P256().NewPublicKey(keys.P256dh)
+	if err != nil {
+		return nil, fmt.Errorf("subscription p256dh key: %w", err)
+	}
+	ecdhSecret, err := asPrivate.ECDH(uaPublic)
+	if err != nil {
+		return nil, fmt.Errorf("ECDH: %w", err)
+	}
+	asPublic := asPrivate.PublicKey().Bytes()
+
+	keyInfo := append(append([]byte("WebPush: info\x00"), keys.P256dh...), asPublic...)
+	ikm, err := hkdf.Key(sha256.New, ecdhSecret, keys.Auth, string(keyInfo), 32)
+	if err != nil {
+		return nil, fmt.Errorf("derive IKM: %w", err)
+	}
+	cek, err := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: aes128gcm\x00", 16)
+	if err != nil {
+		return nil, fmt.Errorf("derive CEK: %w", err)
+	}
+	nonce, err := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: nonce\x00", 12)
+	if err != nil {
+		return nil, fmt.Errorf("derive nonce: %w", err)
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 0, saltLen+4+1+len(asPublic))
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, recordSize)
+	header = append(header, byte(len(asPublic)))
+	header = append(header, asPublic...)
+
+	plaintext := append(append([]byte{}, payload...), 0x02)
+	return gcm.Seal(header, nonce, plaintext, nil), nil
+}
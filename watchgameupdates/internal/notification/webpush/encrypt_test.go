@@ -0,0 +1,47 @@
+package webpush
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"testing"
+)
+
+func b64(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		t.Fatalf("decode %q: %v", s, err)
+	}
+	return b
+}
+
+// TestEncrypt_RFC8291Example reproduces the worked example in RFC 8291 §5.
+func TestEncrypt_RFC8291Example(t *testing.T) {
+	asPrivate, err := ecdh.P256().NewPrivateKey(b64(t, "yfWPiYE-n46HLnH0KqZOF1fJJU3MYrct3AELtAQ-oRw"))
+	if err != nil {
+		t.Fatalf("as_private: %v", err)
+	}
+	keys := subscriptionKeys{
+		P256dh: b64(t, "BCVxsr7N_eNgVRqvHtD0zTZsEc6-VV-JvLexhqUzORcxaOzi6-AYWXvTBHm4bjyPjs7Vd8pZGH6SRpkNtoIAiw4"),
+		Auth:   b64(t, "BTBZMqHH6r4Tts7J_aSIgg"),
+	}
+	got, err := encryptWith([]byte("When I grow up, I want to be a watermelon"), keys, asPrivate, b64(t, "DGv6ra1nlYgDCS1FRnbzlw"))
+	if err != nil {
+		t.Fatalf("encryptWith: %v", err)
+	}
+
+	want := b64(t, "DGv6ra1nlYgDCS1FRnbzlwAAEABBBP4z9KsN6nGRTbVYI_c7VJSPQTBtkgcy27mlmlMoZIIgDll6e3vCYLocInmYWAmS6TlzAC8wEqKK6PBru3jl7A_yl95bQpu6cVPTpK4Mqgkf1CXztLVBSt2Ks3oZwbuwXPXLWyouBWLVWGNWQexSgSxsj_Qulcy4a-fN")
+	if !bytes.Equal(got, want) {
+		t.Errorf("body =\n%s\nwant\n%s", base64.RawURLEncoding.EncodeToString(got), base64.RawURLEncoding.EncodeToString(want))
+	}
+}
+
+func TestEncrypt_RejectsOversizedPayload(t *testing.T) {
+	ua, _ := ecdh.P256().GenerateKey(rand.Reader)
+	keys := subscriptionKeys{P256dh: ua.PublicKey().Bytes(), Auth: make([]byte, 16)}
+	if _, err := encrypt(make([]byte, maxPayload+1), keys); err == nil {
+		t.Error("want error for payload larger than one record")
+	}
+}
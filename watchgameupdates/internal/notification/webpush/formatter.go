@@ -0,0 +1,100 @@
+package webpush
+
+// formatter converts a NotificationRequest into the Web Push dispatch envelope.
+//
+// FormatMessage output shape (parsed by SendNotification):
+//
+//   {
+//     "teams": ["BOS", "NYR"],      // empty for plays that don't warrant a push
+//     "payload": {                  // encrypted per subscription, read by the service worker
+//       "title": "Goal! BOS",
+//       "body":  "Brad Marchand scores. NYR 1 – BOS 2",
+//       "tag":   "nhl-BOS-NYR",     // the final replaces an earlier goal notification
+//       "game":  {"homeTeam":"BOS","awayTeam":"NYR","homeScore":2,"awayScore":1,
+//                 "gameState":"...","eventType":"goal","eventTeam":"BOS"}
+//     }
+//   }
+//
+// Only goals and the final result are pushed; everything else is left to the
+// open tab, which polls the game state itself.
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	. "watchgameupdates/internal/notification"
+	"watchgameupdates/internal/notification/contentstate"
+)
+
+type dispatchEnvelope struct {
+	Teams   []string     `json:"teams"`
+	Payload *pushPayload `json:"payload,omitempty"`
+}
+
+type pushPayload struct {
+	Title string    `json:"title"`
+	Body  string    `json:"body"`
+	Tag   string    `json:"tag"`
+	Game  gameState `json:"game"`
+}
+
+type gameState struct {
+	HomeTeam  string `json:"homeTeam"`
+	AwayTeam  string `json:"awayTeam"`
+	HomeScore int    `json:"homeScore"`
+	AwayScore int    `json:"awayScore"`
+	GameState string `json:"gameState"`
+	EventType string `json:"eventType"` // "goal" or "final"
+	EventTeam string `json:"eventTeam"` // scoring team tricode; "" for the final
+}
+
+// BuildDispatchMessage produces the JSON string for FormatMessage.
+func BuildDispatchMessage(req NotificationRequest) (string, error) {
+	env := dispatchEnvelope{}
+
+	home := strings.ToUpper(req.Data["homeTeamAbbrev"])
+	away := strings.ToUpper(req.Data["awayTeamAbbrev"])
+	game := gameState{
+		HomeTeam:  home,
+		AwayTeam:  away,
+		HomeScore: contentstate.ParseIntSafe(req.Data["homeTeamGoals"]),
+		AwayScore: contentstate.ParseIntSafe(req.Data["awayTeamGoals"]),
+		GameState: req.Data["gameState"],
+	}
+	score := fmt.Sprintf("%s %d – %s %d", away, game.AwayScore, home, game.HomeScore)
+
+	var title, body string
+	switch req.Data["lastPlayType"] {
+	case "goal":
+		game.EventType = "goal"
+		game.EventTeam = strings.ToUpper(req.Data["eventTeamAbbrev"])
+		title = "Goal!"
+		if game.EventTeam != "" {
+			title = "Goal! " + game.EventTeam
+		}
+		body = score
+		if scorer := req.Data["scorerName"]; scorer != "" {
+			body = scorer + " scores. " + score
+		}
+	case "game-end":
+		game.EventType = "final"
+		title = "Final"
+		body = score
+	}
+
+	if title != "" {
+		for _, t := range []string{home, away} {
+			if t != "" && (len(env.Teams) == 0 || env.Teams[0] != t) {
+				env.Teams = append(env.Teams, t)
+			}
+		}
+		env.Payload = &pushPayload{Title: title, Body: body, Tag: "nhl-" + home + "-" + away, Game: game}
+	}
+
+	b, err := json.Marshal(env)
+	if err != nil {
+		return "", fmt.Errorf("marshal web push dispatch envelope: %w", err)
+	}
+	return string(b), nil
+}
@@ -0,0 +1,88 @@
+package webpush
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "watchgameupdates/internal/notification"
+)
+
+func baseReq() NotificationRequest {
+	return NotificationRequest{
+		Team1ID: "Bruins",
+		Team2ID: "Rangers",
+		Data: map[string]string{
+			"homeTeamGoals":   "2",
+			"awayTeamGoals":   "1",
+			"homeTeamAbbrev":  "BOS",
+			"awayTeamAbbrev":  "NYR",
+			"gameState":       "14:32 left, 2nd period",
+			"lastPlayType":    "goal",
+			"eventTeamAbbrev": "BOS",
+			"scorerName":      "Brad Marchand",
+		},
+	}
+}
+
+func mustEnvelope(t *testing.T, req NotificationRequest) dispatchEnvelope {
+	t.Helper()
+	msg, err := BuildDispatchMessage(req)
+	if err != nil {
+		t.Fatalf("BuildDispatchMessage: %v", err)
+	}
+	var env dispatchEnvelope
+	if err := json.Unmarshal([]byte(msg), &env); err != nil {
+		t.Fatalf("unmarshal envelope: %v", err)
+	}
+	return env
+}
+
+func TestBuildDispatchMessage(t *testing.T) {
+	tests := []struct {
+		name      string
+		mutate    func(map[string]string)
+		wantTitle string
+		wantBody  string
+		wantEvent string
+	}{
+		{"goal with scorer", nil, "Goal! BOS", "Brad Marchand scores. NYR 1 – BOS 2", "goal"},
+		{"goal without scorer", func(d map[string]string) { delete(d, "scorerName") }, "Goal! BOS", "NYR 1 – BOS 2", "goal"},
+		{"final", func(d map[string]string) { d["lastPlayType"] = "game-end" }, "Final", "NYR 1 – BOS 2", "final"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := baseReq()
+			if tt.mutate != nil {
+				tt.mutate(req.Data)
+			}
+			env := mustEnvelope(t, req)
+			if env.Payload == nil {
+				t.Fatal("payload is nil, want a push")
+			}
+			p := env.Payload
+			if p.Title != tt.wantTitle || p.Body != tt.wantBody {
+				t.Errorf("title/body = %q / %q, want %q / %q", p.Title, p.Body, tt.wantTitle, tt.wantBody)
+			}
+			if p.Game.EventType != tt.wantEvent || p.Game.HomeScore != 2 || p.Game.AwayScore != 1 {
+				t.Errorf("game = %+v, want %s with 2–1 score", p.Game, tt.wantEvent)
+			}
+			if p.Tag != "nhl-BOS-NYR" {
+				t.Errorf("tag = %q, want nhl-BOS-NYR", p.Tag)
+			}
+			if len(env.Teams) != 2 || env.Teams[0] != "BOS" || env.Teams[1] != "NYR" {
+				t.Errorf("teams = %v, want [BOS NYR]", env.Teams)
+			}
+		})
+	}
+}
+
+func TestBuildDispatchMessage_OtherPlaysHaveNoPayload(t *testing.T) {
+	for _, play := range []string{"shot-on-goal", "period-end", "penalty", ""} {
+		req := baseReq()
+		req.Data["lastPlayType"] = play
+		env := mustEnvelope(t, req)
+		if env.Payload != nil || len(env.Teams) != 0 {
+			t.Errorf("lastPlayType %q: envelope = %+v, want no push", play, env)
+		}
+	}
+}
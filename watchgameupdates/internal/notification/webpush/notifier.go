@@ -0,0 +1,326 @@
+// Package webpush sends goal and final notifications to browsers through the
+// Web Push protocol (RFC 8030), with payloads encrypted per RFC 8291 and the
+// server identified by VAPID (RFC 8292).
+//
+// Dispatch flow (called by notification.Service in a goroutine per notifier):
+//
+//	FormatMessage(req) → JSON dispatch envelope {teams, payload}
+//	SendNotification(ctx, envelope) → for every subscription following a team:
+//	    encrypt payload with the subscription keys
+//	    POST {subscription endpoint}
+//	        authorization:    vapid t={jwt}, k={public key}
+//	        content-encoding: aes128gcm
+//	        ttl:              600
+//	        urgency:          high
+//
+// 404/410 from the push service means the subscription expired; it is removed.
+// 429 and 5xx are retried with backoff.
+package webpush
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"watchgameupdates/internal/clientapi"
+	. "watchgameupdates/internal/notification"
+)
+
+const (
+	httpTimeout = 10 * time.Second
+	maxRetries  = 3
+	retryDelay  = time.Second
+	// pushTTL is how long the push service holds a message for an offline
+	// browser. A goal older than this is no longer news.
+	pushTTL = 10 * time.Minute
+	// maxConcurrentPushes bounds in-flight requests across subscriptions.
+	maxConcurrentPushes = 8
+	responseBodyLimit   = 512
+)
+
+// VAPIDPublicKeyPath serves the applicationServerKey the browser subscribes with.
+const VAPIDPublicKeyPath = "/webpush/vapid-public-key"
+
+var requiredDataKeys = []string{
+	"homeTeamGoals",
+	"awayTeamGoals",
+	"gameState",
+	"homeTeamAbbrev",
+	"awayTeamAbbrev",
+	"lastPlayType",
+}
+
+var optionalDataKeys = []string{
+	"eventTeamAbbrev",
+	"scorerName",
+}
+
+// WebPushNotifier implements notification.Notifier.
+type WebPushNotifier struct {
+	http          *http.Client
+	vapid         *vapidSigner
+	subscriptions *subscriptionStore
+	guard         *clientapi.Guard // on subscription registration
+}
+
+// New creates a WebPushNotifier from environment config.
+func New() (*WebPushNotifier, error) {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	return newNotifier(cfg, &http.Client{Timeout: httpTimeout})
+}
+
+// newNotifier builds a notifier from cfg; tests pass a client trusting their
+// TLS push service.
+func newNotifier(cfg *Config, httpClient *http.Client) (*WebPushNotifier, error) {
+	vapid, err := newVAPIDSigner(cfg.VAPIDPrivateKey, cfg.Subject)
+	if err != nil {
+		return nil, err
+	}
+	subs, err := newSubscriptionStore(cfg.SubscriptionsFile)
+	if err != nil {
+		return nil, fmt.Errorf("load web push subscriptions: %w", err)
+	}
+	if cfg.AllowedHosts != nil {
+		subs.allowedHosts = cfg.AllowedHosts
+	}
+	if cfg.MaxSubscriptions > 0 {
+		subs.max = cfg.MaxSubscriptions
+	}
+	log.Printf("WebPush notifier initialized: subject=%s subscriptions=%d", cfg.Subject, len(subs.subs))
+	return &WebPushNotifier{
+		http:          httpClient,
+		vapid:         vapid,
+		subscriptions: subs,
+		guard:         clientapi.FromEnv(),
+	}, nil
+}
+
+func (n *WebPushNotifier) GetRequiredDataKeys() []string {
+	return requiredDataKeys
+}
+
+// GetOptionalDataKeys implements notification.OptionalDataKeyProvider.
+func (n *WebPushNotifier) GetOptionalDataKeys() []string {
+	return optionalDataKeys
+}
+
+// FormatMessage builds the dispatch envelope (teams + payload) as JSON.
+func (n *WebPushNotifier) FormatMessage(req NotificationRequest) string {
+	msg, err := BuildDispatchMessage(req)
+	if err != nil {
+		log.Printf("ERROR: WebPush FormatMessage: %v", err)
+		return ""
+	}
+	return msg
+}
+
+// SendNotification parses the dispatch envelope from FormatMessage and pushes
+// to every subscription following either team. Envelopes without a payload
+// (plays other than goals and the final) succeed without sending anything.
+// AcceptsMessage implements notification.MessageFilter: only dispatch
+// envelopes from FormatMessage are sent, so plain-text messages (scheduler
+// summaries, postponements) are skipped rather than counted as failures.
+func (n *WebPushNotifier) AcceptsMessage(message string) bool {
+	var env dispatchEnvelope
+	return json.Unmarshal([]byte(message), &env) == nil
+}
+
+func (n *WebPushNotifier) SendNotification(ctx context.Context, message string) (<-chan NotificationResult, error) {
+	resultChan := make(chan NotificationResult, 1)
+
+	if message == "" {
+		close(resultChan)
+		return resultChan, fmt.Errorf("empty dispatch message")
+	}
+	var env dispatchEnvelope
+	if err := json.Unmarshal([]byte(message), &env); err != nil {
+		close(resultChan)
+		return resultChan, fmt.Errorf("parse dispatch envelope: %w", err)
+	}
+
+	id := uuid.New().String()
+	go func() {
+		defer close(resultChan)
+		var err error
+		if env.Payload != nil {
+			err = n.pushToAll(ctx, env)
+		}
+		resultChan <- NotificationResult{
+			ID:        id,
+			Success:   err == nil,
+			Error:     err,
+			Timestamp: time.Now(),
+		}
+	}()
+	return resultChan, nil
+}
+
+// pushToAll pushes to every subscription of env.Teams with bounded
+// concurrency; expired subscriptions are removed and do not count as errors.
+func (n *WebPushNotifier) pushToAll(ctx context.Context, env dispatchEnvelope) error {
+	payload, err := json.Marshal(env.Payload)
+	if err != nil {
+		return fmt.Errorf("marshal web push payload: %w", err)
+	}
+	subs := n.subscriptions.ForTeams(env.Teams...)
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		errs    []error
+		expired int
+		sem     = make(chan struct{}, maxConcurrentPushes)
+	)
+	for _, sub := range subs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(sub subscription) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			err := n.pushWithRetry(ctx, sub, payload)
+			if errors.Is(err, errSubscriptionGone) {
+				if rmErr := n.subscriptions.Remove(sub.Endpoint); rmErr != nil {
+					log.Printf("ERROR: remove web push subscription: %v", rmErr)
+				}
+				mu.Lock()
+				expired++
+				mu.Unlock()
+				return
+			}
+			if err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("%s: %w", shortEndpoint(sub.Endpoint), err))
+				mu.Unlock()
+			}
+		}(sub)
+	}
+	wg.Wait()
+
+	log.Printf("Web push for %v: subscriptions=%d expired=%d failed=%d", env.Teams, len(subs), expired, len(errs))
+	return errors.Join(errs...)
+}
+
+func (n *WebPushNotifier) pushWithRetry(ctx context.Context, sub subscription, payload []byte) error {
+	delay := retryDelay
+	var lastErr error
+	for attempt := range maxRetries {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(delay):
+				delay *= 2
+			}
+		}
+
+		err := n.push(ctx, sub, payload)
+		if err == nil {
+			return nil
+		}
+		var re *retryableError
+		if !errors.As(err, &re) {
+			return err
+		}
+		lastErr = err
+		log.Printf("WARN: web push retryable error %s attempt=%d/%d: %v", shortEndpoint(sub.Endpoint), attempt+1, maxRetries, err)
+	}
+	return fmt.Errorf("web push failed after %d attempts: %w", maxRetries, lastErr)
+}
+
+// push encrypts payload for sub and sends it once.
+func (n *WebPushNotifier) push(ctx context.Context, sub subscription, payload []byte) error {
+	keys, err := sub.keys()
+	if err != nil {
+		return err
+	}
+	body, err := encrypt(payload, keys)
+	if err != nil {
+		return fmt.Errorf("encrypt: %w", err)
+	}
+	auth, err := n.vapid.Authorization(sub.Endpoint)
+	if err != nil {
+		return fmt.Errorf("VAPID: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Authorization", auth)
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("TTL", strconv.Itoa(int(pushTTL.Seconds())))
+	req.Header.Set("Urgency", "high")
+
+	start := time.Now()
+	resp, err := n.http.Do(req)
+	latencyMs := time.Since(start).Milliseconds()
+	if err != nil {
+		log.Printf("Web push error: %s latency_ms=%d err=%v", shortEndpoint(sub.Endpoint), latencyMs, err)
+		return fmt.Errorf("web push: %w", err)
+	}
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, responseBodyLimit))
+	resp.Body.Close()
+	log.Printf("Web push: %s status=%d latency_ms=%d", shortEndpoint(sub.Endpoint), resp.StatusCode, latencyMs)
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return errSubscriptionGone
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return &retryableError{status: resp.StatusCode, body: string(respBody)}
+	default:
+		return fmt.Errorf("web push unexpected status %d: %s", resp.StatusCode, respBody)
+	}
+}
+
+// errSubscriptionGone is returned by push when the push service reports the
+// subscription expired or was unsubscribed (404/410).
+var errSubscriptionGone = errors.New("web push subscription is no longer valid")
+
+// retryableError signals the caller should retry with backoff.
+type retryableError struct {
+	status int
+	body   string
+}
+
+func (e *retryableError) Error() string {
+	return fmt.Sprintf("web push status %d: %s", e.status, e.body)
+}
+
+// Routes exposes subscription registration and the VAPID public key.
+func (n *WebPushNotifier) Routes() map[string]http.Handler {
+	return map[string]http.Handler{
+		SubscriptionsPath:  n.guard.Wrap(n.subscriptions),
+		VAPIDPublicKeyPath: http.HandlerFunc(n.serveVAPIDPublicKey),
+	}
+}
+
+func (n *WebPushNotifier) serveVAPIDPublicKey(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"publicKey": n.vapid.publicKey})
+}
+
+func (n *WebPushNotifier) Close() error {
+	return nil
+}
@@ -0,0 +1,247 @@
+package webpush
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	. "watchgameupdates/internal/notification"
+)
+
+// decrypt reverses encrypt for the browser holding uaPrivate and auth.
+func decrypt(t *testing.T, body []byte, uaPrivate *ecdh.PrivateKey, auth []byte) []byte {
+	t.Helper()
+	if len(body) < saltLen+4+1+65 {
+		t.Fatalf("body is %d bytes, too short for the aes128gcm header", len(body))
+	}
+	salt := body[:saltLen]
+	if rs := binary.BigEndian.Uint32(body[saltLen:]); rs != recordSize {
+		t.Errorf("record size = %d, want %d", rs, recordSize)
+	}
+	idLen := int(body[saltLen+4])
+	asPublicBytes := body[saltLen+5 : saltLen+5+idLen]
+	ciphertext := body[saltLen+5+idLen:]
+
+	asPublic, err := ecdh.P256().NewPublicKey(asPublicBytes)
+	if err != nil {
+		t.Fatalf("key id is not a P-256 key: %v", err)
+	}
+	secret, err := uaPrivate.ECDH(asPublic)
+	if err != nil {
+		t.Fatalf("ECDH: %v", err)
+	}
+	keyInfo := append(append([]byte("WebPush: info\x00"), uaPrivate.PublicKey().Bytes()...), asPublicBytes...)
+	ikm, _ := hkdf.Key(sha256.New, secret, auth, string(keyInfo), 32)
+	cek, _ := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: aes128gcm\x00", 16)
+	nonce, _ := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: nonce\x00", 12)
+	block, _ := aes.NewCipher(cek)
+	gcm, _ := cipher.NewGCM(block)
+	plain, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		t.Fatalf("decrypt: %v", err)
+	}
+	if len(plain) == 0 || plain[len(plain)-1] != 0x02 {
+		t.Fatalf("plaintext does not end with the last-record delimiter")
+	}
+	return plain[:len(plain)-1]
+}
+
+// fakePushService records pushes per path. statuses scripts responses per path;
+// unscripted pushes answer 201 Created.
+type fakePushService struct {
+	mu       sync.Mutex
+	pushes   map[string][]*http.Request
+	bodies   map[string][][]byte
+	statuses map[string][]int
+}
+
+func (f *fakePushService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pushes[r.URL.Path] = append(f.pushes[r.URL.Path], r)
+	f.bodies[r.URL.Path] = append(f.bodies[r.URL.Path], body)
+	if s := f.statuses[r.URL.Path]; len(s) > 0 {
+		f.statuses[r.URL.Path] = s[1:]
+		w.WriteHeader(s[0])
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+func newTestNotifier(t *testing.T, statuses map[string][]int) (*WebPushNotifier, *fakePushService, *httptest.Server) {
+	t.Helper()
+	fake := &fakePushService{pushes: map[string][]*http.Request{}, bodies: map[string][][]byte{}, statuses: statuses}
+	srv := httptest.NewTLSServer(fake)
+	t.Cleanup(srv.Close)
+
+	n, err := newNotifier(&Config{
+		VAPIDPrivateKey: testVAPIDKey(t),
+		Subject:         "mailto:ops@example.com",
+	}, srv.Client())
+	if err != nil {
+		t.Fatalf("newNotifier: %v", err)
+	}
+	return n, fake, srv
+}
+
+func send(t *testing.T, n *WebPushNotifier, req NotificationRequest) error {
+	t.Helper()
+	results, err := n.SendNotification(context.Background(), n.FormatMessage(req))
+	if err != nil {
+		t.Fatalf("SendNotification: %v", err)
+	}
+	return (<-results).Error
+}
+
+func TestSendNotification_EncryptedPushToFollowers(t *testing.T) {
+	n, fake, srv := newTestNotifier(t, nil)
+	bos, nyr, tor := newTestBrowser(t), newTestBrowser(t), newTestBrowser(t)
+	n.subscriptions.Register(bos.subscription(srv.URL+"/push/bos", "BOS"))
+	n.subscriptions.Register(nyr.subscription(srv.URL+"/push/nyr", "NYR", "TOR"))
+	n.subscriptions.Register(tor.subscription(srv.URL+"/push/tor", "TOR"))
+
+	if err := send(t, n, baseReq()); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+
+	if len(fake.pushes["/push/tor"]) != 0 {
+		t.Error("pushed to a subscription following neither team")
+	}
+	for path, b := range map[string]testBrowser{"/push/bos": bos, "/push/nyr": nyr} {
+		if len(fake.pushes[path]) != 1 {
+			t.Fatalf("%s: %d pushes, want 1", path, len(fake.pushes[path]))
+		}
+		r := fake.pushes[path][0]
+		if r.Header.Get("Content-Encoding") != "aes128gcm" || r.Header.Get("TTL") != "600" || r.Header.Get("Urgency") != "high" {
+			t.Errorf("%s: headers = %v", path, r.Header)
+		}
+		claims, _ := parseVAPIDHeader(t, r.Header.Get("Authorization"))
+		if claims["aud"] != srv.URL {
+			t.Errorf("%s: aud = %v, want %s", path, claims["aud"], srv.URL)
+		}
+
+		var p pushPayload
+		if err := json.Unmarshal(decrypt(t, fake.bodies[path][0], b.key, b.auth), &p); err != nil {
+			t.Fatalf("%s: payload: %v", path, err)
+		}
+		if p.Title != "Goal! BOS" || p.Body != "Brad Marchand scores. NYR 1 – BOS 2" {
+			t.Errorf("%s: payload = %+v", path, p)
+		}
+	}
+}
+
+func TestSendNotification_NoPushForOtherPlays(t *testing.T) {
+	n, fake, srv := newTestNotifier(t, nil)
+	n.subscriptions.Register(newTestBrowser(t).subscription(srv.URL+"/push/bos", "BOS"))
+
+	req := baseReq()
+	req.Data["lastPlayType"] = "shot-on-goal"
+	if err := send(t, n, req); err != nil {
+		t.Errorf("send: %v, want success without a push", err)
+	}
+	if len(fake.pushes) != 0 {
+		t.Errorf("pushes = %d, want none", len(fake.pushes))
+	}
+}
+
+func TestSendNotification_ExpiredSubscriptionsRemoved(t *testing.T) {
+	n, fake, srv := newTestNotifier(t, map[string][]int{
+		"/push/gone":     {http.StatusGone},
+		"/push/notfound": {http.StatusNotFound},
+	})
+	for _, path := range []string{"/push/gone", "/push/notfound", "/push/ok"} {
+		n.subscriptions.Register(newTestBrowser(t).subscription(srv.URL+path, "BOS"))
+	}
+
+	if err := send(t, n, baseReq()); err != nil {
+		t.Errorf("send: %v, want expired subscriptions not to fail the notification", err)
+	}
+	left := n.subscriptions.ForTeams("BOS")
+	if len(left) != 1 || !strings.HasSuffix(left[0].Endpoint, "/push/ok") {
+		t.Errorf("subscriptions left = %+v, want only /push/ok", left)
+	}
+
+	if err := send(t, n, baseReq()); err != nil {
+		t.Fatalf("second send: %v", err)
+	}
+	if len(fake.pushes["/push/gone"]) != 1 || len(fake.pushes["/push/ok"]) != 2 {
+		t.Errorf("pushes = gone:%d ok:%d, want gone:1 ok:2", len(fake.pushes["/push/gone"]), len(fake.pushes["/push/ok"]))
+	}
+}
+
+func TestPushWithRetry(t *testing.T) {
+	tests := []struct {
+		name       string
+		statuses   []int
+		wantErr    bool
+		wantPushes int
+	}{
+		{"created", nil, false, 1},
+		{"retry after 503", []int{http.StatusServiceUnavailable}, false, 2},
+		{"retry after 429", []int{http.StatusTooManyRequests}, false, 2},
+		{"bad request is terminal", []int{http.StatusBadRequest}, true, 1},
+		{"payload too large is terminal", []int{http.StatusRequestEntityTooLarge}, true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, fake, srv := newTestNotifier(t, map[string][]int{"/push/1": tt.statuses})
+			sub := newTestBrowser(t).subscription(srv.URL+"/push/1", "BOS")
+			err := n.pushWithRetry(context.Background(), sub, []byte(`{}`))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(fake.pushes["/push/1"]) != tt.wantPushes {
+				t.Errorf("pushes = %d, want %d", len(fake.pushes["/push/1"]), tt.wantPushes)
+			}
+		})
+	}
+}
+
+func TestRoutes_VAPIDPublicKey(t *testing.T) {
+	n, _, _ := newTestNotifier(t, nil)
+	rec := httptest.NewRecorder()
+	n.Routes()[VAPIDPublicKeyPath].ServeHTTP(rec, httptest.NewRequest(http.MethodGet, VAPIDPublicKeyPath, nil))
+
+	var body struct {
+		PublicKey string `json:"publicKey"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body.PublicKey != n.vapid.publicKey {
+		t.Errorf("body = %s, want the VAPID public key %s", rec.Body, n.vapid.publicKey)
+	}
+	if _, ok := n.Routes()[SubscriptionsPath]; !ok {
+		t.Error("Routes() is missing the subscriptions endpoint")
+	}
+}
+
+func TestSendNotification_InvalidEnvelope(t *testing.T) {
+	n, _, _ := newTestNotifier(t, nil)
+	for _, msg := range []string{"", "not-json"} {
+		if _, err := n.SendNotification(context.Background(), msg); err == nil {
+			t.Errorf("SendNotification(%q): want error", msg)
+		}
+	}
+}
+
+func TestAcceptsMessage_OnlyEnvelopes(t *testing.T) {
+	n, _, _ := newTestNotifier(t, nil)
+	for _, msg := range []string{"", "Scheduled 3 games for 2025-01-01", "Game 2025020001 was postponed"} {
+		if n.AcceptsMessage(msg) {
+			t.Errorf("AcceptsMessage(%q) = true, want false", msg)
+		}
+	}
+	if msg := n.FormatMessage(baseReq()); !n.AcceptsMessage(msg) {
+		t.Errorf("AcceptsMessage(FormatMessage()) = false, want true")
+	}
+}
@@ -0,0 +1,317 @@
+package webpush
+
+// Web Push subscription registry.
+//
+// The web app subscribes with PushManager.subscribe({applicationServerKey})
+// using the key from GET /webpush/vapid-public-key, then registers the
+// subscription together with the teams the user follows:
+//
+//   POST   /webpush/subscriptions  {"subscription": <PushSubscription.toJSON()>, "teams": ["BOS"]}
+//   DELETE /webpush/subscriptions  {"endpoint": "https://push.example/..."}
+//
+// Re-registering an endpoint replaces its keys and team list. Subscriptions the
+// push service reports as expired (404/410) are removed by the notifier.
+//
+// Endpoints must be on a known push service (WEBPUSH_ALLOWED_HOSTS), so the
+// server cannot be pointed at arbitrary URLs, and the store holds at most
+// WEBPUSH_MAX_SUBSCRIPTIONS. The route is behind a clientapi.Guard.
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"watchgameupdates/internal/teams"
+)
+
+// SubscriptionsPath is the HTTP route for subscription registration.
+const SubscriptionsPath = "/webpush/subscriptions"
+
+// defaultPushHosts are the push services of Chrome, Firefox, Safari and Edge.
+// A leading "*." matches any subdomain.
+var defaultPushHosts = []string{
+	"fcm.googleapis.com",
+	"updates.push.services.mozilla.com",
+	"web.push.apple.com",
+	"*.notify.windows.com",
+}
+
+// defaultMaxSubscriptions caps the store when WEBPUSH_MAX_SUBSCRIPTIONS is unset.
+const defaultMaxSubscriptions = 100000
+
+// errStoreFull is returned by Register for a new endpoint once the store holds
+// its maximum.
+var errStoreFull = errors.New("subscription limit reached")
+
+// subscriptionKeys are the browser's encryption keys, decoded.
+type subscriptionKeys struct {
+	P256dh []byte // uncompressed P-256 public key (65 bytes)
+	Auth   []byte // authentication secret (16 bytes)
+}
+
+// subscription is one browser's registration as persisted.
+type subscription struct {
+	Endpoint string   `json:"endpoint"`
+	P256dh   string   `json:"p256dh"` // base64url, as sent by the browser
+	Auth     string   `json:"auth"`
+	Teams    []string `json:"teams"`
+}
+
+func (s subscription) keys() (subscriptionKeys, error) {
+	p256dh, err := decodeKey(s.P256dh)
+	if err != nil || len(p256dh) != 65 || p256dh[0] != 0x04 {
+		return subscriptionKeys{}, fmt.Errorf("p256dh must be a base64url uncompressed P-256 point")
+	}
+	auth, err := decodeKey(s.Auth)
+	if err != nil || len(auth) != 16 {
+		return subscriptionKeys{}, fmt.Errorf("auth must be a base64url 16-byte secret")
+	}
+	return subscriptionKeys{P256dh: p256dh, Auth: auth}, nil
+}
+
+// decodeKey accepts base64url with or without padding, as browsers vary.
+func decodeKey(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+// subscriptionStore maps endpoints to subscriptions. It is safe for concurrent
+// use. When path is set, every change is written through to a JSON file so
+// registrations survive restarts.
+type subscriptionStore struct {
+	mu           sync.RWMutex
+	subs         map[string]subscription // endpoint → subscription
+	path         string
+	allowedHosts []string
+	max          int
+}
+
+func newSubscriptionStore(path string) (*subscriptionStore, error) {
+	s := &subscriptionStore{
+		subs:         map[string]subscription{},
+		path:         path,
+		allowedHosts: defaultPushHosts,
+		max:          defaultMaxSubscriptions,
+	}
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read subscriptions file %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &s.subs); err != nil {
+		return nil, fmt.Errorf("parse subscriptions file %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Register stores sub, replacing any previous registration for its endpoint.
+// A new endpoint is refused with errStoreFull once the store is at its maximum.
+func (s *subscriptionStore) Register(sub subscription) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.subs[sub.Endpoint]; !ok && len(s.subs) >= s.max {
+		return errStoreFull
+	}
+	s.subs[sub.Endpoint] = sub
+	return s.saveLocked()
+}
+
+// Remove forgets endpoint. Removing an unknown endpoint is not an error.
+func (s *subscriptionStore) Remove(endpoint string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.subs[endpoint]; !ok {
+		return nil
+	}
+	delete(s.subs, endpoint)
+	return s.saveLocked()
+}
+
+// ForTeams returns every subscription following any of tricodes, once each,
+// sorted by endpoint for stable output.
+func (s *subscriptionStore) ForTeams(tricodes ...string) []subscription {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out []subscription
+	for _, sub := range s.subs {
+		if followsAny(sub.Teams, tricodes) {
+			out = append(out, sub)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Endpoint < out[j].Endpoint })
+	return out
+}
+
+func followsAny(followed, tricodes []string) bool {
+	for _, f := range followed {
+		for _, t := range tricodes {
+			if f == t {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s *subscriptionStore) saveLocked() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(s.subs)
+	if err != nil {
+		return fmt.Errorf("marshal subscriptions: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("write subscriptions file: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// subscriptionRequest is the registration body. Subscription is the browser's
+// PushSubscription.toJSON(); DELETE only needs Endpoint.
+type subscriptionRequest struct {
+	Subscription struct {
+		Endpoint string `json:"endpoint"`
+		Keys     struct {
+			P256dh string `json:"p256dh"`
+			Auth   string `json:"auth"`
+		} `json:"keys"`
+	} `json:"subscription"`
+	Endpoint string   `json:"endpoint"`
+	Teams    []string `json:"teams"`
+}
+
+// ServeHTTP implements the registration endpoint described at the top of this file.
+func (s *subscriptionStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		w.Header().Set("Allow", "POST, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req subscriptionRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 8192)).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if r.Method == http.MethodDelete {
+		endpoint := strings.TrimSpace(req.Endpoint)
+		if endpoint == "" {
+			endpoint = strings.TrimSpace(req.Subscription.Endpoint)
+		}
+		if endpoint == "" {
+			http.Error(w, "endpoint is required", http.StatusBadRequest)
+			return
+		}
+		if err := s.Remove(endpoint); err != nil {
+			log.Printf("ERROR: remove web push subscription: %v", err)
+			http.Error(w, "failed to remove subscription", http.StatusInternalServerError)
+			return
+		}
+		log.Printf("Web push subscription %s unregistered", shortEndpoint(endpoint))
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	sub := subscription{
+		Endpoint: strings.TrimSpace(req.Subscription.Endpoint),
+		P256dh:   req.Subscription.Keys.P256dh,
+		Auth:     req.Subscription.Keys.Auth,
+	}
+	u, err := url.Parse(sub.Endpoint)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		http.Error(w, "subscription.endpoint must be an https URL", http.StatusBadRequest)
+		return
+	}
+	if !hostAllowed(u.Hostname(), s.allowedHosts) || u.Port() != "" {
+		http.Error(w, "subscription.endpoint is not on a known push service", http.StatusBadRequest)
+		return
+	}
+	if _, err := sub.keys(); err != nil {
+		http.Error(w, "subscription.keys: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	followed, err := normalizeTeams(req.Teams)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sub.Teams = followed
+
+	if err := s.Register(sub); errors.Is(err, errStoreFull) {
+		log.Printf("WARN: web push subscription %s refused: %d subscriptions stored", shortEndpoint(sub.Endpoint), s.max)
+		http.Error(w, "subscription limit reached", http.StatusServiceUnavailable)
+		return
+	} else if err != nil {
+		log.Printf("ERROR: register web push subscription: %v", err)
+		http.Error(w, "failed to store subscription", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("Web push subscription %s registered for teams %v", shortEndpoint(sub.Endpoint), followed)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// hostAllowed reports whether host is one of allowed, where an entry "*.example.com"
+// matches any subdomain of example.com.
+func hostAllowed(host string, allowed []string) bool {
+	host = strings.ToLower(host)
+	for _, a := range allowed {
+		if suffix, ok := strings.CutPrefix(a, "*"); ok {
+			if strings.HasSuffix(host, suffix) {
+				return true
+			}
+		} else if host == a {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeTeams uppercases, validates and de-duplicates team tricodes.
+func normalizeTeams(in []string) ([]string, error) {
+	if len(in) == 0 {
+		return nil, fmt.Errorf("teams is required")
+	}
+	seen := map[string]bool{}
+	var out []string
+	for _, t := range in {
+		t = strings.ToUpper(strings.TrimSpace(t))
+		if !teams.IsKnown(t) {
+			return nil, fmt.Errorf("unknown team %q", t)
+		}
+		if !seen[t] {
+			seen[t] = true
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+
+// shortEndpoint keeps the push service host and the start of the path for logs;
+// the full endpoint is a bearer capability.
+func shortEndpoint(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "?"
+	}
+	path := u.Path
+	if len(path) > 12 {
+		path = path[:12] + "…"
+	}
+	return u.Host + path
+}
@@ -0,0 +1,160 @@
+package webpush
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testBrowser is a subscribing user agent: its key pair and auth secret.
+type testBrowser struct {
+	key  *ecdh.PrivateKey
+	auth []byte
+}
+
+func newTestBrowser(t *testing.T) testBrowser {
+	t.Helper()
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	auth := make([]byte, 16)
+	rand.Read(auth)
+	return testBrowser{key: key, auth: auth}
+}
+
+func (b testBrowser) subscription(endpoint string, teams ...string) subscription {
+	return subscription{
+		Endpoint: endpoint,
+		P256dh:   base64.RawURLEncoding.EncodeToString(b.key.PublicKey().Bytes()),
+		Auth:     base64.RawURLEncoding.EncodeToString(b.auth),
+		Teams:    teams,
+	}
+}
+
+func serve(s *subscriptionStore, method, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(method, SubscriptionsPath, strings.NewReader(body)))
+	return rec
+}
+
+func registerBody(sub subscription, teams string) string {
+	return fmt.Sprintf(`{"subscription":{"endpoint":%q,"keys":{"p256dh":%q,"auth":%q}},"teams":%s}`,
+		sub.Endpoint, sub.P256dh, sub.Auth, teams)
+}
+
+func TestSubscriptionStore_RegisterAndUnregister(t *testing.T) {
+	s, _ := newSubscriptionStore("")
+	sub := newTestBrowser(t).subscription("https://fcm.googleapis.com/sub/1")
+
+	if rec := serve(s, http.MethodPost, registerBody(sub, `["bos"," nyr","BOS"]`)); rec.Code != http.StatusNoContent {
+		t.Fatalf("register status = %d: %s", rec.Code, rec.Body)
+	}
+	got := s.ForTeams("NYR")
+	if len(got) != 1 || strings.Join(got[0].Teams, ",") != "BOS,NYR" {
+		t.Fatalf("ForTeams(NYR) = %+v, want the subscription with teams BOS,NYR", got)
+	}
+	if len(s.ForTeams("TOR")) != 0 {
+		t.Error("ForTeams(TOR) returned a subscription that does not follow TOR")
+	}
+
+	if rec := serve(s, http.MethodDelete, `{"endpoint":"https://fcm.googleapis.com/sub/1"}`); rec.Code != http.StatusNoContent {
+		t.Fatalf("unregister status = %d: %s", rec.Code, rec.Body)
+	}
+	if len(s.ForTeams("BOS", "NYR")) != 0 {
+		t.Error("subscription still present after DELETE")
+	}
+}
+
+func TestSubscriptionStore_RejectsInvalidRegistrations(t *testing.T) {
+	b := newTestBrowser(t)
+	good := b.subscription("https://fcm.googleapis.com/sub/1")
+	badKey := good
+	badKey.P256dh = base64.RawURLEncoding.EncodeToString([]byte("not a point"))
+	badAuth := good
+	badAuth.Auth = "c2hvcnQ"
+
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		want   int
+	}{
+		{"plain http endpoint", http.MethodPost, registerBody(b.subscription("http://fcm.googleapis.com/sub/1"), `["BOS"]`), http.StatusBadRequest},
+		{"unknown push service", http.MethodPost, registerBody(b.subscription("https://internal.example/hook"), `["BOS"]`), http.StatusBadRequest},
+		{"push service on another port", http.MethodPost, registerBody(b.subscription("https://fcm.googleapis.com:8443/sub/1"), `["BOS"]`), http.StatusBadRequest},
+		{"lookalike host", http.MethodPost, registerBody(b.subscription("https://fcm.googleapis.com.evil.example/sub/1"), `["BOS"]`), http.StatusBadRequest},
+		{"bad p256dh", http.MethodPost, registerBody(badKey, `["BOS"]`), http.StatusBadRequest},
+		{"bad auth", http.MethodPost, registerBody(badAuth, `["BOS"]`), http.StatusBadRequest},
+		{"no teams", http.MethodPost, registerBody(good, `[]`), http.StatusBadRequest},
+		{"unknown team", http.MethodPost, registerBody(good, `["XXX"]`), http.StatusBadRequest},
+		{"malformed body", http.MethodPost, `{`, http.StatusBadRequest},
+		{"delete without endpoint", http.MethodDelete, `{}`, http.StatusBadRequest},
+		{"get", http.MethodGet, ``, http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newSubscriptionStore("")
+			if rec := serve(s, tt.method, tt.body); rec.Code != tt.want {
+				t.Errorf("status = %d, want %d (%s)", rec.Code, tt.want, rec.Body)
+			}
+			if len(s.subs) != 0 {
+				t.Errorf("store has %d subscriptions, want none", len(s.subs))
+			}
+		})
+	}
+}
+
+func TestSubscriptionStore_AllowsSubdomainWildcard(t *testing.T) {
+	s, _ := newSubscriptionStore("")
+	sub := newTestBrowser(t).subscription("https://wns2-by3p.notify.windows.com/w/?token=abc")
+	if rec := serve(s, http.MethodPost, registerBody(sub, `["BOS"]`)); rec.Code != http.StatusNoContent {
+		t.Errorf("status = %d, want %d (%s)", rec.Code, http.StatusNoContent, rec.Body)
+	}
+}
+
+func TestSubscriptionStore_RefusesNewEndpointsWhenFull(t *testing.T) {
+	s, _ := newSubscriptionStore("")
+	s.max = 1
+	b := newTestBrowser(t)
+
+	if rec := serve(s, http.MethodPost, registerBody(b.subscription("https://fcm.googleapis.com/sub/1"), `["BOS"]`)); rec.Code != http.StatusNoContent {
+		t.Fatalf("first subscription: status %d: %s", rec.Code, rec.Body)
+	}
+	if rec := serve(s, http.MethodPost, registerBody(b.subscription("https://fcm.googleapis.com/sub/2"), `["BOS"]`)); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("second subscription: status %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+	if rec := serve(s, http.MethodPost, registerBody(b.subscription("https://fcm.googleapis.com/sub/1"), `["NYR"]`)); rec.Code != http.StatusNoContent {
+		t.Errorf("re-registering a stored endpoint: status %d, want %d", rec.Code, http.StatusNoContent)
+	}
+	if len(s.subs) != 1 || len(s.ForTeams("NYR")) != 1 {
+		t.Errorf("subscriptions = %+v, want only sub/1 following NYR", s.subs)
+	}
+}
+
+func TestSubscriptionStore_PersistsToFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "subs.json")
+	b := newTestBrowser(t)
+
+	s, err := newSubscriptionStore(path)
+	if err != nil {
+		t.Fatalf("newSubscriptionStore: %v", err)
+	}
+	s.Register(b.subscription("https://fcm.googleapis.com/sub/1", "BOS"))
+	s.Register(b.subscription("https://fcm.googleapis.com/sub/2", "NYR"))
+	s.Remove("https://fcm.googleapis.com/sub/1")
+
+	reloaded, err := newSubscriptionStore(path)
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if len(reloaded.subs) != 1 || len(reloaded.ForTeams("NYR")) != 1 {
+		t.Errorf("reloaded subscriptions = %+v, want only sub/2", reloaded.subs)
+	}
+}
@@ -0,0 +1,132 @@
+package webpush
+
+// VAPID (RFC 8292) identifies this server to push services:
+//
+//   authorization: vapid t={jwt}, k={base64url application server public key}
+//
+//   header: {"typ":"JWT","alg":"ES256"}
+//   claims: {"aud":"https://push.service.origin","exp":now+12h,"sub":WEBPUSH_SUBJECT}
+//
+// A token is cached per push-service origin and re-signed an hour before it
+// expires.
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	vapidTokenLifetime = 12 * time.Hour
+	vapidRefreshMargin = time.Hour
+)
+
+type vapidSigner struct {
+	key       *ecdsa.PrivateKey
+	publicKey string // base64url uncompressed point, the browser's applicationServerKey
+	subject   string
+
+	mu     sync.Mutex
+	tokens map[string]vapidToken // audience → token
+}
+
+type vapidToken struct {
+	jwt       string
+	expiresAt time.Time
+}
+
+// newVAPIDSigner parses a base64url raw P-256 private scalar (the format
+// `web-push generate-vapid-keys` prints) and derives the public key.
+func newVAPIDSigner(privateKey, subject string) (*vapidSigner, error) {
+	d, err := decodeKey(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("base64url-decode WEBPUSH_VAPID_PRIVATE_KEY: %w", err)
+	}
+	priv, err := ecdh.P256().NewPrivateKey(d)
+	if err != nil {
+		return nil, fmt.Errorf("WEBPUSH_VAPID_PRIVATE_KEY is not a P-256 private key: %w", err)
+	}
+	pub := priv.PublicKey().Bytes() // 0x04 | X | Y
+
+	key := &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(pub[1:33]),
+			Y:     new(big.Int).SetBytes(pub[33:]),
+		},
+		D: new(big.Int).SetBytes(d),
+	}
+	return &vapidSigner{
+		key:       key,
+		publicKey: base64.RawURLEncoding.EncodeToString(pub),
+		subject:   subject,
+		tokens:    map[string]vapidToken{},
+	}, nil
+}
+
+// Authorization returns the authorization header value for a push to endpoint.
+func (s *vapidSigner) Authorization(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("parse endpoint: %w", err)
+	}
+	audience := u.Scheme + "://" + u.Host
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tok, ok := s.tokens[audience]
+	if !ok || time.Until(tok.expiresAt) < vapidRefreshMargin {
+		expiresAt := time.Now().Add(vapidTokenLifetime)
+		jwt, err := s.sign(audience, expiresAt)
+		if err != nil {
+			return "", err
+		}
+		tok = vapidToken{jwt: jwt, expiresAt: expiresAt}
+		s.tokens[audience] = tok
+	}
+	return fmt.Sprintf("vapid t=%s, k=%s", tok.jwt, s.publicKey), nil
+}
+
+func (s *vapidSigner) sign(audience string, expiresAt time.Time) (string, error) {
+	header, err := base64JSON(map[string]string{"typ": "JWT", "alg": "ES256"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := base64JSON(map[string]interface{}{
+		"aud": audience,
+		"exp": expiresAt.Unix(),
+		"sub": s.subject,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	msg := header + "." + claims
+	digest := sha256.Sum256([]byte(msg))
+	r, sig, err := ecdsa.Sign(rand.Reader, s.key, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("ecdsa sign: %w", err)
+	}
+	// ES256 signature encoding: r || s, each left-padded to 32 bytes
+	raw := make([]byte, 64)
+	r.FillBytes(raw[:32])
+	sig.FillBytes(raw[32:])
+	return msg + "." + base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func base64JSON(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("marshal JWT part: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
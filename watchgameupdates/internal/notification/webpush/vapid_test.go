@@ -0,0 +1,99 @@
+package webpush
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testVAPIDKey returns a fresh base64url VAPID private key.
+func testVAPIDKey(t *testing.T) string {
+	t.Helper()
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(key.Bytes())
+}
+
+// parseVAPIDHeader splits "vapid t=..., k=..." and verifies the JWT signature
+// against k, returning the claims.
+func parseVAPIDHeader(t *testing.T, header string) (claims map[string]interface{}, publicKey string) {
+	t.Helper()
+	rest, ok := strings.CutPrefix(header, "vapid t=")
+	if !ok {
+		t.Fatalf("authorization = %q, want vapid scheme", header)
+	}
+	jwt, publicKey, ok := strings.Cut(rest, ", k=")
+	if !ok {
+		t.Fatalf("authorization = %q, want k= parameter", header)
+	}
+
+	parts := strings.Split(jwt, ".")
+	if len(parts) != 3 {
+		t.Fatalf("jwt has %d parts, want 3", len(parts))
+	}
+	pub := b64(t, publicKey)
+	if len(pub) != 65 || pub[0] != 0x04 {
+		t.Fatalf("k is not an uncompressed P-256 point")
+	}
+	key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(pub[1:33]), Y: new(big.Int).SetBytes(pub[33:])}
+	sig := b64(t, parts[2])
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if len(sig) != 64 || !ecdsa.Verify(key, digest[:], new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])) {
+		t.Fatal("JWT signature does not verify against k")
+	}
+	if err := json.Unmarshal(b64(t, parts[1]), &claims); err != nil {
+		t.Fatalf("claims: %v", err)
+	}
+	return claims, publicKey
+}
+
+func TestVAPIDSigner_Authorization(t *testing.T) {
+	priv := testVAPIDKey(t)
+	s, err := newVAPIDSigner(priv, "mailto:ops@example.com")
+	if err != nil {
+		t.Fatalf("newVAPIDSigner: %v", err)
+	}
+
+	header, err := s.Authorization("https://updates.push.services.mozilla.com/wpush/v2/abc")
+	if err != nil {
+		t.Fatalf("Authorization: %v", err)
+	}
+	claims, k := parseVAPIDHeader(t, header)
+	if k != s.publicKey {
+		t.Errorf("k = %q, want the derived public key %q", k, s.publicKey)
+	}
+	if claims["aud"] != "https://updates.push.services.mozilla.com" || claims["sub"] != "mailto:ops@example.com" {
+		t.Errorf("claims = %v, want push service origin and subject", claims)
+	}
+	exp := time.Unix(int64(claims["exp"].(float64)), 0)
+	if d := time.Until(exp); d <= 11*time.Hour || d > vapidTokenLifetime {
+		t.Errorf("exp in %v, want about %v", d, vapidTokenLifetime)
+	}
+
+	again, _ := s.Authorization("https://updates.push.services.mozilla.com/wpush/v2/other")
+	if again != header {
+		t.Error("token for the same origin was re-signed, want it cached")
+	}
+	other, _ := s.Authorization("https://fcm.googleapis.com/fcm/send/xyz")
+	if other == header {
+		t.Error("different origin reused the token, want a new audience")
+	}
+}
+
+func TestNewVAPIDSigner_RejectsBadKey(t *testing.T) {
+	for _, key := range []string{"", "not base64!", base64.RawURLEncoding.EncodeToString([]byte("short"))} {
+		if _, err := newVAPIDSigner(key, "mailto:ops@example.com"); err == nil {
+			t.Errorf("newVAPIDSigner(%q): want error", key)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+// Package opsalert raises operator-facing alerts: conditions someone has to act
+// on (a dead APNs channel, an auth key for the wrong environment), as opposed
+// to the fan-facing notifications in package notification.
+//
+// Every alert is logged. When OPS_ALERT_WEBHOOK_URL is set it is also posted
+// to that Discord-compatible webhook as {"content": "..."}.
+package opsalert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+)
+
+const webhookTimeout = 10 * time.Second
+
+// Alerter raises an alert. subject is a short headline; detail says what
+// happened and what to do about it.
+type Alerter interface {
+	Alert(ctx context.Context, subject, detail string) error
+}
+
+// Log only writes alerts to the process log.
+var Log Alerter = logAlerter{}
+
+// FromEnv returns a webhook alerter when OPS_ALERT_WEBHOOK_URL is set and Log
+// otherwise.
+func FromEnv() Alerter {
+	url := os.Getenv("OPS_ALERT_WEBHOOK_URL")
+	if url == "" {
+		return Log
+	}
+	return NewWebhook(url)
+}
+
+type logAlerter struct{}
+
+func (logAlerter) Alert(_ context.Context, subject, detail string) error {
+	log.Printf("OPS ALERT: %s: %s", subject, detail)
+	return nil
+}
+
+// Webhook posts alerts to a Discord-compatible incoming webhook.
+type Webhook struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhook returns an Alerter posting to url.
+func NewWebhook(url string) *Webhook {
+	return &Webhook{url: url, client: &http.Client{Timeout: webhookTimeout}}
+}
+
+func (w *Webhook) Alert(ctx context.Context, subject, detail string) error {
+	Log.Alert(ctx, subject, detail)
+
+	body, err := json.Marshal(map[string]string{"content": fmt.Sprintf("**%s**\n%s", subject, detail)})
+	if err != nil {
+		return fmt.Errorf("marshal ops alert: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build ops alert request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("post ops alert: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("post ops alert: webhook answered %d", resp.StatusCode)
+	}
+	return nil
+}
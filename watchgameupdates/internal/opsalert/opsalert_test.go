@@ -0,0 +1,48 @@
+package opsalert
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWebhook_PostsContent(t *testing.T) {
+	var got map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	if err := NewWebhook(srv.URL).Alert(context.Background(), "Channel gone", "BOS channel returned 410"); err != nil {
+		t.Fatalf("Alert: %v", err)
+	}
+	if !strings.Contains(got["content"], "Channel gone") || !strings.Contains(got["content"], "BOS channel returned 410") {
+		t.Errorf("content = %q, want subject and detail", got["content"])
+	}
+}
+
+func TestWebhook_ErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	if err := NewWebhook(srv.URL).Alert(context.Background(), "s", "d"); err == nil {
+		t.Error("want error for 400 from webhook")
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("OPS_ALERT_WEBHOOK_URL", "")
+	if FromEnv() != Log {
+		t.Error("want Log alerter without OPS_ALERT_WEBHOOK_URL")
+	}
+	t.Setenv("OPS_ALERT_WEBHOOK_URL", "https://example.invalid/hook")
+	if _, ok := FromEnv().(*Webhook); !ok {
+		t.Error("want Webhook alerter with OPS_ALERT_WEBHOOK_URL")
+	}
+}
@@ -5,10 +5,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"path"
+	"strings"
 	"time"
 
 	"watchgameupdates/config"
 	"watchgameupdates/internal/models"
+	"watchgameupdates/internal/taskauth"
 	"watchgameupdates/internal/tasks"
 
 	taskspb "cloud.google.com/go/cloudtasks/apiv2/cloudtaskspb"
@@ -21,8 +24,12 @@ type CloudTasksQueue struct {
 	cfg    *config.Config
 }
 
-// NewCloudTasksQueue creates a new CloudTasksQueue.
+// NewCloudTasksQueue creates a new CloudTasksQueue. Tasks it creates carry
+// the credentials for cfg.TaskAuth.
 func NewCloudTasksQueue(ctx context.Context, cfg *config.Config) (*CloudTasksQueue, error) {
+	if err := validateTaskAuth(cfg); err != nil {
+		return nil, err
+	}
 	client, err := tasks.NewCloudTasksClient(ctx, cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create cloud tasks client: %w", err)
@@ -31,40 +38,67 @@ func NewCloudTasksQueue(ctx context.Context, cfg *config.Config) (*CloudTasksQue
 }
 
 func (q *CloudTasksQueue) Enqueue(ctx context.Context, payload models.Payload, deliverAt time.Time) error {
+	queuePath := q.queuePath()
+	log.Printf("Enqueuing task for game %s (%s vs %s) scheduled at %s",
+		payload.Game.ID,
+		payload.Game.AwayTeam.Abbrev,
+		payload.Game.HomeTeam.Abbrev,
+		deliverAt.Format(time.RFC3339))
+	return q.createTask(ctx, payload, deliverAt, tasks.TaskName(queuePath, payload), nil)
+}
+
+// EnqueuePregame implements scheduler.PregameEnqueuer. The task goes to the
+// same handler URL, marked with tasks.TaskTypeHeader.
+func (q *CloudTasksQueue) EnqueuePregame(ctx context.Context, payload models.Payload, deliverAt time.Time) error {
+	queuePath := q.queuePath()
+	log.Printf("Enqueuing pregame task for game %s (%s vs %s) scheduled at %s",
+		payload.Game.ID,
+		payload.Game.AwayTeam.Abbrev,
+		payload.Game.HomeTeam.Abbrev,
+		deliverAt.Format(time.RFC3339))
+	return q.createTask(ctx, payload, deliverAt, tasks.PregameTaskName(queuePath, payload),
+		map[string]string{tasks.TaskTypeHeader: tasks.TypePregame})
+}
+
+// createTask creates an HTTP task posting payload to the handler at
+// deliverAt. An existing task with the same name counts as success.
+func (q *CloudTasksQueue) createTask(ctx context.Context, payload models.Payload, deliverAt time.Time, name string, headers map[string]string) error {
 	payloadJSON, err := json.Marshal(payload)
 	if err != nil {
 		return fmt.Errorf("failed to marshal payload: %w", err)
 	}
 
-	queuePath := fmt.Sprintf("projects/%s/locations/%s/queues/%s",
-		q.cfg.ProjectID, q.cfg.LocationID, q.cfg.QueueID)
+	requestHeaders := map[string]string{
+		"Content-Type": "application/json",
+	}
+	for k, v := range headers {
+		requestHeaders[k] = v
+	}
+
+	httpReq := &taskspb.HttpRequest{
+		HttpMethod: taskspb.HttpMethod_POST,
+		Url:        q.cfg.HandlerAddress,
+		Headers:    requestHeaders,
+		Body:       payloadJSON,
+	}
+	q.authorize(httpReq, deliverAt)
 
 	task := &taskspb.Task{
-		MessageType: &taskspb.Task_HttpRequest{
-			HttpRequest: &taskspb.HttpRequest{
-				HttpMethod: taskspb.HttpMethod_POST,
-				Url:        q.cfg.HandlerAddress,
-				Headers: map[string]string{
-					"Content-Type": "application/json",
-				},
-				Body: payloadJSON,
-			},
-		},
+		MessageType:  &taskspb.Task_HttpRequest{HttpRequest: httpReq},
 		ScheduleTime: timestamppb.New(deliverAt),
+		Name:         name,
 	}
 
 	req := &taskspb.CreateTaskRequest{
-		Parent: queuePath,
+		Parent: q.queuePath(),
 		Task:   task,
 	}
 
-	log.Printf("Enqueuing task for game %s (%s vs %s) scheduled at %s",
-		payload.Game.ID,
-		payload.Game.AwayTeam.Abbrev,
-		payload.Game.HomeTeam.Abbrev,
-		deliverAt.Format(time.RFC3339))
-
 	_, err = q.client.CreateTask(ctx, req)
+	if tasks.IsDuplicateTask(err) {
+		log.Printf("Task %s for game %s already exists, not enqueuing again", task.Name, payload.Game.ID)
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("failed to create task: %w", err)
 	}
@@ -72,6 +106,200 @@ func (q *CloudTasksQueue) Enqueue(ctx context.Context, payload models.Payload, d
 	return nil
 }
 
+// validateTaskAuth checks that cfg has what its TASK_AUTH mode needs to
+// create authenticated tasks.
+func validateTaskAuth(cfg *config.Config) error {
+	switch cfg.TaskAuth {
+	case taskauth.ModeNone:
+		return nil
+	case taskauth.ModeOIDC:
+		if cfg.TaskAuthServiceAccount == "" {
+			return fmt.Errorf("TASK_AUTH=oidc needs TASK_AUTH_SERVICE_ACCOUNT")
+		}
+		return nil
+	case taskauth.ModeHMAC:
+		if cfg.TaskAuthHMACSecret == "" {
+			return fmt.Errorf("TASK_AUTH=hmac needs TASK_AUTH_HMAC_SECRET")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown TASK_AUTH %q; use oidc or hmac", cfg.TaskAuth)
+	}
+}
+
+// authorize attaches the handler's credentials to req: an OIDC token Cloud
+// Tasks mints at dispatch, or an HMAC signature of the body and task type. A task due
+// already is signed for now, so the signature is fresh when it arrives.
+func (q *CloudTasksQueue) authorize(req *taskspb.HttpRequest, deliverAt time.Time) {
+	switch q.cfg.TaskAuth {
+	case taskauth.ModeOIDC:
+		req.AuthorizationHeader = &taskspb.HttpRequest_OidcToken{
+			OidcToken: &taskspb.OidcToken{
+				ServiceAccountEmail: q.cfg.TaskAuthServiceAccount,
+				Audience:            q.cfg.TaskAuthAudience,
+			},
+		}
+	case taskauth.ModeHMAC:
+		req.Headers[taskauth.SignatureHeader] = taskauth.Sign(q.cfg.TaskAuthHMACSecret, req.Headers[tasks.TaskTypeHeader], req.Body, later(deliverAt, time.Now()))
+	}
+}
+
+func later(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+// CloudTasksMaxScheduleAhead stays under Cloud Tasks' 30-day limit on a
+// task's schedule time, with a day of margin for a slow run.
+const CloudTasksMaxScheduleAhead = 29 * 24 * time.Hour
+
+// MaxScheduleAhead implements scheduler.HorizonLimited.
+func (q *CloudTasksQueue) MaxScheduleAhead() time.Duration {
+	return CloudTasksMaxScheduleAhead
+}
+
 func (q *CloudTasksQueue) Close() error {
 	return q.client.Close()
 }
+
+func (q *CloudTasksQueue) queuePath() string {
+	return fmt.Sprintf("projects/%s/locations/%s/queues/%s",
+		q.cfg.ProjectID, q.cfg.LocationID, q.cfg.QueueID)
+}
+
+// CloudTask is a task in the Cloud Tasks queue with its decoded payload.
+type CloudTask struct {
+	Name         string
+	Type         string // tasks.TypeWatchGameUpdates or tasks.TypePregame
+	State        string
+	ScheduleTime time.Time
+	Payload      models.Payload
+	// Err says why the task is not a game task this service created, e.g. a
+	// body that is not a game payload; Payload is then empty.
+	Err error
+}
+
+// ListTasks returns every task in the queue, game checks and pregame tasks,
+// in queue order.
+func (q *CloudTasksQueue) ListTasks(ctx context.Context) ([]CloudTask, error) {
+	all, err := q.client.ListTasks(ctx, &taskspb.ListTasksRequest{
+		Parent:       q.queuePath(),
+		ResponseView: taskspb.Task_FULL, // BASIC omits the request body
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tasks: %w", err)
+	}
+
+	result := make([]CloudTask, 0, len(all))
+	for _, task := range all {
+		t := CloudTask{
+			Name:         task.Name,
+			Type:         tasks.TypeWatchGameUpdates,
+			State:        cloudTaskState(task),
+			ScheduleTime: task.ScheduleTime.AsTime(),
+		}
+		httpReq := task.GetHttpRequest()
+		if httpReq == nil {
+			t.Err = fmt.Errorf("not an HTTP task")
+			result = append(result, t)
+			continue
+		}
+		if httpReq.Headers[tasks.TaskTypeHeader] == tasks.TypePregame {
+			t.Type = tasks.TypePregame
+		}
+		if err := json.Unmarshal(httpReq.Body, &t.Payload); err != nil {
+			t.Payload = models.Payload{}
+			t.Err = fmt.Errorf("body is not a game payload: %w", err)
+		} else if t.Payload.Game.ID == "" {
+			t.Err = fmt.Errorf("payload has no game ID")
+		}
+		result = append(result, t)
+	}
+	return result, nil
+}
+
+// DeleteTask deletes the task with the fully qualified name.
+func (q *CloudTasksQueue) DeleteTask(ctx context.Context, name string) error {
+	if err := q.client.DeleteTask(ctx, &taskspb.DeleteTaskRequest{Name: name}); err != nil {
+		return fmt.Errorf("failed to delete task %s: %w", name, err)
+	}
+	return nil
+}
+
+// ListGames returns the game tracking tasks in the queue, soonest first.
+// Pregame tasks and tasks whose body is not a game payload are skipped.
+func (q *CloudTasksQueue) ListGames(ctx context.Context) ([]GameLoop, error) {
+	all, err := q.ListTasks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var loops []GameLoop
+	for _, task := range all {
+		if task.Err != nil || task.Type != tasks.TypeWatchGameUpdates {
+			continue
+		}
+		loops = append(loops, newGameLoop(task.Name, task.State, task.ScheduleTime, task.Payload))
+	}
+	sortLoops(loops)
+	return loops, nil
+}
+
+// CountGames returns how many game checks the queue holds, as ListGames would
+// list them, from the task names alone: the BASIC view leaves out the request
+// bodies, so the count stays cheap on a large queue. Checks without a
+// deterministic name (tasks.TaskID) are not counted.
+func (q *CloudTasksQueue) CountGames(ctx context.Context) (int, error) {
+	all, err := q.client.ListTasks(ctx, &taskspb.ListTasksRequest{
+		Parent:       q.queuePath(),
+		ResponseView: taskspb.Task_BASIC,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list tasks: %w", err)
+	}
+	n := 0
+	for _, task := range all {
+		if strings.HasPrefix(path.Base(task.Name), tasks.GameTaskIDPrefix) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// cloudTaskState maps a task's attempt counters to a GameLoop state.
+func cloudTaskState(task *taskspb.Task) string {
+	switch {
+	case task.DispatchCount > task.ResponseCount:
+		return StateActive
+	case task.ResponseCount > 0:
+		return StateRetry
+	case task.ScheduleTime.AsTime().After(time.Now()):
+		return StateScheduled
+	default:
+		return StatePending
+	}
+}
+
+// CancelGame deletes every queued task for gameID and returns how many were
+// removed. A check already being delivered cannot be recalled; its handler
+// may still schedule one more task.
+func (q *CloudTasksQueue) CancelGame(ctx context.Context, gameID string) (int, error) {
+	loops, err := q.ListGames(ctx)
+	if err != nil {
+		return 0, err
+	}
+	deleted := 0
+	for _, loop := range loops {
+		if loop.GameID != gameID {
+			continue
+		}
+		if err := q.DeleteTask(ctx, loop.TaskID); err != nil {
+			return deleted, err
+		}
+		log.Printf("Deleted task %s for game %s", loop.TaskID, gameID)
+		deleted++
+	}
+	return deleted, nil
+}
@@ -0,0 +1,101 @@
+package queue
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"watchgameupdates/config"
+	"watchgameupdates/internal/taskauth"
+	"watchgameupdates/internal/tasks"
+
+	taskspb "cloud.google.com/go/cloudtasks/apiv2/cloudtaskspb"
+)
+
+// fakeCloudTasks serves a fixed task list and records the requests it gets.
+type fakeCloudTasks struct {
+	tasks   []*taskspb.Task
+	list    *taskspb.ListTasksRequest
+	created []*taskspb.Task
+}
+
+func (f *fakeCloudTasks) CreateTask(_ context.Context, req *taskspb.CreateTaskRequest) (*taskspb.Task, error) {
+	f.created = append(f.created, req.Task)
+	return req.Task, nil
+}
+
+func (f *fakeCloudTasks) ListTasks(_ context.Context, req *taskspb.ListTasksRequest) ([]*taskspb.Task, error) {
+	f.list = req
+	return f.tasks, nil
+}
+
+func (f *fakeCloudTasks) DeleteTask(context.Context, *taskspb.DeleteTaskRequest) error { return nil }
+
+func (f *fakeCloudTasks) Close() error { return nil }
+
+func TestCloudTasksQueue_CountGames(t *testing.T) {
+	const queuePath = "projects/p/locations/l/queues/q"
+	client := &fakeCloudTasks{tasks: []*taskspb.Task{
+		{Name: queuePath + "/tasks/game-2025020001-1759964400-0"},
+		{Name: queuePath + "/tasks/game-2025020002-1759964400-7"},
+		{Name: queuePath + "/tasks/pregame-2025020001-1759964400"},
+		{Name: queuePath + "/tasks/0123456789"},
+	}}
+	q := &CloudTasksQueue{client: client, cfg: &config.Config{ProjectID: "p", LocationID: "l", QueueID: "q"}}
+
+	n, err := q.CountGames(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("CountGames = %d, want the 2 game checks", n)
+	}
+	if client.list.Parent != queuePath || client.list.ResponseView != taskspb.Task_BASIC {
+		t.Errorf("list request = %v, want the BASIC view of %s", client.list, queuePath)
+	}
+}
+
+func TestCloudTasksQueue_HMACCoversTaskType(t *testing.T) {
+	client := &fakeCloudTasks{}
+	q := &CloudTasksQueue{client: client, cfg: &config.Config{
+		ProjectID: "p", LocationID: "l", QueueID: "q",
+		HandlerAddress:     "https://handler.example.com/",
+		TaskAuth:           taskauth.ModeHMAC,
+		TaskAuthHMACSecret: "s3cret",
+	}}
+	ctx := context.Background()
+	if err := q.Enqueue(ctx, testPayload("1", 0), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.EnqueuePregame(ctx, testPayload("1", 0), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if len(client.created) != 2 {
+		t.Fatalf("created %d tasks, want 2", len(client.created))
+	}
+
+	verifier := taskauth.NewHMACVerifier("s3cret")
+	check, pregame := client.created[0].GetHttpRequest(), client.created[1].GetHttpRequest()
+	for _, tt := range []struct {
+		name     string
+		signed   *taskspb.HttpRequest
+		taskType string
+		wantErr  bool
+	}{
+		{"game check", check, "", false},
+		{"pregame task", pregame, tasks.TypePregame, false},
+		{"game check replayed as pregame", check, tasks.TypePregame, true},
+		{"pregame replayed as game check", pregame, "", true},
+	} {
+		r := httptest.NewRequest("POST", "/", bytes.NewReader(tt.signed.Body))
+		r.Header.Set(taskauth.SignatureHeader, tt.signed.Headers[taskauth.SignatureHeader])
+		if tt.taskType != "" {
+			r.Header.Set(tasks.TaskTypeHeader, tt.taskType)
+		}
+		if err := verifier.Verify(r, tt.signed.Body); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Verify() error = %v, wantErr %t", tt.name, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,399 @@
+package queue
+
+import (
+	"bytes"
+	"container/heap"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"watchgameupdates/internal/models"
+	"watchgameupdates/internal/tasks"
+
+	"github.com/google/uuid"
+)
+
+// memoryRetention keeps the IDs of finished tasks so they keep colliding with
+// late duplicates, as completed asynq tasks are retained.
+const memoryRetention = 24 * time.Hour
+
+// MemoryQueue is an in-process queue for -mode=standalone and tests: a heap of
+// tasks ordered by delivery time, delivered by Serve. It implements the same
+// interfaces as RedisQueue, pregame tasks and the admin API included. With a
+// file path the queued tasks and the IDs of finished ones are saved after
+// every change and restored by NewMemoryQueue, so a restart resumes the game
+// loops without repeating a check.
+type MemoryQueue struct {
+	path       string
+	now        func() time.Time
+	retryDelay time.Duration // taskRetryDelay; shorter in tests
+	wake       chan struct{}
+
+	mu       sync.Mutex
+	queue    taskHeap
+	tasks    map[string]*memoryTask // queued and running, by task ID
+	finished map[string]time.Time   // finished within memoryRetention, by task ID
+	seq      uint64
+}
+
+// memoryQueueFile is the layout of the queue file. Files written before
+// finished IDs were kept hold just the task list.
+type memoryQueueFile struct {
+	Tasks    []*memoryTask        `json:"tasks"`
+	Finished map[string]time.Time `json:"finished,omitempty"`
+}
+
+// memoryTask is one task; the exported fields are what the file keeps.
+type memoryTask struct {
+	ID        string         `json:"id"`
+	Type      string         `json:"type"` // tasks.TypeWatchGameUpdates or tasks.TypePregame
+	Payload   models.Payload `json:"payload"`
+	DeliverAt time.Time      `json:"deliverAt"`
+	Retried   int            `json:"retried,omitempty"`
+
+	seq    uint64             // enqueue order, breaks delivery time ties
+	index  int                // position in the heap; -1 while running
+	cancel context.CancelFunc // set while running
+}
+
+// NewMemoryQueue returns an empty queue, or the one saved at path. An empty
+// path keeps the queue in memory only.
+func NewMemoryQueue(path string) (*MemoryQueue, error) {
+	q := &MemoryQueue{
+		path:       path,
+		now:        time.Now,
+		retryDelay: taskRetryDelay,
+		wake:       make(chan struct{}, 1),
+		tasks:      map[string]*memoryTask{},
+		finished:   map[string]time.Time{},
+	}
+	if path == "" {
+		return q, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return q, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read queue file: %w", err)
+	}
+	var saved memoryQueueFile
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		err = json.Unmarshal(data, &saved.Tasks)
+	} else {
+		err = json.Unmarshal(data, &saved)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse queue file %s: %w", path, err)
+	}
+	for _, t := range saved.Tasks {
+		q.push(t)
+	}
+	for id, at := range saved.Finished {
+		q.finished[id] = at
+	}
+	q.pruneFinished()
+	log.Printf("Restored %d queued tasks from %s", len(saved.Tasks), path)
+	return q, nil
+}
+
+func (q *MemoryQueue) Enqueue(_ context.Context, payload models.Payload, deliverAt time.Time) error {
+	return q.add(tasks.TaskID(payload), tasks.TypeWatchGameUpdates, payload, deliverAt)
+}
+
+// EnqueuePregame implements scheduler.PregameEnqueuer.
+func (q *MemoryQueue) EnqueuePregame(_ context.Context, payload models.Payload, deliverAt time.Time) error {
+	return q.add(tasks.PregameTaskID(payload), tasks.TypePregame, payload, deliverAt)
+}
+
+// add queues a task unless one with the same ID is queued, running or
+// finished within memoryRetention, which counts as success as it does on the
+// other queues. A task without an ID gets a random one.
+func (q *MemoryQueue) add(id, taskType string, payload models.Payload, deliverAt time.Time) error {
+	if id == "" {
+		id = uuid.NewString()
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	_, queued := q.tasks[id]
+	finishedAt, finished := q.finished[id]
+	if queued || finished && q.now().Sub(finishedAt) < memoryRetention {
+		log.Printf("Task %s for game %s already exists, not enqueuing again", id, payload.Game.ID)
+		return nil
+	}
+	q.push(&memoryTask{ID: id, Type: taskType, Payload: payload, DeliverAt: deliverAt})
+	q.save()
+	log.Printf("Enqueuing %s task for game %s (%s vs %s) scheduled at %s, task ID: %s",
+		taskType,
+		payload.Game.ID,
+		payload.Game.AwayTeam.Abbrev,
+		payload.Game.HomeTeam.Abbrev,
+		deliverAt.Format(time.RFC3339),
+		id)
+	q.signal()
+	return nil
+}
+
+// push adds t to the heap. The caller holds q.mu, or owns q exclusively.
+func (q *MemoryQueue) push(t *memoryTask) {
+	q.seq++
+	t.seq = q.seq
+	q.tasks[t.ID] = t
+	heap.Push(&q.queue, t)
+}
+
+// signal wakes Serve to look at the head of the queue again.
+func (q *MemoryQueue) signal() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Serve delivers due tasks to the handler for their type, up to concurrency
+// at a time, until ctx is cancelled. It then stops taking tasks and waits for
+// the running ones, which are not cancelled: a game check cut short would not
+// schedule the next one.
+func (q *MemoryQueue) Serve(ctx context.Context, concurrency int, handlers map[string]TaskHandler) error {
+	if concurrency < 1 {
+		return fmt.Errorf("concurrency must be positive, got %d", concurrency)
+	}
+	slots := make(chan struct{}, concurrency)
+	var running sync.WaitGroup
+	defer running.Wait()
+
+	for {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil
+		}
+		task, taskCtx, wait := q.next(ctx)
+		if task == nil {
+			<-slots
+			if !q.wait(ctx, wait) {
+				return nil
+			}
+			continue
+		}
+
+		running.Add(1)
+		go func() {
+			defer running.Done()
+			defer func() { <-slots }()
+			q.run(taskCtx, task, handlers[task.Type])
+		}()
+	}
+}
+
+// next takes the head of the queue if it is due, with the context to run it
+// in: one CancelGame can cancel, but not the shutdown of ctx. Otherwise it
+// returns how long until the head is due, or 0 for an empty queue.
+func (q *MemoryQueue) next(ctx context.Context) (*memoryTask, context.Context, time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.queue) == 0 {
+		return nil, nil, 0
+	}
+	head := q.queue[0]
+	if wait := head.DeliverAt.Sub(q.now()); wait > 0 {
+		return nil, nil, wait
+	}
+	heap.Pop(&q.queue)
+	taskCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	head.cancel = cancel
+	return head, taskCtx, 0
+}
+
+// wait blocks until d has passed (forever for 0), a task is added or ctx is
+// cancelled, and reports whether Serve should go on.
+func (q *MemoryQueue) wait(ctx context.Context, d time.Duration) bool {
+	var due <-chan time.Time
+	if d > 0 {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		due = timer.C
+	}
+	select {
+	case <-ctx.Done():
+		return false
+	case <-q.wake:
+	case <-due:
+	}
+	return true
+}
+
+// run hands task to handler, then retries or forgets it.
+func (q *MemoryQueue) run(ctx context.Context, task *memoryTask, handler TaskHandler) {
+	defer task.cancel()
+	if handler == nil {
+		log.Printf("Dropping task %s of unknown type %q", task.ID, task.Type)
+		q.finish(task)
+		return
+	}
+
+	err := handler(ctx, task.Payload)
+
+	switch {
+	case err == nil || ctx.Err() != nil:
+		q.finish(task)
+	case task.Retried >= taskMaxRetries:
+		log.Printf("Dropping task %s after %d retries: %v", task.ID, task.Retried, err)
+		q.finish(task)
+	default:
+		delay := q.retryDelay << task.Retried
+		log.Printf("Task %s failed, retrying in %s: %v", task.ID, delay, err)
+		q.mu.Lock()
+		task.Retried++
+		task.DeliverAt = q.now().Add(delay)
+		task.cancel = nil
+		q.push(task)
+		q.save()
+		q.mu.Unlock()
+		q.signal()
+	}
+}
+
+// finish forgets a task that left the heap, keeping its ID for
+// memoryRetention.
+func (q *MemoryQueue) finish(task *memoryTask) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.tasks, task.ID)
+	q.finished[task.ID] = q.now()
+	q.pruneFinished()
+	q.save()
+}
+
+// pruneFinished forgets finished IDs older than memoryRetention. The caller
+// holds q.mu, or owns q exclusively.
+func (q *MemoryQueue) pruneFinished() {
+	now := q.now()
+	for id, at := range q.finished {
+		if now.Sub(at) >= memoryRetention {
+			delete(q.finished, id)
+		}
+	}
+}
+
+// ListGames returns the game tracking tasks queued or running, soonest first.
+func (q *MemoryQueue) ListGames(_ context.Context) ([]GameLoop, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	now := q.now()
+	var loops []GameLoop
+	for _, t := range q.tasks {
+		if t.Type != tasks.TypeWatchGameUpdates {
+			continue
+		}
+		loops = append(loops, newGameLoop(t.ID, t.state(now), t.DeliverAt, t.Payload))
+	}
+	sortLoops(loops)
+	return loops, nil
+}
+
+func (t *memoryTask) state(now time.Time) string {
+	switch {
+	case t.index < 0:
+		return StateActive
+	case t.Retried > 0:
+		return StateRetry
+	case t.DeliverAt.After(now):
+		return StateScheduled
+	default:
+		return StatePending
+	}
+}
+
+// CancelGame removes every queued task for gameID, pregame tasks included,
+// and cancels a running check, which then finishes without rescheduling. It
+// returns how many game tracking tasks were removed or cancelled.
+func (q *MemoryQueue) CancelGame(_ context.Context, gameID string) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	cancelled := 0
+	for id, t := range q.tasks {
+		if t.Payload.Game.ID != gameID {
+			continue
+		}
+		if t.index < 0 {
+			t.cancel()
+		} else {
+			heap.Remove(&q.queue, t.index)
+			delete(q.tasks, id)
+		}
+		if t.Type == tasks.TypeWatchGameUpdates {
+			log.Printf("Cancelled %s task %s for game %s", t.state(q.now()), id, gameID)
+			cancelled++
+		}
+	}
+	q.save()
+	return cancelled, nil
+}
+
+// Close implements GameTaskQueue. The queue is saved on every change, so
+// there is nothing left to flush.
+func (q *MemoryQueue) Close() error {
+	return nil
+}
+
+// save writes the queued and running tasks and the finished IDs to q.path,
+// replacing the file atomically. Running tasks are kept so a crash delivers
+// them again. The caller holds q.mu.
+func (q *MemoryQueue) save() {
+	if q.path == "" {
+		return
+	}
+	saved := memoryQueueFile{Tasks: make([]*memoryTask, 0, len(q.tasks)), Finished: q.finished}
+	for _, t := range q.tasks {
+		saved.Tasks = append(saved.Tasks, t)
+	}
+	data, err := json.Marshal(saved)
+	if err == nil {
+		tmp := q.path + ".tmp"
+		if err = os.WriteFile(tmp, data, 0o644); err == nil {
+			err = os.Rename(tmp, q.path)
+		}
+	}
+	if err != nil {
+		log.Printf("WARNING: failed to save queue to %s: %v", q.path, err)
+	}
+}
+
+// taskHeap orders tasks by delivery time, then enqueue order.
+type taskHeap []*memoryTask
+
+func (h taskHeap) Len() int { return len(h) }
+
+func (h taskHeap) Less(i, j int) bool {
+	if !h[i].DeliverAt.Equal(h[j].DeliverAt) {
+		return h[i].DeliverAt.Before(h[j].DeliverAt)
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h taskHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *taskHeap) Push(x any) {
+	t := x.(*memoryTask)
+	t.index = len(*h)
+	*h = append(*h, t)
+}
+
+func (h *taskHeap) Pop() any {
+	old := *h
+	t := old[len(old)-1]
+	old[len(old)-1] = nil
+	t.index = -1
+	*h = old[:len(old)-1]
+	return t
+}
@@ -0,0 +1,435 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"watchgameupdates/internal/models"
+	"watchgameupdates/internal/tasks"
+)
+
+func testPayload(gameID string, cycle int) models.Payload {
+	end := "2025-10-09T04:00:00Z"
+	return models.Payload{
+		Game: models.Game{
+			ID:        gameID,
+			StartTime: "2025-10-08T23:00:00Z",
+			HomeTeam:  models.Team{Abbrev: "BOS"},
+			AwayTeam:  models.Team{Abbrev: "NYR"},
+		},
+		ExecutionEnd: &end,
+		Cycle:        cycle,
+	}
+}
+
+// delivery is one task Serve handed to a handler.
+type delivery struct {
+	taskType string
+	gameID   string
+	cycle    int
+}
+
+// recorder collects deliveries and signals each one on got.
+type recorder struct {
+	mu   sync.Mutex
+	seen []delivery
+	got  chan struct{}
+}
+
+func newRecorder() *recorder {
+	return &recorder{got: make(chan struct{}, 100)}
+}
+
+func (r *recorder) handler(taskType string, err error) TaskHandler {
+	return func(_ context.Context, p models.Payload) error {
+		r.mu.Lock()
+		r.seen = append(r.seen, delivery{taskType, p.Game.ID, p.Cycle})
+		r.mu.Unlock()
+		r.got <- struct{}{}
+		return err
+	}
+}
+
+func (r *recorder) wait(t *testing.T, n int) []delivery {
+	t.Helper()
+	for range n {
+		select {
+		case <-r.got:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for %d deliveries", n)
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]delivery(nil), r.seen...)
+}
+
+// server is a queue that runs its own workers.
+type server interface {
+	Serve(ctx context.Context, concurrency int, handlers map[string]TaskHandler) error
+}
+
+// serve runs q.Serve until the test ends and waits for it to return.
+func serve(t *testing.T, q server, handlers map[string]TaskHandler) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- q.Serve(ctx, 2, handlers) }()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Serve: %v", err)
+		}
+	})
+}
+
+func TestMemoryQueue_DeliversInTimeOrder(t *testing.T) {
+	q, err := NewMemoryQueue("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	now := time.Now()
+	rec := newRecorder()
+
+	if err := q.Enqueue(ctx, testPayload("3", 0), now.Add(60*time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Enqueue(ctx, testPayload("1", 0), now); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.EnqueuePregame(ctx, testPayload("2", 0), now.Add(30*time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+	// The same check again is deduplicated, as on the other queues.
+	if err := q.Enqueue(ctx, testPayload("1", 0), now); err != nil {
+		t.Fatal(err)
+	}
+
+	serve(t, q, map[string]TaskHandler{
+		tasks.TypeWatchGameUpdates: rec.handler(tasks.TypeWatchGameUpdates, nil),
+		tasks.TypePregame:          rec.handler(tasks.TypePregame, nil),
+	})
+	got := rec.wait(t, 3)
+	want := []delivery{
+		{tasks.TypeWatchGameUpdates, "1", 0},
+		{tasks.TypePregame, "2", 0},
+		{tasks.TypeWatchGameUpdates, "3", 0},
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("delivery %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestMemoryQueue_WakesForEarlierTask(t *testing.T) {
+	q, err := NewMemoryQueue("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := newRecorder()
+	serve(t, q, map[string]TaskHandler{tasks.TypeWatchGameUpdates: rec.handler(tasks.TypeWatchGameUpdates, nil)})
+
+	ctx := context.Background()
+	if err := q.Enqueue(ctx, testPayload("later", 0), time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * time.Millisecond) // let Serve start waiting for the hour
+	if err := q.Enqueue(ctx, testPayload("now", 0), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if got := rec.wait(t, 1); got[0].gameID != "now" {
+		t.Errorf("delivered %+v, want game now", got[0])
+	}
+}
+
+func TestMemoryQueue_RetriesFailedTask(t *testing.T) {
+	q, err := NewMemoryQueue("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.retryDelay = time.Millisecond
+	rec := newRecorder()
+	if err := q.Enqueue(context.Background(), testPayload("1", 0), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	serve(t, q, map[string]TaskHandler{tasks.TypeWatchGameUpdates: rec.handler(tasks.TypeWatchGameUpdates, errors.New("feed down"))})
+	rec.wait(t, taskMaxRetries+1)
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		loops, _ := q.ListGames(context.Background())
+		if len(loops) == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("task still queued after %d retries: %+v", taskMaxRetries, loops)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case <-rec.got:
+		t.Errorf("task delivered more than %d times", taskMaxRetries+1)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestMemoryQueue_ListAndCancel(t *testing.T) {
+	q, err := NewMemoryQueue("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	now := time.Now()
+	q.now = func() time.Time { return now }
+
+	for _, p := range []models.Payload{testPayload("1", 0), testPayload("2", 3)} {
+		if err := q.Enqueue(ctx, p, now.Add(time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := q.EnqueuePregame(ctx, testPayload("1", 0), now.Add(-time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	loops, err := q.ListGames(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loops) != 2 || loops[0].GameID != "1" || loops[1].GameID != "2" {
+		t.Fatalf("ListGames = %+v, want the two game loops", loops)
+	}
+	if loops[0].State != StateScheduled || loops[0].TaskID != tasks.TaskID(testPayload("1", 0)) {
+		t.Errorf("loop = %+v, want a scheduled task with the check's task ID", loops[0])
+	}
+
+	n, err := q.CancelGame(ctx, "1")
+	if err != nil || n != 1 {
+		t.Fatalf("CancelGame = %d, %v; want 1 game task", n, err)
+	}
+	if len(q.queue) != 1 || len(q.tasks) != 1 {
+		t.Errorf("queue holds %d tasks (%d known), want only game 2", len(q.queue), len(q.tasks))
+	}
+}
+
+func TestMemoryQueue_TasksWithoutIDs(t *testing.T) {
+	q, err := NewMemoryQueue("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	// Ad hoc payloads have no start time, so no deterministic task ID.
+	for _, gameID := range []string{"1", "2"} {
+		p := models.Payload{Game: models.Game{ID: gameID}}
+		if err := q.Enqueue(ctx, p, time.Now().Add(time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	loops, err := q.ListGames(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loops) != 2 || loops[0].TaskID == "" || loops[0].TaskID == loops[1].TaskID {
+		t.Errorf("ListGames = %+v, want two tasks with distinct IDs", loops)
+	}
+}
+
+func TestMemoryQueue_CancelRunningCheck(t *testing.T) {
+	q, err := NewMemoryQueue("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	started := make(chan struct{})
+	cancelled := make(chan struct{})
+	serve(t, q, map[string]TaskHandler{
+		tasks.TypeWatchGameUpdates: func(ctx context.Context, _ models.Payload) error {
+			close(started)
+			<-ctx.Done()
+			close(cancelled)
+			return ctx.Err()
+		},
+	})
+	ctx := context.Background()
+	if err := q.Enqueue(ctx, testPayload("1", 0), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+
+	loops, _ := q.ListGames(ctx)
+	if len(loops) != 1 || loops[0].State != StateActive {
+		t.Fatalf("ListGames = %+v, want one active loop", loops)
+	}
+	if n, err := q.CancelGame(ctx, "1"); err != nil || n != 1 {
+		t.Fatalf("CancelGame = %d, %v; want 1", n, err)
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("running check was not cancelled")
+	}
+}
+
+func TestMemoryQueue_ShutdownWaitsForRunningTask(t *testing.T) {
+	q, err := NewMemoryQueue("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	started := make(chan struct{})
+	var finished bool
+	handler := func(ctx context.Context, _ models.Payload) error {
+		close(started)
+		time.Sleep(20 * time.Millisecond)
+		finished = ctx.Err() == nil
+		return nil
+	}
+	if err := q.Enqueue(context.Background(), testPayload("1", 0), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- q.Serve(ctx, 1, map[string]TaskHandler{tasks.TypeWatchGameUpdates: handler}) }()
+	<-started
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Serve: %v", err)
+	}
+	if !finished {
+		t.Error("shutdown cancelled or abandoned the running check")
+	}
+}
+
+func TestMemoryQueue_PersistsAcrossRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.json")
+	q, err := NewMemoryQueue(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	deliverAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	if err := q.Enqueue(ctx, testPayload("1", 2), deliverAt); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.EnqueuePregame(ctx, testPayload("2", 0), deliverAt); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Enqueue(ctx, testPayload("3", 0), deliverAt); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.CancelGame(ctx, "3"); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := NewMemoryQueue(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loops, err := restored.ListGames(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loops) != 1 || loops[0].GameID != "1" || !loops[0].NextRun.Equal(deliverAt) {
+		t.Fatalf("restored loops = %+v, want game 1 at %s", loops, deliverAt)
+	}
+	if _, ok := restored.tasks[tasks.PregameTaskID(testPayload("2", 0))]; !ok {
+		t.Error("pregame task was not restored")
+	}
+	// A restored task is still deduplicated.
+	if err := restored.Enqueue(ctx, testPayload("1", 2), deliverAt); err != nil {
+		t.Fatal(err)
+	}
+	if len(restored.tasks) != 2 {
+		t.Errorf("restored queue holds %d tasks, want 2", len(restored.tasks))
+	}
+}
+
+func TestMemoryQueue_KeepsFinishedIDs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.json")
+	q, err := NewMemoryQueue(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	rec := newRecorder()
+	if err := q.Enqueue(ctx, testPayload("1", 0), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	serve(t, q, map[string]TaskHandler{tasks.TypeWatchGameUpdates: rec.handler(tasks.TypeWatchGameUpdates, nil)})
+	rec.wait(t, 1)
+	deadline := time.Now().Add(time.Second)
+	for {
+		q.mu.Lock()
+		n := len(q.finished)
+		q.mu.Unlock()
+		if n == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("finished task ID was not kept")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// A late duplicate of the finished check is rejected, after a restart
+	// too, until the retention has passed.
+	restored, err := NewMemoryQueue(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := restored.Enqueue(ctx, testPayload("1", 0), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if len(restored.tasks) != 0 {
+		t.Fatalf("restored queue holds %d tasks, want the duplicate rejected", len(restored.tasks))
+	}
+	restored.now = func() time.Time { return time.Now().Add(memoryRetention) }
+	if err := restored.Enqueue(ctx, testPayload("1", 0), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if len(restored.tasks) != 1 {
+		t.Errorf("queue holds %d tasks after the retention, want the check queued again", len(restored.tasks))
+	}
+}
+
+func TestNewMemoryQueue_LegacyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.json")
+	legacy, err := json.Marshal([]*memoryTask{{
+		ID:        tasks.TaskID(testPayload("1", 0)),
+		Type:      tasks.TypeWatchGameUpdates,
+		Payload:   testPayload("1", 0),
+		DeliverAt: time.Now().Add(time.Hour),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, legacy, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	q, err := NewMemoryQueue(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(q.tasks) != 1 {
+		t.Errorf("restored %d tasks from a task-list file, want 1", len(q.tasks))
+	}
+}
+
+func TestNewMemoryQueue_CorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewMemoryQueue(path); err == nil {
+		t.Error("NewMemoryQueue accepted a corrupt queue file")
+	}
+}
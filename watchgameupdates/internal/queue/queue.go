@@ -2,6 +2,7 @@ package queue
 
 import (
 	"context"
+	"sort"
 	"time"
 
 	"watchgameupdates/internal/models"
@@ -14,3 +15,70 @@ type GameTaskQueue interface {
 	Enqueue(ctx context.Context, payload models.Payload, deliverAt time.Time) error
 	Close() error
 }
+
+// TaskHandler processes the payload of one task delivered by a queue that
+// runs its own workers, MemoryQueue or SQLiteQueue. An error retries the
+// task; a cancelled ctx means the task was cancelled.
+type TaskHandler func(ctx context.Context, payload models.Payload) error
+
+const (
+	// taskMaxRetries is how often a failed task is retried before it is
+	// dropped, as asynq would retry it.
+	taskMaxRetries = 3
+	// taskRetryDelay is the wait before the first retry; it doubles per
+	// attempt.
+	taskRetryDelay = 10 * time.Second
+)
+
+// Task states reported in GameLoop.State.
+const (
+	StateScheduled = "scheduled" // waiting for its delivery time
+	StatePending   = "pending"   // due, waiting for a worker
+	StateActive    = "active"    // being processed now
+	StateRetry     = "retry"     // last attempt failed, will be retried
+)
+
+// GameLoop is one pending task of a game tracking loop. Each loop has a single
+// task at a time: the check that runs next.
+type GameLoop struct {
+	TaskID       string    `json:"taskId"`
+	GameID       string    `json:"gameId"`
+	HomeTeam     string    `json:"homeTeam"`
+	AwayTeam     string    `json:"awayTeam"`
+	State        string    `json:"state"`
+	NextRun      time.Time `json:"nextRun"`
+	LastPlay     string    `json:"lastPlay,omitempty"`
+	ShouldNotify bool      `json:"shouldNotify"`
+	ExecutionEnd string    `json:"executionEnd,omitempty"`
+}
+
+func newGameLoop(taskID, state string, nextRun time.Time, payload models.Payload) GameLoop {
+	return GameLoop{
+		TaskID:       taskID,
+		GameID:       payload.Game.ID,
+		HomeTeam:     payload.Game.HomeTeam.Abbrev,
+		AwayTeam:     payload.Game.AwayTeam.Abbrev,
+		State:        state,
+		NextRun:      nextRun,
+		LastPlay:     payload.LastPlay,
+		ShouldNotify: payload.ShouldNotify == nil || *payload.ShouldNotify,
+		ExecutionEnd: derefString(payload.ExecutionEnd),
+	}
+}
+
+// sortLoops orders loops by next run time, then game ID.
+func sortLoops(loops []GameLoop) {
+	sort.Slice(loops, func(i, j int) bool {
+		if !loops[i].NextRun.Equal(loops[j].NextRun) {
+			return loops[i].NextRun.Before(loops[j].NextRun)
+		}
+		return loops[i].GameID < loops[j].GameID
+	})
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
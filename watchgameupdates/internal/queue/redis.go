@@ -2,8 +2,10 @@ package queue
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"slices"
 	"time"
 
 	"watchgameupdates/config"
@@ -11,40 +13,76 @@ import (
 	"watchgameupdates/internal/tasks"
 
 	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
 )
 
+// asynqQueue is the asynq queue game tasks are enqueued to and served from.
+const asynqQueue = "default"
+
+// listPageSize is the Inspector page size used when walking a task state.
+const listPageSize = 100
+
+// cancelledKeyPrefix prefixes the Redis key CancelGame sets for each check it
+// cancels mid-run, cancelledKeyPrefix + task ID. It outlives the handler's
+// context: asynq retries a check whose context was cancelled, and
+// DropCancelled drops that retry instead of running the cycle again.
+const cancelledKeyPrefix = "watchgameupdates:cancelled:"
+
+// cancelledKeyTTL is how long a cancelled check stays marked, well past
+// asynq's first retry.
+const cancelledKeyTTL = 24 * time.Hour
+
 // RedisQueue implements scheduler.TaskEnqueuer using Redis/Asynq.
 type RedisQueue struct {
-	client *asynq.Client
+	client    *asynq.Client
+	inspector *asynq.Inspector
+	redis     *redis.Client
 }
 
 // NewRedisQueue creates a new RedisQueue from config.
 func NewRedisQueue(cfg *config.Config) *RedisQueue {
-	client := asynq.NewClient(asynq.RedisClientOpt{
+	opt := asynq.RedisClientOpt{
 		Addr:     cfg.RedisAddress,
 		Password: cfg.RedisPassword,
 		DB:       cfg.RedisDB,
-	})
-	return &RedisQueue{client: client}
+	}
+	return &RedisQueue{
+		client:    asynq.NewClient(opt),
+		inspector: asynq.NewInspector(opt),
+		redis: redis.NewClient(&redis.Options{
+			Addr:     cfg.RedisAddress,
+			Password: cfg.RedisPassword,
+			DB:       cfg.RedisDB,
+		}),
+	}
 }
 
 func (q *RedisQueue) Enqueue(_ context.Context, payload models.Payload, deliverAt time.Time) error {
-	task, err := tasks.NewWatchGameUpdatesTask(payload)
+	return tasks.EnqueueGameCheck(q.client, payload, deliverAt)
+}
+
+// EnqueuePregame implements scheduler.PregameEnqueuer.
+func (q *RedisQueue) EnqueuePregame(_ context.Context, payload models.Payload, deliverAt time.Time) error {
+	task, err := tasks.NewPregameTask(payload)
 	if err != nil {
-		return fmt.Errorf("failed to create task: %w", err)
+		return fmt.Errorf("failed to create pregame task: %w", err)
 	}
 
-	opts := []asynq.Option{}
+	opts := tasks.PregameUniqueOptions(payload)
 	if delay := time.Until(deliverAt); delay > 0 {
 		opts = append(opts, asynq.ProcessIn(delay))
 	}
 
 	info, err := q.client.Enqueue(task, opts...)
+	if tasks.IsDuplicateTask(err) {
+		log.Printf("Pregame task %s for game %s already exists, not enqueuing again", tasks.PregameTaskID(payload), payload.Game.ID)
+		return nil
+	}
 	if err != nil {
-		return fmt.Errorf("failed to enqueue task: %w", err)
+		return fmt.Errorf("failed to enqueue pregame task: %w", err)
 	}
 
-	log.Printf("Enqueuing task for game %s (%s vs %s) scheduled at %s, task ID: %s",
+	log.Printf("Enqueuing pregame task for game %s (%s vs %s) scheduled at %s, task ID: %s",
 		payload.Game.ID,
 		payload.Game.AwayTeam.Abbrev,
 		payload.Game.HomeTeam.Abbrev,
@@ -54,6 +92,169 @@ func (q *RedisQueue) Enqueue(_ context.Context, payload models.Payload, deliverA
 	return nil
 }
 
+// ListGames returns the game tracking tasks that have not completed, soonest
+// first.
+func (q *RedisQueue) ListGames(_ context.Context) ([]GameLoop, error) {
+	listers := []struct {
+		state string
+		list  func(string, ...asynq.ListOption) ([]*asynq.TaskInfo, error)
+	}{
+		{StateActive, q.inspector.ListActiveTasks},
+		{StatePending, q.inspector.ListPendingTasks},
+		{StateScheduled, q.inspector.ListScheduledTasks},
+		{StateRetry, q.inspector.ListRetryTasks},
+	}
+
+	var loops []GameLoop
+	for _, l := range listers {
+		for page := 1; ; page++ {
+			infos, err := l.list(asynqQueue, asynq.PageSize(listPageSize), asynq.Page(page))
+			if errors.Is(err, asynq.ErrQueueNotFound) {
+				break // nothing has ever been enqueued
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to list %s tasks: %w", l.state, err)
+			}
+			for _, info := range infos {
+				if info.Type != tasks.TypeWatchGameUpdates {
+					continue
+				}
+				payload, err := tasks.ParseWatchGameUpdatesPayload(asynq.NewTask(info.Type, info.Payload))
+				if err != nil {
+					continue
+				}
+				nextRun := info.NextProcessAt
+				if nextRun.IsZero() {
+					nextRun = time.Now()
+				}
+				loops = append(loops, newGameLoop(info.ID, l.state, nextRun, payload))
+			}
+			if len(infos) < listPageSize {
+				break
+			}
+		}
+	}
+	sortLoops(loops)
+	return loops, nil
+}
+
+// CancelGame deletes every queued task for gameID and cancels an in-flight
+// check. The check is marked cancelled in Redis first, so that
+// DropCancelled ends the loop even though asynq retries the cancelled check.
+// It returns how many tasks were removed or cancelled.
+func (q *RedisQueue) CancelGame(ctx context.Context, gameID string) (int, error) {
+	return q.cancelGame(ctx, gameID, true)
+}
+
+// cancelGame deletes gameID's queued tasks and, when cancelActive is set,
+// marks and cancels its in-flight check.
+func (q *RedisQueue) cancelGame(ctx context.Context, gameID string, cancelActive bool) (int, error) {
+	loops, err := q.ListGames(ctx)
+	if err != nil {
+		return 0, err
+	}
+	cancelled := 0
+	for _, loop := range loops {
+		if loop.GameID != gameID {
+			continue
+		}
+		if loop.State == StateActive {
+			if !cancelActive {
+				continue
+			}
+			err = q.redis.Set(ctx, cancelledKeyPrefix+loop.TaskID, gameID, cancelledKeyTTL).Err()
+			if err == nil {
+				err = q.inspector.CancelProcessing(loop.TaskID)
+			}
+		} else {
+			err = q.inspector.DeleteTask(asynqQueue, loop.TaskID)
+		}
+		if err != nil {
+			return cancelled, fmt.Errorf("failed to cancel task %s: %w", loop.TaskID, err)
+		}
+		log.Printf("Cancelled %s task %s for game %s", loop.State, loop.TaskID, gameID)
+		cancelled++
+	}
+	return cancelled, nil
+}
+
+// DropCancelled wraps the game check handler so a check CancelGame cancelled
+// stays cancelled. asynq retries a check whose context was cancelled; the
+// retry is dropped here instead of running the cycle again. A check that
+// rescheduled before noticing the cancellation has its successor deleted
+// once it returns.
+func (q *RedisQueue) DropCancelled(h asynq.Handler) asynq.Handler {
+	return asynq.HandlerFunc(func(ctx context.Context, t *asynq.Task) error {
+		id, _ := asynq.GetTaskID(ctx)
+		if q.isCancelled(ctx, id) {
+			log.Printf("Task %s was cancelled, dropping it", id)
+			return nil
+		}
+		err := h.ProcessTask(ctx, t)
+		// ctx is done if this check was cancelled; clean up regardless.
+		bg := context.WithoutCancel(ctx)
+		if !q.isCancelled(bg, id) {
+			return err
+		}
+		payload, perr := tasks.ParseWatchGameUpdatesPayload(t)
+		if perr != nil {
+			return err
+		}
+		if _, cerr := q.cancelGame(bg, payload.Game.ID, false); cerr != nil {
+			log.Printf("Failed to delete the tasks queued by cancelled task %s: %v", id, cerr)
+		}
+		return err
+	})
+}
+
+// isCancelled reports whether CancelGame marked taskID. A Redis error counts
+// as not cancelled, so an outage does not end game loops.
+func (q *RedisQueue) isCancelled(ctx context.Context, taskID string) bool {
+	if taskID == "" {
+		return false
+	}
+	n, err := q.redis.Exists(ctx, cancelledKeyPrefix+taskID).Result()
+	if err != nil {
+		log.Printf("Failed to check whether task %s was cancelled: %v", taskID, err)
+		return false
+	}
+	return n > 0
+}
+
+// Depth returns how many tasks the asynq queue holds in each state, game
+// checks and pregame tasks alike. The states are asynq's: pending, active,
+// scheduled, retry, archived and completed (kept for a day so their task
+// IDs stay reserved).
+func (q *RedisQueue) Depth(_ context.Context) (map[string]int, error) {
+	// GetQueueInfo does not report a missing queue as ErrQueueNotFound, so
+	// look it up first.
+	queues, err := q.inspector.Queues()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list queues: %w", err)
+	}
+	info := &asynq.QueueInfo{} // nothing has ever been enqueued
+	if slices.Contains(queues, asynqQueue) {
+		if info, err = q.inspector.GetQueueInfo(asynqQueue); err != nil {
+			return nil, fmt.Errorf("failed to read queue %s: %w", asynqQueue, err)
+		}
+	}
+	return map[string]int{
+		"pending":   info.Pending,
+		"active":    info.Active,
+		"scheduled": info.Scheduled,
+		"retry":     info.Retry,
+		"archived":  info.Archived,
+		"completed": info.Completed,
+	}, nil
+}
+
+// QueueName is the asynq queue game tasks go to.
+func (q *RedisQueue) QueueName() string {
+	return asynqQueue
+}
+
 func (q *RedisQueue) Close() error {
+	q.inspector.Close()
+	q.redis.Close()
 	return q.client.Close()
 }
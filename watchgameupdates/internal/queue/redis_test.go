@@ -0,0 +1,121 @@
+package queue
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"watchgameupdates/config"
+	"watchgameupdates/internal/tasks"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/hibiken/asynq"
+)
+
+func TestRedisQueue_Depth(t *testing.T) {
+	mr := miniredis.RunT(t)
+	q := NewRedisQueue(&config.Config{RedisAddress: mr.Addr()})
+	defer q.Close()
+	ctx := context.Background()
+
+	depth, err := q.Depth(ctx)
+	if err != nil {
+		t.Fatalf("Depth of an unused queue: %v", err)
+	}
+	if depth["scheduled"] != 0 {
+		t.Errorf("unused queue depth = %v, want zeros", depth)
+	}
+
+	if err := q.Enqueue(ctx, testPayload("1", 0), time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.EnqueuePregame(ctx, testPayload("2", 0), time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Enqueue(ctx, testPayload("3", 0), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	depth, err = q.Depth(ctx)
+	if err != nil {
+		t.Fatalf("Depth: %v", err)
+	}
+	if depth["scheduled"] != 2 || depth["pending"] != 1 {
+		t.Errorf("depth = %v, want 2 scheduled and 1 pending", depth)
+	}
+}
+
+func TestRedisQueue_CancelGameEndsLoopInWorker(t *testing.T) {
+	mr := miniredis.RunT(t)
+	q := NewRedisQueue(&config.Config{RedisAddress: mr.Addr()})
+	defer q.Close()
+	ctx := context.Background()
+
+	// The first run blocks until cancelled; any later run is the retry asynq
+	// schedules for it, which would go on to reschedule the loop.
+	var calls atomic.Int32
+	started := make(chan struct{})
+	handler := asynq.HandlerFunc(func(ctx context.Context, _ *asynq.Task) error {
+		if calls.Add(1) == 1 {
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		return q.Enqueue(context.Background(), testPayload("1", 1), time.Now().Add(time.Hour))
+	})
+
+	srv := asynq.NewServer(asynq.RedisClientOpt{Addr: mr.Addr()}, asynq.Config{
+		Concurrency:              1,
+		Queues:                   map[string]int{asynqQueue: 1},
+		RetryDelayFunc:           func(int, error, *asynq.Task) time.Duration { return 0 },
+		DelayedTaskCheckInterval: 50 * time.Millisecond,
+		LogLevel:                 asynq.FatalLevel,
+	})
+	mux := asynq.NewServeMux()
+	mux.Handle(tasks.TypeWatchGameUpdates, q.DropCancelled(handler))
+	if err := srv.Start(mux); err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Shutdown()
+
+	if err := q.Enqueue(ctx, testPayload("1", 0), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("check never started")
+	}
+	if n, err := q.CancelGame(ctx, "1"); err != nil || n != 1 {
+		t.Fatalf("CancelGame = %d, %v; want 1 cancelled", n, err)
+	}
+
+	// asynq retries the cancelled check; wait until that retry is gone, then
+	// give the worker time to run anything it left behind.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		loops, err := q.ListGames(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(loops) == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("loops after cancel = %+v, want none", loops)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	time.Sleep(300 * time.Millisecond)
+	loops, err := q.ListGames(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loops) != 0 {
+		t.Errorf("loops after cancel = %+v, want none", loops)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("handler ran %d times, want once", n)
+	}
+}
@@ -0,0 +1,518 @@
+package queue
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"watchgameupdates/internal/models"
+	"watchgameupdates/internal/tasks"
+
+	"github.com/google/uuid"
+	_ "modernc.org/sqlite" // pure Go driver, registers "sqlite"
+)
+
+const (
+	// sqliteLease is how long a claimed task stays with its worker. Running
+	// tasks renew it every third of that; a crashed worker's tasks are
+	// claimed again once it lapses.
+	sqliteLease = time.Minute
+	// sqlitePollInterval is how often Serve looks for due tasks, which
+	// other processes (the scheduler) may have added. Enqueue through the
+	// serving queue wakes it at once.
+	sqlitePollInterval = time.Second
+	// sqliteRetention keeps finished tasks so their IDs keep colliding with
+	// late duplicates, as completed asynq tasks are retained.
+	sqliteRetention = 24 * time.Hour
+	// sqlitePurgeInterval is how often Serve deletes finished tasks older
+	// than sqliteRetention.
+	sqlitePurgeInterval = time.Hour
+)
+
+// sqliteSchema creates the tasks table. Times are unix milliseconds. A task is
+// claimed while lease_until is in the future and finished once finished_at is
+// set.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS tasks (
+	id          TEXT PRIMARY KEY,
+	type        TEXT NOT NULL,
+	game_id     TEXT NOT NULL,
+	payload     BLOB NOT NULL,
+	deliver_at  INTEGER NOT NULL,
+	retried     INTEGER NOT NULL DEFAULT 0,
+	last_error  TEXT NOT NULL DEFAULT '',
+	lease_owner TEXT NOT NULL DEFAULT '',
+	lease_until INTEGER NOT NULL DEFAULT 0,
+	cancelled   INTEGER NOT NULL DEFAULT 0,
+	finished_at INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS tasks_due ON tasks (finished_at, deliver_at);
+CREATE INDEX IF NOT EXISTS tasks_game ON tasks (game_id);
+`
+
+// SQLiteQueue is a durable queue in an embedded SQLite database, for
+// deployments too small to run Redis. Workers claim due tasks with a lease,
+// so the scheduler and admin tools can share the database file with the
+// -mode=sqlite worker, and tracking loops survive restarts: a task claimed by
+// a worker that died is delivered again when its lease lapses. It implements
+// the same interfaces as RedisQueue, pregame tasks and the admin API included.
+type SQLiteQueue struct {
+	db           *sql.DB
+	owner        string // lease owner written by this process
+	now          func() time.Time
+	retryDelay   time.Duration // taskRetryDelay; shorter in tests
+	lease        time.Duration // sqliteLease; shorter in tests
+	pollInterval time.Duration
+	wake         chan struct{}
+
+	mu      sync.Mutex
+	running map[string]context.CancelFunc // tasks this process is running, by ID
+}
+
+// sqliteTask is a claimed task.
+type sqliteTask struct {
+	id       string
+	taskType string
+	payload  models.Payload
+	retried  int
+}
+
+// NewSQLiteQueue opens the database at path, creating it and the tasks table
+// if needed.
+func NewSQLiteQueue(path string) (*SQLiteQueue, error) {
+	// WAL lets the scheduler enqueue while the worker reads; busy_timeout
+	// waits out the other process's writes instead of failing.
+	dsn := "file:" + path + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=synchronous(NORMAL)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open SQLite queue %s: %w", path, err)
+	}
+	// One connection serialises this process's writes, which SQLite would
+	// otherwise reject with SQLITE_BUSY.
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create SQLite queue schema in %s: %w", path, err)
+	}
+
+	owner := make([]byte, 8)
+	_, _ = rand.Read(owner)
+	return &SQLiteQueue{
+		db:           db,
+		owner:        hex.EncodeToString(owner),
+		now:          time.Now,
+		retryDelay:   taskRetryDelay,
+		lease:        sqliteLease,
+		pollInterval: sqlitePollInterval,
+		wake:         make(chan struct{}, 1),
+		running:      map[string]context.CancelFunc{},
+	}, nil
+}
+
+func (q *SQLiteQueue) Enqueue(ctx context.Context, payload models.Payload, deliverAt time.Time) error {
+	return q.add(ctx, tasks.TaskID(payload), tasks.TypeWatchGameUpdates, payload, deliverAt)
+}
+
+// EnqueuePregame implements scheduler.PregameEnqueuer.
+func (q *SQLiteQueue) EnqueuePregame(ctx context.Context, payload models.Payload, deliverAt time.Time) error {
+	return q.add(ctx, tasks.PregameTaskID(payload), tasks.TypePregame, payload, deliverAt)
+}
+
+// add inserts a task unless one with the same ID exists, queued, running or
+// finished within sqliteRetention, which counts as success as it does on the
+// other queues. An empty ID gets a random one.
+func (q *SQLiteQueue) add(ctx context.Context, id, taskType string, payload models.Payload, deliverAt time.Time) error {
+	if id == "" {
+		id = uuid.NewString()
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	res, err := q.db.ExecContext(ctx,
+		`INSERT INTO tasks (id, type, game_id, payload, deliver_at) VALUES (?, ?, ?, ?, ?)
+		 ON CONFLICT (id) DO NOTHING`,
+		id, taskType, payload.Game.ID, data, deliverAt.UnixMilli())
+	if err != nil {
+		return fmt.Errorf("failed to enqueue %s task: %w", taskType, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		log.Printf("Task %s for game %s already exists, not enqueuing again", id, payload.Game.ID)
+		return nil
+	}
+	log.Printf("Enqueuing %s task for game %s (%s vs %s) scheduled at %s, task ID: %s",
+		taskType,
+		payload.Game.ID,
+		payload.Game.AwayTeam.Abbrev,
+		payload.Game.HomeTeam.Abbrev,
+		deliverAt.Format(time.RFC3339),
+		id)
+	q.signal()
+	return nil
+}
+
+// signal wakes Serve to look for due tasks again.
+func (q *SQLiteQueue) signal() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Serve claims due tasks and delivers them to the handler for their type, up
+// to concurrency at a time, until ctx is cancelled. It then stops claiming and
+// waits for the running tasks, which are not cancelled: a game check cut short
+// would not schedule the next one.
+func (q *SQLiteQueue) Serve(ctx context.Context, concurrency int, handlers map[string]TaskHandler) error {
+	if concurrency < 1 {
+		return fmt.Errorf("concurrency must be positive, got %d", concurrency)
+	}
+	slots := make(chan struct{}, concurrency)
+	var running sync.WaitGroup
+	defer running.Wait()
+
+	var purged time.Time
+	for {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil
+		}
+		task, err := q.claim(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Failed to claim task: %v", err)
+		}
+		if task == nil {
+			<-slots
+			if now := q.now(); now.Sub(purged) >= sqlitePurgeInterval {
+				q.purge(ctx)
+				purged = now
+			}
+			if !q.wait(ctx) {
+				return nil
+			}
+			continue
+		}
+
+		taskCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		q.mu.Lock()
+		q.running[task.id] = cancel
+		q.mu.Unlock()
+
+		running.Add(1)
+		go func() {
+			defer running.Done()
+			defer func() { <-slots }()
+			q.run(taskCtx, task, handlers[task.taskType])
+		}()
+	}
+}
+
+// wait blocks for the poll interval, until a task is added or until ctx is
+// cancelled, and reports whether Serve should go on.
+func (q *SQLiteQueue) wait(ctx context.Context) bool {
+	timer := time.NewTimer(q.pollInterval)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-q.wake:
+	case <-timer.C:
+	}
+	return true
+}
+
+// claim leases the earliest due task to this process, or returns nil when
+// none is due. A task whose lease lapsed, because its worker died, is due
+// again.
+func (q *SQLiteQueue) claim(ctx context.Context) (*sqliteTask, error) {
+	now := q.now()
+	var (
+		task sqliteTask
+		data []byte
+	)
+	err := q.db.QueryRowContext(ctx,
+		`UPDATE tasks SET lease_owner = ?, lease_until = ?
+		 WHERE id = (
+			SELECT id FROM tasks
+			WHERE finished_at = 0 AND cancelled = 0 AND deliver_at <= ? AND lease_until < ?
+			ORDER BY deliver_at, rowid LIMIT 1)
+		 RETURNING id, type, payload, retried`,
+		q.owner, now.Add(q.lease).UnixMilli(), now.UnixMilli(), now.UnixMilli(),
+	).Scan(&task.id, &task.taskType, &data, &task.retried)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &task.payload); err != nil {
+		// Not deliverable now or ever; finish it rather than claim it again.
+		log.Printf("Dropping task %s with unreadable payload: %v", task.id, err)
+		q.finish(task.id)
+		return nil, nil
+	}
+	return &task, nil
+}
+
+// run hands task to handler while renewing its lease, then retries or
+// finishes it.
+func (q *SQLiteQueue) run(ctx context.Context, task *sqliteTask, handler TaskHandler) {
+	defer func() {
+		q.mu.Lock()
+		q.running[task.id]()
+		delete(q.running, task.id)
+		q.mu.Unlock()
+	}()
+	if handler == nil {
+		log.Printf("Dropping task %s of unknown type %q", task.id, task.taskType)
+		q.finish(task.id)
+		return
+	}
+
+	renewed := make(chan struct{})
+	stop := make(chan struct{})
+	go func() {
+		defer close(renewed)
+		q.renew(ctx, task.id, stop)
+	}()
+	err := handler(ctx, task.payload)
+	close(stop)
+	<-renewed
+
+	switch {
+	case err == nil || ctx.Err() != nil:
+		q.finish(task.id)
+	case task.retried >= taskMaxRetries:
+		log.Printf("Dropping task %s after %d retries: %v", task.id, task.retried, err)
+		q.finish(task.id)
+	default:
+		delay := q.retryDelay << task.retried
+		log.Printf("Task %s failed, retrying in %s: %v", task.id, delay, err)
+		_, dbErr := q.db.Exec(
+			`UPDATE tasks SET retried = retried + 1, deliver_at = ?, last_error = ?, lease_owner = '', lease_until = 0
+			 WHERE id = ? AND lease_owner = ?`,
+			q.now().Add(delay).UnixMilli(), err.Error(), task.id, q.owner)
+		if dbErr != nil {
+			// The lease lapses and the task is claimed again.
+			log.Printf("Failed to schedule retry of task %s: %v", task.id, dbErr)
+		}
+		q.signal()
+	}
+}
+
+// renew extends the lease on a running task until stop is closed. It cancels
+// the task when CancelGame, possibly in another process, flagged it, or when
+// the lease was lost to another worker.
+func (q *SQLiteQueue) renew(ctx context.Context, id string, stop <-chan struct{}) {
+	ticker := time.NewTicker(q.lease / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		var cancelled bool
+		err := q.db.QueryRow(
+			`UPDATE tasks SET lease_until = ? WHERE id = ? AND lease_owner = ? AND finished_at = 0
+			 RETURNING cancelled`,
+			q.now().Add(q.lease).UnixMilli(), id, q.owner,
+		).Scan(&cancelled)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			log.Printf("Lost the lease on task %s, cancelling it", id)
+			q.cancel(id)
+			return
+		case err != nil:
+			log.Printf("Failed to renew the lease on task %s: %v", id, err)
+		case cancelled:
+			q.cancel(id)
+			return
+		}
+	}
+}
+
+// cancel cancels the context of a task this process is running.
+func (q *SQLiteQueue) cancel(id string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if cancel, ok := q.running[id]; ok {
+		cancel()
+	}
+}
+
+// finish marks a claimed task done. It stays in the table for sqliteRetention
+// so that re-enqueuing its ID is still a duplicate.
+func (q *SQLiteQueue) finish(id string) {
+	_, err := q.db.Exec(
+		`UPDATE tasks SET finished_at = ?, lease_owner = '', lease_until = 0 WHERE id = ? AND lease_owner = ?`,
+		q.now().UnixMilli(), id, q.owner)
+	if err != nil {
+		log.Printf("Failed to finish task %s: %v", id, err)
+	}
+}
+
+// purge deletes tasks finished longer than sqliteRetention ago, and tasks
+// cancelled while running whose worker died before finishing them.
+func (q *SQLiteQueue) purge(ctx context.Context) {
+	now := q.now()
+	res, err := q.db.ExecContext(ctx,
+		`DELETE FROM tasks WHERE (finished_at > 0 AND finished_at < ?) OR (cancelled = 1 AND finished_at = 0 AND lease_until < ?)`,
+		now.Add(-sqliteRetention).UnixMilli(), now.UnixMilli())
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("Failed to purge finished tasks: %v", err)
+		}
+		return
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		log.Printf("Purged %d finished tasks", n)
+	}
+}
+
+// sqliteRow is a task that has not finished, as the inspection queries read
+// it.
+type sqliteRow struct {
+	id         string
+	taskType   string
+	payload    models.Payload
+	deliverAt  time.Time
+	retried    int
+	leaseUntil time.Time
+}
+
+func (r sqliteRow) state(now time.Time) string {
+	switch {
+	case r.leaseUntil.After(now):
+		return StateActive
+	case r.retried > 0:
+		return StateRetry
+	case r.deliverAt.After(now):
+		return StateScheduled
+	default:
+		return StatePending
+	}
+}
+
+// unfinished returns the tasks that have not finished and were not
+// cancelled, optionally only those of gameID.
+func unfinished(ctx context.Context, db interface {
+	QueryContext(context.Context, string, ...any) (*sql.Rows, error)
+}, gameID string) ([]sqliteRow, error) {
+	rows, err := db.QueryContext(ctx,
+		`SELECT id, type, payload, deliver_at, retried, lease_until FROM tasks
+		 WHERE finished_at = 0 AND cancelled = 0 AND (? = '' OR game_id = ?)`,
+		gameID, gameID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tasks: %w", err)
+	}
+	defer rows.Close()
+
+	var result []sqliteRow
+	for rows.Next() {
+		var (
+			r                     sqliteRow
+			data                  []byte
+			deliverAt, leaseUntil int64
+		)
+		if err := rows.Scan(&r.id, &r.taskType, &data, &deliverAt, &r.retried, &leaseUntil); err != nil {
+			return nil, fmt.Errorf("failed to read task: %w", err)
+		}
+		if err := json.Unmarshal(data, &r.payload); err != nil {
+			continue
+		}
+		r.deliverAt, r.leaseUntil = time.UnixMilli(deliverAt), time.UnixMilli(leaseUntil)
+		result = append(result, r)
+	}
+	return result, rows.Err()
+}
+
+// ListGames returns the game tracking tasks queued or running, soonest first.
+func (q *SQLiteQueue) ListGames(ctx context.Context) ([]GameLoop, error) {
+	rows, err := unfinished(ctx, q.db, "")
+	if err != nil {
+		return nil, err
+	}
+	now := q.now()
+	var loops []GameLoop
+	for _, r := range rows {
+		if r.taskType != tasks.TypeWatchGameUpdates {
+			continue
+		}
+		loops = append(loops, newGameLoop(r.id, r.state(now), r.deliverAt, r.payload))
+	}
+	sortLoops(loops)
+	return loops, nil
+}
+
+// Counts returns how many unfinished tasks of every type are in each state.
+func (q *SQLiteQueue) Counts(ctx context.Context) (map[string]int, error) {
+	rows, err := unfinished(ctx, q.db, "")
+	if err != nil {
+		return nil, err
+	}
+	now := q.now()
+	counts := map[string]int{}
+	for _, r := range rows {
+		counts[r.state(now)]++
+	}
+	return counts, nil
+}
+
+// CancelGame deletes every queued task for gameID, pregame tasks included,
+// and flags running ones, whose worker cancels them within a third of the
+// lease, or at once if it is this process. It returns how many game tracking
+// tasks were removed or cancelled.
+func (q *SQLiteQueue) CancelGame(ctx context.Context, gameID string) (int, error) {
+	tx, err := q.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := unfinished(ctx, tx, gameID)
+	if err != nil {
+		return 0, err
+	}
+	now := q.now()
+	var running []string
+	cancelled := 0
+	for _, r := range rows {
+		state := r.state(now)
+		if state == StateActive {
+			_, err = tx.ExecContext(ctx, `UPDATE tasks SET cancelled = 1 WHERE id = ?`, r.id)
+			running = append(running, r.id)
+		} else {
+			_, err = tx.ExecContext(ctx, `DELETE FROM tasks WHERE id = ?`, r.id)
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to cancel task %s: %w", r.id, err)
+		}
+		if r.taskType == tasks.TypeWatchGameUpdates {
+			log.Printf("Cancelled %s task %s for game %s", state, r.id, gameID)
+			cancelled++
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to cancel tasks for game %s: %w", gameID, err)
+	}
+	for _, id := range running {
+		q.cancel(id)
+	}
+	return cancelled, nil
+}
+
+// Close closes the database. Call it after Serve has returned.
+func (q *SQLiteQueue) Close() error {
+	return q.db.Close()
+}
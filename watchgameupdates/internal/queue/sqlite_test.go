@@ -0,0 +1,274 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"watchgameupdates/internal/models"
+	"watchgameupdates/internal/tasks"
+)
+
+// openSQLiteQueue opens the queue at path with test timings; several calls
+// with one path act as separate processes sharing the database.
+func openSQLiteQueue(t *testing.T, path string) *SQLiteQueue {
+	t.Helper()
+	q, err := NewSQLiteQueue(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.pollInterval = 5 * time.Millisecond
+	q.retryDelay = time.Millisecond
+	t.Cleanup(func() { q.Close() })
+	return q
+}
+
+func newSQLiteQueue(t *testing.T) (*SQLiteQueue, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "queue.db")
+	return openSQLiteQueue(t, path), path
+}
+
+func TestSQLiteQueue_DeliversInTimeOrder(t *testing.T) {
+	q, _ := newSQLiteQueue(t)
+	ctx := context.Background()
+	now := time.Now()
+	rec := newRecorder()
+
+	if err := q.Enqueue(ctx, testPayload("3", 0), now.Add(60*time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Enqueue(ctx, testPayload("1", 0), now); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.EnqueuePregame(ctx, testPayload("2", 0), now.Add(30*time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+
+	serve(t, q, map[string]TaskHandler{
+		tasks.TypeWatchGameUpdates: rec.handler(tasks.TypeWatchGameUpdates, nil),
+		tasks.TypePregame:          rec.handler(tasks.TypePregame, nil),
+	})
+	got := rec.wait(t, 3)
+	want := []delivery{
+		{tasks.TypeWatchGameUpdates, "1", 0},
+		{tasks.TypePregame, "2", 0},
+		{tasks.TypeWatchGameUpdates, "3", 0},
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("delivery %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestSQLiteQueue_DeduplicatesQueuedAndFinishedTasks(t *testing.T) {
+	q, _ := newSQLiteQueue(t)
+	ctx := context.Background()
+	rec := newRecorder()
+	payload := testPayload("1", 4)
+
+	for range 2 {
+		if err := q.Enqueue(ctx, payload, time.Now()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	serve(t, q, map[string]TaskHandler{tasks.TypeWatchGameUpdates: rec.handler(tasks.TypeWatchGameUpdates, nil)})
+	rec.wait(t, 1)
+
+	// A late duplicate of the check that already ran, as a retried previous
+	// check would enqueue it.
+	if err := q.Enqueue(ctx, payload, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-rec.got:
+		t.Error("a finished task was delivered again")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestSQLiteQueue_RetriesFailedTask(t *testing.T) {
+	q, _ := newSQLiteQueue(t)
+	rec := newRecorder()
+	if err := q.Enqueue(context.Background(), testPayload("1", 0), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	serve(t, q, map[string]TaskHandler{tasks.TypeWatchGameUpdates: rec.handler(tasks.TypeWatchGameUpdates, errors.New("feed down"))})
+	rec.wait(t, taskMaxRetries+1)
+	select {
+	case <-rec.got:
+		t.Errorf("task delivered more than %d times", taskMaxRetries+1)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if loops, _ := q.ListGames(context.Background()); len(loops) != 0 {
+		t.Errorf("ListGames = %+v after the last retry, want none", loops)
+	}
+}
+
+func TestSQLiteQueue_SurvivesRestart(t *testing.T) {
+	q, path := newSQLiteQueue(t)
+	ctx := context.Background()
+	deliverAt := time.Now().Add(time.Hour).Truncate(time.Millisecond)
+	if err := q.Enqueue(ctx, testPayload("1", 2), deliverAt); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	restarted := openSQLiteQueue(t, path)
+	loops, err := restarted.ListGames(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loops) != 1 || loops[0].TaskID != tasks.TaskID(testPayload("1", 2)) || !loops[0].NextRun.Equal(deliverAt) {
+		t.Fatalf("ListGames after restart = %+v, want game 1's check at %s", loops, deliverAt)
+	}
+}
+
+func TestSQLiteQueue_ReclaimsTaskOfDeadWorker(t *testing.T) {
+	dead, path := newSQLiteQueue(t)
+	ctx := context.Background()
+	if err := dead.Enqueue(ctx, testPayload("1", 0), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if task, err := dead.claim(ctx); err != nil || task == nil {
+		t.Fatalf("claim = %v, %v; want the task", task, err)
+	}
+	// The worker dies here, holding the lease.
+
+	other := openSQLiteQueue(t, path)
+	if task, err := other.claim(ctx); err != nil || task != nil {
+		t.Fatalf("claim under a live lease = %v, %v; want nothing", task, err)
+	}
+	other.now = func() time.Time { return time.Now().Add(sqliteLease + time.Second) }
+	task, err := other.claim(ctx)
+	if err != nil || task == nil || task.payload.Game.ID != "1" {
+		t.Fatalf("claim after the lease lapsed = %+v, %v; want game 1's check", task, err)
+	}
+}
+
+func TestSQLiteQueue_ListAndCancel(t *testing.T) {
+	q, _ := newSQLiteQueue(t)
+	ctx := context.Background()
+	now := time.Now()
+	q.now = func() time.Time { return now }
+
+	for _, enqueue := range []struct {
+		payload   models.Payload
+		deliverAt time.Time
+	}{
+		{testPayload("1", 0), now.Add(-2 * time.Minute)}, // claimed below
+		{testPayload("1", 1), now.Add(time.Minute)},
+		{testPayload("2", 0), now.Add(-time.Minute)},
+	} {
+		if err := q.Enqueue(ctx, enqueue.payload, enqueue.deliverAt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := q.EnqueuePregame(ctx, testPayload("1", 0), now.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.claim(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	loops, err := q.ListGames(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	states := map[string]string{}
+	for _, l := range loops {
+		states[l.TaskID] = l.State
+	}
+	want := map[string]string{
+		tasks.TaskID(testPayload("1", 0)): StateActive,
+		tasks.TaskID(testPayload("1", 1)): StateScheduled,
+		tasks.TaskID(testPayload("2", 0)): StatePending,
+	}
+	if len(states) != len(want) {
+		t.Fatalf("ListGames = %+v, want %v", loops, want)
+	}
+	for id, state := range want {
+		if states[id] != state {
+			t.Errorf("task %s state = %q, want %q", id, states[id], state)
+		}
+	}
+
+	n, err := q.CancelGame(ctx, "1")
+	if err != nil || n != 2 {
+		t.Fatalf("CancelGame = %d, %v; want 2 game tasks", n, err)
+	}
+	counts, err := q.Counts(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(counts) != 1 || counts[StatePending] != 1 {
+		t.Errorf("Counts after cancelling game 1 = %v, want only game 2 pending", counts)
+	}
+}
+
+func TestSQLiteQueue_CancelFromAnotherProcess(t *testing.T) {
+	worker, path := newSQLiteQueue(t)
+	worker.lease = 30 * time.Millisecond
+	started := make(chan struct{})
+	cancelled := make(chan struct{})
+	serve(t, worker, map[string]TaskHandler{
+		tasks.TypeWatchGameUpdates: func(ctx context.Context, _ models.Payload) error {
+			close(started)
+			<-ctx.Done()
+			close(cancelled)
+			return ctx.Err()
+		},
+	})
+	ctx := context.Background()
+	if err := worker.Enqueue(ctx, testPayload("1", 0), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+
+	admin := openSQLiteQueue(t, path)
+	if n, err := admin.CancelGame(ctx, "1"); err != nil || n != 1 {
+		t.Fatalf("CancelGame = %d, %v; want 1", n, err)
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("running check was not cancelled")
+	}
+}
+
+func TestSQLiteQueue_PurgesExpiredFinishedTasks(t *testing.T) {
+	q, _ := newSQLiteQueue(t)
+	ctx := context.Background()
+	payload := testPayload("1", 0)
+	if err := q.Enqueue(ctx, payload, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.claim(ctx); err != nil {
+		t.Fatal(err)
+	}
+	q.finish(tasks.TaskID(payload))
+
+	q.purge(ctx)
+	var n int
+	if err := q.db.QueryRow(`SELECT COUNT(*) FROM tasks`).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Fatalf("%d tasks after purging within retention, want the finished one kept", n)
+	}
+
+	q.now = func() time.Time { return time.Now().Add(sqliteRetention + time.Minute) }
+	q.purge(ctx)
+	if err := q.db.QueryRow(`SELECT COUNT(*) FROM tasks`).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Errorf("%d tasks after retention, want the finished one purged", n)
+	}
+}
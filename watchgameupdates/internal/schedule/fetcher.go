@@ -3,6 +3,7 @@ package schedule
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -15,38 +16,137 @@ type ScheduleFetcher interface {
 	FetchSchedule(ctx context.Context, date string) ([]ScheduleGame, error)
 }
 
+// RangeFetcher is implemented by fetchers that can return several days of the
+// schedule without one request per day.
+type RangeFetcher interface {
+	// FetchScheduleRange returns the games of every date from..to (inclusive,
+	// YYYY-MM-DD) keyed by date. Dates without games may be missing.
+	FetchScheduleRange(ctx context.Context, from, to string) (map[string][]ScheduleGame, error)
+}
+
+// SeasonFetcher is implemented by fetchers that can return a team's schedule
+// for the current season.
+type SeasonFetcher interface {
+	FetchTeamSeason(ctx context.Context, team string) ([]ScheduleGame, error)
+}
+
 // HTTPScheduleFetcher fetches the schedule from the live NHL API.
 type HTTPScheduleFetcher struct {
 	BaseURL string
 }
 
 func (f *HTTPScheduleFetcher) FetchSchedule(ctx context.Context, date string) ([]ScheduleGame, error) {
-	url := fmt.Sprintf("%s/v1/schedule/%s", f.BaseURL, date)
+	var scheduleResp ScheduleResponse
+	if err := f.getJSON(ctx, fmt.Sprintf("%s/v1/schedule/%s", f.BaseURL, date), &scheduleResp); err != nil {
+		return nil, fmt.Errorf("failed to fetch schedule: %w", err)
+	}
+	return filterGamesByDate(scheduleResp, date), nil
+}
+
+// FetchScheduleRange walks the week-long gameWeek responses from `from` until
+// `to` is covered, keeping every day in the range instead of one per request.
+func (f *HTTPScheduleFetcher) FetchScheduleRange(ctx context.Context, from, to string) (map[string][]ScheduleGame, error) {
+	byDate := map[string][]ScheduleGame{}
+	for cursor := from; cursor <= to; {
+		var scheduleResp ScheduleResponse
+		if err := f.getJSON(ctx, fmt.Sprintf("%s/v1/schedule/%s", f.BaseURL, cursor), &scheduleResp); err != nil {
+			return nil, fmt.Errorf("failed to fetch schedule for %s: %w", cursor, err)
+		}
+
+		last := cursor
+		for _, day := range scheduleResp.GameWeek {
+			if day.Date >= from && day.Date <= to {
+				byDate[day.Date] = day.Games
+			}
+			if day.Date > last {
+				last = day.Date
+			}
+		}
+
+		next, err := time.Parse("2006-01-02", last)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule date %q: %w", last, err)
+		}
+		if len(scheduleResp.GameWeek) == 0 {
+			next = next.AddDate(0, 0, 6) // empty week: skip it rather than ask again
+		}
+		cursor = next.AddDate(0, 0, 1).Format("2006-01-02")
+	}
+	return byDate, nil
+}
+
+// FetchTeamSeason returns every game of the team's current season, played or
+// not, from the club-schedule-season endpoint.
+func (f *HTTPScheduleFetcher) FetchTeamSeason(ctx context.Context, team string) ([]ScheduleGame, error) {
+	var clubResp ClubScheduleResponse
+	if err := f.getJSON(ctx, fmt.Sprintf("%s/v1/club-schedule-season/%s/now", f.BaseURL, team), &clubResp); err != nil {
+		return nil, fmt.Errorf("failed to fetch %s season schedule: %w", team, err)
+	}
+	return clubResp.Games, nil
+}
+
+// getJSON GETs url and decodes a 200 response into v.
+func (f *HTTPScheduleFetcher) getJSON(ctx context.Context, url string, v interface{}) error {
 	log.Printf("Fetching NHL schedule from %s", url)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return fmt.Errorf("failed to create request: %w", err)
 	}
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch schedule: %w", err)
+		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("NHL API returned status %d", resp.StatusCode)
+		return fmt.Errorf("NHL API returned status %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
 	}
+	return nil
+}
 
-	var scheduleResp ScheduleResponse
-	if err := json.NewDecoder(resp.Body).Decode(&scheduleResp); err != nil {
-		return nil, fmt.Errorf("failed to decode schedule response: %w", err)
+// FetchGame looks up a single game by ID through the gamecenter landing
+// endpoint, which carries the same id/date/start/state/team fields as a
+// schedule entry.
+func (f *HTTPScheduleFetcher) FetchGame(ctx context.Context, gameID string) (ScheduleGame, error) {
+	url := fmt.Sprintf("%s/v1/gamecenter/%s/landing", f.BaseURL, gameID)
+	log.Printf("Fetching NHL game from %s", url)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return ScheduleGame{}, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	return filterGamesByDate(scheduleResp, date), nil
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return ScheduleGame{}, fmt.Errorf("failed to fetch game: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ScheduleGame{}, fmt.Errorf("game %s: %w", gameID, ErrGameNotFound)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return ScheduleGame{}, fmt.Errorf("NHL API returned status %d", resp.StatusCode)
+	}
+
+	var game ScheduleGame
+	if err := json.NewDecoder(resp.Body).Decode(&game); err != nil {
+		return ScheduleGame{}, fmt.Errorf("failed to decode game response: %w", err)
+	}
+	if game.ID == 0 {
+		return ScheduleGame{}, fmt.Errorf("game %s: %w", gameID, ErrGameNotFound)
+	}
+	return game, nil
 }
 
+// ErrGameNotFound is returned by FetchGame when the NHL API has no such game.
+var ErrGameNotFound = errors.New("game not found")
+
 // filterGamesByDate returns only games matching the target date from the gameWeek response.
 func filterGamesByDate(resp ScheduleResponse, date string) []ScheduleGame {
 	for _, day := range resp.GameWeek {
@@ -98,3 +198,12 @@ func ResolveTargetDate(dateOverride string) string {
 	}
 	return time.Now().UTC().Format("2006-01-02")
 }
+
+// AddDays returns the YYYY-MM-DD date n days after date.
+func AddDays(date string, n int) (string, error) {
+	d, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return "", err
+	}
+	return d.AddDate(0, 0, n).Format("2006-01-02"), nil
+}
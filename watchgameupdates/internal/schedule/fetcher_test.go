@@ -3,6 +3,7 @@ package schedule
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -136,3 +137,93 @@ func TestResolveTargetDate_Default(t *testing.T) {
 		t.Errorf("expected date in YYYY-MM-DD format, got %s", date)
 	}
 }
+
+func TestHTTPScheduleFetcher_FetchGame(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/gamecenter/2025020010/landing":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"id":2025020010,"gameDate":"2025-10-08","startTimeUTC":"2025-10-08T23:00:00Z",
+				"gameState":"FUT","gameType":2,
+				"homeTeam":{"id":6,"abbrev":"BOS","commonName":{"default":"Bruins"}},
+				"awayTeam":{"id":3,"abbrev":"NYR","commonName":{"default":"Rangers"}}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	fetcher := NewHTTPScheduleFetcher(server.URL)
+	game, err := fetcher.FetchGame(context.Background(), "2025020010")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if game.ID != 2025020010 || game.StartTimeUTC != "2025-10-08T23:00:00Z" || game.HomeTeam.Abbrev != "BOS" || game.AwayTeam.CommonName["default"] != "Rangers" {
+		t.Errorf("game = %+v", game)
+	}
+
+	if _, err := fetcher.FetchGame(context.Background(), "1"); !errors.Is(err, ErrGameNotFound) {
+		t.Errorf("unknown game: err = %v, want ErrGameNotFound", err)
+	}
+}
+
+func TestHTTPScheduleFetcher_FetchScheduleRange(t *testing.T) {
+	// Each response is the week starting at the requested date.
+	weeks := map[string]ScheduleResponse{
+		"/v1/schedule/2025-10-07": {GameWeek: []GameWeekDay{
+			{Date: "2025-10-07", Games: []ScheduleGame{{ID: 1}}},
+			{Date: "2025-10-08", Games: []ScheduleGame{{ID: 2}, {ID: 3}}},
+			{Date: "2025-10-09"}, {Date: "2025-10-10"}, {Date: "2025-10-11"},
+			{Date: "2025-10-12", Games: []ScheduleGame{{ID: 4}}},
+			{Date: "2025-10-13"},
+		}},
+		"/v1/schedule/2025-10-14": {GameWeek: []GameWeekDay{
+			{Date: "2025-10-14", Games: []ScheduleGame{{ID: 5}}},
+			{Date: "2025-10-15", Games: []ScheduleGame{{ID: 6}}},
+		}},
+	}
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Path)
+		resp, ok := weeks[r.URL.Path]
+		if !ok {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	byDate, err := NewHTTPScheduleFetcher(server.URL).FetchScheduleRange(context.Background(), "2025-10-07", "2025-10-14")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(requests) != 2 {
+		t.Errorf("expected one request per week, got %v", requests)
+	}
+	if _, ok := byDate["2025-10-15"]; ok {
+		t.Error("date after the range should be dropped")
+	}
+	if len(byDate["2025-10-08"]) != 2 || len(byDate["2025-10-12"]) != 1 || len(byDate["2025-10-14"]) != 1 {
+		t.Errorf("byDate = %v", byDate)
+	}
+}
+
+func TestHTTPScheduleFetcher_FetchTeamSeason(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/club-schedule-season/BOS/now" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		w.Write([]byte(`{"currentSeason":20252026,"games":[
+			{"id":2025020010,"gameDate":"2025-10-08","startTimeUTC":"2025-10-08T23:00:00Z","gameState":"FUT",
+			 "homeTeam":{"id":6,"abbrev":"BOS"},"awayTeam":{"id":3,"abbrev":"NYR"}}]}`))
+	}))
+	defer server.Close()
+
+	games, err := NewHTTPScheduleFetcher(server.URL).FetchTeamSeason(context.Background(), "BOS")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(games) != 1 || games[0].ID != 2025020010 || games[0].AwayTeam.Abbrev != "NYR" {
+		t.Errorf("games = %+v", games)
+	}
+}
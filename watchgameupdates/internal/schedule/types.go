@@ -1,12 +1,22 @@
 package schedule
 
-import "watchgameupdates/internal/models"
+import (
+	"strconv"
+
+	"watchgameupdates/internal/models"
+)
 
 // ScheduleResponse represents the NHL API response from /v1/schedule/{date}.
 type ScheduleResponse struct {
 	GameWeek []GameWeekDay `json:"gameWeek"`
 }
 
+// ClubScheduleResponse represents the NHL API response from
+// /v1/club-schedule-season/{team}/now.
+type ClubScheduleResponse struct {
+	Games []ScheduleGame `json:"games"`
+}
+
 // GameWeekDay represents a single day within the gameWeek array.
 type GameWeekDay struct {
 	Date  string         `json:"date"`
@@ -15,11 +25,25 @@ type GameWeekDay struct {
 
 // ScheduleGame represents a single game in the NHL schedule response.
 type ScheduleGame struct {
-	ID           int         `json:"id"`
-	GameDate     string      `json:"gameDate"`
-	StartTimeUTC string      `json:"startTimeUTC"`
-	GameState    string      `json:"gameState"`
-	GameType     int         `json:"gameType"`
-	HomeTeam     models.Team `json:"homeTeam"`
-	AwayTeam     models.Team `json:"awayTeam"`
+	ID           int                  `json:"id"`
+	GameDate     string               `json:"gameDate"`
+	StartTimeUTC string               `json:"startTimeUTC"`
+	GameState    string               `json:"gameState"`
+	GameType     int                  `json:"gameType"`
+	HomeTeam     models.Team          `json:"homeTeam"`
+	AwayTeam     models.Team          `json:"awayTeam"`
+	SeriesStatus *models.SeriesStatus `json:"seriesStatus,omitempty"` // playoff games only
+}
+
+// Game returns the task payload's view of the game.
+func (g ScheduleGame) Game() models.Game {
+	return models.Game{
+		ID:        strconv.Itoa(g.ID),
+		GameDate:  g.GameDate,
+		StartTime: g.StartTimeUTC,
+		HomeTeam:  g.HomeTeam,
+		AwayTeam:  g.AwayTeam,
+		GameType:  g.GameType,
+		Series:    g.SeriesStatus,
+	}
 }
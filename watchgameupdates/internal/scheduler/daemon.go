@@ -0,0 +1,158 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"watchgameupdates/internal/schedule"
+)
+
+// Lock elects a single daemon among replicas. leader.RedisLock implements it.
+type Lock interface {
+	// Acquire takes the lock if free; true if this process holds it.
+	Acquire(ctx context.Context) (bool, error)
+	// Renew extends the lease; false if the lock was lost.
+	Renew(ctx context.Context) (bool, error)
+	Release(ctx context.Context) error
+	TTL() time.Duration
+}
+
+const (
+	// passTimeout bounds one poll of the schedule.
+	passTimeout = 10 * time.Minute
+	// shutdownGrace is how long a poll in progress may continue after a
+	// shutdown signal; it stays under the 30s Kubernetes termination grace.
+	shutdownGrace = 20 * time.Second
+)
+
+// Daemon keeps the queue in step with the schedule: while it holds the lock,
+// it polls the next days every interval (gameDayInterval while today has
+// games) and enqueues games that are new or moved since the previous poll.
+type Daemon struct {
+	scheduler       *Scheduler
+	lock            Lock
+	days            int
+	interval        time.Duration
+	gameDayInterval time.Duration
+}
+
+// NewDaemon creates a Daemon polling days dates from today. It makes s
+// remember what it enqueued, so each poll only reports and announces new
+// games.
+func NewDaemon(s *Scheduler, lock Lock, days int, interval, gameDayInterval time.Duration) *Daemon {
+	s.RememberEnqueued()
+	return &Daemon{
+		scheduler:       s,
+		lock:            lock,
+		days:            days,
+		interval:        interval,
+		gameDayInterval: gameDayInterval,
+	}
+}
+
+// Run campaigns for the lock and polls while it is held, until ctx is
+// cancelled. A poll in progress at cancellation gets shutdownGrace to finish,
+// then the lock is released so another replica takes over at once.
+func (d *Daemon) Run(ctx context.Context) error {
+	retry := d.lock.TTL() / 3
+	for {
+		leader, err := d.lock.Acquire(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Scheduler daemon: leader election failed: %v", err)
+		}
+		if leader {
+			log.Printf("Scheduler daemon: elected leader")
+			d.lead(ctx)
+		}
+		select {
+		case <-ctx.Done():
+			log.Printf("Scheduler daemon: shut down")
+			return nil
+		case <-time.After(retry):
+		}
+	}
+}
+
+// lead polls on the daemon's cadence until ctx is cancelled or the lock is
+// lost, renewing the lease in the background, and releases the lock.
+func (d *Daemon) lead(ctx context.Context) {
+	leadCtx, stepDown := context.WithCancel(ctx)
+	defer stepDown()
+	go d.keepLock(leadCtx, stepDown)
+	defer func() {
+		releaseCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := d.lock.Release(releaseCtx); err != nil {
+			log.Printf("Scheduler daemon: release lock: %v", err)
+		}
+	}()
+
+	for {
+		wait := d.interval
+		if d.poll(leadCtx) {
+			wait = d.gameDayInterval
+		}
+		if leadCtx.Err() != nil {
+			return
+		}
+		log.Printf("Scheduler daemon: next poll in %v", wait)
+		select {
+		case <-leadCtx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// keepLock renews the lease every third of its TTL and calls stepDown when it
+// is lost.
+func (d *Daemon) keepLock(ctx context.Context, stepDown context.CancelFunc) {
+	ticker := time.NewTicker(d.lock.TTL() / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			held, err := d.lock.Renew(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil || !held {
+				log.Printf("Scheduler daemon: lost leadership (renew held=%v err=%v)", held, err)
+				stepDown()
+				return
+			}
+		}
+	}
+}
+
+// poll schedules today and the following days and reports whether today has
+// games for the filtered teams. It outlives ctx by up to shutdownGrace so a
+// shutdown does not cut an enqueue in half.
+func (d *Daemon) poll(ctx context.Context) bool {
+	pollCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), passTimeout)
+	defer cancel()
+	stop := context.AfterFunc(ctx, func() {
+		select {
+		case <-pollCtx.Done():
+		case <-time.After(shutdownGrace):
+			cancel()
+		}
+	})
+	defer stop()
+
+	today := d.scheduler.now().UTC().Format("2006-01-02")
+	last, err := schedule.AddDays(today, d.days-1)
+	if err != nil {
+		log.Printf("Scheduler daemon: %v", err)
+		return false
+	}
+	results, err := d.scheduler.RunRange(pollCtx, today, last)
+	if err != nil {
+		log.Printf("Scheduler daemon: poll failed: %v", err)
+		return false
+	}
+	return len(results) > 0 && results[0].Matched > 0
+}
@@ -0,0 +1,187 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"watchgameupdates/config"
+	"watchgameupdates/internal/models"
+	"watchgameupdates/internal/schedule"
+)
+
+// fakeLock is held while held is true; Renew can be made to fail.
+type fakeLock struct {
+	mu       sync.Mutex
+	free     bool
+	held     bool
+	lose     bool // next Renew reports the lock lost
+	released int
+}
+
+func (l *fakeLock) Acquire(context.Context) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.free {
+		l.held = true
+	}
+	return l.held, nil
+}
+
+func (l *fakeLock) Renew(context.Context) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.lose {
+		l.held, l.free, l.lose = false, false, false
+	}
+	return l.held, nil
+}
+
+func (l *fakeLock) Release(context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.held {
+		l.held = false
+		l.free = true
+	}
+	l.released++
+	return nil
+}
+
+func (l *fakeLock) TTL() time.Duration { return 30 * time.Millisecond }
+
+// syncQueue is a mockQueue safe to read while the daemon runs.
+type syncQueue struct {
+	mu sync.Mutex
+	mockQueue
+}
+
+func (q *syncQueue) Enqueue(ctx context.Context, p models.Payload, at time.Time) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.mockQueue.Enqueue(ctx, p, at)
+}
+
+func (q *syncQueue) count() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.tasks)
+}
+
+// countingFetcher counts schedule fetches.
+type countingFetcher struct {
+	mu    sync.Mutex
+	games []schedule.ScheduleGame
+	calls int
+}
+
+func (f *countingFetcher) FetchSchedule(context.Context, string) ([]schedule.ScheduleGame, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	return f.games, nil
+}
+
+func (f *countingFetcher) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
+
+func runDaemon(t *testing.T, d *Daemon) (stop func()) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		d.Run(ctx)
+		close(done)
+	}()
+	return func() {
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("daemon did not shut down")
+		}
+	}
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestDaemon_LeaderPollsAndEnqueuesOnce(t *testing.T) {
+	start := time.Now().Add(3 * time.Hour)
+	fetcher := &countingFetcher{games: []schedule.ScheduleGame{futureGame(1, "BOS", "NYR", "2025-10-08", start)}}
+	q := &syncQueue{}
+	sender := &recordingSender{}
+	lock := &fakeLock{free: true}
+	d := NewDaemon(New(fetcher, q, 5, true, config.GameFilter{}, sender, false), lock, 1, time.Hour, 10*time.Millisecond)
+
+	stop := runDaemon(t, d)
+	// Today has a game, so the daemon polls on the game-day cadence.
+	waitFor(t, "several polls", func() bool { return fetcher.count() >= 3 })
+	stop()
+
+	if n := q.count(); n != 1 {
+		t.Errorf("enqueued %d tasks over several polls, want 1", n)
+	}
+	if len(sender.messages) != 1 {
+		t.Errorf("sent %d summaries, want 1 for the one new game", len(sender.messages))
+	}
+	if lock.released == 0 || lock.held {
+		t.Error("lock was not released on shutdown")
+	}
+}
+
+func TestDaemon_FollowerDoesNotPoll(t *testing.T) {
+	fetcher := &countingFetcher{}
+	lock := &fakeLock{} // held by another replica
+	d := NewDaemon(New(fetcher, &syncQueue{}, 5, true, config.GameFilter{}, nil, false), lock, 1, 10*time.Millisecond, 10*time.Millisecond)
+
+	stop := runDaemon(t, d)
+	time.Sleep(50 * time.Millisecond)
+	if fetcher.count() != 0 {
+		t.Errorf("follower polled %d times", fetcher.count())
+	}
+
+	// The leader goes away; the follower takes over.
+	lock.mu.Lock()
+	lock.free = true
+	lock.mu.Unlock()
+	waitFor(t, "takeover poll", func() bool { return fetcher.count() > 0 })
+	stop()
+}
+
+func TestDaemon_StepsDownWhenLockIsLost(t *testing.T) {
+	fetcher := &countingFetcher{}
+	lock := &fakeLock{free: true}
+	d := NewDaemon(New(fetcher, &syncQueue{}, 5, true, config.GameFilter{}, nil, false), lock, 1, 5*time.Millisecond, 5*time.Millisecond)
+
+	stop := runDaemon(t, d)
+	defer stop()
+	waitFor(t, "first poll", func() bool { return fetcher.count() > 0 })
+
+	lock.mu.Lock()
+	lock.lose = true
+	lock.mu.Unlock()
+	waitFor(t, "step down", func() bool {
+		lock.mu.Lock()
+		defer lock.mu.Unlock()
+		return !lock.held
+	})
+	time.Sleep(20 * time.Millisecond)
+	polls := fetcher.count()
+	time.Sleep(50 * time.Millisecond)
+	if fetcher.count() != polls {
+		t.Errorf("kept polling after losing the lock: %d → %d", polls, fetcher.count())
+	}
+}
@@ -0,0 +1,196 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"watchgameupdates/internal/schedule"
+)
+
+// maxRangeDays bounds a date range; a season is about 200 days.
+const maxRangeDays = 366
+
+// maxSummaryDates bounds the per-date lines of a range summary message.
+const maxSummaryDates = 20
+
+// DateResult reports what a run did with one date's games.
+type DateResult struct {
+	Date      string
+	Found     int
+	Matched   int // passed the team filter
+	Scheduled int
+	Deferred  int // start beyond the queue's horizon; a later run picks them up
+	Skipped   int // filtered out, started, finished or listed twice
+	Failed    int
+	Err       error // the date's schedule could not be fetched
+}
+
+// RunRange schedules the games of every date from..to (inclusive, YYYY-MM-DD)
+// and returns a result per date. Fetchers implementing schedule.RangeFetcher
+// are asked once for the whole range; others once per date.
+func (s *Scheduler) RunRange(ctx context.Context, from, to string) ([]DateResult, error) {
+	dates, err := dateRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+	s.logFilter()
+	log.Printf("Fetching schedule for %s to %s", from, to)
+
+	byDate := map[string][]schedule.ScheduleGame{}
+	fetchErrs := map[string]error{}
+	if rf, ok := s.fetcher.(schedule.RangeFetcher); ok {
+		byDate, err = rf.FetchScheduleRange(ctx, from, to)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch schedule: %w", err)
+		}
+	} else {
+		for _, date := range dates {
+			games, err := s.fetcher.FetchSchedule(ctx, date)
+			if err != nil {
+				fetchErrs[date] = err
+				continue
+			}
+			byDate[date] = games
+		}
+	}
+
+	results := s.scheduleDates(ctx, dates, byDate, fetchErrs)
+	s.report(ctx, fmt.Sprintf("%s to %s", from, to), results)
+	return results, nil
+}
+
+// RunSeason schedules every remaining game of the current season for the
+// filtered teams, from the club-schedule-season endpoint, and returns a
+// result per game date. It needs a team filter: a season for every team is
+// the whole league calendar.
+func (s *Scheduler) RunSeason(ctx context.Context) ([]DateResult, error) {
+	if len(s.filter.Teams) == 0 {
+		return nil, fmt.Errorf("season scheduling needs TEAM_FILTER teams")
+	}
+	sf, ok := s.fetcher.(schedule.SeasonFetcher)
+	if !ok {
+		return nil, fmt.Errorf("the schedule source does not provide season schedules (SCHEDULE_FILE is set)")
+	}
+	s.logFilter()
+
+	today := s.now().UTC().Format("2006-01-02")
+	seen := map[int]bool{}
+	byDate := map[string][]schedule.ScheduleGame{}
+	for _, team := range s.filter.Teams {
+		games, err := sf.FetchTeamSeason(ctx, team)
+		if err != nil {
+			return nil, err
+		}
+		for _, game := range games {
+			if seen[game.ID] || game.GameDate < today {
+				continue
+			}
+			seen[game.ID] = true
+			byDate[game.GameDate] = append(byDate[game.GameDate], game)
+		}
+	}
+
+	dates := make([]string, 0, len(byDate))
+	for date := range byDate {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+	if len(dates) == 0 {
+		log.Printf("No remaining games this season for %v", s.filter.Teams)
+		return nil, nil
+	}
+
+	results := s.scheduleDates(ctx, dates, byDate, nil)
+	s.report(ctx, fmt.Sprintf("the rest of the season (%s to %s)", dates[0], dates[len(dates)-1]), results)
+	return results, nil
+}
+
+func (s *Scheduler) scheduleDates(ctx context.Context, dates []string, byDate map[string][]schedule.ScheduleGame, fetchErrs map[string]error) []DateResult {
+	seen := map[int]bool{}
+	results := make([]DateResult, 0, len(dates))
+	for _, date := range dates {
+		if err := fetchErrs[date]; err != nil {
+			log.Printf("Failed to fetch schedule for %s: %v", date, err)
+			results = append(results, DateResult{Date: date, Err: err})
+			continue
+		}
+		result, _ := s.scheduleGames(ctx, date, byDate[date], seen)
+		results = append(results, result)
+	}
+	return results
+}
+
+// report logs one line per date plus totals and sends the summary message.
+func (s *Scheduler) report(ctx context.Context, span string, results []DateResult) {
+	var total DateResult
+	log.Printf("Schedule report for %s:", span)
+	for _, r := range results {
+		if r.Err != nil {
+			log.Printf("  %s  fetch failed: %v", r.Date, r.Err)
+			continue
+		}
+		log.Printf("  %s  found=%d scheduled=%d deferred=%d skipped=%d failed=%d",
+			r.Date, r.Found, r.Scheduled, r.Deferred, r.Skipped, r.Failed)
+		total.Found += r.Found
+		total.Scheduled += r.Scheduled
+		total.Deferred += r.Deferred
+		total.Skipped += r.Skipped
+		total.Failed += r.Failed
+	}
+	log.Printf("  total       found=%d scheduled=%d deferred=%d skipped=%d failed=%d",
+		total.Found, total.Scheduled, total.Deferred, total.Skipped, total.Failed)
+
+	if total.Scheduled > 0 && s.notifier != nil {
+		s.notifier.SendMessage(ctx, formatRangeSummary(span, results, total))
+	}
+}
+
+// formatRangeSummary builds a Discord message with the scheduled count per
+// date, capped at maxSummaryDates lines.
+func formatRangeSummary(span string, results []DateResult, total DateResult) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "🏒 Scheduled %d game(s) for %s:\n", total.Scheduled, span)
+	lines := 0
+	for _, r := range results {
+		if r.Scheduled == 0 {
+			continue
+		}
+		if lines == maxSummaryDates {
+			b.WriteString("• …\n")
+			break
+		}
+		fmt.Fprintf(&b, "• %s: %d\n", r.Date, r.Scheduled)
+		lines++
+	}
+	if total.Deferred > 0 {
+		fmt.Fprintf(&b, "%d game(s) beyond the queue horizon will be scheduled by a later run.\n", total.Deferred)
+	}
+	return b.String()
+}
+
+// dateRange returns every date from..to inclusive.
+func dateRange(from, to string) ([]string, error) {
+	start, err := time.Parse("2006-01-02", from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from date %q: %w", from, err)
+	}
+	end, err := time.Parse("2006-01-02", to)
+	if err != nil {
+		return nil, fmt.Errorf("invalid to date %q: %w", to, err)
+	}
+	if end.Before(start) {
+		return nil, fmt.Errorf("to date %s is before from date %s", to, from)
+	}
+	if days := int(end.Sub(start).Hours()/24) + 1; days > maxRangeDays {
+		return nil, fmt.Errorf("date range of %d days exceeds %d", days, maxRangeDays)
+	}
+	var dates []string
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		dates = append(dates, d.Format("2006-01-02"))
+	}
+	return dates, nil
+}
@@ -0,0 +1,195 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"watchgameupdates/config"
+	"watchgameupdates/internal/models"
+	"watchgameupdates/internal/schedule"
+)
+
+// rangeFetcher serves a whole range in one call and counts the calls.
+type rangeFetcher struct {
+	datedFetcher
+	rangeCalls int
+}
+
+func (f *rangeFetcher) FetchScheduleRange(_ context.Context, from, to string) (map[string][]schedule.ScheduleGame, error) {
+	f.rangeCalls++
+	out := map[string][]schedule.ScheduleGame{}
+	for date, games := range f.datedFetcher {
+		if date >= from && date <= to {
+			out[date] = games
+		}
+	}
+	return out, nil
+}
+
+// seasonFetcher returns a season schedule per team.
+type seasonFetcher struct {
+	mockFetcher
+	seasons map[string][]schedule.ScheduleGame
+}
+
+func (f *seasonFetcher) FetchTeamSeason(_ context.Context, team string) ([]schedule.ScheduleGame, error) {
+	games, ok := f.seasons[team]
+	if !ok {
+		return nil, fmt.Errorf("no season for %s", team)
+	}
+	return games, nil
+}
+
+// horizonQueue is a mockQueue with a scheduling horizon.
+type horizonQueue struct {
+	mockQueue
+	horizon time.Duration
+}
+
+func (q *horizonQueue) MaxScheduleAhead() time.Duration { return q.horizon }
+
+// recordingSender captures summary messages.
+type recordingSender struct{ messages []string }
+
+func (r *recordingSender) SendMessage(_ context.Context, message string) {
+	r.messages = append(r.messages, message)
+}
+
+func futureGame(id int, home, away, date string, start time.Time) schedule.ScheduleGame {
+	return schedule.ScheduleGame{
+		ID:           id,
+		GameDate:     date,
+		StartTimeUTC: start.Format(time.RFC3339),
+		GameState:    gameStateFUT,
+		HomeTeam:     models.Team{Abbrev: home},
+		AwayTeam:     models.Team{Abbrev: away},
+	}
+}
+
+func TestScheduler_RunRange_PerDateFetches(t *testing.T) {
+	start := time.Now().Add(24 * time.Hour)
+	fetcher := datedFetcher{
+		"2025-10-08": {futureGame(1, "BOS", "NYR", "2025-10-08", start), futureGame(2, "TOR", "MTL", "2025-10-08", start)},
+		"2025-10-10": {futureGame(3, "BOS", "TOR", "2025-10-10", start.Add(48*time.Hour))},
+	}
+	q := &mockQueue{}
+	sender := &recordingSender{}
+	s := New(fetcher, q, 5, true, config.GameFilter{Teams: []string{"BOS"}}, sender, false)
+
+	results, err := s.RunRange(context.Background(), "2025-10-08", "2025-10-10")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("expected a result per date, got %+v", results)
+	}
+	want := []DateResult{
+		{Date: "2025-10-08", Found: 2, Matched: 1, Scheduled: 1, Skipped: 1},
+		{Date: "2025-10-09"},
+		{Date: "2025-10-10", Found: 1, Matched: 1, Scheduled: 1},
+	}
+	for i, w := range want {
+		if results[i] != w {
+			t.Errorf("results[%d] = %+v, want %+v", i, results[i], w)
+		}
+	}
+	if len(q.tasks) != 2 {
+		t.Errorf("expected 2 tasks, got %d", len(q.tasks))
+	}
+	if len(sender.messages) != 1 || !strings.Contains(sender.messages[0], "Scheduled 2 game(s)") {
+		t.Errorf("summary = %v", sender.messages)
+	}
+}
+
+func TestScheduler_RunRange_UsesRangeFetcher(t *testing.T) {
+	start := time.Now().Add(24 * time.Hour)
+	fetcher := &rangeFetcher{datedFetcher: datedFetcher{
+		"2025-10-08": {futureGame(1, "BOS", "NYR", "2025-10-08", start)},
+		// A game listed on two dates (the file fetcher does this) is scheduled once.
+		"2025-10-09": {futureGame(1, "BOS", "NYR", "2025-10-08", start)},
+	}}
+	q := &mockQueue{}
+	s := New(fetcher, q, 5, true, config.GameFilter{}, nil, false)
+
+	results, err := s.RunRange(context.Background(), "2025-10-08", "2025-10-09")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fetcher.rangeCalls != 1 {
+		t.Errorf("expected one range fetch, got %d", fetcher.rangeCalls)
+	}
+	if len(q.tasks) != 1 || results[1].Skipped != 1 {
+		t.Errorf("tasks = %d, results = %+v; want the repeated game skipped", len(q.tasks), results)
+	}
+}
+
+func TestScheduler_RunRange_InvalidRange(t *testing.T) {
+	s := New(datedFetcher{}, &mockQueue{}, 5, true, config.GameFilter{}, nil, false)
+	for _, r := range [][2]string{{"2025-10-10", "2025-10-08"}, {"10/08/2025", "2025-10-10"}, {"2025-01-01", "2026-06-01"}} {
+		if _, err := s.RunRange(context.Background(), r[0], r[1]); err == nil {
+			t.Errorf("RunRange(%s, %s): expected error", r[0], r[1])
+		}
+	}
+}
+
+func TestScheduler_Horizon_DefersDistantGames(t *testing.T) {
+	now := time.Now()
+	fetcher := datedFetcher{
+		"2025-10-08": {
+			futureGame(1, "BOS", "NYR", "2025-10-08", now.Add(24*time.Hour)),
+			futureGame(2, "BOS", "TOR", "2025-10-08", now.Add(40*24*time.Hour)),
+		},
+	}
+	q := &horizonQueue{horizon: 29 * 24 * time.Hour}
+	s := New(fetcher, q, 5, true, config.GameFilter{}, nil, false)
+
+	results, err := s.RunRange(context.Background(), "2025-10-08", "2025-10-08")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if results[0].Scheduled != 1 || results[0].Deferred != 1 || results[0].Failed != 0 {
+		t.Errorf("result = %+v, want 1 scheduled and 1 deferred", results[0])
+	}
+	if len(q.tasks) != 1 || q.tasks[0].payload.Game.ID != "1" {
+		t.Errorf("tasks = %+v", q.tasks)
+	}
+}
+
+func TestScheduler_RunSeason(t *testing.T) {
+	now := time.Date(2025, 11, 1, 12, 0, 0, 0, time.UTC)
+	shared := futureGame(20, "BOS", "TOR", "2025-11-05", now.Add(4*24*time.Hour))
+	past := schedule.ScheduleGame{ID: 10, GameDate: "2025-10-20", GameState: "OFF"}
+	fetcher := &seasonFetcher{seasons: map[string][]schedule.ScheduleGame{
+		"BOS": {past, futureGame(11, "BOS", "NYR", "2025-11-02", now.Add(24*time.Hour)), shared},
+		"TOR": {shared, futureGame(21, "MTL", "TOR", "2025-11-05", now.Add(4*24*time.Hour+time.Hour))},
+	}}
+	q := &mockQueue{}
+	s := New(fetcher, q, 5, true, config.GameFilter{Teams: []string{"BOS", "TOR"}}, nil, false)
+	s.now = func() time.Time { return now }
+
+	results, err := s.RunSeason(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 2 || results[0].Date != "2025-11-02" || results[1].Date != "2025-11-05" {
+		t.Fatalf("results = %+v, want one per remaining game date", results)
+	}
+	if results[1].Found != 2 || results[1].Scheduled != 2 {
+		t.Errorf("2025-11-05 = %+v, want the shared game once plus MTL @ TOR", results[1])
+	}
+	if len(q.tasks) != 3 {
+		t.Errorf("expected 3 tasks, got %d", len(q.tasks))
+	}
+}
+
+func TestScheduler_RunSeason_Errors(t *testing.T) {
+	if _, err := New(&seasonFetcher{}, &mockQueue{}, 5, true, config.GameFilter{}, nil, false).RunSeason(context.Background()); err == nil {
+		t.Error("expected an error without a team filter")
+	}
+	if _, err := New(&mockFetcher{}, &mockQueue{}, 5, true, config.GameFilter{Teams: []string{"BOS"}}, nil, false).RunSeason(context.Background()); err == nil {
+		t.Error("expected an error for a fetcher without season schedules")
+	}
+}
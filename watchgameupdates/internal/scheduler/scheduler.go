@@ -4,10 +4,9 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"strconv"
-	"strings"
 	"time"
 
+	"watchgameupdates/config"
 	"watchgameupdates/internal/models"
 	"watchgameupdates/internal/schedule"
 )
@@ -37,31 +36,74 @@ type Scheduler struct {
 	queue            TaskEnqueuer
 	gameMaxDuration  time.Duration
 	shouldNotify     bool
-	teamFilters      []string // empty = monitor all games
+	filter           config.GameFilter
 	notifier         MessageSender
 	includeLiveGames bool
+	horizon          time.Duration // 0 = no limit; see HorizonLimited
+	pregameLead      time.Duration // 0 = no pregame tasks; see EnablePregame
+	now              func() time.Time
+	// enqueued maps game IDs this scheduler enqueued to their start time, so a
+	// long-running scheduler only enqueues games that are new or moved. nil
+	// unless RememberEnqueued was called.
+	enqueued map[int]string
+}
+
+// HorizonLimited is implemented by queues that cannot hold a task arbitrarily
+// far in the future. Games starting beyond the horizon are deferred to a later
+// run instead of failing.
+type HorizonLimited interface {
+	MaxScheduleAhead() time.Duration
+}
+
+// PregameEnqueuer is implemented by queues that can hold the pregame task
+// (tasks.TypePregame) next to the game loop. queue.RedisQueue and
+// queue.CloudTasksQueue implement it.
+type PregameEnqueuer interface {
+	EnqueuePregame(ctx context.Context, payload models.Payload, deliverAt time.Time) error
 }
 
 // New creates a new Scheduler.
-func New(fetcher schedule.ScheduleFetcher, q TaskEnqueuer, gameMaxDurationHours int, shouldNotify bool, teamFilters []string, notifier MessageSender, includeLiveGames bool) *Scheduler {
-	return &Scheduler{
+func New(fetcher schedule.ScheduleFetcher, q TaskEnqueuer, gameMaxDurationHours int, shouldNotify bool, filter config.GameFilter, notifier MessageSender, includeLiveGames bool) *Scheduler {
+	s := &Scheduler{
 		fetcher:          fetcher,
 		queue:            q,
 		gameMaxDuration:  time.Duration(gameMaxDurationHours) * time.Hour,
 		shouldNotify:     shouldNotify,
-		teamFilters:      teamFilters,
+		filter:           filter,
 		notifier:         notifier,
 		includeLiveGames: includeLiveGames,
+		now:              time.Now,
+	}
+	if h, ok := q.(HorizonLimited); ok {
+		s.horizon = h.MaxScheduleAhead()
+	}
+	return s
+}
+
+// RememberEnqueued makes later runs skip games this scheduler already
+// enqueued with the same start time. Task deduplication would make enqueuing
+// them again harmless, but not silent: every rerun would report and announce
+// them as newly scheduled, and would re-create a loop cancelled by hand.
+func (s *Scheduler) RememberEnqueued() {
+	if s.enqueued == nil {
+		s.enqueued = map[int]string{}
+	}
+}
+
+// EnablePregame makes the scheduler enqueue a pregame task lead before each
+// game it schedules, when the queue implements PregameEnqueuer. Games already
+// closer to puck drop than lead get none.
+func (s *Scheduler) EnablePregame(lead time.Duration) {
+	if _, ok := s.queue.(PregameEnqueuer); !ok {
+		log.Printf("Queue does not support pregame tasks; pregame notifications disabled")
+		return
 	}
+	s.pregameLead = lead
 }
 
 // Run fetches the schedule for the given date and enqueues a task for each future game.
 func (s *Scheduler) Run(ctx context.Context, date string) error {
-	if len(s.teamFilters) == 0 {
-		log.Printf("Monitoring ALL teams")
-	} else {
-		log.Printf("Monitoring teams: %v", s.teamFilters)
-	}
+	s.logFilter()
 
 	log.Printf("Fetching schedule for %s", date)
 
@@ -77,84 +119,126 @@ func (s *Scheduler) Run(ctx context.Context, date string) error {
 
 	log.Printf("Found %d games for %s", len(games), date)
 
-	scheduled := 0
+	result, scheduledGames := s.scheduleGames(ctx, date, games, map[int]bool{})
+
+	log.Printf("Successfully scheduled %d/%d tasks for %s", result.Scheduled, len(games), date)
+
+	if result.Scheduled > 0 && s.notifier != nil {
+		s.notifier.SendMessage(ctx, formatSchedulerSummary(date, scheduledGames))
+	}
+
+	return nil
+}
+
+func (s *Scheduler) logFilter() {
+	log.Printf("Monitoring %s", s.filter)
+}
+
+// scheduleGames enqueues a task for each game of date that passes the game
+// filter and has not started, skipping games already in seen (a range can list
+// a game twice) and adding the ones it handles.
+func (s *Scheduler) scheduleGames(ctx context.Context, date string, games []schedule.ScheduleGame, seen map[int]bool) (DateResult, []schedule.ScheduleGame) {
+	result := DateResult{Date: date, Found: len(games)}
 	var scheduledGames []schedule.ScheduleGame
 	for _, game := range games {
-		if len(s.teamFilters) > 0 && !containsTeam(s.teamFilters, game.HomeTeam.Abbrev) && !containsTeam(s.teamFilters, game.AwayTeam.Abbrev) {
-			log.Printf("Skipping game %d (%s vs %s) - neither team in roster %v",
-				game.ID, game.AwayTeam.Abbrev, game.HomeTeam.Abbrev, s.teamFilters)
+		if seen[game.ID] {
+			result.Skipped++
 			continue
 		}
+		if !s.filter.Matches(game.HomeTeam.Abbrev, game.AwayTeam.Abbrev, game.GameType) {
+			log.Printf("Skipping game %d (%s vs %s, gameType %d) - not in the filter (%s)",
+				game.ID, game.AwayTeam.Abbrev, game.HomeTeam.Abbrev, game.GameType, s.filter)
+			result.Skipped++
+			continue
+		}
+		result.Matched++
+		if start, ok := s.enqueued[game.ID]; ok {
+			if start == game.StartTimeUTC {
+				result.Skipped++
+				continue
+			}
+			log.Printf("Game %d (%s vs %s) moved from %s to %s, enqueuing again",
+				game.ID, game.AwayTeam.Abbrev, game.HomeTeam.Abbrev, start, game.StartTimeUTC)
+		}
 
 		isLive := game.GameState == gameStateLIVE
 		if game.GameState != gameStateFUT && game.GameState != gameStatePRE && !isLive {
 			log.Printf("Skipping game %d (%s vs %s) - state is %s, not FUT or PRE",
 				game.ID, game.AwayTeam.Abbrev, game.HomeTeam.Abbrev, game.GameState)
+			result.Skipped++
 			continue
 		}
 		if isLive && !s.includeLiveGames {
 			log.Printf("Skipping game %d (%s vs %s) - state is LIVE (set INCLUDE_LIVE_GAMES=true to monitor)",
 				game.ID, game.AwayTeam.Abbrev, game.HomeTeam.Abbrev)
+			result.Skipped++
 			continue
 		}
 
 		startTime, err := time.Parse(time.RFC3339, game.StartTimeUTC)
 		if err != nil {
 			log.Printf("Failed to parse start time for game %d: %v", game.ID, err)
+			result.Failed++
 			continue
 		}
 
 		// For live games, deliver immediately and set executionEnd from now.
+		now := s.now()
 		deliverAt := startTime
 		executionEndBase := startTime
 		if isLive {
-			now := time.Now()
 			deliverAt = now
 			executionEndBase = now
 		}
+		if s.horizon > 0 && deliverAt.After(now.Add(s.horizon)) {
+			log.Printf("Deferring game %d (%s vs %s) - starts %s, beyond the queue's %v scheduling horizon",
+				game.ID, game.AwayTeam.Abbrev, game.HomeTeam.Abbrev, game.StartTimeUTC, s.horizon)
+			seen[game.ID] = true
+			result.Deferred++
+			continue
+		}
 		executionEnd := executionEndBase.Add(s.gameMaxDuration).Format(time.RFC3339)
 		shouldNotify := s.shouldNotify
 
 		payload := models.Payload{
-			Game: models.Game{
-				ID:        strconv.Itoa(game.ID),
-				GameDate:  game.GameDate,
-				StartTime: game.StartTimeUTC,
-				HomeTeam:  game.HomeTeam,
-				AwayTeam:  game.AwayTeam,
-			},
+			Game:         game.Game(),
 			ExecutionEnd: &executionEnd,
 			ShouldNotify: &shouldNotify,
 		}
 
 		if err := s.queue.Enqueue(ctx, payload, deliverAt); err != nil {
 			log.Printf("Failed to enqueue task for game %d: %v", game.ID, err)
+			result.Failed++
 			continue
 		}
 
-		scheduledGames = append(scheduledGames, game)
-		scheduled++
-	}
-
-	log.Printf("Successfully scheduled %d/%d tasks for %s", scheduled, len(games), date)
+		if !isLive {
+			s.enqueuePregame(ctx, payload, startTime)
+		}
 
-	if scheduled > 0 && s.notifier != nil {
-		s.notifier.SendMessage(ctx, formatSchedulerSummary(date, scheduledGames))
+		seen[game.ID] = true
+		if s.enqueued != nil {
+			s.enqueued[game.ID] = game.StartTimeUTC
+		}
+		scheduledGames = append(scheduledGames, game)
+		result.Scheduled++
 	}
-
-	return nil
+	return result, scheduledGames
 }
 
-// containsTeam reports whether abbrev is in the roster slice.
-// Normalizes abbrev to uppercase to match the roster (which is uppercased by ParseTeamFilter).
-func containsTeam(roster []string, abbrev string) bool {
-	upper := strings.ToUpper(abbrev)
-	for _, t := range roster {
-		if t == upper {
-			return true
-		}
+// enqueuePregame enqueues payload's pregame task pregameLead before start. A
+// failure is logged only: the game itself is tracked either way.
+func (s *Scheduler) enqueuePregame(ctx context.Context, payload models.Payload, start time.Time) {
+	if s.pregameLead <= 0 {
+		return
+	}
+	deliverAt := start.Add(-s.pregameLead)
+	if deliverAt.Before(s.now()) {
+		return
+	}
+	if err := s.queue.(PregameEnqueuer).EnqueuePregame(ctx, payload, deliverAt); err != nil {
+		log.Printf("Failed to enqueue pregame task for game %s: %v", payload.Game.ID, err)
 	}
-	return false
 }
 
 // formatSchedulerSummary builds a Discord message summarising the scheduled games.
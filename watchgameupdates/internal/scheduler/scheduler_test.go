@@ -6,7 +6,9 @@ import (
 	"testing"
 	"time"
 
+	"watchgameupdates/config"
 	"watchgameupdates/internal/models"
+	"watchgameupdates/internal/queue"
 	"watchgameupdates/internal/schedule"
 )
 
@@ -66,7 +68,7 @@ func TestScheduler_Run_SchedulesFutureGames(t *testing.T) {
 
 	q := &mockQueue{}
 	fetcher := &mockFetcher{games: games}
-	s := New(fetcher, q, 5, true, nil, nil, false)
+	s := New(fetcher, q, 5, true, config.GameFilter{}, nil, false)
 
 	err := s.Run(context.Background(), "2025-10-08")
 	if err != nil {
@@ -139,7 +141,7 @@ func TestScheduler_Run_SkipsNonFutureGames(t *testing.T) {
 
 	q := &mockQueue{}
 	fetcher := &mockFetcher{games: games}
-	s := New(fetcher, q, 5, true, nil, nil, false)
+	s := New(fetcher, q, 5, true, config.GameFilter{}, nil, false)
 
 	err := s.Run(context.Background(), "2025-10-08")
 	if err != nil {
@@ -162,7 +164,7 @@ func TestScheduler_Run_SkipsNonFutureGames(t *testing.T) {
 func TestScheduler_Run_NoGames(t *testing.T) {
 	q := &mockQueue{}
 	fetcher := &mockFetcher{games: nil}
-	s := New(fetcher, q, 5, true, nil, nil, false)
+	s := New(fetcher, q, 5, true, config.GameFilter{}, nil, false)
 
 	err := s.Run(context.Background(), "2025-07-15")
 	if err != nil {
@@ -190,7 +192,7 @@ func TestScheduler_Run_ExecutionEndCalculation(t *testing.T) {
 	q := &mockQueue{}
 	fetcher := &mockFetcher{games: games}
 	maxHours := 5
-	s := New(fetcher, q, maxHours, false, nil, nil, false)
+	s := New(fetcher, q, maxHours, false, config.GameFilter{}, nil, false)
 
 	err := s.Run(context.Background(), "2025-10-08")
 	if err != nil {
@@ -229,7 +231,7 @@ func TestScheduler_Run_ExecutionEndCalculation(t *testing.T) {
 func TestScheduler_Run_FetcherError(t *testing.T) {
 	q := &mockQueue{}
 	fetcher := &mockFetcher{err: fmt.Errorf("NHL API unavailable")}
-	s := New(fetcher, q, 5, true, nil, nil, false)
+	s := New(fetcher, q, 5, true, config.GameFilter{}, nil, false)
 
 	err := s.Run(context.Background(), "2025-10-08")
 	if err == nil {
@@ -265,7 +267,7 @@ func TestScheduler_Run_EnqueueErrorContinues(t *testing.T) {
 	// Fail on the first enqueue, succeed on the second
 	q := &mockQueue{failOn: 1}
 	fetcher := &mockFetcher{games: games}
-	s := New(fetcher, q, 5, true, nil, nil, false)
+	s := New(fetcher, q, 5, true, config.GameFilter{}, nil, false)
 
 	err := s.Run(context.Background(), "2025-10-08")
 	if err != nil {
@@ -296,7 +298,7 @@ func TestScheduler_Run_InvalidStartTime(t *testing.T) {
 
 	q := &mockQueue{}
 	fetcher := &mockFetcher{games: games}
-	s := New(fetcher, q, 5, true, nil, nil, false)
+	s := New(fetcher, q, 5, true, config.GameFilter{}, nil, false)
 
 	err := s.Run(context.Background(), "2025-10-08")
 	if err != nil {
@@ -323,7 +325,7 @@ func TestScheduler_Run_GameIDConversion(t *testing.T) {
 
 	q := &mockQueue{}
 	fetcher := &mockFetcher{games: games}
-	s := New(fetcher, q, 5, true, nil, nil, false)
+	s := New(fetcher, q, 5, true, config.GameFilter{}, nil, false)
 
 	s.Run(context.Background(), "2025-10-08")
 
@@ -364,7 +366,7 @@ func TestScheduler_Run_TeamFilter(t *testing.T) {
 
 	q := &mockQueue{}
 	fetcher := &mockFetcher{games: games}
-	s := New(fetcher, q, 5, true, []string{"DAL"}, nil, false)
+	s := New(fetcher, q, 5, true, config.GameFilter{Teams: []string{"DAL"}}, nil, false)
 
 	err := s.Run(context.Background(), "2025-10-08")
 	if err != nil {
@@ -384,6 +386,41 @@ func TestScheduler_Run_TeamFilter(t *testing.T) {
 	}
 }
 
+func TestScheduler_Run_GameTypeAndExclusionFilter(t *testing.T) {
+	futureTime := time.Now().Add(2 * time.Hour).Format(time.RFC3339)
+	game := func(id, gameType int, home, away string) schedule.ScheduleGame {
+		return schedule.ScheduleGame{
+			ID: id, GameDate: "2025-10-08", StartTimeUTC: futureTime, GameState: gameStateFUT, GameType: gameType,
+			HomeTeam: models.Team{Abbrev: home}, AwayTeam: models.Team{Abbrev: away},
+		}
+	}
+	games := []schedule.ScheduleGame{
+		game(2025010001, models.GameTypePreseason, "BOS", "NYR"),
+		game(2025020002, models.GameTypeRegular, "TOR", "MTL"),
+		game(2025020003, models.GameTypeRegular, "DAL", "COL"),
+		game(2025030004, models.GameTypePlayoff, "MTL", "BOS"),
+	}
+
+	q := &mockQueue{}
+	filter := config.GameFilter{ExcludeTeams: []string{"TOR"}, GameTypes: []int{models.GameTypeRegular, models.GameTypePlayoff}}
+	s := New(&mockFetcher{games: games}, q, 5, true, filter, nil, false)
+
+	if err := s.Run(context.Background(), "2025-10-08"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got []string
+	for _, task := range q.tasks {
+		got = append(got, task.payload.Game.ID)
+	}
+	if want := []string{"2025020003", "2025030004"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("scheduled %v, want %v (no preseason, no TOR games)", got, want)
+	}
+	if q.tasks[1].payload.Game.GameType != models.GameTypePlayoff {
+		t.Errorf("GameType = %d, want the playoff game type carried in the payload", q.tasks[1].payload.Game.GameType)
+	}
+}
+
 func TestScheduler_Run_TeamFilterCaseInsensitive(t *testing.T) {
 	// Guard against NHL API returning mixed-case abbrevs while the roster is uppercased.
 	futureTime := time.Now().Add(2 * time.Hour).Format(time.RFC3339)
@@ -400,7 +437,7 @@ func TestScheduler_Run_TeamFilterCaseInsensitive(t *testing.T) {
 
 	q := &mockQueue{}
 	fetcher := &mockFetcher{games: games}
-	s := New(fetcher, q, 5, true, []string{"DAL"}, nil, false)
+	s := New(fetcher, q, 5, true, config.GameFilter{Teams: []string{"DAL"}}, nil, false)
 
 	err := s.Run(context.Background(), "2025-10-08")
 	if err != nil {
@@ -436,7 +473,7 @@ func TestScheduler_Run_IncludeLiveGames(t *testing.T) {
 	q := &mockQueue{}
 	fetcher := &mockFetcher{games: games}
 	before := time.Now()
-	s := New(fetcher, q, 5, true, nil, nil, true)
+	s := New(fetcher, q, 5, true, config.GameFilter{}, nil, true)
 
 	err := s.Run(context.Background(), "2025-10-08")
 	if err != nil {
@@ -471,6 +508,40 @@ func TestScheduler_Run_IncludeLiveGames(t *testing.T) {
 	}
 }
 
+func TestScheduler_Run_RerunKeepsOneLoopForLiveGame(t *testing.T) {
+	start := time.Date(2025, 10, 8, 23, 0, 0, 0, time.UTC)
+	games := []schedule.ScheduleGame{{
+		ID:           2025020002,
+		GameDate:     "2025-10-08",
+		StartTimeUTC: start.Format(time.RFC3339),
+		GameState:    gameStateLIVE,
+		HomeTeam:     models.Team{Abbrev: "BOS", ID: 6},
+		AwayTeam:     models.Team{Abbrev: "NYR", ID: 3},
+	}}
+	q, err := queue.NewMemoryQueue("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each run is a fresh scheduler process, later into the game, so the
+	// execution end taken from the clock differs between them.
+	for _, now := range []time.Time{start.Add(20 * time.Minute), start.Add(50 * time.Minute)} {
+		s := New(&mockFetcher{games: games}, q, 5, true, config.GameFilter{}, nil, true)
+		s.now = func() time.Time { return now }
+		if err := s.Run(context.Background(), "2025-10-08"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	loops, err := q.ListGames(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loops) != 1 {
+		t.Fatalf("expected 1 game loop after a rerun, got %d: %+v", len(loops), loops)
+	}
+}
+
 func TestScheduler_Run_LiveGameWithTeamFilter(t *testing.T) {
 	futureTime := time.Now().Add(2 * time.Hour).Format(time.RFC3339)
 	games := []schedule.ScheduleGame{
@@ -494,7 +565,7 @@ func TestScheduler_Run_LiveGameWithTeamFilter(t *testing.T) {
 
 	q := &mockQueue{}
 	fetcher := &mockFetcher{games: games}
-	s := New(fetcher, q, 5, true, []string{"TOR"}, nil, true)
+	s := New(fetcher, q, 5, true, config.GameFilter{Teams: []string{"TOR"}}, nil, true)
 
 	err := s.Run(context.Background(), "2025-10-08")
 	if err != nil {
@@ -525,7 +596,7 @@ func TestScheduler_Run_LiveGamesSkippedByDefault(t *testing.T) {
 
 	q := &mockQueue{}
 	fetcher := &mockFetcher{games: games}
-	s := New(fetcher, q, 5, true, nil, nil, false)
+	s := New(fetcher, q, 5, true, config.GameFilter{}, nil, false)
 
 	err := s.Run(context.Background(), "2025-10-08")
 	if err != nil {
@@ -568,7 +639,7 @@ func TestScheduler_Run_MultiTeamFilter(t *testing.T) {
 
 	q := &mockQueue{}
 	fetcher := &mockFetcher{games: games}
-	s := New(fetcher, q, 5, true, []string{"DAL", "COL"}, nil, false)
+	s := New(fetcher, q, 5, true, config.GameFilter{Teams: []string{"DAL", "COL"}}, nil, false)
 
 	err := s.Run(context.Background(), "2025-10-08")
 	if err != nil {
@@ -605,7 +676,7 @@ func TestScheduler_Run_TwoListedTeamsOneGame(t *testing.T) {
 
 	q := &mockQueue{}
 	fetcher := &mockFetcher{games: games}
-	s := New(fetcher, q, 5, true, []string{"DAL", "COL"}, nil, false)
+	s := New(fetcher, q, 5, true, config.GameFilter{Teams: []string{"DAL", "COL"}}, nil, false)
 
 	err := s.Run(context.Background(), "2025-10-08")
 	if err != nil {
@@ -643,7 +714,7 @@ func TestScheduler_Run_EmptyFilterSchedulesAllGames(t *testing.T) {
 
 	q := &mockQueue{}
 	fetcher := &mockFetcher{games: games}
-	s := New(fetcher, q, 5, true, nil, nil, false)
+	s := New(fetcher, q, 5, true, config.GameFilter{}, nil, false)
 
 	err := s.Run(context.Background(), "2025-10-08")
 	if err != nil {
@@ -654,3 +725,55 @@ func TestScheduler_Run_EmptyFilterSchedulesAllGames(t *testing.T) {
 		t.Fatalf("expected all 2 games scheduled when filter is empty, got %d", len(q.tasks))
 	}
 }
+
+// pregameQueue is a mockQueue that also takes pregame tasks.
+type pregameQueue struct {
+	mockQueue
+	pregame []enqueuedTask
+}
+
+func (q *pregameQueue) EnqueuePregame(_ context.Context, payload models.Payload, deliverAt time.Time) error {
+	q.pregame = append(q.pregame, enqueuedTask{payload: payload, deliverAt: deliverAt})
+	return nil
+}
+
+func TestScheduler_Run_EnqueuesPregame(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	later := now.Add(2 * time.Hour)
+	soon := now.Add(10 * time.Minute)
+	games := []schedule.ScheduleGame{
+		{ID: 2025020001, GameDate: "2025-10-08", StartTimeUTC: later.Format(time.RFC3339), GameState: gameStateFUT,
+			HomeTeam: models.Team{Abbrev: "TOR"}, AwayTeam: models.Team{Abbrev: "MTL"}},
+		{ID: 2025020002, GameDate: "2025-10-08", StartTimeUTC: soon.Format(time.RFC3339), GameState: gameStatePRE,
+			HomeTeam: models.Team{Abbrev: "BOS"}, AwayTeam: models.Team{Abbrev: "NYR"}},
+	}
+
+	q := &pregameQueue{}
+	s := New(&mockFetcher{games: games}, q, 5, true, config.GameFilter{}, nil, false)
+	s.now = func() time.Time { return now }
+	s.EnablePregame(30 * time.Minute)
+
+	if err := s.Run(context.Background(), "2025-10-08"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(q.tasks) != 2 {
+		t.Fatalf("expected 2 game loop tasks, got %d", len(q.tasks))
+	}
+	if len(q.pregame) != 1 {
+		t.Fatalf("expected 1 pregame task (the game starting in 10 min gets none), got %d", len(q.pregame))
+	}
+	if q.pregame[0].payload.Game.ID != "2025020001" {
+		t.Errorf("pregame task for game %s, want 2025020001", q.pregame[0].payload.Game.ID)
+	}
+	if want := later.Add(-30 * time.Minute); !q.pregame[0].deliverAt.Equal(want) {
+		t.Errorf("pregame deliverAt = %v, want %v", q.pregame[0].deliverAt, want)
+	}
+}
+
+func TestScheduler_EnablePregame_UnsupportedQueue(t *testing.T) {
+	s := New(&mockFetcher{}, &mockQueue{}, 5, true, config.GameFilter{}, nil, false)
+	s.EnablePregame(30 * time.Minute)
+	if s.pregameLead != 0 {
+		t.Errorf("pregameLead = %v on a queue without pregame support, want 0", s.pregameLead)
+	}
+}
@@ -0,0 +1,171 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"watchgameupdates/config"
+	"watchgameupdates/internal/models"
+	"watchgameupdates/internal/opsalert"
+	"watchgameupdates/internal/queue"
+	"watchgameupdates/internal/schedule"
+)
+
+// GameLoopQueue is a queue the watchdog can inspect as well as enqueue to.
+// queue.RedisQueue and queue.CloudTasksQueue implement it.
+type GameLoopQueue interface {
+	TaskEnqueuer
+	ListGames(ctx context.Context) ([]queue.GameLoop, error)
+}
+
+const gameStateCRIT = "CRIT"
+
+// Watchdog finds games that are under way but whose tracking loop has died
+// (a failed scheduleNextCheck ends the chain) and re-seeds them.
+type Watchdog struct {
+	fetcher         schedule.ScheduleFetcher
+	queue           GameLoopQueue
+	gameMaxDuration time.Duration
+	shouldNotify    bool
+	filter          config.GameFilter
+	alerter         opsalert.Alerter
+	now             func() time.Time
+}
+
+// NewWatchdog creates a Watchdog. gameMaxDurationHours, shouldNotify and
+// filter must match the scheduler's so a revived loop is the one the
+// scheduler would have started.
+func NewWatchdog(fetcher schedule.ScheduleFetcher, q GameLoopQueue, gameMaxDurationHours int, shouldNotify bool, filter config.GameFilter, alerter opsalert.Alerter) *Watchdog {
+	return &Watchdog{
+		fetcher:         fetcher,
+		queue:           q,
+		gameMaxDuration: time.Duration(gameMaxDurationHours) * time.Hour,
+		shouldNotify:    shouldNotify,
+		filter:          filter,
+		alerter:         alerter,
+		now:             time.Now,
+	}
+}
+
+// Check compares the live games of date (and the day before, whose late games
+// run past midnight UTC) with the games that have a pending check, re-seeds
+// every missing loop and raises an ops alert naming the game. It returns how
+// many loops were revived.
+func (w *Watchdog) Check(ctx context.Context, date string) (int, error) {
+	live, err := w.liveGames(ctx, date)
+	if err != nil {
+		return 0, err
+	}
+	if len(live) == 0 {
+		log.Printf("Watchdog: no live games")
+		return 0, nil
+	}
+
+	loops, err := w.queue.ListGames(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list game loops: %w", err)
+	}
+	tracked := make(map[string]bool, len(loops))
+	for _, loop := range loops {
+		tracked[loop.GameID] = true
+	}
+
+	revived := 0
+	for _, game := range live {
+		gameID := strconv.Itoa(game.ID)
+		if tracked[gameID] {
+			continue
+		}
+		if w.revive(ctx, game) {
+			revived++
+		}
+	}
+	log.Printf("Watchdog: %d live games, %d revived", len(live), revived)
+	return revived, nil
+}
+
+// liveGames returns the games in progress on date or the day before that pass
+// the team filter.
+func (w *Watchdog) liveGames(ctx context.Context, date string) ([]schedule.ScheduleGame, error) {
+	day, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date %q: %w", date, err)
+	}
+
+	seen := map[int]bool{}
+	var live []schedule.ScheduleGame
+	for _, d := range []string{day.AddDate(0, 0, -1).Format("2006-01-02"), date} {
+		games, err := w.fetcher.FetchSchedule(ctx, d)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch schedule for %s: %w", d, err)
+		}
+		for _, game := range games {
+			if seen[game.ID] || (game.GameState != gameStateLIVE && game.GameState != gameStateCRIT) {
+				continue
+			}
+			if !w.filter.Matches(game.HomeTeam.Abbrev, game.AwayTeam.Abbrev, game.GameType) {
+				continue
+			}
+			seen[game.ID] = true
+			live = append(live, game)
+		}
+	}
+	return live, nil
+}
+
+// revive enqueues an immediate check for game with the ExecutionEnd the
+// scheduler gave it, extended for a playoff game still live past it. Cycle
+// numbers only have to keep increasing within a loop, so the revived loop
+// resumes at the seconds elapsed since puck drop, which a loop polling at most
+// once a second cannot have reached; cycle 0 would collide with the dead
+// loop's first task.
+func (w *Watchdog) revive(ctx context.Context, game schedule.ScheduleGame) bool {
+	label := fmt.Sprintf("Game %d (%s @ %s)", game.ID, game.AwayTeam.Abbrev, game.HomeTeam.Abbrev)
+
+	startTime, err := time.Parse(time.RFC3339, game.StartTimeUTC)
+	if err != nil {
+		log.Printf("Watchdog: %s has no valid start time: %v", label, err)
+		return false
+	}
+	now := w.now()
+	end := startTime.Add(w.gameMaxDuration)
+	if game.Game().IsPlayoff() {
+		// Playoff overtimes can outlast the fixed window; the revived loop
+		// keeps extending it while the game is live.
+		end = models.ExtendPlayoffWindow(end, now)
+	}
+	if !now.Before(end) {
+		log.Printf("Watchdog: %s is still %s past its execution window (%s), not reviving",
+			label, game.GameState, end.Format(time.RFC3339))
+		return false
+	}
+
+	executionEnd := end.Format(time.RFC3339)
+	shouldNotify := w.shouldNotify
+	payload := models.Payload{
+		Game:         game.Game(),
+		ExecutionEnd: &executionEnd,
+		ShouldNotify: &shouldNotify,
+		Cycle:        max(int(now.Sub(startTime).Seconds()), 0),
+	}
+
+	if err := w.queue.Enqueue(ctx, payload, now); err != nil {
+		w.alert(ctx, "Dead game loop", fmt.Sprintf(
+			"%s is %s but has no pending check, and re-seeding it failed: %v. Start it with the admin API or the enqueue tool.",
+			label, game.GameState, err))
+		return false
+	}
+	w.alert(ctx, "Revived dead game loop", fmt.Sprintf(
+		"%s is %s but had no pending check. Re-seeded a check now, tracking until %s.",
+		label, game.GameState, executionEnd))
+	return true
+}
+
+func (w *Watchdog) alert(ctx context.Context, subject, detail string) {
+	if err := w.alerter.Alert(ctx, subject, detail); err != nil {
+		log.Printf("ERROR: watchdog ops alert: %v", err)
+	}
+}
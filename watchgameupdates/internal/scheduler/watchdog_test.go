@@ -0,0 +1,170 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"watchgameupdates/config"
+	"watchgameupdates/internal/models"
+	"watchgameupdates/internal/queue"
+	"watchgameupdates/internal/schedule"
+)
+
+// loopQueue is a mockQueue that also reports pending game loops.
+type loopQueue struct {
+	mockQueue
+	loops   []queue.GameLoop
+	listErr error
+}
+
+func (q *loopQueue) ListGames(context.Context) ([]queue.GameLoop, error) {
+	return q.loops, q.listErr
+}
+
+// datedFetcher returns the games of each date.
+type datedFetcher map[string][]schedule.ScheduleGame
+
+func (f datedFetcher) FetchSchedule(_ context.Context, date string) ([]schedule.ScheduleGame, error) {
+	return f[date], nil
+}
+
+type alert struct{ subject, detail string }
+
+type recordingAlerter struct{ alerts []alert }
+
+func (a *recordingAlerter) Alert(_ context.Context, subject, detail string) error {
+	a.alerts = append(a.alerts, alert{subject, detail})
+	return nil
+}
+
+var watchdogNow = time.Date(2025, 10, 9, 1, 0, 0, 0, time.UTC)
+
+func newTestWatchdog(f schedule.ScheduleFetcher, q GameLoopQueue, teams []string) (*Watchdog, *recordingAlerter) {
+	alerts := &recordingAlerter{}
+	w := NewWatchdog(f, q, 5, true, config.GameFilter{Teams: teams}, alerts)
+	w.now = func() time.Time { return watchdogNow }
+	return w, alerts
+}
+
+func liveGame(id int, home, away, start string) schedule.ScheduleGame {
+	return schedule.ScheduleGame{
+		ID:           id,
+		GameDate:     "2025-10-08",
+		StartTimeUTC: start,
+		GameState:    gameStateLIVE,
+		HomeTeam:     models.Team{Abbrev: home},
+		AwayTeam:     models.Team{Abbrev: away},
+	}
+}
+
+func TestWatchdog_RevivesDeadLoop(t *testing.T) {
+	// 7pm ET games are on the previous UTC date's schedule after midnight UTC.
+	fetcher := datedFetcher{
+		"2025-10-08": {
+			liveGame(2025020010, "BOS", "NYR", "2025-10-08T23:00:00Z"),
+			liveGame(2025020011, "TOR", "MTL", "2025-10-08T23:30:00Z"),
+			{ID: 2025020012, StartTimeUTC: "2025-10-08T23:00:00Z", GameState: "OFF"},
+		},
+	}
+	q := &loopQueue{loops: []queue.GameLoop{{GameID: "2025020011"}}}
+	w, alerts := newTestWatchdog(fetcher, q, nil)
+
+	revived, err := w.Check(context.Background(), "2025-10-09")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if revived != 1 || len(q.tasks) != 1 {
+		t.Fatalf("revived %d, enqueued %d; want 1 of each", revived, len(q.tasks))
+	}
+
+	task := q.tasks[0]
+	if task.payload.Game.ID != "2025020010" || !task.deliverAt.Equal(watchdogNow) {
+		t.Errorf("enqueued game %s at %v, want 2025020010 now", task.payload.Game.ID, task.deliverAt)
+	}
+	if task.payload.ExecutionEnd == nil || *task.payload.ExecutionEnd != "2025-10-09T04:00:00Z" {
+		t.Errorf("ExecutionEnd = %v, want start + 5h", task.payload.ExecutionEnd)
+	}
+	if task.payload.Cycle != 7200 {
+		t.Errorf("Cycle = %d, want seconds since puck drop (7200)", task.payload.Cycle)
+	}
+	if task.payload.ShouldNotify == nil || !*task.payload.ShouldNotify {
+		t.Error("expected ShouldNotify to be true")
+	}
+
+	if len(alerts.alerts) != 1 || !strings.Contains(alerts.alerts[0].detail, "2025020010") {
+		t.Errorf("alerts = %+v, want one naming game 2025020010", alerts.alerts)
+	}
+}
+
+func TestWatchdog_AllLoopsAlive(t *testing.T) {
+	fetcher := datedFetcher{"2025-10-09": {liveGame(2025020010, "BOS", "NYR", "2025-10-08T23:00:00Z")}}
+	q := &loopQueue{loops: []queue.GameLoop{{GameID: "2025020010"}}}
+	w, alerts := newTestWatchdog(fetcher, q, nil)
+
+	revived, err := w.Check(context.Background(), "2025-10-09")
+	if err != nil || revived != 0 || len(q.tasks) != 0 || len(alerts.alerts) != 0 {
+		t.Errorf("revived=%d err=%v tasks=%d alerts=%d; want nothing", revived, err, len(q.tasks), len(alerts.alerts))
+	}
+}
+
+func TestWatchdog_SkipsFilteredAndExpiredGames(t *testing.T) {
+	fetcher := datedFetcher{"2025-10-09": {
+		liveGame(2025020010, "BOS", "NYR", "2025-10-08T23:00:00Z"),
+		liveGame(2025020011, "TOR", "MTL", "2025-10-08T19:00:00Z"), // window ended at 00:00
+	}}
+	q := &loopQueue{}
+	w, alerts := newTestWatchdog(fetcher, q, []string{"TOR"})
+
+	revived, err := w.Check(context.Background(), "2025-10-09")
+	if err != nil || revived != 0 || len(q.tasks) != 0 || len(alerts.alerts) != 0 {
+		t.Errorf("revived=%d err=%v tasks=%d alerts=%d; want nothing", revived, err, len(q.tasks), len(alerts.alerts))
+	}
+}
+
+func TestWatchdog_ExtendsPlayoffWindow(t *testing.T) {
+	// Started 6h ago: past the 5h window, but playoff overtimes are uncapped.
+	game := liveGame(2025030411, "FLA", "EDM", "2025-10-08T19:00:00Z")
+	game.GameType = models.GameTypePlayoff
+	fetcher := datedFetcher{"2025-10-09": {game}}
+	q := &loopQueue{}
+	w, _ := newTestWatchdog(fetcher, q, nil)
+
+	revived, err := w.Check(context.Background(), "2025-10-09")
+	if err != nil || revived != 1 || len(q.tasks) != 1 {
+		t.Fatalf("revived=%d err=%v tasks=%d; want the playoff game revived", revived, err, len(q.tasks))
+	}
+	// Whole hours past now (01:00): a window ending right now would expire.
+	if end := q.tasks[0].payload.ExecutionEnd; end == nil || *end != "2025-10-09T02:00:00Z" {
+		t.Errorf("ExecutionEnd = %v, want the 00:00 window extended to 02:00", end)
+	}
+}
+
+func TestWatchdog_EnqueueFailureAlerts(t *testing.T) {
+	fetcher := datedFetcher{"2025-10-09": {liveGame(2025020010, "BOS", "NYR", "2025-10-08T23:00:00Z")}}
+	q := &loopQueue{mockQueue: mockQueue{failOn: 1}}
+	w, alerts := newTestWatchdog(fetcher, q, nil)
+
+	revived, err := w.Check(context.Background(), "2025-10-09")
+	if err != nil || revived != 0 {
+		t.Errorf("revived=%d err=%v; want 0 revived, no error", revived, err)
+	}
+	if len(alerts.alerts) != 1 || alerts.alerts[0].subject != "Dead game loop" {
+		t.Errorf("alerts = %+v, want one dead game loop alert", alerts.alerts)
+	}
+}
+
+func TestWatchdog_ListError(t *testing.T) {
+	fetcher := datedFetcher{"2025-10-09": {liveGame(2025020010, "BOS", "NYR", "2025-10-08T23:00:00Z")}}
+	q := &loopQueue{listErr: fmt.Errorf("redis down")}
+	w, _ := newTestWatchdog(fetcher, q, nil)
+
+	if _, err := w.Check(context.Background(), "2025-10-09"); err == nil {
+		t.Error("expected an error when game loops cannot be listed")
+	}
+	if len(q.tasks) != 0 {
+		t.Errorf("enqueued %d tasks without knowing which loops are alive", len(q.tasks))
+	}
+}
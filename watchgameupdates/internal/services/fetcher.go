@@ -7,6 +7,10 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"time"
+
+	"watchgameupdates/internal/metrics"
 )
 
 // ErrCSVParse classifies a failure to parse the MoneyPuck CSV (e.g. a transient
@@ -114,12 +118,16 @@ func (f *HTTPGameDataFetcher) FetchGameData(gameID string) ([][]string, error) {
 	url := fmt.Sprintf("%s/moneypuck/gameData/20252026/%s.csv", statsAPIBaseURL, gameID)
 	log.Printf("DEBUG: Requesting URL: %s", url)
 
+	start := time.Now()
 	resp, err := http.Get(url)
+	metrics.MoneyPuckFetchDuration.Observe(time.Since(start).Seconds())
 	if err != nil {
 		log.Printf("ERROR: HTTP request failed for game %s: %v", gameID, err)
+		metrics.MoneyPuckFetches.WithLabelValues("error").Inc()
 		return nil, err
 	}
 	defer resp.Body.Close()
+	metrics.MoneyPuckFetches.WithLabelValues(strconv.Itoa(resp.StatusCode)).Inc()
 
 	if resp.StatusCode != http.StatusOK {
 		log.Printf("ERROR: MoneyPuck API returned status %d for game %s", resp.StatusCode, gameID)
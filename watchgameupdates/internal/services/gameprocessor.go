@@ -8,10 +8,15 @@ import (
 	"time"
 
 	"watchgameupdates/config"
+	"watchgameupdates/internal/metrics"
 	"watchgameupdates/internal/models"
 	"watchgameupdates/internal/notification"
 )
 
+// playoffWindowMargin is how close to its execution end a playoff game still in
+// play may get before the window is extended, so the next check still runs.
+const playoffWindowMargin = 30 * time.Minute
+
 // ParseErrorRetryInterval is how long to wait before retrying a game check after
 // the MoneyPuck CSV failed to parse. The malformed file is usually transient, so
 // we retry the same scenario shortly rather than notifying with missing data.
@@ -22,6 +27,11 @@ const ParseErrorRetryInterval = 1 * time.Minute
 type GameProcessor struct {
 	Fetcher             GameDataFetcher
 	NotificationService *notification.Service
+	// Config supplies the reschedule intervals; nil loads it from the environment.
+	Config *config.Config
+	// Games re-validates the first poll of a loop (RevalidateSchedule); nil
+	// skips the check.
+	Games GameLookup
 }
 
 // ProcessResult holds the outcome of processing a game update.
@@ -33,6 +43,24 @@ type ProcessResult struct {
 	// notification was sent this cycle; the caller should reschedule a short retry
 	// (ParseErrorRetryInterval) rather than the normal play-type interval.
 	RetryAfterDataError bool
+	// GameState is the play-by-play gameState (FUT, PRE, LIVE, CRIT, OFF, FINAL);
+	// empty when the feed did not report one.
+	GameState string
+	// NextCheckInterval is when the caller should run the next check, as
+	// chosen by the PollingPolicy and already announced to notifiers (e.g. as
+	// the Live Activity stale date). Zero when ShouldReschedule is false.
+	// RetryAfterDataError overrides it.
+	NextCheckInterval time.Duration
+	// NextCheckReason is the PollDecision.Reason for NextCheckInterval.
+	NextCheckReason string
+	// Poll is the polling policy's state for the next check; callers copy it
+	// into the rescheduled payload. nil when ShouldReschedule is false.
+	Poll *models.PollState
+	// ExecutionEnd is the execution window the next check should carry. It is
+	// the payload's own unless a playoff game still in play was about to
+	// outlast it (see models.ExtendPlayoffWindow); callers copy it into the
+	// rescheduled payload.
+	ExecutionEnd *string
 }
 
 // ShouldSkipExecution returns true if the current time is past the execution end window.
@@ -64,7 +92,39 @@ func (gp *GameProcessor) ProcessGameUpdate(payload models.Payload) ProcessResult
 		"period-end":   {},
 	}
 
-	lastPlay, maxPeriods := FetchPlayByPlay(payload.Game.ID)
+	snap := FetchPlayByPlay(payload.Game.ID)
+	lastPlay, maxPeriods, gameState := snap.LastPlay, snap.MaxPeriods, snap.GameState
+	metrics.CountGamePoll(payload.Game.ID)
+	if lastPlay.TypeDescKey != "" {
+		metrics.PlaysSeen.WithLabelValues(lastPlay.TypeDescKey).Inc()
+	}
+
+	shouldReschedule := ShouldReschedule(payload, lastPlay)
+	var nextCheck time.Duration
+	var nextCheckReason string
+	var pollState *models.PollState
+	if shouldReschedule {
+		cfg := gp.Config
+		if cfg == nil {
+			cfg = config.LoadConfig()
+		}
+		decision := NewPollingPolicy(cfg).Next(snap, payload.Poll, time.Now())
+		log.Printf("Next check for game %s in %s (%s)", payload.Game.ID, decision.Interval, decision.Reason)
+		nextCheck = decision.Interval
+		nextCheckReason = decision.Reason
+		pollState = &decision.State
+	}
+	executionEnd := payload.ExecutionEnd
+	if shouldReschedule {
+		executionEnd = playoffExecutionEnd(payload, gameState, time.Now().Add(nextCheck+playoffWindowMargin))
+	}
+
+	if IsGameStarting(gameState, lastPlay) {
+		gp.NotificationService.SendGameStart(payload.Game, map[string]string{
+			"gameState":    FormatGameState(lastPlay),
+			"lastPlayType": lastPlay.TypeDescKey,
+		})
+	}
 
 	if _, ok := recomputeTypes[lastPlay.TypeDescKey]; ok {
 		log.Printf("Processing play type '%s' for game %s - fetching MoneyPuck data", lastPlay.TypeDescKey, payload.Game.ID)
@@ -77,6 +137,7 @@ func (gp *GameProcessor) ProcessGameUpdate(payload models.Payload) ProcessResult
 		// gameState → "Pregame" on iOS). Instead, send nothing and retry shortly so
 		// the next fetch can pick up the corrected file.
 		if errors.Is(err, ErrCSVParse) {
+			metrics.MoneyPuckCSVParseErrors.Inc()
 			log.Printf("WARNING: MoneyPuck CSV parse error for game %s; skipping notification and retrying in %s: %v",
 				payload.Game.ID, ParseErrorRetryInterval, err)
 			return ProcessResult{
@@ -84,6 +145,9 @@ func (gp *GameProcessor) ProcessGameUpdate(payload models.Payload) ProcessResult
 				LastPlay:            lastPlay,
 				MaxPeriods:          maxPeriods,
 				RetryAfterDataError: true,
+				GameState:           gameState,
+				ExecutionEnd:        executionEnd,
+				Poll:                pollState,
 			}
 		}
 
@@ -95,6 +159,15 @@ func (gp *GameProcessor) ProcessGameUpdate(payload models.Payload) ProcessResult
 		if gameData != nil {
 			gameData["gameState"] = FormatGameState(lastPlay)
 			gameData["lastPlayType"] = lastPlay.TypeDescKey
+			if lastPlay.EventTeamAbbrev != "" {
+				gameData["eventTeamAbbrev"] = lastPlay.EventTeamAbbrev
+			}
+			if lastPlay.ScorerName != "" {
+				gameData["scorerName"] = lastPlay.ScorerName
+			}
+			if nextCheck > 0 {
+				gameData["nextCheckSeconds"] = strconv.Itoa(int(nextCheck.Seconds()))
+			}
 		}
 
 		// Only run shootout adjustment when the fetch succeeded — a partial map
@@ -109,58 +182,127 @@ func (gp *GameProcessor) ProcessGameUpdate(payload models.Payload) ProcessResult
 			}
 		}
 
+		if gameData != nil && payload.Game.Series != nil {
+			addSeriesData(gameData, payload.Game, lastPlay, err == nil)
+		}
+
 		gp.NotificationService.SendGameEventNotifications(payload.Game, gameData)
 	}
 
-	shouldReschedule := ShouldReschedule(payload, lastPlay)
 	log.Printf("Last play type: %s, Should reschedule: %v\n", lastPlay.TypeDescKey, shouldReschedule)
 
 	return ProcessResult{
-		ShouldReschedule: shouldReschedule,
-		LastPlay:         lastPlay,
-		MaxPeriods:       maxPeriods,
+		ShouldReschedule:  shouldReschedule,
+		LastPlay:          lastPlay,
+		MaxPeriods:        maxPeriods,
+		GameState:         gameState,
+		NextCheckInterval: nextCheck,
+		NextCheckReason:   nextCheckReason,
+		ExecutionEnd:      executionEnd,
+		Poll:              pollState,
+	}
+}
+
+// playoffExecutionEnd returns the execution end the next check of payload
+// should carry: extended past until for a playoff game that is still live,
+// the payload's own otherwise. Task IDs are keyed on the start time, so an
+// extended loop keeps its IDs.
+func playoffExecutionEnd(payload models.Payload, gameState string, until time.Time) *string {
+	if !payload.Game.IsPlayoff() || payload.ExecutionEnd == nil {
+		return payload.ExecutionEnd
+	}
+	if gameState != "LIVE" && gameState != "CRIT" {
+		return payload.ExecutionEnd
+	}
+	end, err := time.Parse(time.RFC3339, *payload.ExecutionEnd)
+	if err != nil {
+		return payload.ExecutionEnd
+	}
+	extended := models.ExtendPlayoffWindow(end, until)
+	if extended.Equal(end) {
+		return payload.ExecutionEnd
 	}
+	formatted := extended.Format(time.RFC3339)
+	log.Printf("Playoff game %s is still %s; extending its execution window from %s to %s",
+		payload.Game.ID, gameState, *payload.ExecutionEnd, formatted)
+	return &formatted
 }
 
-// RescheduleInterval returns the next-check interval to use for the given last play.
-// Period-end events use the extended interval except in regular-season period 3,
-// where OT/game-end is imminent and the standard interval is used.
-func RescheduleInterval(lastPlay models.Play, maxPeriods *int, cfg *config.Config) time.Duration {
-	if lastPlay.TypeDescKey == "period-end" {
-		isRegularSeason := maxPeriods != nil
-		if isRegularSeason && lastPlay.PeriodDescriptor.Number == 3 {
-			return time.Duration(cfg.MessageIntervalSeconds) * time.Second
+// addSeriesData adds the playoff series summary ("BOS leads 2-1") to gameData.
+// At game-end the final score is counted towards the series first, when it is
+// known, and a series-deciding result adds its announcement as seriesClinched.
+func addSeriesData(gameData map[string]string, game models.Game, lastPlay models.Play, scoreKnown bool) {
+	series := *game.Series
+	if lastPlay.TypeDescKey == "game-end" && scoreKnown {
+		homeGoals, homeErr := strconv.Atoi(gameData["homeTeamGoals"])
+		awayGoals, awayErr := strconv.Atoi(gameData["awayTeamGoals"])
+		if homeErr == nil && awayErr == nil && homeGoals != awayGoals {
+			winner := game.HomeTeam.Abbrev
+			if awayGoals > homeGoals {
+				winner = game.AwayTeam.Abbrev
+			}
+			series = series.Record(winner)
+			if clinch := series.ClinchMessage(); clinch != "" {
+				gameData["seriesClinched"] = clinch
+			}
 		}
-		return time.Duration(cfg.PeriodEndIntervalSeconds) * time.Second
 	}
-	return time.Duration(cfg.MessageIntervalSeconds) * time.Second
+	gameData["seriesStatus"] = series.Summary()
+}
+
+// IsGameStarting reports whether a poll should trigger game-start notifications
+// (e.g. Live Activity push-to-start): the game is in pre-game or live.
+// Finished games, games still days out (FUT) and polls whose play-by-play
+// fetch failed (no state) never trigger. Notifiers dedupe per game, so this
+// may safely return true on every poll while the game is live.
+func IsGameStarting(gameState string, lastPlay models.Play) bool {
+	if lastPlay.TypeDescKey == "game-end" {
+		return false
+	}
+	switch gameState {
+	case "PRE", "LIVE", "CRIT":
+		return true
+	default:
+		return false
+	}
 }
 
 // FormatGameState returns a formatted game state string based on the play data.
-// Returns "Final" for game-end, "Shootout" for SO, "X:XX left, OT" for overtime,
-// or "X:XX left, Nth period" for regular periods.
+// Returns "Final" for game-end, "Intermission" after a period ends (rather than
+// a clock frozen at 00:00 for the whole break), "Shootout" for SO,
+// "X:XX left, OT" for overtime ("2OT", "3OT", ... for playoff overtimes), or
+// "X:XX left, Nth period" for regular periods.
 func FormatGameState(play models.Play) string {
 	if play.TypeDescKey == "game-end" {
 		return "Final"
 	}
+	if play.TypeDescKey == "period-end" && play.PeriodDescriptor.PeriodType != "SO" {
+		return "Intermission"
+	}
 
 	if play.TimeRemaining == "" {
 		return ""
 	}
 
-	switch play.PeriodDescriptor.PeriodType {
-	case "OT":
-		return fmt.Sprintf("%s left, OT", play.TimeRemaining)
-	case "SO":
+	number := play.PeriodDescriptor.Number
+	switch {
+	case play.PeriodDescriptor.PeriodType == "SO":
 		return "Shootout"
+	case play.PeriodDescriptor.PeriodType == "OT" || number > 3:
+		return fmt.Sprintf("%s left, %s", play.TimeRemaining, overtimeLabel(number))
 	default:
 		periodSuffix := map[int]string{1: "1st", 2: "2nd", 3: "3rd"}
-		suffix, ok := periodSuffix[play.PeriodDescriptor.Number]
-		if !ok {
-			suffix = fmt.Sprintf("%dth", play.PeriodDescriptor.Number)
-		}
-		return fmt.Sprintf("%s left, %s period", play.TimeRemaining, suffix)
+		return fmt.Sprintf("%s left, %s period", play.TimeRemaining, periodSuffix[number])
+	}
+}
+
+// overtimeLabel names the overtime that period number is: "OT" for the 4th
+// period, then "2OT", "3OT", ... for playoff overtimes.
+func overtimeLabel(number int) string {
+	if n := number - 3; n > 1 {
+		return fmt.Sprintf("%dOT", n)
 	}
+	return "OT"
 }
 
 // AdjustScoreForShootout increments the winning team's score by 1 when the game
@@ -4,7 +4,6 @@ import (
 	"testing"
 	"time"
 
-	"watchgameupdates/config"
 	"watchgameupdates/internal/models"
 )
 
@@ -219,6 +218,30 @@ func TestFormatGameState(t *testing.T) {
 			},
 			expected: "Final",
 		},
+		{
+			name: "PeriodEnd_ReturnsIntermission",
+			play: models.Play{
+				TypeDescKey:   "period-end",
+				TimeRemaining: "00:00",
+				PeriodDescriptor: models.PeriodDescriptor{
+					Number:     2,
+					PeriodType: "REG",
+				},
+			},
+			expected: "Intermission",
+		},
+		{
+			name: "ShootoutPeriodEnd_ReturnsShootout",
+			play: models.Play{
+				TypeDescKey:   "period-end",
+				TimeRemaining: "00:00",
+				PeriodDescriptor: models.PeriodDescriptor{
+					Number:     5,
+					PeriodType: "SO",
+				},
+			},
+			expected: "Shootout",
+		},
 		{
 			name: "FirstPeriod_ReturnsCorrectSuffix",
 			play: models.Play{
@@ -256,7 +279,7 @@ func TestFormatGameState(t *testing.T) {
 			expected: "01:45 left, 3rd period",
 		},
 		{
-			name: "FourthPeriod_ReturnsFallbackSuffix",
+			name: "FourthPeriod_ReturnsOT",
 			play: models.Play{
 				TypeDescKey:   "blocked-shot",
 				TimeRemaining: "10:00",
@@ -265,7 +288,31 @@ func TestFormatGameState(t *testing.T) {
 					PeriodType: "REG",
 				},
 			},
-			expected: "10:00 left, 4th period",
+			expected: "10:00 left, OT",
+		},
+		{
+			name: "PlayoffSecondOvertime_Returns2OT",
+			play: models.Play{
+				TypeDescKey:   "shot-on-goal",
+				TimeRemaining: "14:10",
+				PeriodDescriptor: models.PeriodDescriptor{
+					Number:     5,
+					PeriodType: "OT",
+				},
+			},
+			expected: "14:10 left, 2OT",
+		},
+		{
+			name: "PlayoffFifthOvertime_Returns5OT",
+			play: models.Play{
+				TypeDescKey:   "goal",
+				TimeRemaining: "08:02",
+				PeriodDescriptor: models.PeriodDescriptor{
+					Number:     8,
+					PeriodType: "OT",
+				},
+			},
+			expected: "08:02 left, 5OT",
 		},
 		{
 			name: "Overtime_ReturnsOTFormat",
@@ -329,81 +376,6 @@ func TestFormatGameState(t *testing.T) {
 	}
 }
 
-func TestPeriodEndInterval(t *testing.T) {
-	cfg := &config.Config{
-		MessageIntervalSeconds:   60,
-		PeriodEndIntervalSeconds: 1200,
-	}
-	maxPeriods := 5
-
-	testCases := []struct {
-		name       string
-		period     int
-		maxPeriods *int
-		expected   time.Duration
-	}{
-		{
-			name:       "RegularSeason_Period1_ReturnsExtendedInterval",
-			period:     1,
-			maxPeriods: &maxPeriods,
-			expected:   1200 * time.Second,
-		},
-		{
-			name:       "RegularSeason_Period2_ReturnsExtendedInterval",
-			period:     2,
-			maxPeriods: &maxPeriods,
-			expected:   1200 * time.Second,
-		},
-		{
-			name:       "RegularSeason_Period3_ReturnsStandardInterval",
-			period:     3,
-			maxPeriods: &maxPeriods,
-			expected:   60 * time.Second,
-		},
-		{
-			name:       "Playoffs_Period1_ReturnsExtendedInterval",
-			period:     1,
-			maxPeriods: nil,
-			expected:   1200 * time.Second,
-		},
-		{
-			name:       "Playoffs_Period2_ReturnsExtendedInterval",
-			period:     2,
-			maxPeriods: nil,
-			expected:   1200 * time.Second,
-		},
-		{
-			name:       "Playoffs_Period3_ReturnsExtendedInterval",
-			period:     3,
-			maxPeriods: nil,
-			expected:   1200 * time.Second,
-		},
-		{
-			name:       "Playoffs_OTPeriod4_ReturnsExtendedInterval",
-			period:     4,
-			maxPeriods: nil,
-			expected:   1200 * time.Second,
-		},
-	}
-
-	for _, tc := range testCases {
-		t.Run(tc.name, func(t *testing.T) {
-			play := models.Play{
-				TypeDescKey: "period-end",
-				PeriodDescriptor: models.PeriodDescriptor{
-					Number: tc.period,
-				},
-			}
-
-			result := RescheduleInterval(play, tc.maxPeriods, cfg)
-
-			if result != tc.expected {
-				t.Errorf("Expected %v, got %v", tc.expected, result)
-			}
-		})
-	}
-}
-
 func TestShouldSkipExecution(t *testing.T) {
 	t.Run("NilExecutionEnd_ShouldNotSkip", func(t *testing.T) {
 		payload := models.Payload{
@@ -511,3 +483,61 @@ func TestShouldReschedule(t *testing.T) {
 		}
 	})
 }
+
+func TestIsGameStarting(t *testing.T) {
+	testCases := []struct {
+		name      string
+		gameState string
+		playType  string
+		expected  bool
+	}{
+		{"NoStateReported_FetchFailed", "", "", false},
+		{"PreGame", "PRE", "", true},
+		{"Live", "LIVE", "faceoff", true},
+		{"Critical", "CRIT", "shot-on-goal", true},
+		{"Future", "FUT", "", false},
+		{"Official", "OFF", "", false},
+		{"Final", "FINAL", "", false},
+		{"LiveButGameEnded", "LIVE", "game-end", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := IsGameStarting(tc.gameState, models.Play{TypeDescKey: tc.playType})
+			if result != tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestResolvePlayNames(t *testing.T) {
+	data := &models.PlayByPlayResponse{
+		HomeTeam: models.TeamSummary{ID: 6, Abbrev: "BOS"},
+		AwayTeam: models.TeamSummary{ID: 3, Abbrev: "NYR"},
+		RosterSpots: []models.RosterSpot{
+			{TeamID: 6, PlayerID: 8473419, FirstName: models.LocalizedName{Default: "Brad"}, LastName: models.LocalizedName{Default: "Marchand"}},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		play       models.Play
+		wantTeam   string
+		wantScorer string
+	}{
+		{"goal", models.Play{TypeDescKey: "goal", Details: &models.PlayDetails{EventOwnerTeamID: 6, ScoringPlayerID: 8473419}}, "BOS", "Brad Marchand"},
+		{"goal by unknown player", models.Play{TypeDescKey: "goal", Details: &models.PlayDetails{EventOwnerTeamID: 3, ScoringPlayerID: 1}}, "NYR", ""},
+		{"penalty has team but no scorer", models.Play{TypeDescKey: "penalty", Details: &models.PlayDetails{EventOwnerTeamID: 3}}, "NYR", ""},
+		{"no details", models.Play{TypeDescKey: "period-end"}, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			play := tt.play
+			resolvePlayNames(data, &play)
+			if play.EventTeamAbbrev != tt.wantTeam || play.ScorerName != tt.wantScorer {
+				t.Errorf("got team=%q scorer=%q, want %q %q", play.EventTeamAbbrev, play.ScorerName, tt.wantTeam, tt.wantScorer)
+			}
+		})
+	}
+}
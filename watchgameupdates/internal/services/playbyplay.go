@@ -7,10 +7,25 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"time"
+
+	"watchgameupdates/internal/metrics"
 	"watchgameupdates/internal/models"
 )
 
-func FetchPlayByPlay(gameID string) (lastPlay models.Play, maxPeriods *int) {
+// GameSnapshot is what one play-by-play poll saw of a game. Fields the feed
+// did not provide (or a failed fetch) are left zero.
+type GameSnapshot struct {
+	LastPlay   models.Play
+	MaxPeriods *int   // nil in the playoffs
+	GameState  string // FUT, PRE, LIVE, CRIT, OFF, FINAL
+	Clock      *models.GameClock
+	HomeScore  int
+	AwayScore  int
+	StartTime  time.Time // scheduled puck drop
+}
+
+func FetchPlayByPlay(gameID string) (snap GameSnapshot) {
 	// Get play-by-play API base URL from environment variable
 	playByPlayAPIBaseURL := os.Getenv("PLAYBYPLAY_API_BASE_URL")
 	if playByPlayAPIBaseURL == "" {
@@ -21,6 +36,7 @@ func FetchPlayByPlay(gameID string) (lastPlay models.Play, maxPeriods *int) {
 	resp, err := http.Get(playByPlayUrl)
 	if err != nil {
 		log.Printf("Failed to fetch play-by-play data: %v", err)
+		metrics.PlayByPlayFetchFailures.WithLabelValues("request").Inc()
 		// http.Error(w, "Failed to fetch play-by-play data", http.StatusInternalServerError)
 		return
 	}
@@ -28,6 +44,7 @@ func FetchPlayByPlay(gameID string) (lastPlay models.Play, maxPeriods *int) {
 
 	if resp.StatusCode != http.StatusOK {
 		log.Printf("Failed to fetch play-by-play data, status code: %d", resp.StatusCode)
+		metrics.PlayByPlayFetchFailures.WithLabelValues("status").Inc()
 		// http.Error(w, "Failed to fetch play-by-play data", http.StatusInternalServerError)
 		return
 	}
@@ -36,21 +53,45 @@ func FetchPlayByPlay(gameID string) (lastPlay models.Play, maxPeriods *int) {
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		log.Printf("Failed to read response body: %v", err)
+		metrics.PlayByPlayFetchFailures.WithLabelValues("read").Inc()
 		// http.Error(w, "Failed to read response body", http.StatusInternalServerError)
 		return
 	}
 	var data models.PlayByPlayResponse
 	if err := json.Unmarshal(body, &data); err != nil {
+		metrics.PlayByPlayFetchFailures.WithLabelValues("decode").Inc()
 		panic(err)
 	}
 
+	snap.GameState = data.GameState
+	snap.MaxPeriods = data.MaxPeriods
+	snap.Clock = data.Clock
+	snap.HomeScore = data.HomeTeam.Score
+	snap.AwayScore = data.AwayTeam.Score
+	if start, err := time.Parse(time.RFC3339, data.StartTimeUTC); err == nil {
+		snap.StartTime = start
+	}
+
 	if len(data.Plays) == 0 {
 		log.Printf("No plays found for GameID: %s", gameID)
 		// http.Error(w, "No plays found for the game", http.StatusNotFound)
 		return
 	}
 
-	lastPlay = data.Plays[len(data.Plays)-1]
-	log.Printf("Last play type: %s, regular season: %t", lastPlay.TypeDescKey, data.MaxPeriods != nil)
-	return lastPlay, data.MaxPeriods
+	snap.LastPlay = data.Plays[len(data.Plays)-1]
+	resolvePlayNames(&data, &snap.LastPlay)
+	log.Printf("Last play type: %s, regular season: %t, game state: %s", snap.LastPlay.TypeDescKey, data.MaxPeriods != nil, snap.GameState)
+	return snap
+}
+
+// resolvePlayNames fills the play's event team and scorer from the response's
+// team and roster tables, which the play itself only references by ID.
+func resolvePlayNames(data *models.PlayByPlayResponse, play *models.Play) {
+	if play.Details == nil {
+		return
+	}
+	play.EventTeamAbbrev = data.TeamAbbrev(play.Details.EventOwnerTeamID)
+	if play.TypeDescKey == "goal" {
+		play.ScorerName = data.PlayerName(play.Details.ScoringPlayerID)
+	}
 }
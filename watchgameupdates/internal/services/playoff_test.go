@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"watchgameupdates/internal/models"
+	"watchgameupdates/internal/schedule"
+)
+
+func playoffGame(topWins, bottomWins int) models.Game {
+	return models.Game{
+		ID:       "2024030415",
+		GameType: models.GameTypePlayoff,
+		HomeTeam: models.Team{Abbrev: "FLA"},
+		AwayTeam: models.Team{Abbrev: "EDM"},
+		Series: &models.SeriesStatus{
+			Round: 4, SeriesTitle: "Stanley Cup Final", NeededToWin: 4,
+			TopSeedTeamAbbrev: "EDM", TopSeedWins: topWins,
+			BottomSeedTeamAbbrev: "FLA", BottomSeedWins: bottomWins,
+		},
+	}
+}
+
+func TestAddSeriesData(t *testing.T) {
+	tests := []struct {
+		name       string
+		game       models.Game
+		playType   string
+		homeGoals  string
+		awayGoals  string
+		scoreKnown bool
+		wantStatus string
+		wantClinch string
+	}{
+		{
+			name:       "in play shows the series before the game",
+			game:       playoffGame(2, 3),
+			playType:   "goal",
+			homeGoals:  "1",
+			awayGoals:  "0",
+			scoreKnown: true,
+			wantStatus: "FLA leads 3-2",
+		},
+		{
+			name:       "final counts the result",
+			game:       playoffGame(2, 2),
+			playType:   "game-end",
+			homeGoals:  "2",
+			awayGoals:  "3",
+			scoreKnown: true,
+			wantStatus: "EDM leads 3-2",
+		},
+		{
+			name:       "clinching final announces the Cup",
+			game:       playoffGame(2, 3),
+			playType:   "game-end",
+			homeGoals:  "5",
+			awayGoals:  "1",
+			scoreKnown: true,
+			wantStatus: "FLA wins series 4-2",
+			wantClinch: "FLA wins the Stanley Cup 4-2",
+		},
+		{
+			name:       "final without a known score leaves the series",
+			game:       playoffGame(2, 3),
+			playType:   "game-end",
+			homeGoals:  "5",
+			awayGoals:  "1",
+			wantStatus: "FLA leads 3-2",
+		},
+		{
+			name:       "tied series",
+			game:       playoffGame(1, 1),
+			playType:   "period-end",
+			wantStatus: "Series tied 1-1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := map[string]string{"homeTeamGoals": tt.homeGoals, "awayTeamGoals": tt.awayGoals}
+			addSeriesData(data, tt.game, models.Play{TypeDescKey: tt.playType}, tt.scoreKnown)
+
+			if data["seriesStatus"] != tt.wantStatus {
+				t.Errorf("seriesStatus = %q, want %q", data["seriesStatus"], tt.wantStatus)
+			}
+			if data["seriesClinched"] != tt.wantClinch {
+				t.Errorf("seriesClinched = %q, want %q", data["seriesClinched"], tt.wantClinch)
+			}
+		})
+	}
+}
+
+func TestPlayoffExecutionEnd(t *testing.T) {
+	end := "2025-06-18T05:00:00Z"
+	until := time.Date(2025, 6, 18, 5, 10, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		game      models.Game
+		gameState string
+		until     time.Time
+		want      string
+	}{
+		{name: "live playoff game near its end is extended", game: playoffGame(2, 3), gameState: "LIVE", until: until, want: "2025-06-18T06:00:00Z"},
+		{name: "critical playoff game is extended", game: playoffGame(2, 3), gameState: "CRIT", until: until.Add(2 * time.Hour), want: "2025-06-18T08:00:00Z"},
+		{name: "playoff game with time left keeps its end", game: playoffGame(2, 3), gameState: "LIVE", until: until.Add(-time.Hour), want: end},
+		{name: "finished playoff game keeps its end", game: playoffGame(2, 3), gameState: "OFF", until: until, want: end},
+		{name: "regular season game keeps its end", game: models.Game{ID: "2024020500"}, gameState: "LIVE", until: until, want: end},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endCopy := end
+			got := playoffExecutionEnd(models.Payload{Game: tt.game, ExecutionEnd: &endCopy}, tt.gameState, tt.until)
+			if got == nil || *got != tt.want {
+				t.Errorf("ExecutionEnd = %v, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRevalidateSchedule_RefreshesSeries(t *testing.T) {
+	now := time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)
+	scheduled := "2025-01-10T00:00:00Z"
+	series := &models.SeriesStatus{Round: 2, NeededToWin: 4, TopSeedTeamAbbrev: "CAR", TopSeedWins: 3, BottomSeedTeamAbbrev: "FLA", BottomSeedWins: 1}
+	lookup := &fakeLookup{game: schedule.ScheduleGame{
+		ID: 2024020500, StartTimeUTC: scheduled, GameState: "FUT",
+		GameType: models.GameTypePlayoff, SeriesStatus: series,
+	}}
+	gp := &GameProcessor{Games: lookup}
+
+	got := gp.RevalidateSchedule(context.Background(), firstPoll(scheduled), now)
+	if got.Check != GameOnSchedule {
+		t.Fatalf("Check = %v, want GameOnSchedule", got.Check)
+	}
+	if got.Payload.Game.Series != series || !got.Payload.Game.IsPlayoff() {
+		t.Errorf("Game = %+v, want the current series and playoff game type", got.Payload.Game)
+	}
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"time"
+
+	"watchgameupdates/config"
+	"watchgameupdates/internal/models"
+)
+
+const (
+	// closeGameWindow is the end of regulation that is polled fast when the
+	// game is within closeGameMargin goals.
+	closeGameWindow = 5 * time.Minute
+	closeGameMargin = 1
+	// stoppageChecks is how many checks in a row must find the clock stopped
+	// at the same time before polling backs off.
+	stoppageChecks = 2
+	// maxBackoff caps the wait before puck drop and during stoppages.
+	maxBackoff = 5 * time.Minute
+)
+
+// PollingPolicy decides when to check a game next from what the play-by-play
+// feed reports: the clock, the score and the game state. It is shared by the
+// Cloud Tasks and asynq handlers through ProcessGameUpdate.
+type PollingPolicy struct {
+	// Interval is the normal cadence during play (MESSAGE_INTERVAL_SECONDS).
+	Interval time.Duration
+	// FastInterval is used in the last minutes of a close game, in overtime
+	// and in shootouts (FAST_INTERVAL_SECONDS).
+	FastInterval time.Duration
+	// PeriodEndInterval is the wait after a period ends when the feed has no
+	// intermission clock (PERIOD_END_INTERVAL_SECONDS).
+	PeriodEndInterval time.Duration
+}
+
+// NewPollingPolicy builds the policy from cfg. The fast interval is never
+// slower than the normal one.
+func NewPollingPolicy(cfg *config.Config) PollingPolicy {
+	interval := time.Duration(cfg.MessageIntervalSeconds) * time.Second
+	return PollingPolicy{
+		Interval:          interval,
+		FastInterval:      min(time.Duration(cfg.FastIntervalSeconds)*time.Second, interval),
+		PeriodEndInterval: time.Duration(cfg.PeriodEndIntervalSeconds) * time.Second,
+	}
+}
+
+// PollDecision is the outcome of PollingPolicy.Next.
+type PollDecision struct {
+	Interval time.Duration
+	// Reason names the rule that chose Interval, for logs and metrics:
+	// "pregame", "shootout", "intermission", "period-end", "overtime",
+	// "close game", "stoppage" or "play".
+	Reason string
+	// State is carried in the payload of the next check.
+	State models.PollState
+}
+
+// Next returns when to check the game in snap next. prev is the state the
+// previous check returned, nil for the first check of a loop.
+//
+// In order: before puck drop it waits until the scheduled start (at most
+// maxBackoff); shootouts, overtime and the last closeGameWindow of a game
+// within closeGameMargin goals poll at FastInterval; an intermission waits
+// until the intermission clock runs out; a clock stopped at the same time for
+// stoppageChecks checks backs off by Interval per check, up to maxBackoff.
+// Everything else polls at Interval.
+func (p PollingPolicy) Next(snap GameSnapshot, prev *models.PollState, now time.Time) PollDecision {
+	var state models.PollState
+	clock := snap.Clock
+	if clock != nil {
+		state.ClockSeconds = clock.SecondsRemaining
+		if !clock.Running && !clock.InIntermission && prev != nil && prev.ClockSeconds == clock.SecondsRemaining {
+			state.StoppedChecks = prev.StoppedChecks + 1
+		}
+	}
+	decide := func(interval time.Duration, reason string) PollDecision {
+		return PollDecision{Interval: interval, Reason: reason, State: state}
+	}
+
+	period := snap.LastPlay.PeriodDescriptor
+	switch {
+	case snap.GameState == "FUT" || snap.GameState == "PRE":
+		if untilStart := snap.StartTime.Sub(now); !snap.StartTime.IsZero() && untilStart > p.Interval {
+			return decide(min(untilStart, maxBackoff), "pregame")
+		}
+		return decide(p.Interval, "pregame")
+	case period.PeriodType == "SO":
+		return decide(p.FastInterval, "shootout")
+	case clock != nil && clock.InIntermission && clock.SecondsRemaining > 0:
+		return decide(time.Duration(clock.SecondsRemaining)*time.Second, "intermission")
+	case snap.LastPlay.TypeDescKey == "period-end":
+		return decide(p.periodEndInterval(snap.LastPlay, snap.MaxPeriods), "period-end")
+	case period.PeriodType == "OT" || period.Number > 3:
+		return decide(p.FastInterval, "overtime")
+	case p.isCloseLate(snap):
+		return decide(p.FastInterval, "close game")
+	case state.StoppedChecks >= stoppageChecks:
+		return decide(min(time.Duration(state.StoppedChecks)*p.Interval, maxBackoff), "stoppage")
+	default:
+		return decide(p.Interval, "play")
+	}
+}
+
+// isCloseLate reports whether snap is in the last closeGameWindow of the third
+// period with the score within closeGameMargin.
+func (p PollingPolicy) isCloseLate(snap GameSnapshot) bool {
+	if snap.Clock == nil || snap.LastPlay.PeriodDescriptor.Number != 3 {
+		return false
+	}
+	diff := snap.HomeScore - snap.AwayScore
+	return time.Duration(snap.Clock.SecondsRemaining)*time.Second <= closeGameWindow &&
+		diff >= -closeGameMargin && diff <= closeGameMargin
+}
+
+// periodEndInterval is the wait after a period ends when the feed has no
+// intermission clock. Period-end events use the extended interval except in
+// regular-season period 3, where OT/game-end is imminent and the standard
+// interval is used.
+func (p PollingPolicy) periodEndInterval(lastPlay models.Play, maxPeriods *int) time.Duration {
+	isRegularSeason := maxPeriods != nil
+	if isRegularSeason && lastPlay.PeriodDescriptor.Number == 3 {
+		return p.Interval
+	}
+	return p.PeriodEndInterval
+}
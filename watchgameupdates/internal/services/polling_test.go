@@ -0,0 +1,228 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"watchgameupdates/config"
+	"watchgameupdates/internal/models"
+)
+
+var testPolicy = PollingPolicy{
+	Interval:          60 * time.Second,
+	FastInterval:      20 * time.Second,
+	PeriodEndInterval: 1200 * time.Second,
+}
+
+// pollStep is one recorded check of a game: what the feed reported and what
+// the policy should decide.
+type pollStep struct {
+	snap         GameSnapshot
+	wantInterval time.Duration
+	wantReason   string
+}
+
+// play builds a live snapshot whose last play is playType at clock seconds
+// remaining in period.
+func play(playType string, period int, seconds int, running bool, home, away int) GameSnapshot {
+	periodType := "REG"
+	if period > 3 {
+		periodType = "OT"
+	}
+	regular := 5
+	return GameSnapshot{
+		LastPlay: models.Play{
+			TypeDescKey:      playType,
+			PeriodDescriptor: models.PeriodDescriptor{Number: period, PeriodType: periodType},
+		},
+		MaxPeriods: &regular,
+		GameState:  "LIVE",
+		Clock:      &models.GameClock{SecondsRemaining: seconds, Running: running},
+		HomeScore:  home,
+		AwayScore:  away,
+	}
+}
+
+// replay feeds steps to the policy in order, threading the poll state from
+// each decision into the next check as the handlers do.
+func replay(t *testing.T, now time.Time, steps []pollStep) {
+	t.Helper()
+	var state *models.PollState
+	for i, step := range steps {
+		got := testPolicy.Next(step.snap, state, now)
+		if got.Interval != step.wantInterval || got.Reason != step.wantReason {
+			t.Errorf("check %d: got %s (%s), want %s (%s)", i, got.Interval, got.Reason, step.wantInterval, step.wantReason)
+		}
+		state = &got.State
+		now = now.Add(got.Interval)
+	}
+}
+
+func TestPollingPolicy_CloseLateGame(t *testing.T) {
+	replay(t, time.Now(), []pollStep{
+		{play("shot-on-goal", 3, 720, true, 1, 1), 60 * time.Second, "play"},
+		{play("faceoff", 3, 300, false, 1, 1), 20 * time.Second, "close game"},
+		{play("goal", 3, 270, false, 2, 1), 20 * time.Second, "close game"},
+		{play("goal", 3, 240, false, 3, 1), 60 * time.Second, "play"},
+		{play("goal", 3, 65, false, 3, 2), 20 * time.Second, "close game"},
+	})
+}
+
+func TestPollingPolicy_Intermission(t *testing.T) {
+	intermission := play("period-end", 1, 1020, true, 0, 0)
+	intermission.Clock.InIntermission = true
+
+	noClock := play("period-end", 2, 0, false, 0, 0)
+	noClock.Clock = nil
+
+	regulationEnd := play("period-end", 3, 0, false, 2, 2)
+	regulationEnd.Clock = nil
+
+	playoffEnd := play("period-end", 3, 0, false, 2, 2)
+	playoffEnd.Clock, playoffEnd.MaxPeriods = nil, nil
+
+	replay(t, time.Now(), []pollStep{
+		{intermission, 1020 * time.Second, "intermission"},
+		{noClock, 1200 * time.Second, "period-end"},
+		{regulationEnd, 60 * time.Second, "period-end"},
+		{playoffEnd, 1200 * time.Second, "period-end"},
+	})
+}
+
+func TestPollingPolicy_LongStoppage(t *testing.T) {
+	stopped := play("stoppage", 2, 600, false, 1, 0)
+	replay(t, time.Now(), []pollStep{
+		{stopped, 60 * time.Second, "play"},
+		{stopped, 60 * time.Second, "play"},
+		{stopped, 2 * time.Minute, "stoppage"},
+		{stopped, 3 * time.Minute, "stoppage"},
+		{stopped, 4 * time.Minute, "stoppage"},
+		{stopped, 5 * time.Minute, "stoppage"},
+		{stopped, 5 * time.Minute, "stoppage"},
+		{play("faceoff", 2, 600, true, 1, 0), 60 * time.Second, "play"},
+		{play("stoppage", 2, 580, false, 1, 0), 60 * time.Second, "play"},
+	})
+}
+
+func TestPollingPolicy_OvertimeAndShootout(t *testing.T) {
+	shootout := play("shot-on-goal", 5, 0, false, 2, 2)
+	shootout.LastPlay.PeriodDescriptor.PeriodType = "SO"
+
+	playoffOT := play("stoppage", 6, 900, false, 3, 3)
+	playoffOT.MaxPeriods = nil
+
+	replay(t, time.Now(), []pollStep{
+		{play("shot-on-goal", 4, 200, true, 2, 2), 20 * time.Second, "overtime"},
+		{shootout, 20 * time.Second, "shootout"},
+		{playoffOT, 20 * time.Second, "overtime"},
+	})
+}
+
+func TestPollingPolicy_BeforePuckDrop(t *testing.T) {
+	now := time.Date(2025, 10, 8, 23, 0, 0, 0, time.UTC)
+	pregame := func(state string, start time.Time) GameSnapshot {
+		return GameSnapshot{GameState: state, StartTime: start}
+	}
+
+	tests := []struct {
+		name string
+		snap GameSnapshot
+		want time.Duration
+	}{
+		{"hours out", pregame("FUT", now.Add(2*time.Hour)), 5 * time.Minute},
+		{"minutes out", pregame("PRE", now.Add(3*time.Minute)), 3 * time.Minute},
+		{"starting now", pregame("PRE", now.Add(30*time.Second)), 60 * time.Second},
+		{"late start", pregame("PRE", now.Add(-10*time.Minute)), 60 * time.Second},
+		{"unknown start", pregame("PRE", time.Time{}), 60 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := testPolicy.Next(tt.snap, nil, now)
+			if got.Interval != tt.want || got.Reason != "pregame" {
+				t.Errorf("got %s (%s), want %s (pregame)", got.Interval, got.Reason, tt.want)
+			}
+		})
+	}
+}
+
+func TestPeriodEndInterval(t *testing.T) {
+	cfg := &config.Config{
+		MessageIntervalSeconds:   60,
+		PeriodEndIntervalSeconds: 1200,
+	}
+	maxPeriods := 5
+
+	testCases := []struct {
+		name       string
+		period     int
+		maxPeriods *int
+		expected   time.Duration
+	}{
+		{
+			name:       "RegularSeason_Period1_ReturnsExtendedInterval",
+			period:     1,
+			maxPeriods: &maxPeriods,
+			expected:   1200 * time.Second,
+		},
+		{
+			name:       "RegularSeason_Period2_ReturnsExtendedInterval",
+			period:     2,
+			maxPeriods: &maxPeriods,
+			expected:   1200 * time.Second,
+		},
+		{
+			name:       "RegularSeason_Period3_ReturnsStandardInterval",
+			period:     3,
+			maxPeriods: &maxPeriods,
+			expected:   60 * time.Second,
+		},
+		{
+			name:       "Playoffs_Period1_ReturnsExtendedInterval",
+			period:     1,
+			maxPeriods: nil,
+			expected:   1200 * time.Second,
+		},
+		{
+			name:       "Playoffs_Period2_ReturnsExtendedInterval",
+			period:     2,
+			maxPeriods: nil,
+			expected:   1200 * time.Second,
+		},
+		{
+			name:       "Playoffs_Period3_ReturnsExtendedInterval",
+			period:     3,
+			maxPeriods: nil,
+			expected:   1200 * time.Second,
+		},
+		{
+			name:       "Playoffs_OTPeriod4_ReturnsExtendedInterval",
+			period:     4,
+			maxPeriods: nil,
+			expected:   1200 * time.Second,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			play := models.Play{
+				TypeDescKey: "period-end",
+				PeriodDescriptor: models.PeriodDescriptor{
+					Number: tc.period,
+				},
+			}
+
+			result := NewPollingPolicy(cfg).periodEndInterval(play, tc.maxPeriods)
+
+			if result != tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestNewPollingPolicy_FastNeverSlower(t *testing.T) {
+	policy := NewPollingPolicy(&config.Config{MessageIntervalSeconds: 15, FastIntervalSeconds: 20, PeriodEndIntervalSeconds: 1200})
+	if policy.FastInterval != 15*time.Second {
+		t.Errorf("FastInterval = %s, want it capped at the 15s interval", policy.FastInterval)
+	}
+}
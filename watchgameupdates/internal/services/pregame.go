@@ -0,0 +1,64 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"watchgameupdates/internal/models"
+	"watchgameupdates/internal/notification"
+)
+
+// FetchGamePreview reads the pregame data (records, probable goalies, venue,
+// broadcasts) from the gamecenter landing endpoint.
+func FetchGamePreview(ctx context.Context, gameID string) (models.GamePreview, error) {
+	baseURL := os.Getenv("PLAYBYPLAY_API_BASE_URL")
+	if baseURL == "" {
+		baseURL = "https://api-web.nhle.com"
+	}
+	url := fmt.Sprintf("%s/v1/gamecenter/%s/landing", baseURL, gameID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return models.GamePreview{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return models.GamePreview{}, fmt.Errorf("failed to fetch game preview: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return models.GamePreview{}, fmt.Errorf("NHL API returned status %d", resp.StatusCode)
+	}
+	var preview models.GamePreview
+	if err := json.NewDecoder(resp.Body).Decode(&preview); err != nil {
+		return models.GamePreview{}, fmt.Errorf("failed to decode game preview: %w", err)
+	}
+	return preview, nil
+}
+
+// ProcessPregame sends the game-starting-soon notifications for payload's
+// game. A failed preview fetch still sends the teams and countdown; a game
+// that is no longer upcoming (postponed, already live) gets nothing.
+func ProcessPregame(ctx context.Context, svc *notification.Service, payload models.Payload) {
+	preview, err := FetchGamePreview(ctx, payload.Game.ID)
+	if err != nil {
+		log.Printf("WARNING: no preview for game %s, sending a plain pregame message: %v", payload.Game.ID, err)
+		preview = models.GamePreview{}
+	}
+
+	switch preview.GameState {
+	case "", "FUT", "PRE":
+	default:
+		log.Printf("Game %s is %s, skipping pregame notifications", payload.Game.ID, preview.GameState)
+		return
+	}
+
+	log.Printf("Sending pregame notifications for game %s (%s @ %s)",
+		payload.Game.ID, payload.Game.AwayTeam.Abbrev, payload.Game.HomeTeam.Abbrev)
+	svc.SendPregame(ctx, payload.Game, preview)
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"watchgameupdates/internal/models"
+	"watchgameupdates/internal/notification"
+)
+
+// previewNotifier records the preview handed to NotifyPregame.
+type previewNotifier struct {
+	recordingNotifier
+	calls   atomic.Int32
+	preview models.GamePreview
+}
+
+func (n *previewNotifier) NotifyPregame(_ context.Context, _ models.Game, preview models.GamePreview) error {
+	n.calls.Add(1)
+	n.preview = preview
+	return nil
+}
+
+func servePreview(t *testing.T, status int, body string) {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/gamecenter/2024020500/landing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	t.Setenv("PLAYBYPLAY_API_BASE_URL", srv.URL)
+}
+
+func pregamePayload() models.Payload {
+	return models.Payload{Game: models.Game{
+		ID:        "2024020500",
+		StartTime: "2025-01-10T00:00:00Z",
+		HomeTeam:  models.Team{Abbrev: "CAR"},
+		AwayTeam:  models.Team{Abbrev: "FLA"},
+	}}
+}
+
+func TestProcessPregame(t *testing.T) {
+	servePreview(t, http.StatusOK, `{
+		"gameState": "FUT",
+		"venue": {"default": "Lenovo Center"},
+		"tvBroadcasts": [{"network": "ESPN+", "countryCode": "US"}],
+		"homeTeam": {"abbrev": "CAR", "record": "12-3-1"},
+		"awayTeam": {"abbrev": "FLA", "record": "10-5-2"},
+		"matchup": {"goalieComparison": {
+			"homeTeam": {"leaders": [{"name": {"default": "F. Andersen"}, "record": "8-2-0"}]},
+			"awayTeam": {"leaders": [{"name": {"default": "S. Bobrovsky"}}]}
+		}}
+	}`)
+
+	n := &previewNotifier{}
+	svc := notification.NewServiceWithNotificationFlag(true)
+	svc.RegisterNotifier(n)
+
+	ProcessPregame(context.Background(), svc, pregamePayload())
+
+	if n.calls.Load() != 1 {
+		t.Fatalf("NotifyPregame called %d times, want 1", n.calls.Load())
+	}
+	home, away := n.preview.Goalies()
+	if home != "F. Andersen" || away != "S. Bobrovsky" {
+		t.Errorf("goalies = %q, %q", home, away)
+	}
+	if n.preview.Venue.Default != "Lenovo Center" || n.preview.HomeTeam.Record != "12-3-1" {
+		t.Errorf("preview = %+v", n.preview)
+	}
+}
+
+func TestProcessPregame_PreviewUnavailable(t *testing.T) {
+	servePreview(t, http.StatusInternalServerError, "")
+
+	n := &previewNotifier{}
+	svc := notification.NewServiceWithNotificationFlag(true)
+	svc.RegisterNotifier(n)
+
+	ProcessPregame(context.Background(), svc, pregamePayload())
+	if n.calls.Load() != 1 {
+		t.Errorf("NotifyPregame called %d times, want 1 (plain message without a preview)", n.calls.Load())
+	}
+}
+
+func TestProcessPregame_NotUpcoming(t *testing.T) {
+	for _, state := range []string{"PPD", "LIVE"} {
+		t.Run(state, func(t *testing.T) {
+			servePreview(t, http.StatusOK, `{"gameState": "`+state+`"}`)
+
+			n := &previewNotifier{}
+			svc := notification.NewServiceWithNotificationFlag(true)
+			svc.RegisterNotifier(n)
+
+			ProcessPregame(context.Background(), svc, pregamePayload())
+			if n.calls.Load() != 0 {
+				t.Errorf("NotifyPregame called for a %s game", state)
+			}
+		})
+	}
+}
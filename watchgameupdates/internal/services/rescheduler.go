@@ -1,8 +1,12 @@
 package services
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"time"
+
+	"watchgameupdates/internal/metrics"
 	"watchgameupdates/internal/models"
 )
 
@@ -25,3 +29,86 @@ func ShouldReschedule(payload models.Payload, lastPlay models.Play) bool {
 
 	return false
 }
+
+// GameEnqueuer is the part of queue.GameTaskQueue the Rescheduler needs. Both
+// queue.CloudTasksQueue and queue.RedisQueue satisfy it, so HTTP mode and
+// worker mode reschedule through the same code.
+type GameEnqueuer interface {
+	Enqueue(ctx context.Context, payload models.Payload, deliverAt time.Time) error
+}
+
+// Rescheduler schedules the next check of a game loop after ProcessGameUpdate.
+// It is built once per process and shared by every check.
+type Rescheduler struct {
+	queue GameEnqueuer
+	// horizon is how far ahead the queue can hold a task; 0 means no limit.
+	horizon time.Duration
+	// now is time.Now, replaced in tests.
+	now func() time.Time
+}
+
+// NewRescheduler returns a Rescheduler enqueuing to q. A queue that reports a
+// MaxScheduleAhead (as Cloud Tasks does) limits how far a moved game is
+// re-enqueued.
+func NewRescheduler(q GameEnqueuer) *Rescheduler {
+	r := &Rescheduler{queue: q, now: time.Now}
+	if h, ok := q.(interface{ MaxScheduleAhead() time.Duration }); ok {
+		r.horizon = h.MaxScheduleAhead()
+	}
+	return r
+}
+
+// Reschedule enqueues the check that follows one processed from payload. It
+// ends the game loop, dropping its per-game metrics, when
+// result.ShouldReschedule is false. The next payload carries
+// the result's last play, execution window and poll state, and the next
+// cycle; it runs after result.NextCheckInterval, or ParseErrorRetryInterval
+// when the MoneyPuck data could not be parsed.
+func (r *Rescheduler) Reschedule(ctx context.Context, payload models.Payload, result ProcessResult) error {
+	if !result.ShouldReschedule {
+		metrics.ForgetGame(payload.Game.ID)
+		return nil
+	}
+	next, interval := NextCheck(payload, result)
+	if err := r.queue.Enqueue(ctx, next, r.now().Add(interval)); err != nil {
+		return fmt.Errorf("failed to schedule next check for game %s: %w", payload.Game.ID, err)
+	}
+	log.Printf("Scheduled next check for game %s (cycle %d) in %s", next.Game.ID, next.Cycle, interval)
+	reason := result.NextCheckReason
+	if result.RetryAfterDataError {
+		reason = "parse-error"
+	}
+	metrics.RescheduleIntervals.WithLabelValues(reason).Observe(interval.Seconds())
+	return nil
+}
+
+// Requeue enqueues a game loop for a game that moved to a later start time
+// (see RevalidateSchedule). A start beyond the queue's horizon is left to the
+// scheduler, which enqueues the game once it is within reach.
+func (r *Rescheduler) Requeue(ctx context.Context, check ScheduleCheckResult) error {
+	if r.horizon > 0 && check.StartAt.Sub(r.now()) > r.horizon {
+		log.Printf("Game %s moved to %s, beyond the queue's horizon; leaving it to the scheduler",
+			check.Payload.Game.ID, check.Payload.Game.StartTime)
+		return nil
+	}
+	if err := r.queue.Enqueue(ctx, check.Payload, check.StartAt); err != nil {
+		return fmt.Errorf("failed to re-enqueue moved game %s: %w", check.Payload.Game.ID, err)
+	}
+	return nil
+}
+
+// NextCheck returns the payload and delay of the check that follows one
+// processed from payload with result.
+func NextCheck(payload models.Payload, result ProcessResult) (models.Payload, time.Duration) {
+	interval := result.NextCheckInterval
+	if result.RetryAfterDataError {
+		// MoneyPuck data was unparseable and nothing was sent; ignore the
+		// play-type interval and retry soon to pick up the corrected file.
+		interval = ParseErrorRetryInterval
+	}
+	payload.LastPlay = result.LastPlay.TypeDescKey
+	payload.ExecutionEnd = result.ExecutionEnd
+	payload.Poll = result.Poll
+	payload.Cycle++
+	return payload, interval
+}
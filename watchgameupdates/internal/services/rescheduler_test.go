@@ -0,0 +1,233 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"watchgameupdates/internal/metrics"
+	"watchgameupdates/internal/models"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// recordingQueue is a GameEnqueuer that records what it is given.
+type recordingQueue struct {
+	payloads  []models.Payload
+	deliverAt []time.Time
+	err       error
+	horizon   time.Duration
+}
+
+func (q *recordingQueue) Enqueue(_ context.Context, payload models.Payload, deliverAt time.Time) error {
+	if q.err != nil {
+		return q.err
+	}
+	q.payloads = append(q.payloads, payload)
+	q.deliverAt = append(q.deliverAt, deliverAt)
+	return nil
+}
+
+// horizonQueue is a recordingQueue that reports a MaxScheduleAhead, like
+// queue.CloudTasksQueue.
+type horizonQueue struct{ *recordingQueue }
+
+func (q horizonQueue) MaxScheduleAhead() time.Duration { return q.horizon }
+
+func newTestRescheduler(q GameEnqueuer, now time.Time) *Rescheduler {
+	r := NewRescheduler(q)
+	r.now = func() time.Time { return now }
+	return r
+}
+
+func TestRescheduler_Reschedule(t *testing.T) {
+	now := time.Date(2025, 10, 8, 23, 30, 0, 0, time.UTC)
+	end := "2025-10-09T04:00:00Z"
+	extended := "2025-10-09T05:00:00Z"
+	payload := models.Payload{Game: models.Game{ID: "2025020001"}, ExecutionEnd: &end, Cycle: 4, LastPlay: "faceoff"}
+	poll := &models.PollState{ClockSeconds: 300}
+
+	tests := []struct {
+		name      string
+		result    ProcessResult
+		wantDelay time.Duration
+		wantEnd   string
+	}{
+		{
+			name: "next check interval",
+			result: ProcessResult{
+				ShouldReschedule:  true,
+				LastPlay:          models.Play{TypeDescKey: "goal"},
+				NextCheckInterval: 20 * time.Second,
+				ExecutionEnd:      &end,
+				Poll:              poll,
+			},
+			wantDelay: 20 * time.Second,
+			wantEnd:   end,
+		},
+		{
+			name: "data error retries soon",
+			result: ProcessResult{
+				ShouldReschedule:    true,
+				RetryAfterDataError: true,
+				LastPlay:            models.Play{TypeDescKey: "period-end"},
+				NextCheckInterval:   1200 * time.Second,
+				ExecutionEnd:        &end,
+				Poll:                poll,
+			},
+			wantDelay: ParseErrorRetryInterval,
+			wantEnd:   end,
+		},
+		{
+			name: "extended playoff window",
+			result: ProcessResult{
+				ShouldReschedule:  true,
+				LastPlay:          models.Play{TypeDescKey: "stoppage"},
+				NextCheckInterval: time.Minute,
+				ExecutionEnd:      &extended,
+				Poll:              poll,
+			},
+			wantDelay: time.Minute,
+			wantEnd:   extended,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := &recordingQueue{}
+			if err := newTestRescheduler(q, now).Reschedule(context.Background(), payload, tt.result); err != nil {
+				t.Fatalf("Reschedule: %v", err)
+			}
+			if len(q.payloads) != 1 {
+				t.Fatalf("enqueued %d checks, want 1", len(q.payloads))
+			}
+			next := q.payloads[0]
+			if got := q.deliverAt[0].Sub(now); got != tt.wantDelay {
+				t.Errorf("delay = %s, want %s", got, tt.wantDelay)
+			}
+			if next.Cycle != 5 {
+				t.Errorf("Cycle = %d, want 5", next.Cycle)
+			}
+			if next.LastPlay != tt.result.LastPlay.TypeDescKey {
+				t.Errorf("LastPlay = %q, want %q", next.LastPlay, tt.result.LastPlay.TypeDescKey)
+			}
+			if next.ExecutionEnd == nil || *next.ExecutionEnd != tt.wantEnd {
+				t.Errorf("ExecutionEnd = %v, want %s", next.ExecutionEnd, tt.wantEnd)
+			}
+			if next.Poll != poll {
+				t.Errorf("Poll = %+v, want %+v", next.Poll, poll)
+			}
+		})
+	}
+}
+
+func TestRescheduler_Reschedule_GameOver(t *testing.T) {
+	q := &recordingQueue{}
+	err := NewRescheduler(q).Reschedule(context.Background(), models.Payload{}, ProcessResult{ShouldReschedule: false})
+	if err != nil {
+		t.Fatalf("Reschedule: %v", err)
+	}
+	if len(q.payloads) != 0 {
+		t.Errorf("enqueued %d checks for a finished game, want 0", len(q.payloads))
+	}
+}
+
+func TestRescheduler_Reschedule_ReturnsQueueError(t *testing.T) {
+	queueErr := errors.New("queue unavailable")
+	q := &recordingQueue{err: queueErr}
+	err := NewRescheduler(q).Reschedule(context.Background(), models.Payload{Game: models.Game{ID: "2025020001"}},
+		ProcessResult{ShouldReschedule: true, NextCheckInterval: time.Minute})
+	if !errors.Is(err, queueErr) {
+		t.Errorf("err = %v, want it to wrap %v", err, queueErr)
+	}
+}
+
+func TestRescheduler_Requeue(t *testing.T) {
+	now := time.Date(2025, 10, 8, 23, 30, 0, 0, time.UTC)
+	moved := func(startAt time.Time) ScheduleCheckResult {
+		return ScheduleCheckResult{
+			Check:   GameMoved,
+			Payload: models.Payload{Game: models.Game{ID: "2025020001", StartTime: startAt.Format(time.RFC3339)}},
+			StartAt: startAt,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		horizon time.Duration
+		startAt time.Time
+		want    int
+	}{
+		{"no horizon", 0, now.Add(60 * 24 * time.Hour), 1},
+		{"within horizon", 29 * 24 * time.Hour, now.Add(2 * time.Hour), 1},
+		{"beyond horizon", 29 * 24 * time.Hour, now.Add(40 * 24 * time.Hour), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := &recordingQueue{horizon: tt.horizon}
+			var r *Rescheduler
+			if tt.horizon > 0 {
+				r = newTestRescheduler(horizonQueue{q}, now)
+			} else {
+				r = newTestRescheduler(q, now)
+			}
+			if err := r.Requeue(context.Background(), moved(tt.startAt)); err != nil {
+				t.Fatalf("Requeue: %v", err)
+			}
+			if len(q.payloads) != tt.want {
+				t.Fatalf("enqueued %d checks, want %d", len(q.payloads), tt.want)
+			}
+			if tt.want == 1 && !q.deliverAt[0].Equal(tt.startAt) {
+				t.Errorf("deliverAt = %s, want the new start %s", q.deliverAt[0], tt.startAt)
+			}
+		})
+	}
+}
+
+// histogramSample returns the sample count and sum of h.
+func histogramSample(t *testing.T, h prometheus.Observer) (uint64, float64) {
+	t.Helper()
+	var m dto.Metric
+	if err := h.(prometheus.Metric).Write(&m); err != nil {
+		t.Fatal(err)
+	}
+	return m.GetHistogram().GetSampleCount(), m.GetHistogram().GetSampleSum()
+}
+
+func TestRescheduler_Reschedule_RecordsIntervalByReason(t *testing.T) {
+	payload := models.Payload{Game: models.Game{ID: "2025020001"}}
+	tests := []struct {
+		name       string
+		result     ProcessResult
+		wantReason string
+		wantDelay  time.Duration
+	}{
+		{
+			name:       "polling rule",
+			result:     ProcessResult{ShouldReschedule: true, NextCheckInterval: 15 * time.Second, NextCheckReason: "close game"},
+			wantReason: "close game",
+			wantDelay:  15 * time.Second,
+		},
+		{
+			name:       "data error",
+			result:     ProcessResult{ShouldReschedule: true, RetryAfterDataError: true, NextCheckInterval: 20 * time.Minute, NextCheckReason: "intermission"},
+			wantReason: "parse-error",
+			wantDelay:  ParseErrorRetryInterval,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := metrics.RescheduleIntervals.WithLabelValues(tt.wantReason)
+			count, sum := histogramSample(t, h)
+			if err := NewRescheduler(&recordingQueue{}).Reschedule(context.Background(), payload, tt.result); err != nil {
+				t.Fatalf("Reschedule: %v", err)
+			}
+			gotCount, gotSum := histogramSample(t, h)
+			if gotCount != count+1 || gotSum-sum != tt.wantDelay.Seconds() {
+				t.Errorf("%q interval observations: count %d → %d, sum +%v; want one of %v",
+					tt.wantReason, count, gotCount, gotSum-sum, tt.wantDelay.Seconds())
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"watchgameupdates/config"
+	"watchgameupdates/internal/models"
+	"watchgameupdates/internal/schedule"
+)
+
+// GameLookup resolves a game's current schedule entry.
+// schedule.HTTPScheduleFetcher implements it with the gamecenter landing endpoint.
+type GameLookup interface {
+	FetchGame(ctx context.Context, gameID string) (schedule.ScheduleGame, error)
+}
+
+// ScheduleCheck is the outcome of re-validating a game loop's first poll
+// against the NHL schedule.
+type ScheduleCheck int
+
+const (
+	// GameOnSchedule: poll as usual with the returned payload, whose execution
+	// window is recomputed if the start time moved a little or earlier.
+	GameOnSchedule ScheduleCheck = iota
+	// GameMoved: the game now starts later; end this loop and enqueue the
+	// returned payload for StartAt.
+	GameMoved
+	// GamePostponed: the game is postponed (PPD); end the loop.
+	GamePostponed
+)
+
+// gameStatePostponed is the schedule gameState of a postponed game.
+const gameStatePostponed = "PPD"
+
+// MovedStartThreshold is how far past now a moved start must be for the loop
+// to be re-enqueued; a game pushed back by less is simply polled until it starts.
+const MovedStartThreshold = 10 * time.Minute
+
+// ScheduleCheckResult holds the outcome of RevalidateSchedule.
+type ScheduleCheckResult struct {
+	Check   ScheduleCheck
+	Payload models.Payload
+	// StartAt is the game's new start time; set for GameMoved.
+	StartAt time.Time
+}
+
+// RevalidateSchedule checks the first poll of a game loop (Cycle 0) against
+// the game's current schedule entry, since the NHL may have moved or postponed
+// the game after the scheduler enqueued it. A postponement is announced to the
+// notifiers; a playoff game also picks up the current series status. Later
+// polls, a nil Games lookup and lookup failures leave the payload as it is: a
+// failed check must not stop tracking.
+func (gp *GameProcessor) RevalidateSchedule(ctx context.Context, payload models.Payload, now time.Time) ScheduleCheckResult {
+	onSchedule := ScheduleCheckResult{Check: GameOnSchedule, Payload: payload}
+	if gp.Games == nil || payload.Cycle != 0 {
+		return onSchedule
+	}
+
+	game, err := gp.Games.FetchGame(ctx, payload.Game.ID)
+	if err != nil {
+		log.Printf("WARNING: could not re-validate schedule for game %s, polling as scheduled: %v", payload.Game.ID, err)
+		return onSchedule
+	}
+
+	if game.GameState == gameStatePostponed {
+		log.Printf("Game %s is postponed, ending its game loop", payload.Game.ID)
+		gp.NotificationService.SendMessage(ctx, fmt.Sprintf("📅 %s @ %s on %s has been postponed. Updates will resume once it is rescheduled.",
+			payload.Game.AwayTeam.Abbrev, payload.Game.HomeTeam.Abbrev, payload.Game.GameDate))
+		return ScheduleCheckResult{Check: GamePostponed, Payload: payload}
+	}
+
+	// The series may have moved on since the scheduler enqueued the game
+	// (it can enqueue days ahead), so take the current one.
+	if game.SeriesStatus != nil {
+		payload.Game.Series = game.SeriesStatus
+		payload.Game.GameType = game.GameType
+		onSchedule.Payload = payload
+	}
+
+	start, err := time.Parse(time.RFC3339, game.StartTimeUTC)
+	if err != nil || game.StartTimeUTC == payload.Game.StartTime {
+		return onSchedule
+	}
+	if scheduled, err := time.Parse(time.RFC3339, payload.Game.StartTime); err == nil && scheduled.Equal(start) {
+		return onSchedule
+	}
+
+	cfg := gp.Config
+	if cfg == nil {
+		cfg = config.LoadConfig()
+	}
+	executionEnd := start.Add(time.Duration(cfg.GameMaxDurationHours) * time.Hour).Format(time.RFC3339)
+	log.Printf("Game %s moved from %s to %s; execution window now ends %s",
+		payload.Game.ID, payload.Game.StartTime, game.StartTimeUTC, executionEnd)
+
+	payload.Game.StartTime = game.StartTimeUTC
+	if game.GameDate != "" {
+		payload.Game.GameDate = game.GameDate
+	}
+	payload.ExecutionEnd = &executionEnd
+
+	if start.Sub(now) > MovedStartThreshold {
+		return ScheduleCheckResult{Check: GameMoved, Payload: payload, StartAt: start}
+	}
+	return ScheduleCheckResult{Check: GameOnSchedule, Payload: payload}
+}
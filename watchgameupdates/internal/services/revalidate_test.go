@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"watchgameupdates/config"
+	"watchgameupdates/internal/models"
+	"watchgameupdates/internal/notification"
+	"watchgameupdates/internal/schedule"
+)
+
+type fakeLookup struct {
+	game  schedule.ScheduleGame
+	err   error
+	calls int
+}
+
+func (f *fakeLookup) FetchGame(ctx context.Context, gameID string) (schedule.ScheduleGame, error) {
+	f.calls++
+	return f.game, f.err
+}
+
+func firstPoll(start string) models.Payload {
+	execEnd := "2025-01-10T05:00:00Z"
+	return models.Payload{
+		Game: models.Game{
+			ID:        "2024020500",
+			GameDate:  "2025-01-10",
+			StartTime: start,
+			HomeTeam:  models.Team{Abbrev: "CAR"},
+			AwayTeam:  models.Team{Abbrev: "FLA"},
+		},
+		ExecutionEnd: &execEnd,
+	}
+}
+
+func TestRevalidateSchedule(t *testing.T) {
+	now := time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)
+	scheduled := "2025-01-10T00:00:00Z"
+
+	tests := []struct {
+		name        string
+		game        schedule.ScheduleGame
+		err         error
+		wantCheck   ScheduleCheck
+		wantStart   string
+		wantDate    string
+		wantExecEnd string
+	}{
+		{
+			name:        "unchanged",
+			game:        schedule.ScheduleGame{ID: 2024020500, GameDate: "2025-01-10", StartTimeUTC: scheduled, GameState: "FUT"},
+			wantCheck:   GameOnSchedule,
+			wantStart:   scheduled,
+			wantDate:    "2025-01-10",
+			wantExecEnd: "2025-01-10T05:00:00Z",
+		},
+		{
+			name:        "lookup fails",
+			err:         errors.New("connection refused"),
+			wantCheck:   GameOnSchedule,
+			wantStart:   scheduled,
+			wantDate:    "2025-01-10",
+			wantExecEnd: "2025-01-10T05:00:00Z",
+		},
+		{
+			name:        "moved later",
+			game:        schedule.ScheduleGame{ID: 2024020500, GameDate: "2025-01-10", StartTimeUTC: "2025-01-10T02:30:00Z", GameState: "FUT"},
+			wantCheck:   GameMoved,
+			wantStart:   "2025-01-10T02:30:00Z",
+			wantDate:    "2025-01-10",
+			wantExecEnd: "2025-01-10T07:30:00Z",
+		},
+		{
+			name:        "rescheduled to another day",
+			game:        schedule.ScheduleGame{ID: 2024020500, GameDate: "2025-02-03", StartTimeUTC: "2025-02-04T00:00:00Z", GameState: "FUT"},
+			wantCheck:   GameMoved,
+			wantStart:   "2025-02-04T00:00:00Z",
+			wantDate:    "2025-02-03",
+			wantExecEnd: "2025-02-04T05:00:00Z",
+		},
+		{
+			name:        "moved within threshold",
+			game:        schedule.ScheduleGame{ID: 2024020500, GameDate: "2025-01-10", StartTimeUTC: "2025-01-10T00:05:00Z", GameState: "FUT"},
+			wantCheck:   GameOnSchedule,
+			wantStart:   "2025-01-10T00:05:00Z",
+			wantDate:    "2025-01-10",
+			wantExecEnd: "2025-01-10T05:05:00Z",
+		},
+		{
+			name:        "moved earlier",
+			game:        schedule.ScheduleGame{ID: 2024020500, GameDate: "2025-01-09", StartTimeUTC: "2025-01-09T23:00:00Z", GameState: "LIVE"},
+			wantCheck:   GameOnSchedule,
+			wantStart:   "2025-01-09T23:00:00Z",
+			wantDate:    "2025-01-09",
+			wantExecEnd: "2025-01-10T04:00:00Z",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gp := &GameProcessor{
+				NotificationService: notification.NewServiceWithNotificationFlag(true),
+				Config:              &config.Config{GameMaxDurationHours: 5},
+				Games:               &fakeLookup{game: tt.game, err: tt.err},
+			}
+
+			got := gp.RevalidateSchedule(context.Background(), firstPoll(scheduled), now)
+			if got.Check != tt.wantCheck {
+				t.Fatalf("Check = %v, want %v", got.Check, tt.wantCheck)
+			}
+			if got.Payload.Game.StartTime != tt.wantStart {
+				t.Errorf("StartTime = %q, want %q", got.Payload.Game.StartTime, tt.wantStart)
+			}
+			if got.Payload.Game.GameDate != tt.wantDate {
+				t.Errorf("GameDate = %q, want %q", got.Payload.Game.GameDate, tt.wantDate)
+			}
+			if *got.Payload.ExecutionEnd != tt.wantExecEnd {
+				t.Errorf("ExecutionEnd = %q, want %q", *got.Payload.ExecutionEnd, tt.wantExecEnd)
+			}
+			if tt.wantCheck == GameMoved && got.StartAt.Format(time.RFC3339) != tt.wantStart {
+				t.Errorf("StartAt = %v, want %s", got.StartAt, tt.wantStart)
+			}
+		})
+	}
+}
+
+func TestRevalidateSchedule_PostponedNotifies(t *testing.T) {
+	notifier := &recordingNotifier{}
+	svc := notification.NewServiceWithNotificationFlag(true)
+	svc.RegisterNotifier(notifier)
+
+	gp := &GameProcessor{
+		NotificationService: svc,
+		Config:              &config.Config{GameMaxDurationHours: 5},
+		Games:               &fakeLookup{game: schedule.ScheduleGame{ID: 2024020500, StartTimeUTC: "2025-01-10T00:00:00Z", GameState: "PPD"}},
+	}
+
+	got := gp.RevalidateSchedule(context.Background(), firstPoll("2025-01-10T00:00:00Z"), time.Now())
+	if got.Check != GamePostponed {
+		t.Fatalf("Check = %v, want GamePostponed", got.Check)
+	}
+	if !notifier.sent.Load() {
+		t.Error("expected a postponement message")
+	}
+}
+
+func TestRevalidateSchedule_OnlyFirstPoll(t *testing.T) {
+	lookup := &fakeLookup{game: schedule.ScheduleGame{GameState: "PPD"}}
+	gp := &GameProcessor{NotificationService: notification.NewServiceWithNotificationFlag(true), Games: lookup}
+
+	payload := firstPoll("2025-01-10T00:00:00Z")
+	payload.Cycle = 4
+	if got := gp.RevalidateSchedule(context.Background(), payload, time.Now()); got.Check != GameOnSchedule {
+		t.Errorf("Check = %v, want GameOnSchedule", got.Check)
+	}
+	if lookup.calls != 0 {
+		t.Errorf("looked up the game %d times on a later poll, want 0", lookup.calls)
+	}
+
+	gp.Games = nil
+	if got := gp.RevalidateSchedule(context.Background(), firstPoll("2025-01-10T00:00:00Z"), time.Now()); got.Check != GameOnSchedule {
+		t.Errorf("Check without a lookup = %v, want GameOnSchedule", got.Check)
+	}
+}
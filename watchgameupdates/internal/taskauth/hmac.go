@@ -0,0 +1,101 @@
+package taskauth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SignatureHeader carries the HMAC signature of a task: "t=<unix>,v1=<hex>".
+const SignatureHeader = "X-Task-Signature"
+
+// TaskTypeHeader names the task type on a request (tasks.TaskTypeHeader). The
+// signature covers it, so a signed game check cannot be replayed as another
+// task type.
+const TaskTypeHeader = "X-Task-Type"
+
+// MaxSignatureAge is how long after its delivery time a signed task is
+// accepted. It bounds how long a captured request can be replayed, and is
+// generous enough for Cloud Tasks retries of a single check.
+const MaxSignatureAge = time.Hour
+
+// Sign returns the SignatureHeader value for a task of taskType (its
+// TaskTypeHeader, "" for none) with body delivered at deliverAt. The
+// signature covers the delivery time, so a task scheduled hours ahead is
+// still fresh when it arrives.
+func Sign(secret, taskType string, body []byte, deliverAt time.Time) string {
+	t := deliverAt.Unix()
+	return fmt.Sprintf("t=%d,v1=%s", t, hex.EncodeToString(mac(secret, t, taskType, body)))
+}
+
+// mac is the HMAC-SHA256 of "t.taskType.body". Task types contain no dots.
+func mac(secret string, t int64, taskType string, body []byte) []byte {
+	h := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(h, "%d.%s.", t, taskType)
+	h.Write(body)
+	return h.Sum(nil)
+}
+
+// HMACVerifier accepts requests signed with Sign using the same secret.
+type HMACVerifier struct {
+	secret string
+	now    func() time.Time
+}
+
+// NewHMACVerifier returns a verifier for signatures made with secret.
+func NewHMACVerifier(secret string) *HMACVerifier {
+	return &HMACVerifier{secret: secret, now: time.Now}
+}
+
+// Verify checks the SignatureHeader against body and the TaskTypeHeader, and
+// requires its delivery time to be no more than MaxSignatureAge ago and not in the future.
+func (v *HMACVerifier) Verify(r *http.Request, body []byte) error {
+	header := r.Header.Get(SignatureHeader)
+	if header == "" {
+		return ErrMissingCredentials
+	}
+	t, sig, err := parseSignature(header)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal(sig, mac(v.secret, t, r.Header.Get(TaskTypeHeader), body)) {
+		return fmt.Errorf("signature mismatch")
+	}
+	deliverAt := time.Unix(t, 0)
+	now := v.now()
+	if deliverAt.After(now.Add(leeway)) {
+		return fmt.Errorf("signature is for a delivery at %s, in the future", deliverAt.Format(time.RFC3339))
+	}
+	if now.Sub(deliverAt) > MaxSignatureAge {
+		return fmt.Errorf("signature for delivery at %s has expired", deliverAt.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// parseSignature splits "t=<unix>,v1=<hex>".
+func parseSignature(header string) (int64, []byte, error) {
+	var t int64
+	var sig []byte
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		var err error
+		switch key {
+		case "t":
+			t, err = strconv.ParseInt(value, 10, 64)
+		case "v1":
+			sig, err = hex.DecodeString(value)
+		}
+		if err != nil {
+			return 0, nil, fmt.Errorf("malformed %s: %w", SignatureHeader, err)
+		}
+	}
+	if t == 0 || sig == nil {
+		return 0, nil, fmt.Errorf("malformed %s: need t and v1", SignatureHeader)
+	}
+	return t, sig, nil
+}
@@ -0,0 +1,251 @@
+package taskauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Google's OIDC issuer and signing keys, which Cloud Tasks tokens use.
+const (
+	GoogleIssuer  = "https://accounts.google.com"
+	GoogleJWKSURL = "https://www.googleapis.com/oauth2/v3/certs"
+)
+
+const (
+	// keySetMaxAge is how long fetched keys are trusted before the next
+	// verification refetches them; Google rotates its keys every few days.
+	keySetMaxAge = time.Hour
+	// keySetMinRefresh limits refetches for a key ID that is not in the set,
+	// so forged kids cannot hammer the JWKS endpoint.
+	keySetMinRefresh = time.Minute
+	jwksBodyLimit    = 1 << 20
+)
+
+// OIDCOptions configures an OIDCVerifier.
+type OIDCOptions struct {
+	// Audience must equal the token's aud: the audience Cloud Tasks was
+	// told to mint tokens for, by default the handler URL.
+	Audience string
+	// Issuer must equal the token's iss. GoogleIssuer also accepts Google's
+	// scheme-less "accounts.google.com".
+	Issuer string
+	// JWKSURL serves the issuer's signing keys.
+	JWKSURL string
+	// ServiceAccount, when set, must equal the token's email.
+	ServiceAccount string
+	// HTTPClient fetches the JWKS; nil uses a client with a 10s timeout.
+	HTTPClient *http.Client
+}
+
+// OIDCVerifier accepts requests carrying an RS256 OIDC token from the
+// configured issuer.
+type OIDCVerifier struct {
+	opts    OIDCOptions
+	issuers []string
+	keys    *KeySet
+	now     func() time.Time
+}
+
+// NewOIDCVerifier returns a verifier for opts. Audience, Issuer and JWKSURL
+// are required.
+func NewOIDCVerifier(opts OIDCOptions) (*OIDCVerifier, error) {
+	if opts.Audience == "" || opts.Issuer == "" || opts.JWKSURL == "" {
+		return nil, fmt.Errorf("OIDC task auth needs an audience, issuer and JWKS URL")
+	}
+	issuers := []string{opts.Issuer}
+	if opts.Issuer == GoogleIssuer {
+		issuers = append(issuers, strings.TrimPrefix(GoogleIssuer, "https://"))
+	}
+	client := opts.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &OIDCVerifier{
+		opts:    opts,
+		issuers: issuers,
+		keys:    NewKeySet(opts.JWKSURL, client),
+		now:     time.Now,
+	}, nil
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type oidcClaims struct {
+	Issuer   string   `json:"iss"`
+	Audience audience `json:"aud"`
+	Expiry   int64    `json:"exp"`
+	IssuedAt int64    `json:"iat"`
+	Email    string   `json:"email"`
+}
+
+// audience is a JWT aud claim, which may be a string or a list.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*a = audience{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*a = many
+	return nil
+}
+
+// Verify checks the bearer token's signature, issuer, audience, lifetime and,
+// when configured, service account.
+func (v *OIDCVerifier) Verify(r *http.Request, _ []byte) error {
+	token, ok := bearerToken(r)
+	if !ok {
+		return ErrMissingCredentials
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return fmt.Errorf("malformed token")
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return fmt.Errorf("malformed token header: %w", err)
+	}
+	if header.Alg != "RS256" {
+		return fmt.Errorf("unsupported token algorithm %q", header.Alg)
+	}
+	key, err := v.keys.Key(r.Context(), header.Kid)
+	if err != nil {
+		return err
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return fmt.Errorf("malformed token signature: %w", err)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig); err != nil {
+		return fmt.Errorf("invalid token signature")
+	}
+
+	var claims oidcClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return fmt.Errorf("malformed token claims: %w", err)
+	}
+	now := v.now()
+	switch {
+	case !slices.Contains(v.issuers, claims.Issuer):
+		return fmt.Errorf("unexpected token issuer %q", claims.Issuer)
+	case !slices.Contains(claims.Audience, v.opts.Audience):
+		return fmt.Errorf("token audience %v does not include %q", []string(claims.Audience), v.opts.Audience)
+	case claims.Expiry == 0 || now.After(time.Unix(claims.Expiry, 0).Add(leeway)):
+		return fmt.Errorf("token has expired")
+	case time.Unix(claims.IssuedAt, 0).After(now.Add(leeway)):
+		return fmt.Errorf("token is issued in the future")
+	case v.opts.ServiceAccount != "" && claims.Email != v.opts.ServiceAccount:
+		return fmt.Errorf("token is for %q, not the task service account", claims.Email)
+	}
+	return nil
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// KeySet is a cached JWKS of RSA signing keys, fetched on demand.
+type KeySet struct {
+	url  string
+	http *http.Client
+
+	mu      sync.Mutex
+	keys    map[string]*rsa.PublicKey
+	fetched time.Time
+}
+
+// NewKeySet returns a key set served at url.
+func NewKeySet(url string, client *http.Client) *KeySet {
+	return &KeySet{url: url, http: client}
+}
+
+// Key returns the key with ID kid, fetching the set when it is stale or does
+// not have kid yet.
+func (s *KeySet) Key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	age := time.Since(s.fetched)
+	if key, ok := s.keys[kid]; ok && age < keySetMaxAge {
+		return key, nil
+	}
+	if s.keys == nil || age >= keySetMinRefresh {
+		keys, err := s.fetch(ctx)
+		if err != nil {
+			return nil, err
+		}
+		s.keys, s.fetched = keys, time.Now()
+	}
+	if key, ok := s.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown token key %q", kid)
+}
+
+type jwks struct {
+	Keys []struct {
+		Kid string `json:"kid"`
+		Kty string `json:"kty"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+	} `json:"keys"`
+}
+
+func (s *KeySet) fetch(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("build JWKS request: %w", err)
+	}
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch JWKS: status %d", resp.StatusCode)
+	}
+
+	var set jwks
+	if err := json.NewDecoder(io.LimitReader(resp.Body, jwksBodyLimit)).Decode(&set); err != nil {
+		return nil, fmt.Errorf("decode JWKS: %w", err)
+	}
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(k.N)
+		e, errE := base64.RawURLEncoding.DecodeString(k.E)
+		if errN != nil || errE != nil {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	return keys, nil
+}
@@ -0,0 +1,207 @@
+package taskauth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const (
+	testAudience = "https://watchgameupdates.example.com/"
+	testAccount  = "tasks@project.iam.gserviceaccount.com"
+)
+
+// newTestOIDC starts a LocalIssuer JWKS server and returns the issuer and a
+// verifier trusting it. fetches counts JWKS requests.
+func newTestOIDC(t *testing.T, serviceAccount string) (*LocalIssuer, *OIDCVerifier, *atomic.Int32) {
+	t.Helper()
+	issuer, err := NewLocalIssuer("https://issuer.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fetches atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		issuer.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	v, err := NewOIDCVerifier(OIDCOptions{
+		Audience:       testAudience,
+		Issuer:         issuer.Issuer,
+		JWKSURL:        srv.URL,
+		ServiceAccount: serviceAccount,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return issuer, v, &fetches
+}
+
+func requestWithToken(token string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	return r
+}
+
+func TestOIDCVerifier(t *testing.T) {
+	issuer, v, _ := newTestOIDC(t, testAccount)
+	other, err := NewLocalIssuer(issuer.Issuer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		mint    func() (string, error)
+		wantErr bool
+	}{
+		{"valid", func() (string, error) {
+			return issuer.Token(testAudience, testAccount, time.Hour, nil)
+		}, false},
+		{"audience list", func() (string, error) {
+			return issuer.Token(testAudience, testAccount, time.Hour, map[string]any{"aud": []string{"other", testAudience}})
+		}, false},
+		{"wrong audience", func() (string, error) {
+			return issuer.Token("https://elsewhere.example.com/", testAccount, time.Hour, nil)
+		}, true},
+		{"wrong issuer", func() (string, error) {
+			return issuer.Token(testAudience, testAccount, time.Hour, map[string]any{"iss": "https://evil.example.com"})
+		}, true},
+		{"expired", func() (string, error) {
+			return issuer.Token(testAudience, testAccount, -time.Hour, nil)
+		}, true},
+		{"issued in the future", func() (string, error) {
+			return issuer.Token(testAudience, testAccount, time.Hour, map[string]any{"iat": time.Now().Add(time.Hour).Unix()})
+		}, true},
+		{"other service account", func() (string, error) {
+			return issuer.Token(testAudience, "someone@example.com", time.Hour, nil)
+		}, true},
+		{"signed by another key with the same kid", func() (string, error) {
+			return other.Token(testAudience, testAccount, time.Hour, nil)
+		}, true},
+		{"no token", func() (string, error) { return "", nil }, true},
+		{"garbage", func() (string, error) { return "not.a.jwt", nil }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := tt.mint()
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = v.Verify(requestWithToken(token), nil)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Verify() error = %v, wantErr %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOIDCVerifier_GoogleIssuerWithoutScheme(t *testing.T) {
+	issuer, err := NewLocalIssuer("accounts.google.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(issuer)
+	defer srv.Close()
+	v, err := NewOIDCVerifier(OIDCOptions{Audience: testAudience, Issuer: GoogleIssuer, JWKSURL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := issuer.Token(testAudience, testAccount, time.Hour, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Verify(requestWithToken(token), nil); err != nil {
+		t.Errorf("Verify() = %v, want Google's scheme-less issuer accepted", err)
+	}
+}
+
+func TestOIDCVerifier_CachesKeys(t *testing.T) {
+	issuer, v, fetches := newTestOIDC(t, "")
+	token, err := issuer.Token(testAudience, testAccount, time.Hour, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 3 {
+		if err := v.Verify(requestWithToken(token), nil); err != nil {
+			t.Fatalf("Verify() = %v", err)
+		}
+	}
+	if got := fetches.Load(); got != 1 {
+		t.Errorf("JWKS fetched %d times, want 1", got)
+	}
+
+	// An unknown kid does not refetch within keySetMinRefresh.
+	issuer.KeyID = "rotated"
+	token, err = issuer.Token(testAudience, testAccount, time.Hour, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Verify(requestWithToken(token), nil); err == nil {
+		t.Error("Verify() accepted a token for a key the verifier has not fetched")
+	}
+	if got := fetches.Load(); got != 1 {
+		t.Errorf("JWKS fetched %d times after an unknown kid, want 1", got)
+	}
+}
+
+func TestNewOIDCVerifier_RequiresOptions(t *testing.T) {
+	if _, err := NewOIDCVerifier(OIDCOptions{Issuer: GoogleIssuer, JWKSURL: GoogleJWKSURL}); err == nil {
+		t.Error("NewOIDCVerifier accepted an empty audience")
+	}
+}
+
+func TestHMACVerifier(t *testing.T) {
+	const secret = "s3cret"
+	body := []byte(`{"game":{"id":"2025020001"}}`)
+	now := time.Date(2025, 10, 8, 23, 30, 0, 0, time.UTC)
+
+	const pregame = "game:pregame"
+
+	tests := []struct {
+		name        string
+		header      string
+		taskType    string
+		body        []byte
+		wantErr     bool
+		wantMissing bool
+	}{
+		{"valid", Sign(secret, "", body, now.Add(-time.Minute)), "", body, false, false},
+		{"valid with task type", Sign(secret, pregame, body, now), pregame, body, false, false},
+		{"retried within the hour", Sign(secret, "", body, now.Add(-50*time.Minute)), "", body, false, false},
+		{"tampered body", Sign(secret, "", body, now), "", []byte(`{"game":{"id":"2025020002"}}`), true, false},
+		{"task type added", Sign(secret, "", body, now), pregame, body, true, false},
+		{"task type removed", Sign(secret, pregame, body, now), "", body, true, false},
+		{"wrong secret", Sign("other", "", body, now), "", body, true, false},
+		{"expired", Sign(secret, "", body, now.Add(-2*time.Hour)), "", body, true, false},
+		{"future delivery", Sign(secret, "", body, now.Add(time.Hour)), "", body, true, false},
+		{"malformed", "v1=zz", "", body, true, false},
+		{"missing", "", "", body, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewHMACVerifier(secret)
+			v.now = func() time.Time { return now }
+			r := httptest.NewRequest(http.MethodPost, "/", nil)
+			if tt.header != "" {
+				r.Header.Set(SignatureHeader, tt.header)
+			}
+			if tt.taskType != "" {
+				r.Header.Set(TaskTypeHeader, tt.taskType)
+			}
+			err := v.Verify(r, tt.body)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Verify() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if tt.wantMissing && !errors.Is(err, ErrMissingCredentials) {
+				t.Errorf("Verify() error = %v, want ErrMissingCredentials", err)
+			}
+		})
+	}
+}
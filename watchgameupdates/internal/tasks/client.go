@@ -2,13 +2,18 @@ package tasks
 
 import (
 	"context"
+	"errors"
 
 	cloudtasks "cloud.google.com/go/cloudtasks/apiv2"
 	taskspb "cloud.google.com/go/cloudtasks/apiv2/cloudtaskspb"
+	"google.golang.org/api/iterator"
 )
 
 type CloudTasksClient interface {
 	CreateTask(ctx context.Context, req *taskspb.CreateTaskRequest) (*taskspb.Task, error)
+	// ListTasks returns every task in the queue, following all pages.
+	ListTasks(ctx context.Context, req *taskspb.ListTasksRequest) ([]*taskspb.Task, error)
+	DeleteTask(ctx context.Context, req *taskspb.DeleteTaskRequest) error
 	Close() error
 }
 
@@ -20,6 +25,25 @@ func (r *realClient) CreateTask(ctx context.Context, req *taskspb.CreateTaskRequ
 	return r.client.CreateTask(ctx, req)
 }
 
+func (r *realClient) ListTasks(ctx context.Context, req *taskspb.ListTasksRequest) ([]*taskspb.Task, error) {
+	var out []*taskspb.Task
+	it := r.client.ListTasks(ctx, req)
+	for {
+		task, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			return out, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, task)
+	}
+}
+
+func (r *realClient) DeleteTask(ctx context.Context, req *taskspb.DeleteTaskRequest) error {
+	return r.client.DeleteTask(ctx, req)
+}
+
 func (r *realClient) Close() error {
 	return r.client.Close()
 }
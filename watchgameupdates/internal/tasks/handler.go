@@ -2,6 +2,7 @@ package tasks
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"time"
@@ -10,6 +11,7 @@ import (
 	"watchgameupdates/internal/models"
 	"watchgameupdates/internal/notification"
 	"watchgameupdates/internal/notification/notifiers"
+	"watchgameupdates/internal/schedule"
 	"watchgameupdates/internal/services"
 
 	"github.com/hibiken/asynq"
@@ -23,14 +25,28 @@ type TaskEnqueuer interface {
 // WatchGameUpdatesHandler processes game update tasks from the Redis queue.
 type WatchGameUpdatesHandler struct {
 	cfg                 *config.Config
-	enqueuer            TaskEnqueuer
+	rescheduler         *services.Rescheduler
 	notificationService *notification.Service
+	games               services.GameLookup
 }
 
-func NewWatchGameUpdatesHandler(cfg *config.Config, enqueuer TaskEnqueuer) *WatchGameUpdatesHandler {
+// NewWatchGameUpdatesHandler returns a handler that schedules each game's next
+// check through rescheduler.
+func NewWatchGameUpdatesHandler(cfg *config.Config, rescheduler *services.Rescheduler) *WatchGameUpdatesHandler {
 	// Build once so JWT signers and HTTP connections survive across task invocations.
 	svc := notifiers.New(true)
-	return &WatchGameUpdatesHandler{cfg: cfg, enqueuer: enqueuer, notificationService: svc}
+	return &WatchGameUpdatesHandler{
+		cfg:                 cfg,
+		rescheduler:         rescheduler,
+		notificationService: svc,
+		games:               schedule.NewHTTPScheduleFetcher(cfg.ScheduleAPIBaseURL),
+	}
+}
+
+// NotificationService returns the long-lived notification service shared by
+// every task this handler processes.
+func (h *WatchGameUpdatesHandler) NotificationService() *notification.Service {
+	return h.notificationService
 }
 
 func (h *WatchGameUpdatesHandler) ProcessTask(ctx context.Context, t *asynq.Task) error {
@@ -40,7 +56,12 @@ func (h *WatchGameUpdatesHandler) ProcessTask(ctx context.Context, t *asynq.Task
 		log.Printf("Dropping task with invalid payload: %v", err)
 		return nil
 	}
+	return h.Process(ctx, payload)
+}
 
+// Process runs one check of payload's game and schedules the next. Errors are
+// the ones worth retrying; a cancelled ctx ends the game loop.
+func (h *WatchGameUpdatesHandler) Process(ctx context.Context, payload models.Payload) error {
 	log.Printf("Processing task for game %s", payload.Game.ID)
 
 	// Check execution window
@@ -60,37 +81,59 @@ func (h *WatchGameUpdatesHandler) ProcessTask(ctx context.Context, t *asynq.Task
 	processor := &services.GameProcessor{
 		Fetcher:             fetcher,
 		NotificationService: h.notificationService.WithShouldNotify(shouldNotify),
+		Config:              h.cfg,
+		Games:               h.games,
+	}
+
+	check := processor.RevalidateSchedule(ctx, payload, time.Now())
+	switch check.Check {
+	case services.GamePostponed:
+		return nil
+	case services.GameMoved:
+		return h.rescheduler.Requeue(ctx, check)
 	}
+	payload = check.Payload
 
 	result := processor.ProcessGameUpdate(payload)
 
-	if result.ShouldReschedule {
-		interval := time.Duration(h.cfg.MessageIntervalSeconds) * time.Second
-		if result.RetryAfterDataError {
-			// MoneyPuck data was unparseable and nothing was sent; retry soon to
-			// pick up the corrected file.
-			interval = services.ParseErrorRetryInterval
-		}
-		if err := h.scheduleNextCheck(payload, interval); err != nil {
-			return fmt.Errorf("failed to schedule next check for game %s: %w", payload.Game.ID, err)
-		}
+	// The admin API cancels an in-flight check through the asynq Inspector;
+	// finish without rescheduling so the game loop ends here.
+	if errors.Is(ctx.Err(), context.Canceled) {
+		log.Printf("Task for game %s was cancelled, not rescheduling", payload.Game.ID)
+		return nil
 	}
 
-	return nil
+	return h.rescheduler.Reschedule(ctx, payload, result)
 }
 
-func (h *WatchGameUpdatesHandler) scheduleNextCheck(payload models.Payload, interval time.Duration) error {
+// EnqueueGameCheck enqueues a game tracking task for payload to run at
+// deliverAt, pinned to the check's task ID so a redelivered check cannot fork
+// its game loop. A task that already exists counts as success.
+func EnqueueGameCheck(enqueuer TaskEnqueuer, payload models.Payload, deliverAt time.Time) error {
 	task, err := NewWatchGameUpdatesTask(payload)
 	if err != nil {
 		return fmt.Errorf("failed to create task: %w", err)
 	}
 
-	info, err := h.enqueuer.Enqueue(task, asynq.ProcessIn(interval))
+	opts := UniqueOptions(payload)
+	if delay := time.Until(deliverAt); delay > 0 {
+		opts = append(opts, asynq.ProcessIn(delay))
+	}
+
+	info, err := enqueuer.Enqueue(task, opts...)
+	if IsDuplicateTask(err) {
+		log.Printf("Task %s for game %s already exists, not enqueuing again", TaskID(payload), payload.Game.ID)
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("failed to enqueue task: %w", err)
 	}
 
-	log.Printf("Scheduled next check for game %s, task ID: %s, processing in: %v",
-		payload.Game.ID, info.ID, interval)
+	log.Printf("Enqueuing task for game %s (%s vs %s) scheduled at %s, task ID: %s",
+		payload.Game.ID,
+		payload.Game.AwayTeam.Abbrev,
+		payload.Game.HomeTeam.Abbrev,
+		deliverAt.Format(time.RFC3339),
+		info.ID)
 	return nil
 }
@@ -9,6 +9,7 @@ import (
 
 	"watchgameupdates/config"
 	"watchgameupdates/internal/models"
+	"watchgameupdates/internal/services"
 
 	"github.com/hibiken/asynq"
 )
@@ -46,10 +47,24 @@ func (m *mockEnqueuer) taskCount() int {
 	return len(m.enqueued)
 }
 
+// asynqQueue enqueues game checks to an asynq enqueuer, as queue.RedisQueue
+// does.
+type asynqQueue struct {
+	enqueuer TaskEnqueuer
+}
+
+func (q asynqQueue) Enqueue(_ context.Context, payload models.Payload, deliverAt time.Time) error {
+	return EnqueueGameCheck(q.enqueuer, payload, deliverAt)
+}
+
+func newTestHandler(cfg *config.Config, enqueuer TaskEnqueuer) *WatchGameUpdatesHandler {
+	return NewWatchGameUpdatesHandler(cfg, services.NewRescheduler(asynqQueue{enqueuer}))
+}
+
 func TestProcessTask_InvalidPayload(t *testing.T) {
 	cfg := &config.Config{MessageIntervalSeconds: 60}
 	enqueuer := &mockEnqueuer{}
-	h := NewWatchGameUpdatesHandler(cfg, enqueuer)
+	h := newTestHandler(cfg, enqueuer)
 
 	task := asynq.NewTask(TypeWatchGameUpdates, []byte("invalid-json"))
 
@@ -65,7 +80,7 @@ func TestProcessTask_InvalidPayload(t *testing.T) {
 func TestProcessTask_ExpiredExecutionWindow(t *testing.T) {
 	cfg := &config.Config{MessageIntervalSeconds: 60}
 	enqueuer := &mockEnqueuer{}
-	h := NewWatchGameUpdatesHandler(cfg, enqueuer)
+	h := newTestHandler(cfg, enqueuer)
 
 	past := time.Now().Add(-1 * time.Hour).Format(time.RFC3339)
 	payload := models.Payload{
@@ -90,7 +105,7 @@ func TestProcessTask_ExpiredExecutionWindow(t *testing.T) {
 func TestProcessTask_InvalidExecutionEndFormat(t *testing.T) {
 	cfg := &config.Config{MessageIntervalSeconds: 60}
 	enqueuer := &mockEnqueuer{}
-	h := NewWatchGameUpdatesHandler(cfg, enqueuer)
+	h := newTestHandler(cfg, enqueuer)
 
 	invalid := "not-a-date"
 	payload := models.Payload{
@@ -112,30 +127,28 @@ func TestProcessTask_InvalidExecutionEndFormat(t *testing.T) {
 
 func TestNewWatchGameUpdatesHandler_NotNil(t *testing.T) {
 	cfg := &config.Config{MessageIntervalSeconds: 60, RedisAddress: "localhost:6379"}
-	enqueuer := &mockEnqueuer{}
+	rescheduler := services.NewRescheduler(asynqQueue{&mockEnqueuer{}})
 
-	h := NewWatchGameUpdatesHandler(cfg, enqueuer)
+	h := NewWatchGameUpdatesHandler(cfg, rescheduler)
 	if h == nil {
 		t.Error("Expected non-nil handler")
 	}
 	if h.cfg != cfg {
 		t.Error("Handler config mismatch")
 	}
-	if h.enqueuer != enqueuer {
-		t.Error("Handler enqueuer mismatch")
+	if h.rescheduler != rescheduler {
+		t.Error("Handler rescheduler mismatch")
 	}
 }
 
-func TestScheduleNextCheck_EnqueuesCalled(t *testing.T) {
-	cfg := &config.Config{MessageIntervalSeconds: 30}
+func TestEnqueueGameCheck_EnqueuesCalled(t *testing.T) {
 	enqueuer := &mockEnqueuer{}
-	h := NewWatchGameUpdatesHandler(cfg, enqueuer)
 
 	payload := models.Payload{
 		Game: models.Game{ID: "2024030411"},
 	}
 
-	err := h.scheduleNextCheck(payload, time.Duration(cfg.MessageIntervalSeconds)*time.Second)
+	err := EnqueueGameCheck(enqueuer, payload, time.Now().Add(30*time.Second))
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
@@ -161,17 +174,49 @@ func TestScheduleNextCheck_EnqueuesCalled(t *testing.T) {
 	}
 }
 
-func TestScheduleNextCheck_EnqueueError(t *testing.T) {
-	cfg := &config.Config{MessageIntervalSeconds: 30}
+func TestEnqueueGameCheck_EnqueueError(t *testing.T) {
 	enqueuer := &mockEnqueuer{err: asynq.ErrDuplicateTask}
-	h := NewWatchGameUpdatesHandler(cfg, enqueuer)
 
 	payload := models.Payload{
 		Game: models.Game{ID: "2024030411"},
 	}
 
-	err := h.scheduleNextCheck(payload, time.Duration(cfg.MessageIntervalSeconds)*time.Second)
+	err := EnqueueGameCheck(enqueuer, payload, time.Now().Add(30*time.Second))
 	if err == nil {
 		t.Error("Expected error when enqueue fails, got nil")
 	}
 }
+
+func TestEnqueueGameCheck_PinsTaskID(t *testing.T) {
+	enqueuer := &mockEnqueuer{}
+
+	execEnd := "2025-01-01T17:00:00Z"
+	payload := models.Payload{
+		Game:         models.Game{ID: "2024030411", StartTime: "2025-01-01T12:00:00Z"},
+		ExecutionEnd: &execEnd,
+		Cycle:        3,
+	}
+	if err := EnqueueGameCheck(enqueuer, payload, time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var taskID string
+	for _, opt := range enqueuer.enqueued[0].opts {
+		if opt.Type() == asynq.TaskIDOpt {
+			taskID = opt.Value().(string)
+		}
+	}
+	if want := "game-2024030411-1735732800-3"; taskID != want {
+		t.Errorf("task ID option = %q, want %q", taskID, want)
+	}
+}
+
+func TestEnqueueGameCheck_AlreadyScheduled(t *testing.T) {
+	enqueuer := &mockEnqueuer{err: asynq.ErrTaskIDConflict}
+
+	execEnd := "2025-01-01T12:00:00Z"
+	payload := models.Payload{Game: models.Game{ID: "2024030411"}, ExecutionEnd: &execEnd, Cycle: 3}
+	if err := EnqueueGameCheck(enqueuer, payload, time.Now().Add(time.Minute)); err != nil {
+		t.Errorf("an already scheduled next check should count as success, got %v", err)
+	}
+}
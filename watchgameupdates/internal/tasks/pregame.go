@@ -0,0 +1,41 @@
+package tasks
+
+import (
+	"context"
+	"log"
+
+	"watchgameupdates/internal/models"
+	"watchgameupdates/internal/notification"
+	"watchgameupdates/internal/services"
+
+	"github.com/hibiken/asynq"
+)
+
+// PregameHandler processes pregame tasks from the Redis queue.
+type PregameHandler struct {
+	notificationService *notification.Service
+}
+
+// NewPregameHandler creates a PregameHandler sending through svc, normally the
+// game check handler's long-lived service.
+func NewPregameHandler(svc *notification.Service) *PregameHandler {
+	return &PregameHandler{notificationService: svc}
+}
+
+// ProcessTask sends the game-starting-soon notifications. It never asks asynq
+// to retry: a late pregame message is worse than none.
+func (h *PregameHandler) ProcessTask(ctx context.Context, t *asynq.Task) error {
+	payload, err := ParseWatchGameUpdatesPayload(t)
+	if err != nil {
+		log.Printf("Dropping pregame task with invalid payload: %v", err)
+		return nil
+	}
+	return h.Process(ctx, payload)
+}
+
+// Process sends the game-starting-soon notifications for payload.
+func (h *PregameHandler) Process(ctx context.Context, payload models.Payload) error {
+	shouldNotify := payload.ShouldNotify == nil || *payload.ShouldNotify
+	services.ProcessPregame(ctx, h.notificationService.WithShouldNotify(shouldNotify), payload)
+	return nil
+}
@@ -2,17 +2,30 @@ package tasks
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
 
 	"watchgameupdates/internal/models"
+	"watchgameupdates/internal/taskauth"
 
 	"github.com/hibiken/asynq"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 const (
 	TypeWatchGameUpdates = "game:watch_updates"
+	// TypePregame sends the game-starting-soon notifications once, before
+	// puck drop. Its payload is a models.Payload like TypeWatchGameUpdates.
+	TypePregame = "game:pregame"
 )
 
+// TaskTypeHeader marks the task type on Cloud Tasks HTTP requests, which all
+// go to the same handler URL. Requests without it are game checks. An HMAC
+// signature covers it.
+const TaskTypeHeader = taskauth.TaskTypeHeader
+
 // NewWatchGameUpdatesTask creates a new asynq task from a game payload.
 func NewWatchGameUpdatesTask(payload models.Payload) (*asynq.Task, error) {
 	data, err := json.Marshal(payload)
@@ -22,6 +35,15 @@ func NewWatchGameUpdatesTask(payload models.Payload) (*asynq.Task, error) {
 	return asynq.NewTask(TypeWatchGameUpdates, data), nil
 }
 
+// NewPregameTask creates a pregame asynq task from a game payload.
+func NewPregameTask(payload models.Payload) (*asynq.Task, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	return asynq.NewTask(TypePregame, data), nil
+}
+
 // ParseWatchGameUpdatesPayload deserializes a payload from an asynq task.
 func ParseWatchGameUpdatesPayload(t *asynq.Task) (models.Payload, error) {
 	var payload models.Payload
@@ -30,3 +52,95 @@ func ParseWatchGameUpdatesPayload(t *asynq.Task) (models.Payload, error) {
 	}
 	return payload, nil
 }
+
+// completedTaskRetention keeps finished asynq tasks around so their IDs keep
+// colliding with late duplicates (a retried check re-enqueuing a cycle that
+// already ran). It covers a whole game loop with margin.
+const completedTaskRetention = 24 * time.Hour
+
+// GameTaskIDPrefix starts every TaskID, so game checks can be told apart from
+// pregame tasks by name alone.
+const GameTaskIDPrefix = "game-"
+
+// TaskID returns the deterministic ID of the check payload describes:
+//
+//	game-{game ID}-{start time, unix seconds}-{cycle}
+//
+// A scheduler rerun or a redelivered task enqueues the same check under the
+// same ID, which the queue rejects instead of starting a second loop. The
+// start time stays fixed for the whole loop, unlike the execution end, which
+// a live game's enqueuer takes from the clock and a playoff game extends. A
+// moved game gets fresh IDs; a loop started again after being cancelled
+// resumes at a later cycle instead (see admin and the watchdog). Payloads
+// without a start time (ad hoc enqueues) return "" and let the queue pick an
+// ID.
+func TaskID(payload models.Payload) string {
+	start, err := time.Parse(time.RFC3339, payload.Game.StartTime)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%s%s-%d-%d", GameTaskIDPrefix, payload.Game.ID, start.Unix(), payload.Cycle)
+}
+
+// TaskName returns the fully qualified Cloud Tasks name for payload's check in
+// the queue at queuePath, or "" when TaskID has none.
+func TaskName(queuePath string, payload models.Payload) string {
+	id := TaskID(payload)
+	if id == "" {
+		return ""
+	}
+	return queuePath + "/tasks/" + id
+}
+
+// UniqueOptions returns the asynq options that pin payload's check to its
+// TaskID.
+func UniqueOptions(payload models.Payload) []asynq.Option {
+	id := TaskID(payload)
+	if id == "" {
+		return nil
+	}
+	return []asynq.Option{asynq.TaskID(id), asynq.Retention(completedTaskRetention)}
+}
+
+// PregameTaskID returns the deterministic ID of payload's pregame task,
+//
+//	pregame-{game ID}-{start time, unix seconds}
+//
+// so scheduler reruns do not announce a game twice, while a moved game gets a
+// new one. It returns "" when the payload has no valid start time.
+func PregameTaskID(payload models.Payload) string {
+	start, err := time.Parse(time.RFC3339, payload.Game.StartTime)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("pregame-%s-%d", payload.Game.ID, start.Unix())
+}
+
+// PregameTaskName returns the fully qualified Cloud Tasks name for payload's
+// pregame task in the queue at queuePath, or "" when PregameTaskID has none.
+func PregameTaskName(queuePath string, payload models.Payload) string {
+	id := PregameTaskID(payload)
+	if id == "" {
+		return ""
+	}
+	return queuePath + "/tasks/" + id
+}
+
+// PregameUniqueOptions returns the asynq options that pin payload's pregame
+// task to its PregameTaskID.
+func PregameUniqueOptions(payload models.Payload) []asynq.Option {
+	id := PregameTaskID(payload)
+	if id == "" {
+		return nil
+	}
+	return []asynq.Option{asynq.TaskID(id), asynq.Retention(completedTaskRetention)}
+}
+
+// IsDuplicateTask reports whether err means the task already exists, in asynq
+// or Cloud Tasks. Enqueuers treat it as success: the check is already queued.
+func IsDuplicateTask(err error) bool {
+	if errors.Is(err, asynq.ErrTaskIDConflict) {
+		return true
+	}
+	return status.Code(err) == codes.AlreadyExists
+}
@@ -1,11 +1,15 @@
 package tasks
 
 import (
+	"errors"
+	"fmt"
 	"testing"
 
 	"watchgameupdates/internal/models"
 
 	"github.com/hibiken/asynq"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func TestNewWatchGameUpdatesTask(t *testing.T) {
@@ -136,3 +140,97 @@ func TestParseWatchGameUpdatesPayload(t *testing.T) {
 func newTaskWithPayload(typeName string, payload []byte) *asynq.Task {
 	return asynq.NewTask(typeName, payload)
 }
+
+func TestTaskID(t *testing.T) {
+	execEnd := "2025-01-01T17:00:00Z"
+	base := models.Payload{
+		Game:         models.Game{ID: "2024030411", StartTime: "2025-01-01T12:00:00Z"},
+		ExecutionEnd: &execEnd,
+	}
+
+	if got, want := TaskID(base), "game-2024030411-1735732800-0"; got != want {
+		t.Errorf("TaskID = %q, want %q", got, want)
+	}
+
+	next := base
+	next.Cycle = 1
+	next.LastPlay = "shot-on-goal"
+	if got, want := TaskID(next), "game-2024030411-1735732800-1"; got != want {
+		t.Errorf("TaskID(cycle 1) = %q, want %q", got, want)
+	}
+
+	// A live game's enqueuer takes the execution end from the clock, and a
+	// playoff game extends it, so it must not change the ID.
+	extended := next
+	laterEnd := "2025-01-01T18:30:00Z"
+	extended.ExecutionEnd = &laterEnd
+	if TaskID(extended) != TaskID(next) {
+		t.Error("a different execution end must keep the task ID")
+	}
+
+	moved := base
+	moved.Game.StartTime = "2025-01-01T13:00:00Z"
+	if TaskID(moved) == TaskID(base) {
+		t.Error("a moved game must get a different task ID")
+	}
+
+	if got := TaskID(models.Payload{Game: models.Game{ID: "2024030411"}, ExecutionEnd: &execEnd}); got != "" {
+		t.Errorf("TaskID without start time = %q, want empty", got)
+	}
+	if got := UniqueOptions(models.Payload{Game: models.Game{ID: "2024030411"}}); got != nil {
+		t.Errorf("UniqueOptions without start time = %v, want none", got)
+	}
+
+	queuePath := "projects/p/locations/l/queues/q"
+	if got, want := TaskName(queuePath, base), queuePath+"/tasks/game-2024030411-1735732800-0"; got != want {
+		t.Errorf("TaskName = %q, want %q", got, want)
+	}
+}
+
+func TestPregameTaskID(t *testing.T) {
+	execEnd := "2025-01-01T05:00:00Z"
+	payload := models.Payload{
+		Game:         models.Game{ID: "2024020500", StartTime: "2025-01-01T00:00:00Z"},
+		ExecutionEnd: &execEnd,
+	}
+
+	if got, want := PregameTaskID(payload), "pregame-2024020500-1735689600"; got != want {
+		t.Errorf("PregameTaskID = %q, want %q", got, want)
+	}
+	if PregameTaskID(payload) == TaskID(payload) {
+		t.Error("pregame and first check task IDs must differ")
+	}
+
+	moved := payload
+	moved.Game.StartTime = "2025-01-01T01:00:00Z"
+	if PregameTaskID(moved) == PregameTaskID(payload) {
+		t.Error("a moved game must get a new pregame task ID")
+	}
+
+	if got := PregameTaskID(models.Payload{Game: models.Game{ID: "2024020500"}}); got != "" {
+		t.Errorf("PregameTaskID without start time = %q, want empty", got)
+	}
+	queuePath := "projects/p/locations/l/queues/q"
+	if got, want := PregameTaskName(queuePath, payload), queuePath+"/tasks/pregame-2024020500-1735689600"; got != want {
+		t.Errorf("PregameTaskName = %q, want %q", got, want)
+	}
+}
+
+func TestIsDuplicateTask(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"asynq task ID conflict", fmt.Errorf("enqueue: %w", asynq.ErrTaskIDConflict), true},
+		{"cloud tasks already exists", status.Error(codes.AlreadyExists, "task exists"), true},
+		{"cloud tasks other", status.Error(codes.Unavailable, "try again"), false},
+		{"plain error", errors.New("boom"), false},
+	}
+	for _, tt := range tests {
+		if got := IsDuplicateTask(tt.err); got != tt.want {
+			t.Errorf("%s: IsDuplicateTask = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+// Package teams is the registry of NHL franchises the backend can track and
+// notify for, with their divisions and conferences. It mirrors the 32-team set
+// in the iOS source of truth (github.com/FirepowerApp/ios,
+// main:Firepower/NHLTeams.swift).
+package teams
+
+import "strings"
+
+// Tricodes lists every active franchise, sorted alphabetically.
+var Tricodes = []string{
+	"ANA", "BOS", "BUF", "CAR", "CBJ", "CGY", "CHI", "COL",
+	"DAL", "DET", "EDM", "FLA", "LAK", "MIN", "MTL", "NJD",
+	"NSH", "NYI", "NYR", "OTT", "PHI", "PIT", "SEA", "SJS",
+	"STL", "TBL", "TOR", "UTA", "VAN", "VGK", "WPG", "WSH",
+}
+
+// IsKnown reports whether tricode (case-insensitive) is an active franchise.
+func IsKnown(tricode string) bool {
+	upper := strings.ToUpper(tricode)
+	for _, t := range Tricodes {
+		if t == upper {
+			return true
+		}
+	}
+	return false
+}
+
+// Divisions maps each division to its franchises.
+var Divisions = map[string][]string{
+	"ATLANTIC":     {"BOS", "BUF", "DET", "FLA", "MTL", "OTT", "TBL", "TOR"},
+	"METROPOLITAN": {"CAR", "CBJ", "NJD", "NYI", "NYR", "PHI", "PIT", "WSH"},
+	"CENTRAL":      {"CHI", "COL", "DAL", "MIN", "NSH", "STL", "UTA", "WPG"},
+	"PACIFIC":      {"ANA", "CGY", "EDM", "LAK", "SEA", "SJS", "VAN", "VGK"},
+}
+
+// Conferences maps each conference to its divisions.
+var Conferences = map[string][]string{
+	"EAST": {"ATLANTIC", "METROPOLITAN"},
+	"WEST": {"CENTRAL", "PACIFIC"},
+}
+
+// groupAliases are the other accepted spellings of division and conference
+// names.
+var groupAliases = map[string]string{
+	"METRO":   "METROPOLITAN",
+	"EASTERN": "EAST",
+	"WESTERN": "WEST",
+}
+
+// Group returns the franchises of the division or conference name
+// (case-insensitive, e.g. "Atlantic", "EAST"), and false for any other name.
+func Group(name string) ([]string, bool) {
+	upper := strings.ToUpper(name)
+	if alias, ok := groupAliases[upper]; ok {
+		upper = alias
+	}
+	if members, ok := Divisions[upper]; ok {
+		return members, true
+	}
+	divisions, ok := Conferences[upper]
+	if !ok {
+		return nil, false
+	}
+	var members []string
+	for _, d := range divisions {
+		members = append(members, Divisions[d]...)
+	}
+	return members, true
+}
@@ -0,0 +1,72 @@
+package teams
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestTricodes_ThirtyTwoUnique(t *testing.T) {
+	seen := map[string]bool{}
+	for _, tri := range Tricodes {
+		if seen[tri] {
+			t.Errorf("duplicate tricode %s", tri)
+		}
+		seen[tri] = true
+	}
+	if len(seen) != 32 {
+		t.Errorf("want 32 teams, got %d", len(seen))
+	}
+}
+
+func TestIsKnown(t *testing.T) {
+	for in, want := range map[string]bool{"BOS": true, "bos": true, "ARI": false, "": false} {
+		if got := IsKnown(in); got != want {
+			t.Errorf("IsKnown(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestDivisions_CoverEveryTeamOnce(t *testing.T) {
+	seen := map[string]string{}
+	for division, members := range Divisions {
+		for _, tri := range members {
+			if !IsKnown(tri) {
+				t.Errorf("%s lists unknown tricode %s", division, tri)
+			}
+			if other, dup := seen[tri]; dup {
+				t.Errorf("%s is in both %s and %s", tri, other, division)
+			}
+			seen[tri] = division
+		}
+	}
+	if len(seen) != len(Tricodes) {
+		t.Errorf("divisions cover %d teams, want %d", len(seen), len(Tricodes))
+	}
+}
+
+func TestGroup(t *testing.T) {
+	tests := []struct {
+		name     string
+		wantOK   bool
+		wantSize int
+		contains string
+	}{
+		{name: "ATLANTIC", wantOK: true, wantSize: 8, contains: "BOS"},
+		{name: "pacific", wantOK: true, wantSize: 8, contains: "VGK"},
+		{name: "Metro", wantOK: true, wantSize: 8, contains: "WSH"},
+		{name: "EAST", wantOK: true, wantSize: 16, contains: "NYR"},
+		{name: "western", wantOK: true, wantSize: 16, contains: "UTA"},
+		{name: "BOS"},
+		{name: "NORTH"},
+	}
+	for _, tt := range tests {
+		members, ok := Group(tt.name)
+		if ok != tt.wantOK || len(members) != tt.wantSize {
+			t.Errorf("Group(%q) = %d teams, %v; want %d, %v", tt.name, len(members), ok, tt.wantSize, tt.wantOK)
+			continue
+		}
+		if tt.contains != "" && !slices.Contains(members, tt.contains) {
+			t.Errorf("Group(%q) = %v, want it to contain %s", tt.name, members, tt.contains)
+		}
+	}
+}